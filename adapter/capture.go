@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+type PacketCapture interface {
+	LifecycleService
+	ConnectionTracker
+	// Capturing reports whether raw packets of the inbound are being captured.
+	Capturing(inbound string) bool
+	// CapturePacket records a raw IP packet passing through the inbound,
+	// outgoing packets are those written back to the interface. The packet is not retained.
+	CapturePacket(inbound string, outgoing bool, packet []byte)
+	// StartCapture starts a capture with the options, or with the configured options if nil.
+	StartCapture(options *option.CaptureOptions) error
+	StopCapture() error
+	CaptureStatus() CaptureStatus
+}
+
+type CaptureStatus struct {
+	Running   bool      `json:"running"`
+	Path      string    `json:"path,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+	Packets   uint64    `json:"packets"`
+	Bytes     uint64    `json:"bytes"`
+	Dropped   uint64    `json:"dropped"`
+}
@@ -21,6 +21,7 @@ type DNSRouter interface {
 	ClearCache()
 	LookupReverseMapping(ip netip.Addr) (string, bool)
 	ResetNetwork()
+	Rules() []DNSRule
 }
 
 type DNSClient interface {
@@ -77,6 +78,11 @@ type LegacyDNSTransport interface {
 	LegacyClientSubnet() netip.Prefix
 }
 
+type DNS64Transport interface {
+	// DNS64Prefix returns the prefix to synthesize AAAA records with, or an invalid prefix if DNS64 is disabled.
+	DNS64Prefix() netip.Prefix
+}
+
 type DNSTransportRegistry interface {
 	option.DNSTransportOptionsRegistry
 	CreateDNSTransport(ctx context.Context, logger log.ContextLogger, tag string, transportType string, options any) (DNSTransport, error)
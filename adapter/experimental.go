@@ -133,6 +133,12 @@ type OutboundGroup interface {
 	All() []string
 }
 
+type OutboundChain interface {
+	Outbound
+	// Hops returns tags of outbounds the chain dials through, from the first hop to the last.
+	Hops() []string
+}
+
 type URLTestGroup interface {
 	OutboundGroup
 	URLTest(ctx context.Context) (map[string]uint16, error)
@@ -73,20 +73,29 @@ type InboundContext struct {
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
 	TLSRecordFragment         bool
+	Mirrors                   []TrafficMirror
 
 	NetworkStrategy     *C.NetworkStrategy
 	NetworkType         []C.InterfaceType
 	FallbackNetworkType []C.InterfaceType
 	FallbackDelay       time.Duration
 
-	DestinationAddresses []netip.Addr
-	SourceGeoIPCode      string
-	GeoIPCode            string
-	ProcessInfo          *ConnectionOwner
-	SourceMACAddress     net.HardwareAddr
-	SourceHostname       string
-	QueryType            uint16
-	FakeIP               bool
+	DestinationAddresses  []netip.Addr
+	SourceGeoIPCode       string
+	GeoIPCode             string
+	ProcessInfo           *ConnectionOwner
+	SourceMACAddress      net.HardwareAddr
+	SourceHostname        string
+	SourceDevice          *Device
+	DestinationMACAddress net.HardwareAddr
+	QueryType             uint16
+	FakeIP                bool
+
+	// verified TLS client certificate
+
+	ClientCertificateCommonName      string
+	ClientCertificateSAN             []string
+	ClientCertificatePublicKeySHA256 []byte
 
 	// rule cache
 
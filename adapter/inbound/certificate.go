@@ -0,0 +1,22 @@
+package inbound
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/auth"
+)
+
+// ClientCertificateUser returns the user authenticated by the verified TLS client certificate,
+// whose common name must match one of the users, or any name if no users are configured.
+func ClientCertificateUser(metadata *adapter.InboundContext, users []auth.User) (string, bool) {
+	name := metadata.ClientCertificateCommonName
+	if name == "" {
+		return "", false
+	}
+	if len(users) > 0 && !common.Any(users, func(it auth.User) bool {
+		return it.Username == name
+	}) {
+		return "", false
+	}
+	return name, true
+}
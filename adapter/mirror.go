@@ -0,0 +1,13 @@
+package adapter
+
+import (
+	"context"
+	"net"
+
+	N "github.com/sagernet/sing/common/network"
+)
+
+type TrafficMirror interface {
+	NewConnection(ctx context.Context, conn net.Conn, metadata InboundContext) net.Conn
+	NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext) N.PacketConn
+}
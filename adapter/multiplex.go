@@ -0,0 +1,30 @@
+package adapter
+
+import "time"
+
+type MultiplexOutbound interface {
+	Outbound
+	// MultiplexStatus returns nil if multiplex is disabled.
+	MultiplexStatus() *MultiplexStatus
+}
+
+type MultiplexStatus struct {
+	Protocol  string                   `json:"protocol"`
+	Scheduler string                   `json:"scheduler"`
+	Sessions  []MultiplexSessionStatus `json:"sessions"`
+}
+
+type MultiplexSessionStatus struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Streams   int       `json:"streams"`
+	Upload    int64     `json:"upload"`
+	Download  int64     `json:"download"`
+	// Throughput is the recent transfer rate of the session in bytes per second.
+	Throughput int64 `json:"throughput"`
+	// Latency is the smoothed round-trip time of the session, measured by yamux pings
+	// or the TCP round-trip time of the connection, in milliseconds.
+	Latency int64 `json:"latency"`
+	// MinLatency is the lowest round-trip time observed on the session, in milliseconds.
+	MinLatency int64 `json:"min_latency"`
+}
@@ -0,0 +1,28 @@
+package adapter
+
+type MultiWANOutbound interface {
+	Outbound
+	// MultiWANStatus returns nil if multi-WAN is disabled.
+	MultiWANStatus() *MultiWANStatus
+}
+
+type MultiWANStatus struct {
+	Strategy   string                    `json:"strategy"`
+	Interfaces []MultiWANInterfaceStatus `json:"interfaces"`
+}
+
+type MultiWANInterfaceStatus struct {
+	Interface   string `json:"interface"`
+	Weight      uint32 `json:"weight"`
+	Healthy     bool   `json:"healthy"`
+	Connections int32  `json:"connections"`
+	Upload      int64  `json:"upload"`
+	Download    int64  `json:"download"`
+	// Throughput is the smoothed transfer rate of the interface in bytes per second,
+	// as of the last sample, which is taken when the throughput strategy picks an uplink.
+	Throughput int64 `json:"throughput"`
+	// Capacity is the decaying peak of the throughput in bytes per second.
+	Capacity int64 `json:"capacity"`
+	// Latency is the delay of the last successful health check, in milliseconds.
+	Latency uint16 `json:"latency"`
+}
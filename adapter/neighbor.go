@@ -11,6 +11,12 @@ type NeighborEntry struct {
 	Hostname   string
 }
 
+// Device is a named LAN device identified by its MAC addresses.
+type Device struct {
+	Name   string
+	Groups []string
+}
+
 type NeighborResolver interface {
 	LookupMAC(address netip.Addr) (net.HardwareAddr, bool)
 	LookupHostname(address netip.Addr) (string, bool)
@@ -44,4 +44,6 @@ type OutboundManager interface {
 	Default() Outbound
 	Remove(tag string) error
 	Create(ctx context.Context, router Router, logger log.ContextLogger, tag string, outboundType string, options any) error
+	// OutboundOptions returns the type and options the outbound was created with.
+	OutboundOptions(tag string) (outboundType string, options any, loaded bool)
 }
@@ -30,6 +30,7 @@ type Manager struct {
 	stage                   adapter.StartStage
 	outbounds               []adapter.Outbound
 	outboundByTag           map[string]adapter.Outbound
+	optionsByTag            map[string]outboundOptions
 	dependByTag             map[string][]string
 	defaultOutbound         adapter.Outbound
 	defaultOutboundFallback func() (adapter.Outbound, error)
@@ -42,6 +43,7 @@ func NewManager(logger logger.ContextLogger, registry adapter.OutboundRegistry,
 		endpoint:      endpoint,
 		defaultTag:    defaultTag,
 		outboundByTag: make(map[string]adapter.Outbound),
+		optionsByTag:  make(map[string]outboundOptions),
 		dependByTag:   make(map[string][]string),
 	}
 }
@@ -50,6 +52,34 @@ func (m *Manager) Initialize(defaultOutboundFallback func() (adapter.Outbound, e
 	m.defaultOutboundFallback = defaultOutboundFallback
 }
 
+// ResolveDefault resolves the default outbound without starting outbounds,
+// for inspecting routing decisions only.
+func (m *Manager) ResolveDefault() error {
+	m.access.Lock()
+	defer m.access.Unlock()
+	return m.resolveDefault()
+}
+
+func (m *Manager) resolveDefault() error {
+	if m.defaultTag != "" && m.defaultOutbound == nil {
+		defaultEndpoint, loaded := m.endpoint.Get(m.defaultTag)
+		if !loaded {
+			return E.New("default outbound not found: ", m.defaultTag)
+		}
+		m.defaultOutbound = defaultEndpoint
+	}
+	if m.defaultOutbound == nil {
+		directOutbound, err := m.defaultOutboundFallback()
+		if err != nil {
+			return E.Cause(err, "create direct outbound for fallback")
+		}
+		m.outbounds = append(m.outbounds, directOutbound)
+		m.outboundByTag[directOutbound.Tag()] = directOutbound
+		m.defaultOutbound = directOutbound
+	}
+	return nil
+}
+
 func (m *Manager) Start(stage adapter.StartStage) error {
 	m.access.Lock()
 	if m.started && m.stage >= stage {
@@ -58,23 +88,10 @@ func (m *Manager) Start(stage adapter.StartStage) error {
 	m.started = true
 	m.stage = stage
 	if stage == adapter.StartStateStart {
-		if m.defaultTag != "" && m.defaultOutbound == nil {
-			defaultEndpoint, loaded := m.endpoint.Get(m.defaultTag)
-			if !loaded {
-				m.access.Unlock()
-				return E.New("default outbound not found: ", m.defaultTag)
-			}
-			m.defaultOutbound = defaultEndpoint
-		}
-		if m.defaultOutbound == nil {
-			directOutbound, err := m.defaultOutboundFallback()
-			if err != nil {
-				m.access.Unlock()
-				return E.Cause(err, "create direct outbound for fallback")
-			}
-			m.outbounds = append(m.outbounds, directOutbound)
-			m.outboundByTag[directOutbound.Tag()] = directOutbound
-			m.defaultOutbound = directOutbound
+		err := m.resolveDefault()
+		if err != nil {
+			m.access.Unlock()
+			return err
 		}
 		outbounds := m.outbounds
 		m.access.Unlock()
@@ -204,6 +221,18 @@ func (m *Manager) Outbounds() []adapter.Outbound {
 	return m.outbounds
 }
 
+type outboundOptions struct {
+	outboundType string
+	options      any
+}
+
+func (m *Manager) OutboundOptions(tag string) (string, any, bool) {
+	m.access.RLock()
+	defer m.access.RUnlock()
+	options, loaded := m.optionsByTag[tag]
+	return options.outboundType, options.options, loaded
+}
+
 func (m *Manager) Outbound(tag string) (adapter.Outbound, bool) {
 	m.access.RLock()
 	outbound, found := m.outboundByTag[tag]
@@ -228,6 +257,7 @@ func (m *Manager) Remove(tag string) error {
 		return os.ErrInvalid
 	}
 	delete(m.outboundByTag, tag)
+	delete(m.optionsByTag, tag)
 	index := common.Index(m.outbounds, func(it adapter.Outbound) bool {
 		return it == outbound
 	})
@@ -264,11 +294,11 @@ func (m *Manager) Remove(tag string) error {
 	return nil
 }
 
-func (m *Manager) Create(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, inboundType string, options any) error {
+func (m *Manager) Create(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, outboundType string, options any) error {
 	if tag == "" {
 		return os.ErrInvalid
 	}
-	outbound, err := m.registry.CreateOutbound(ctx, router, logger, tag, inboundType, options)
+	outbound, err := m.registry.CreateOutbound(ctx, router, logger, tag, outboundType, options)
 	if err != nil {
 		return err
 	}
@@ -303,6 +333,7 @@ func (m *Manager) Create(ctx context.Context, router adapter.Router, logger log.
 	}
 	m.outbounds = append(m.outbounds, outbound)
 	m.outboundByTag[tag] = outbound
+	m.optionsByTag[tag] = outboundOptions{outboundType, options}
 	dependencies := outbound.Dependencies()
 	for _, dependency := range dependencies {
 		m.dependByTag[dependency] = append(m.dependByTag[dependency], tag)
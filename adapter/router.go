@@ -22,6 +22,10 @@ type Router interface {
 	Lifecycle
 	ConnectionRouter
 	PreMatch(metadata InboundContext, context tun.DirectRouteContext, timeout time.Duration, supportBypass bool) (tun.DirectRouteDestination, error)
+	// PreMatchOffload pre-matches a connection with bypass supported, and returns a tracked offloaded connection
+	// if it is routed to a direct outbound which can be forwarded by the kernel, or nil otherwise.
+	PreMatchOffload(ctx context.Context, metadata InboundContext) (OffloadedConnection, error)
+	ExplainRoute(ctx context.Context, metadata InboundContext, sniffResult SniffResult) (*RouteExplanation, error)
 	ConnectionRouterEx
 	RuleSet(tag string) (RuleSet, bool)
 	Rules() []Rule
@@ -37,6 +41,24 @@ type ConnectionTracker interface {
 	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) N.PacketConn
 }
 
+// OffloadedConnectionTracker is implemented by connection trackers which account
+// connections forwarded by the kernel, whose traffic never passes through userspace.
+type OffloadedConnectionTracker interface {
+	OffloadedConnection(ctx context.Context, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) OffloadedConnection
+}
+
+// DeviceConnectionTracker is implemented by connection trackers which account
+// traffic of devices, to drop devices removed from the devices file.
+type DeviceConnectionTracker interface {
+	UpdateDevices(devices []*Device)
+}
+
+type OffloadedConnection interface {
+	// CountTraffic reports traffic transferred by the kernel since the last call.
+	CountTraffic(upload int64, download int64)
+	Close() error
+}
+
 // Deprecated: Use ConnectionRouterEx instead.
 type ConnectionRouter interface {
 	RouteConnection(ctx context.Context, conn net.Conn, metadata InboundContext) error
@@ -14,6 +14,7 @@ type Rule interface {
 	SimpleLifecycle
 	Type() string
 	Action() RuleAction
+	Statistics() *RuleStatistics
 }
 
 type DNSRule interface {
@@ -29,7 +30,7 @@ type RuleAction interface {
 
 func IsFinalAction(action RuleAction) bool {
 	switch action.Type() {
-	case C.RuleActionTypeSniff, C.RuleActionTypeResolve:
+	case C.RuleActionTypeSniff, C.RuleActionTypeResolve, C.RuleActionTypeMirror:
 		return false
 	default:
 		return true
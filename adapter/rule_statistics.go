@@ -0,0 +1,66 @@
+package adapter
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+const ruleStatisticsShards = 8
+
+// RuleStatistics counts matches of a rule on the routing hot path,
+// so hits are spread over cache line padded shards and the last matched time has second precision.
+type RuleStatistics struct {
+	shards      atomic.Pointer[[ruleStatisticsShards]ruleStatisticsShard]
+	lastMatched atomic.Int64
+}
+
+type ruleStatisticsShard struct {
+	hits atomic.Uint64
+	_    [56]byte
+}
+
+func (s *RuleStatistics) Hit() {
+	shards := s.shards.Load()
+	if shards == nil {
+		// allocated on first match, since most rules of rule-sets are never counted
+		s.shards.CompareAndSwap(nil, new([ruleStatisticsShards]ruleStatisticsShard))
+		shards = s.shards.Load()
+	}
+	shards[rand.Uint32()%ruleStatisticsShards].hits.Add(1)
+	now := time.Now().Unix()
+	if s.lastMatched.Load() != now {
+		s.lastMatched.Store(now)
+	}
+}
+
+func (s *RuleStatistics) Hits() uint64 {
+	shards := s.shards.Load()
+	if shards == nil {
+		return 0
+	}
+	var hits uint64
+	for i := range shards {
+		hits += shards[i].hits.Load()
+	}
+	return hits
+}
+
+func (s *RuleStatistics) LastMatched() time.Time {
+	lastMatched := s.lastMatched.Load()
+	if lastMatched == 0 {
+		return time.Time{}
+	}
+	return time.Unix(lastMatched, 0)
+}
+
+func (s *RuleStatistics) Reset() {
+	s.shards.Store(nil)
+	s.lastMatched.Store(0)
+}
+
+func ResetRuleStatistics[T Rule](rules []T) {
+	for _, rule := range rules {
+		rule.Statistics().Reset()
+	}
+}
@@ -0,0 +1,44 @@
+package adapter
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleStatistics(t *testing.T) {
+	t.Parallel()
+	var statistics RuleStatistics
+	require.Zero(t, statistics.Hits())
+	require.True(t, statistics.LastMatched().IsZero())
+	var group sync.WaitGroup
+	for range 8 {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for range 1000 {
+				statistics.Hit()
+			}
+		}()
+	}
+	group.Wait()
+	require.Equal(t, uint64(8000), statistics.Hits())
+	require.WithinDuration(t, time.Now(), statistics.LastMatched(), 2*time.Second)
+	statistics.Reset()
+	require.Zero(t, statistics.Hits())
+	require.True(t, statistics.LastMatched().IsZero())
+	statistics.Hit()
+	require.Equal(t, uint64(1), statistics.Hits())
+}
+
+func BenchmarkRuleStatisticsHit(b *testing.B) {
+	var statistics RuleStatistics
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			statistics.Hit()
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"context"
+)
+
+type RuleTraceCondition struct {
+	Condition string `json:"condition"`
+	Matched   bool   `json:"matched"`
+}
+
+type RuleTrace struct {
+	Type       string               `json:"type"`
+	Index      int                  `json:"index"`
+	Rule       string               `json:"rule,omitempty"`
+	Action     string               `json:"action"`
+	Matched    bool                 `json:"matched"`
+	Skipped    string               `json:"skipped,omitempty"`
+	Conditions []RuleTraceCondition `json:"conditions,omitempty"`
+}
+
+type RuleTracer interface {
+	TraceRule(trace RuleTrace)
+	// SimulateSniff is called on sniff actions when there is no connection to read from.
+	SimulateSniff(metadata *InboundContext)
+}
+
+type ruleTracerKey struct{}
+
+func WithRuleTracer(ctx context.Context, tracer RuleTracer) context.Context {
+	return context.WithValue(ctx, (*ruleTracerKey)(nil), tracer)
+}
+
+func RuleTracerFromContext(ctx context.Context) RuleTracer {
+	tracer := ctx.Value((*ruleTracerKey)(nil))
+	if tracer == nil {
+		return nil
+	}
+	return tracer.(RuleTracer)
+}
+
+type SniffResult struct {
+	Protocol string
+	Domain   string
+	Client   string
+}
+
+type RouteExplanation struct {
+	Rules                []RuleTrace `json:"rules"`
+	RuleIndex            int         `json:"rule_index"`
+	Action               string      `json:"action"`
+	Outbound             string      `json:"outbound,omitempty"`
+	Chain                []string    `json:"chain,omitempty"`
+	Protocol             string      `json:"protocol,omitempty"`
+	Domain               string      `json:"domain,omitempty"`
+	Destination          string      `json:"destination"`
+	DestinationAddresses []string    `json:"destination_addresses,omitempty"`
+	Error                string      `json:"error,omitempty"`
+}
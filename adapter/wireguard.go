@@ -0,0 +1,28 @@
+package adapter
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+)
+
+type WireGuardEndpoint interface {
+	Endpoint
+	Peers() ([]WireGuardPeerStatus, error)
+	// UpdatePeer adds the peer, or replaces the configuration of the peer with the same public key.
+	UpdatePeer(peer option.WireGuardPeer) error
+	RemovePeer(publicKey string) error
+}
+
+type WireGuardPeerStatus struct {
+	PublicKey                   string         `json:"public_key"`
+	Address                     string         `json:"address,omitempty"`
+	Port                        uint16         `json:"port,omitempty"`
+	Endpoint                    string         `json:"endpoint,omitempty"`
+	AllowedIPs                  []netip.Prefix `json:"allowed_ips"`
+	PersistentKeepaliveInterval uint16         `json:"persistent_keepalive_interval,omitempty"`
+	LastHandshake               time.Time      `json:"last_handshake,omitempty"`
+	Upload                      uint64         `json:"upload"`
+	Download                    uint64         `json:"download"`
+}
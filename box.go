@@ -22,6 +22,7 @@ import (
 	"github.com/sagernet/sing-box/dns/transport/local"
 	"github.com/sagernet/sing-box/experimental"
 	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/experimental/capture"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/protocol/direct"
@@ -355,6 +356,12 @@ func New(options Options) (*Box, error) {
 		service.MustRegister[adapter.ClashServer](ctx, clashServer)
 		internalServices = append(internalServices, clashServer)
 	}
+	if experimentalOptions.Capture != nil {
+		captureService := capture.New(ctx, logFactory.NewLogger("capture"), *experimentalOptions.Capture)
+		router.AppendTracker(captureService)
+		service.MustRegister[adapter.PacketCapture](ctx, captureService)
+		internalServices = append(internalServices, captureService)
+	}
 	if needV2RayAPI {
 		v2rayServer, err := experimental.NewV2RayServer(logFactory.NewLogger("v2ray-api"), common.PtrValueOrDefault(experimentalOptions.V2RayAPI))
 		if err != nil {
@@ -493,6 +500,33 @@ func (s *Box) start() error {
 	return nil
 }
 
+// StartRouter starts DNS and the router with its rule-sets only, without starting inbounds,
+// outbounds, endpoints or services, to inspect routing decisions of the configuration.
+func (s *Box) StartRouter() error {
+	err := s.logFactory.Start()
+	if err != nil {
+		return E.Cause(err, "start logger")
+	}
+	err = adapter.StartNamed(s.logger, adapter.StartStateInitialize, s.internalService)
+	if err != nil {
+		return err
+	}
+	err = adapter.Start(s.logger, adapter.StartStateInitialize, s.network, s.dnsTransport, s.dnsRouter, s.connection, s.router, s.outbound)
+	if err != nil {
+		return err
+	}
+	// outbounds are not started, but the final outbound of the router is the default one
+	err = s.outbound.ResolveDefault()
+	if err != nil {
+		return err
+	}
+	err = adapter.Start(s.logger, adapter.StartStateStart, s.dnsTransport, s.dnsRouter, s.network, s.router)
+	if err != nil {
+		return err
+	}
+	return adapter.Start(s.logger, adapter.StartStatePostStart, s.router)
+}
+
 func (s *Box) Close() error {
 	select {
 	case <-s.done:
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var commandRoute = &cobra.Command{
+	Use:   "route",
+	Short: "Routing tools",
+}
+
+func init() {
+	mainCommand.AddCommand(commandRoute)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagRouteTestInbound     string
+	flagRouteTestNetwork     string
+	flagRouteTestSource      string
+	flagRouteTestUser        string
+	flagRouteTestProcessName string
+	flagRouteTestProcessPath string
+	flagRouteTestProtocol    string
+	flagRouteTestDomain      string
+	flagRouteTestClient      string
+	flagRouteTestJSON        bool
+)
+
+var commandRouteTest = &cobra.Command{
+	Use:   "test <destination>",
+	Short: "Explain the routing decision for a synthetic connection",
+	Long: "Explain the routing decision for a synthetic connection.\n\n" +
+		"Destination is a domain or IP address, with an optional port (default 443).\n" +
+		"Only DNS and the router with its rule-sets are started, and no connection is dialed.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := routeTest(cmd.OutOrStdout(), args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRouteTest.Flags().StringVarP(&flagRouteTestInbound, "inbound", "i", "", "inbound tag")
+	commandRouteTest.Flags().StringVarP(&flagRouteTestNetwork, "network", "n", N.NetworkTCP, "network (tcp or udp)")
+	commandRouteTest.Flags().StringVarP(&flagRouteTestSource, "source", "s", "", "source address")
+	commandRouteTest.Flags().StringVarP(&flagRouteTestUser, "user", "u", "", "authenticated user")
+	commandRouteTest.Flags().StringVar(&flagRouteTestProcessName, "process-name", "", "process name (default to the base name of process path)")
+	commandRouteTest.Flags().StringVar(&flagRouteTestProcessPath, "process-path", "", "process path")
+	commandRouteTest.Flags().StringVarP(&flagRouteTestProtocol, "protocol", "p", "", "protocol reported by sniff actions")
+	commandRouteTest.Flags().StringVar(&flagRouteTestDomain, "sniff-domain", "", "domain reported by sniff actions (default to destination domain)")
+	commandRouteTest.Flags().StringVar(&flagRouteTestClient, "sniff-client", "", "client reported by sniff actions")
+	commandRouteTest.Flags().BoolVar(&flagRouteTestJSON, "json", false, "print result as JSON")
+	commandRoute.AddCommand(commandRouteTest)
+}
+
+func parseRouteTestAddress(address string, defaultPort uint16) (M.Socksaddr, error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return M.ParseSocksaddrHostPort(strings.Trim(address, "[]"), defaultPort), nil
+	}
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return M.Socksaddr{}, E.Cause(err, "parse port")
+	}
+	return M.ParseSocksaddrHostPort(host, uint16(port)), nil
+}
+
+func routeTest(writer io.Writer, destinationAddress string) error {
+	options, err := readConfigAndMerge()
+	if err != nil {
+		return err
+	}
+	var metadata adapter.InboundContext
+	if flagRouteTestInbound != "" {
+		inboundOptions := common.Find(options.Inbounds, func(it option.Inbound) bool {
+			return it.Tag == flagRouteTestInbound
+		})
+		if inboundOptions.Tag != "" {
+			metadata.InboundType = inboundOptions.Type
+		} else if !common.Any(options.Endpoints, func(it option.Endpoint) bool {
+			return it.Tag == flagRouteTestInbound
+		}) {
+			return E.New("inbound not found: ", flagRouteTestInbound)
+		}
+		metadata.Inbound = flagRouteTestInbound
+	}
+	switch flagRouteTestNetwork {
+	case N.NetworkTCP, N.NetworkUDP:
+		metadata.Network = flagRouteTestNetwork
+	default:
+		return E.New("unknown network: ", flagRouteTestNetwork)
+	}
+	metadata.Destination, err = parseRouteTestAddress(destinationAddress, 443)
+	if err != nil {
+		return E.Cause(err, "parse destination")
+	}
+	if !metadata.Destination.IsValid() {
+		return E.New("invalid destination: ", destinationAddress)
+	}
+	if flagRouteTestSource != "" {
+		metadata.Source, err = parseRouteTestAddress(flagRouteTestSource, 0)
+		if err != nil {
+			return E.Cause(err, "parse source")
+		}
+		if !metadata.Source.IsIP() {
+			return E.New("source must be an IP address: ", flagRouteTestSource)
+		}
+	}
+	metadata.User = flagRouteTestUser
+	if flagRouteTestProcessPath != "" || flagRouteTestProcessName != "" {
+		// process_name rules match the base name of the process path
+		processPath := flagRouteTestProcessPath
+		if processPath == "" {
+			processPath = flagRouteTestProcessName
+		} else if flagRouteTestProcessName != "" && filepath.Base(processPath) != flagRouteTestProcessName {
+			return E.New("process name ", flagRouteTestProcessName, " does not match process path ", processPath)
+		}
+		metadata.ProcessInfo = &adapter.ConnectionOwner{
+			ProcessPath: processPath,
+			UserId:      -1,
+		}
+	}
+	sniffResult := adapter.SniffResult{
+		Protocol: flagRouteTestProtocol,
+		Domain:   flagRouteTestDomain,
+		Client:   flagRouteTestClient,
+	}
+	if sniffResult.Protocol != "" && sniffResult.Domain == "" && metadata.Destination.IsFqdn() {
+		sniffResult.Domain = metadata.Destination.Fqdn
+	}
+	options.Inbounds = nil
+	options.Services = nil
+	if options.Experimental != nil {
+		options.Experimental.ClashAPI = nil
+		options.Experimental.V2RayAPI = nil
+	}
+	if options.Log == nil {
+		options.Log = &option.LogOptions{}
+	}
+	if flagRouteTestJSON {
+		options.Log.Disabled = true
+	}
+	ctx, cancel := context.WithCancel(globalCtx)
+	defer cancel()
+	instance, err := box.New(box.Options{
+		Context: ctx,
+		Options: options,
+	})
+	if err != nil {
+		return E.Cause(err, "create service")
+	}
+	defer instance.Close()
+	err = instance.StartRouter()
+	if err != nil {
+		return E.Cause(err, "start router")
+	}
+	explanation, err := instance.Router().ExplainRoute(ctx, metadata, sniffResult)
+	if err != nil {
+		return err
+	}
+	if flagRouteTestJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanation)
+	}
+	printRouteExplanation(writer, explanation)
+	return nil
+}
+
+func printRouteExplanation(writer io.Writer, explanation *adapter.RouteExplanation) {
+	for _, rule := range explanation.Rules {
+		var status string
+		if rule.Skipped != "" {
+			status = "skipped (" + rule.Skipped + ")"
+		} else if rule.Matched {
+			status = "matched"
+		} else {
+			status = "not matched"
+		}
+		if rule.Rule != "" {
+			io.WriteString(writer, F.ToString(rule.Type, "[", rule.Index, "] ", rule.Rule, " => ", rule.Action, ": ", status, "\n"))
+		} else {
+			io.WriteString(writer, F.ToString(rule.Type, "[", rule.Index, "] => ", rule.Action, ": ", status, "\n"))
+		}
+		for _, condition := range rule.Conditions {
+			if condition.Matched {
+				io.WriteString(writer, "  + "+condition.Condition+"\n")
+			} else {
+				io.WriteString(writer, "  - "+condition.Condition+"\n")
+			}
+		}
+	}
+	io.WriteString(writer, "\n")
+	if explanation.RuleIndex >= 0 {
+		io.WriteString(writer, F.ToString("final rule: route[", explanation.RuleIndex, "]\n"))
+	} else {
+		io.WriteString(writer, "final rule: (final)\n")
+	}
+	io.WriteString(writer, "action: "+explanation.Action+"\n")
+	if explanation.Protocol != "" {
+		io.WriteString(writer, "protocol: "+explanation.Protocol+"\n")
+	}
+	if explanation.Domain != "" {
+		io.WriteString(writer, "domain: "+explanation.Domain+"\n")
+	}
+	io.WriteString(writer, "destination: "+explanation.Destination+"\n")
+	if len(explanation.DestinationAddresses) > 0 {
+		io.WriteString(writer, "resolved: "+strings.Join(explanation.DestinationAddresses, " ")+"\n")
+	}
+	if explanation.Outbound != "" {
+		io.WriteString(writer, "outbound: "+explanation.Outbound+"\n")
+	}
+	if len(explanation.Chain) > 1 {
+		io.WriteString(writer, "chain: "+strings.Join(explanation.Chain, " -> ")+"\n")
+	}
+	if explanation.Error != "" {
+		io.WriteString(writer, "error: "+explanation.Error+"\n")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+const routeTestConfig = `{
+  "log": {
+    "disabled": true
+  },
+  "outbounds": [
+    {
+      "type": "direct",
+      "tag": "direct"
+    },
+    {
+      "type": "direct",
+      "tag": "proxy"
+    },
+    {
+      "type": "selector",
+      "tag": "select",
+      "outbounds": ["proxy", "direct"]
+    }
+  ],
+  "route": {
+    "rules": [
+      {
+        "ip_cidr": "10.0.0.0/8",
+        "action": "reject"
+      },
+      {
+        "process_name": "curl",
+        "outbound": "direct"
+      },
+      {
+        "domain_suffix": "example.com",
+        "outbound": "select"
+      }
+    ],
+    "final": "direct"
+  }
+}`
+
+func runRouteTest(t *testing.T, destination string, setup func()) string {
+	return runRouteTestWithConfig(t, routeTestConfig, destination, setup)
+}
+
+func runRouteTestWithConfig(t *testing.T, config string, destination string, setup func()) string {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o644))
+	configPaths = []string{configPath}
+	configDirectories = nil
+	flagRouteTestInbound = ""
+	flagRouteTestNetwork = "tcp"
+	flagRouteTestSource = ""
+	flagRouteTestUser = ""
+	flagRouteTestProcessName = ""
+	flagRouteTestProcessPath = ""
+	flagRouteTestProtocol = ""
+	flagRouteTestDomain = ""
+	flagRouteTestClient = ""
+	flagRouteTestJSON = false
+	if setup != nil {
+		setup()
+	}
+	preRun(mainCommand, nil)
+	var output bytes.Buffer
+	require.NoError(t, routeTest(&output, destination))
+	return output.String()
+}
+
+func TestRouteTest(t *testing.T) {
+	output := runRouteTest(t, "www.example.com", nil)
+	require.Equal(t, `route[0] ip_cidr=10.0.0.0/8 => reject: not matched
+  - ip_cidr=10.0.0.0/8
+route[1] process_name=curl => route(direct): not matched
+  - process_name=curl
+route[2] domain_suffix=example.com => route(select): matched
+  + domain_suffix=example.com
+
+final rule: route[2]
+action: route
+destination: www.example.com:443
+outbound: select
+chain: select -> proxy
+`, output)
+	output = runRouteTest(t, "10.0.0.1:80", nil)
+	require.Contains(t, output, "route[0] ip_cidr=10.0.0.0/8 => reject: matched\n")
+	require.Contains(t, output, "action: reject\n")
+	require.Contains(t, output, "error: ")
+}
+
+func TestRouteTestProcess(t *testing.T) {
+	output := runRouteTest(t, "1.1.1.1", func() {
+		flagRouteTestProcessPath = "/usr/bin/curl"
+	})
+	require.Contains(t, output, "final rule: route[1]\n")
+	output = runRouteTest(t, "1.1.1.1", func() {
+		flagRouteTestProcessName = "curl"
+	})
+	require.Contains(t, output, "final rule: route[1]\n")
+	output = runRouteTest(t, "1.1.1.1", func() {
+		flagRouteTestProcessName = "curl"
+		flagRouteTestProcessPath = "/usr/bin/curl"
+	})
+	require.Contains(t, output, "final rule: route[1]\n")
+	flagRouteTestProcessName = "wget"
+	require.Error(t, routeTest(&bytes.Buffer{}, "1.1.1.1"))
+}
+
+func TestRouteTestJSON(t *testing.T) {
+	output := runRouteTest(t, "1.1.1.1", func() {
+		flagRouteTestJSON = true
+	})
+	var explanation adapter.RouteExplanation
+	require.NoError(t, json.Unmarshal([]byte(output), &explanation))
+	require.Equal(t, -1, explanation.RuleIndex)
+	require.Equal(t, "route", explanation.Action)
+	require.Equal(t, "direct", explanation.Outbound)
+	require.Equal(t, "1.1.1.1:443", explanation.Destination)
+	require.Len(t, explanation.Rules, 3)
+	for _, rule := range explanation.Rules {
+		require.False(t, rule.Matched)
+	}
+}
+
+func TestRouteTestDefaultOutbound(t *testing.T) {
+	output := runRouteTestWithConfig(t, `{"log": {"disabled": true}}`, "1.1.1.1", nil)
+	require.Contains(t, output, "final rule: (final)\n")
+	require.Contains(t, output, "outbound: direct\n")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/sagernet/sing-box/common/srs"
@@ -67,17 +68,27 @@ func compileRuleSet(sourcePath string) error {
 	} else {
 		outputPath = flagRuleSetCompileOutput
 	}
-	outputFile, err := os.Create(outputPath)
+	// write to a temporary file and rename it, since running instances may
+	// have the previous output memory-mapped
+	outputFile, err := os.CreateTemp(filepath.Dir(outputPath), filepath.Base(outputPath)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	err = srs.Write(outputFile, plainRuleSet.Options, downgradeRuleSetVersion(plainRuleSet.Version, plainRuleSet.Options))
+	err = outputFile.Chmod(0o644)
+	if err == nil {
+		err = srs.Write(outputFile, plainRuleSet.Options, downgradeRuleSetVersion(plainRuleSet.Version, plainRuleSet.Options))
+	}
 	if err != nil {
 		outputFile.Close()
-		os.Remove(outputPath)
+		os.Remove(outputFile.Name())
 		return err
 	}
 	outputFile.Close()
+	err = os.Rename(outputFile.Name(), outputPath)
+	if err != nil {
+		os.Remove(outputFile.Name())
+		return err
+	}
 	return nil
 }
 
@@ -15,7 +15,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var commandRuleSetUpgradeFlagWrite bool
+var (
+	commandRuleSetUpgradeFlagWrite   bool
+	commandRuleSetUpgradeFlagVersion uint8
+)
 
 var commandRuleSetUpgrade = &cobra.Command{
 	Use:   "upgrade <source-path>",
@@ -31,6 +34,7 @@ var commandRuleSetUpgrade = &cobra.Command{
 
 func init() {
 	commandRuleSetUpgrade.Flags().BoolVarP(&commandRuleSetUpgradeFlagWrite, "write", "w", false, "write result to (source) file instead of stdout")
+	commandRuleSetUpgrade.Flags().Uint8Var(&commandRuleSetUpgradeFlagVersion, "version", 0, "upgrade to the version (default to upgrade version 1 to 4)")
 	commandRuleSet.AddCommand(commandRuleSetUpgrade)
 }
 
@@ -55,9 +59,18 @@ func upgradeRuleSet(sourcePath string) error {
 	if err != nil {
 		return err
 	}
-	switch plainRuleSetCompat.Version {
-	case C.RuleSetVersion1:
-	default:
+	// only rule-sets of version 1 are upgraded by default, since version 5 changes the binary format
+	targetVersion := commandRuleSetUpgradeFlagVersion
+	if targetVersion == 0 {
+		if plainRuleSetCompat.Version == C.RuleSetVersion1 {
+			targetVersion = C.RuleSetVersion4
+		} else {
+			targetVersion = plainRuleSetCompat.Version
+		}
+	} else if targetVersion < C.RuleSetVersion2 || targetVersion > C.RuleSetVersionCurrent {
+		return E.New("unknown rule-set version: ", targetVersion)
+	}
+	if plainRuleSetCompat.Version >= targetVersion {
 		log.Info("already up-to-date")
 		return nil
 	}
@@ -65,7 +78,7 @@ func upgradeRuleSet(sourcePath string) error {
 	if err != nil {
 		return err
 	}
-	plainRuleSetCompat.Version = C.RuleSetVersionCurrent
+	plainRuleSetCompat.Version = targetVersion
 	buffer := new(bytes.Buffer)
 	encoder := json.NewEncoder(buffer)
 	encoder.SetIndent("", "  ")
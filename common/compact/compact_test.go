@@ -0,0 +1,68 @@
+package compact
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing/common/domain"
+
+	"github.com/stretchr/testify/require"
+	"go4.org/netipx"
+)
+
+func TestDomainSet(t *testing.T) {
+	t.Parallel()
+	domains := []string{"example.com", "example.org", "a.b.c.example.net"}
+	domainSuffix := []string{"google.com", ".github.io", "cn", "sagernet.org"}
+	for i := 0; i < 200; i++ {
+		domains = append(domains, fmt.Sprint("host", i, ".example.com"))
+		domainSuffix = append(domainSuffix, fmt.Sprint("suffix", i, ".net"))
+	}
+	set, err := ParseDomainSet(BuildDomainSet(domains, domainSuffix), nil)
+	require.NoError(t, err)
+	matcher := domain.NewMatcher(domains, domainSuffix, false)
+	for _, testDomain := range []string{
+		"example.com", "www.example.com", "example.org", "example.net", "a.b.c.example.net", "b.c.example.net",
+		"google.com", "www.google.com", "fakegoogle.com", "github.io", "sagernet.github.io",
+		"cn", "example.cn", "host1.example.com", "host199.example.com", "host200.example.com",
+		"suffix5.net", "www.suffix5.net", "xsuffix5.net", "sagernet.org", "", "com",
+	} {
+		require.Equal(t, matcher.Match(testDomain), set.Match(testDomain), testDomain)
+	}
+	dumpDomains, dumpSuffix := set.Dump()
+	matcherDomains, matcherSuffix := matcher.Dump()
+	require.Equal(t, matcherDomains, dumpDomains)
+	require.Equal(t, matcherSuffix, dumpSuffix)
+}
+
+func TestDomainSetInvalid(t *testing.T) {
+	t.Parallel()
+	content := BuildDomainSet([]string{"example.com"}, nil)
+	_, err := ParseDomainSet(content[:len(content)-1], nil)
+	require.Error(t, err)
+	content[domainSetHeaderLength+8] ^= 1
+	_, err = ParseDomainSet(content, nil)
+	require.Error(t, err)
+}
+
+func TestIPSet(t *testing.T) {
+	t.Parallel()
+	var builder netipx.IPSetBuilder
+	for _, prefix := range []string{"10.0.0.0/8", "192.168.1.0/24", "1.1.1.1/32", "2001:db8::/32", "fd00::1/128"} {
+		builder.AddPrefix(netip.MustParsePrefix(prefix))
+	}
+	ipSet, err := builder.IPSet()
+	require.NoError(t, err)
+	set, err := ParseIPSet(BuildIPSet(ipSet), nil)
+	require.NoError(t, err)
+	for _, addr := range []string{
+		"10.0.0.0", "10.255.255.255", "11.0.0.0", "9.255.255.255", "192.168.1.100", "192.168.2.1",
+		"1.1.1.1", "1.1.1.2", "0.0.0.0", "255.255.255.255", "2001:db8::1", "2001:db9::", "fd00::1", "fd00::2",
+		"::", "::ffff:10.0.0.1",
+	} {
+		testAddr := netip.MustParseAddr(addr)
+		require.Equal(t, ipSet.Contains(testAddr), set.Contains(testAddr), addr)
+	}
+	require.Equal(t, ipSet.Prefixes(), set.IPSet().Prefixes())
+}
@@ -0,0 +1,348 @@
+package compact
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+	"unicode/utf8"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// mod from https://github.com/openacid/succinct, stored in a fixed little-endian
+// layout so that it can be queried directly from a memory-mapped file
+
+const (
+	prefixLabel = '\r'
+	rootLabel   = '\n'
+)
+
+const domainSetHeaderLength = 16
+
+// DomainSet is a succinct trie of reversed domain keys, compatible with the
+// domain matcher of binary rule-sets, that is queried in place.
+type DomainSet struct {
+	leaves      []byte
+	labelBitmap []byte
+	ranks       []byte
+	selects     []byte
+	labels      []byte
+	owner       any
+}
+
+func BuildDomainSet(domains []string, domainSuffix []string) []byte {
+	domainList := make([]string, 0, len(domains)+len(domainSuffix))
+	seen := make(map[string]bool, len(domainList))
+	for _, domain := range domainSuffix {
+		if seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		if domain[0] == '.' {
+			domainList = append(domainList, reverseDomain(string(prefixLabel)+domain))
+		} else {
+			domainList = append(domainList, reverseDomain(string(rootLabel)+domain))
+		}
+	}
+	for _, domain := range domains {
+		if seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domainList = append(domainList, reverseDomain(domain))
+	}
+	sort.Strings(domainList)
+	return buildSuccinctSet(domainList)
+}
+
+func buildSuccinctSet(keys []string) []byte {
+	var (
+		leaves, labelBitmap []uint64
+		labels              []byte
+	)
+	lIdx := 0
+	type qElt struct{ s, e, col int }
+	queue := []qElt{{0, len(keys), 0}}
+	for i := 0; i < len(queue); i++ {
+		elt := queue[i]
+		if elt.col == len(keys[elt.s]) {
+			elt.s++
+			setBit(&leaves, i)
+		}
+		for j := elt.s; j < elt.e; {
+			frm := j
+			for ; j < elt.e && keys[j][elt.col] == keys[frm][elt.col]; j++ {
+			}
+			queue = append(queue, qElt{frm, j, elt.col + 1})
+			labels = append(labels, keys[frm][elt.col])
+			for lIdx>>6 >= len(labelBitmap) {
+				labelBitmap = append(labelBitmap, 0)
+			}
+			lIdx++
+		}
+		setBit(&labelBitmap, lIdx)
+		lIdx++
+	}
+	ranks := make([]uint32, len(labelBitmap)+1)
+	var (
+		selects []uint32
+		ones    uint32
+	)
+	for i, word := range labelBitmap {
+		ranks[i] = ones
+		for ; word != 0; word &= word - 1 {
+			if ones&31 == 0 {
+				selects = append(selects, uint32(i<<6+bits.TrailingZeros64(word)))
+			}
+			ones++
+		}
+	}
+	ranks[len(labelBitmap)] = ones
+	content := make([]byte, domainSetHeaderLength, domainSetHeaderLength+8*len(leaves)+8*len(labelBitmap)+4*len(ranks)+4*len(selects)+len(labels))
+	binary.LittleEndian.PutUint32(content[0:], uint32(len(leaves)))
+	binary.LittleEndian.PutUint32(content[4:], uint32(len(labelBitmap)))
+	binary.LittleEndian.PutUint32(content[8:], uint32(len(selects)))
+	binary.LittleEndian.PutUint32(content[12:], uint32(len(labels)))
+	for _, word := range leaves {
+		content = binary.LittleEndian.AppendUint64(content, word)
+	}
+	for _, word := range labelBitmap {
+		content = binary.LittleEndian.AppendUint64(content, word)
+	}
+	for _, rank := range ranks {
+		content = binary.LittleEndian.AppendUint32(content, rank)
+	}
+	for _, index := range selects {
+		content = binary.LittleEndian.AppendUint32(content, index)
+	}
+	return append(content, labels...)
+}
+
+func setBit(bm *[]uint64, i int) {
+	for i>>6 >= len(*bm) {
+		*bm = append(*bm, 0)
+	}
+	(*bm)[i>>6] |= 1 << uint(i&63)
+}
+
+// ParseDomainSet returns a DomainSet that references content without copying.
+// owner is retained by the set to keep the backing memory alive.
+func ParseDomainSet(content []byte, owner any) (*DomainSet, error) {
+	if len(content) < domainSetHeaderLength {
+		return nil, E.New("domain set: truncated header")
+	}
+	leavesLength := uint64(binary.LittleEndian.Uint32(content[0:]))
+	bitmapLength := uint64(binary.LittleEndian.Uint32(content[4:]))
+	selectsLength := uint64(binary.LittleEndian.Uint32(content[8:]))
+	labelsLength := uint64(binary.LittleEndian.Uint32(content[12:]))
+	totalLength := domainSetHeaderLength + 8*leavesLength + 8*bitmapLength + 4*(bitmapLength+1) + 4*selectsLength + labelsLength
+	if uint64(len(content)) != totalLength {
+		return nil, E.New("domain set: bad length: expected ", totalLength, ", got ", len(content))
+	}
+	set := &DomainSet{owner: owner}
+	content = content[domainSetHeaderLength:]
+	set.leaves, content = content[:8*leavesLength], content[8*leavesLength:]
+	set.labelBitmap, content = content[:8*bitmapLength], content[8*bitmapLength:]
+	set.ranks, content = content[:4*(bitmapLength+1)], content[4*(bitmapLength+1):]
+	set.selects, content = content[:4*selectsLength], content[4*selectsLength:]
+	set.labels = content
+	var ones uint32
+	for i := 0; i < int(bitmapLength); i++ {
+		if set.rank(i) != ones {
+			return nil, E.New("domain set: bad rank index")
+		}
+		ones += uint32(bits.OnesCount64(set.bitmapWord(i)))
+	}
+	if set.rank(int(bitmapLength)) != ones || uint64(ones+31)/32 != selectsLength {
+		return nil, E.New("domain set: bad rank index")
+	}
+	return set, nil
+}
+
+func (s *DomainSet) Match(domain string) bool {
+	return s.has(reverseDomain(domain))
+}
+
+func (s *DomainSet) has(key string) bool {
+	var nodeId, bmIdx int
+	for i := 0; i < len(key); i++ {
+		currentChar := key[i]
+		for ; ; bmIdx++ {
+			if s.bitmapBit(bmIdx) {
+				return false
+			}
+			nextLabel, loaded := s.label(bmIdx - nodeId)
+			if !loaded {
+				return false
+			}
+			if nextLabel == prefixLabel {
+				return true
+			}
+			if nextLabel == rootLabel {
+				nextNodeId := s.countZeros(bmIdx + 1)
+				if currentChar == '.' && s.leaf(nextNodeId) {
+					return true
+				}
+			}
+			if nextLabel == currentChar {
+				break
+			}
+		}
+		nodeId = s.countZeros(bmIdx + 1)
+		bmIdx = s.selectIthOne(nodeId-1) + 1
+		if bmIdx <= 0 {
+			return false
+		}
+	}
+	if s.leaf(nodeId) {
+		return true
+	}
+	for ; ; bmIdx++ {
+		if s.bitmapBit(bmIdx) {
+			return false
+		}
+		nextLabel, loaded := s.label(bmIdx - nodeId)
+		if !loaded {
+			return false
+		}
+		if nextLabel == prefixLabel || nextLabel == rootLabel {
+			return true
+		}
+	}
+}
+
+func (s *DomainSet) Dump() (domainList []string, prefixList []string) {
+	domainMap := make(map[string]bool)
+	prefixMap := make(map[string]bool)
+	for _, key := range s.keys() {
+		key = reverseDomain(key)
+		if key[0] == prefixLabel {
+			prefixMap[key[1:]] = true
+		} else if key[0] == rootLabel {
+			prefixList = append(prefixList, key[1:])
+		} else {
+			domainMap[key] = true
+		}
+	}
+	for rawPrefix := range prefixMap {
+		if rawPrefix[0] == '.' {
+			if rootDomain := rawPrefix[1:]; domainMap[rootDomain] {
+				delete(domainMap, rootDomain)
+				prefixList = append(prefixList, rootDomain)
+				continue
+			}
+		}
+		prefixList = append(prefixList, rawPrefix)
+	}
+	for domain := range domainMap {
+		domainList = append(domainList, domain)
+	}
+	sort.Strings(domainList)
+	sort.Strings(prefixList)
+	return domainList, prefixList
+}
+
+func (s *DomainSet) keys() []string {
+	var (
+		result     []string
+		currentKey []byte
+		traverse   func(int, int)
+	)
+	traverse = func(nodeId, bmIdx int) {
+		if s.leaf(nodeId) {
+			result = append(result, string(currentKey))
+		}
+		for ; !s.bitmapBit(bmIdx); bmIdx++ {
+			nextLabel, loaded := s.label(bmIdx - nodeId)
+			if !loaded {
+				return
+			}
+			currentKey = append(currentKey, nextLabel)
+			nextNodeId := s.countZeros(bmIdx + 1)
+			nextBmIdx := s.selectIthOne(nextNodeId-1) + 1
+			if nextBmIdx > 0 {
+				traverse(nextNodeId, nextBmIdx)
+			}
+			currentKey = currentKey[:len(currentKey)-1]
+		}
+	}
+	traverse(0, 0)
+	return result
+}
+
+func (s *DomainSet) label(i int) (byte, bool) {
+	if i < 0 || i >= len(s.labels) {
+		return 0, false
+	}
+	return s.labels[i], true
+}
+
+func (s *DomainSet) leaf(i int) bool {
+	if i>>6 >= len(s.leaves)/8 {
+		return false
+	}
+	return binary.LittleEndian.Uint64(s.leaves[i>>6*8:])&(1<<uint(i&63)) != 0
+}
+
+func (s *DomainSet) bitmapWords() int {
+	return len(s.labelBitmap) / 8
+}
+
+func (s *DomainSet) bitmapWord(i int) uint64 {
+	return binary.LittleEndian.Uint64(s.labelBitmap[i*8:])
+}
+
+func (s *DomainSet) bitmapBit(i int) bool {
+	if i>>6 >= s.bitmapWords() {
+		// out of range is treated as the end of a node
+		return true
+	}
+	return s.bitmapWord(i>>6)&(1<<uint(i&63)) != 0
+}
+
+func (s *DomainSet) rank(i int) uint32 {
+	return binary.LittleEndian.Uint32(s.ranks[i*4:])
+}
+
+func (s *DomainSet) countZeros(i int) int {
+	wordI := i >> 6
+	if wordI >= s.bitmapWords() {
+		return i - int(s.rank(s.bitmapWords()))
+	}
+	ones := int(s.rank(wordI)) + bits.OnesCount64(s.bitmapWord(wordI)&(1<<uint(i&63)-1))
+	return i - ones
+}
+
+// selectIthOne returns the position of the i-th (zero-based) set bit in the label
+// bitmap, or -1 if it does not exist.
+func (s *DomainSet) selectIthOne(i int) int {
+	if i < 0 || i>>5 >= len(s.selects)/4 {
+		return -1
+	}
+	wordI := int(binary.LittleEndian.Uint32(s.selects[i>>5*4:]) >> 6)
+	words := s.bitmapWords()
+	for ; wordI < words && int(s.rank(wordI+1)) <= i; wordI++ {
+	}
+	if wordI >= words {
+		return -1
+	}
+	word := s.bitmapWord(wordI)
+	for findIth := i - int(s.rank(wordI)); findIth > 0; findIth-- {
+		word &= word - 1
+	}
+	if word == 0 {
+		return -1
+	}
+	return wordI<<6 + bits.TrailingZeros64(word)
+}
+
+func reverseDomain(domain string) string {
+	l := len(domain)
+	b := make([]byte, l)
+	for i := 0; i < l; {
+		r, n := utf8.DecodeRuneInString(domain[i:])
+		i += n
+		utf8.EncodeRune(b[l-i:], r)
+	}
+	return string(b)
+}
@@ -0,0 +1,125 @@
+package compact
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"sort"
+	"sync"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"go4.org/netipx"
+)
+
+const ipSetHeaderLength = 8
+
+// IPSet is a sorted list of disjoint IP ranges that is queried in place by
+// binary search.
+type IPSet struct {
+	ranges4 []byte
+	ranges6 []byte
+	owner   any
+	ipSet   *netipx.IPSet
+	access  sync.Mutex
+}
+
+func BuildIPSet(ipSet *netipx.IPSet) []byte {
+	var ranges4, ranges6 []netipx.IPRange
+	for _, ipRange := range ipSet.Ranges() {
+		if ipRange.From().Is4() {
+			ranges4 = append(ranges4, ipRange)
+		} else {
+			ranges6 = append(ranges6, ipRange)
+		}
+	}
+	content := make([]byte, ipSetHeaderLength, ipSetHeaderLength+8*len(ranges4)+32*len(ranges6))
+	binary.LittleEndian.PutUint32(content[0:], uint32(len(ranges4)))
+	binary.LittleEndian.PutUint32(content[4:], uint32(len(ranges6)))
+	for _, ipRange := range ranges4 {
+		content = appendAddr4(content, ipRange.From())
+		content = appendAddr4(content, ipRange.To())
+	}
+	for _, ipRange := range ranges6 {
+		content = appendAddr16(content, ipRange.From())
+		content = appendAddr16(content, ipRange.To())
+	}
+	return content
+}
+
+func appendAddr4(content []byte, addr netip.Addr) []byte {
+	addrBytes := addr.As4()
+	return append(content, addrBytes[:]...)
+}
+
+func appendAddr16(content []byte, addr netip.Addr) []byte {
+	addrBytes := addr.As16()
+	return append(content, addrBytes[:]...)
+}
+
+// ParseIPSet returns an IPSet that references content without copying.
+// owner is retained by the set to keep the backing memory alive.
+func ParseIPSet(content []byte, owner any) (*IPSet, error) {
+	if len(content) < ipSetHeaderLength {
+		return nil, E.New("ip set: truncated header")
+	}
+	count4 := uint64(binary.LittleEndian.Uint32(content[0:]))
+	count6 := uint64(binary.LittleEndian.Uint32(content[4:]))
+	totalLength := ipSetHeaderLength + 8*count4 + 32*count6
+	if uint64(len(content)) != totalLength {
+		return nil, E.New("ip set: bad length: expected ", totalLength, ", got ", len(content))
+	}
+	content = content[ipSetHeaderLength:]
+	return &IPSet{
+		ranges4: content[:8*count4],
+		ranges6: content[8*count4:],
+		owner:   owner,
+	}, nil
+}
+
+func (s *IPSet) Contains(addr netip.Addr) bool {
+	switch {
+	case addr.Is4():
+		addrBytes := addr.As4()
+		return containsAddr(s.ranges4, addrBytes[:])
+	case addr.Is6():
+		addrBytes := addr.As16()
+		return containsAddr(s.ranges6, addrBytes[:])
+	default:
+		return false
+	}
+}
+
+func containsAddr(ranges []byte, addr []byte) bool {
+	addrLength := len(addr)
+	rangeLength := 2 * addrLength
+	count := len(ranges) / rangeLength
+	index := sort.Search(count, func(i int) bool {
+		to := ranges[i*rangeLength+addrLength : (i+1)*rangeLength]
+		return bytes.Compare(to, addr) >= 0
+	})
+	if index == count {
+		return false
+	}
+	from := ranges[index*rangeLength : index*rangeLength+addrLength]
+	return bytes.Compare(from, addr) <= 0
+}
+
+// IPSet builds an equivalent netipx.IPSet, used for route address extraction
+// and decompiling.
+func (s *IPSet) IPSet() *netipx.IPSet {
+	s.access.Lock()
+	defer s.access.Unlock()
+	if s.ipSet != nil {
+		return s.ipSet
+	}
+	var builder netipx.IPSetBuilder
+	for i := 0; i+8 <= len(s.ranges4); i += 8 {
+		builder.AddRange(netipx.IPRangeFrom(netip.AddrFrom4([4]byte(s.ranges4[i:])), netip.AddrFrom4([4]byte(s.ranges4[i+4:]))))
+	}
+	for i := 0; i+32 <= len(s.ranges6); i += 32 {
+		builder.AddRange(netipx.IPRangeFrom(netip.AddrFrom16([16]byte(s.ranges6[i:])), netip.AddrFrom16([16]byte(s.ranges6[i+16:]))))
+	}
+	s.ipSet, _ = builder.IPSet()
+	return s.ipSet
+}
@@ -43,6 +43,9 @@ func NewWithOptions(options Options) (N.Dialer, error) {
 		err    error
 	)
 	if dialOptions.Detour != "" {
+		if dialOptions.ProxyProtocol != 0 {
+			return nil, E.New("`proxy_protocol` is conflict with `detour`")
+		}
 		outboundManager := service.FromContext[adapter.OutboundManager](options.Context)
 		if outboundManager == nil {
 			return nil, E.New("missing outbound manager")
@@ -0,0 +1,120 @@
+package dialer
+
+import (
+	"context"
+	"net"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/pires/go-proxyproto"
+)
+
+func proxyProtocolSource(ctx context.Context) M.Socksaddr {
+	metadata := adapter.ContextFrom(ctx)
+	if metadata == nil || !metadata.Source.IsIP() {
+		return M.Socksaddr{}
+	}
+	return metadata.Source
+}
+
+func proxyProtocolHeader(version uint8, network string, source M.Socksaddr, destination M.Socksaddr) ([]byte, error) {
+	if !source.IsIP() || !destination.IsIP() {
+		// the LOCAL command tells the next hop to use the real connection addresses
+		return proxyproto.HeaderProxyFromAddrs(version, nil, nil).Format()
+	}
+	sourceAddr, destinationAddr := source.Addr.Unmap(), destination.Addr.Unmap()
+	// both addresses must be of the same family
+	if sourceAddr.Is4() != destinationAddr.Is4() {
+		sourceAddr = netip.AddrFrom16(sourceAddr.As16())
+		destinationAddr = netip.AddrFrom16(destinationAddr.As16())
+	}
+	sourceAddrPort := netip.AddrPortFrom(sourceAddr, source.Port)
+	destinationAddrPort := netip.AddrPortFrom(destinationAddr, destination.Port)
+	if N.NetworkName(network) == N.NetworkUDP {
+		return proxyproto.HeaderProxyFromAddrs(version, net.UDPAddrFromAddrPort(sourceAddrPort), net.UDPAddrFromAddrPort(destinationAddrPort)).Format()
+	}
+	return proxyproto.HeaderProxyFromAddrs(version, net.TCPAddrFromAddrPort(sourceAddrPort), net.TCPAddrFromAddrPort(destinationAddrPort)).Format()
+}
+
+func (d *DefaultDialer) writeProxyProtocolHeader(ctx context.Context, network string, destination M.Socksaddr, conn net.Conn) (net.Conn, error) {
+	switch N.NetworkName(network) {
+	case N.NetworkTCP:
+		if d.proxyProtocol == 0 {
+			return conn, nil
+		}
+	case N.NetworkUDP:
+		// PROXY protocol v1 is only defined for TCP
+		if d.proxyProtocol != 2 {
+			return conn, nil
+		}
+	default:
+		return conn, nil
+	}
+	header, err := proxyProtocolHeader(d.proxyProtocol, network, proxyProtocolSource(ctx), destination)
+	if err != nil {
+		conn.Close()
+		return nil, E.Cause(err, "create PROXY protocol header")
+	}
+	if N.NetworkName(network) == N.NetworkUDP {
+		return &proxyProtocolUDPConn{conn, header}, nil
+	}
+	_, err = conn.Write(header)
+	if err != nil {
+		conn.Close()
+		return nil, E.Cause(err, "write PROXY protocol header")
+	}
+	return conn, nil
+}
+
+func (d *DefaultDialer) newProxyProtocolPacketConn(ctx context.Context, conn net.PacketConn) net.PacketConn {
+	if d.proxyProtocol != 2 {
+		return conn
+	}
+	return &proxyProtocolPacketConn{conn, d.proxyProtocol, proxyProtocolSource(ctx)}
+}
+
+// proxyProtocolUDPConn prepends a PROXY protocol v2 header to every packet.
+type proxyProtocolUDPConn struct {
+	net.Conn
+	header []byte
+}
+
+func (c *proxyProtocolUDPConn) Write(p []byte) (int, error) {
+	buffer := buf.NewSize(len(c.header) + len(p))
+	defer buffer.Release()
+	buffer.Write(c.header)
+	buffer.Write(p)
+	_, err := c.Conn.Write(buffer.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// proxyProtocolPacketConn prepends a PROXY protocol v2 header to every packet.
+type proxyProtocolPacketConn struct {
+	net.PacketConn
+	version uint8
+	source  M.Socksaddr
+}
+
+func (c *proxyProtocolPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	header, err := proxyProtocolHeader(c.version, N.NetworkUDP, c.source, M.SocksaddrFromNet(addr))
+	if err != nil {
+		return 0, err
+	}
+	buffer := buf.NewSize(len(header) + len(p))
+	defer buffer.Release()
+	buffer.Write(header)
+	buffer.Write(p)
+	_, err = c.PacketConn.WriteTo(buffer.Bytes(), addr)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
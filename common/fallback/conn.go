@@ -0,0 +1,113 @@
+package fallback
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+)
+
+// Conn records bytes read before the protocol handshake completes,
+// so they can be replayed to the fallback target when authentication fails.
+type Conn struct {
+	net.Conn
+	access  sync.Mutex
+	buffer  *buf.Buffer
+	stopped atomic.Bool
+	// protected by access
+	overflowed bool
+}
+
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		Conn:   conn,
+		buffer: buf.New(),
+	}
+}
+
+func (c *Conn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 && !c.stopped.Load() {
+		c.access.Lock()
+		if c.buffer != nil {
+			if c.buffer.FreeLen() < n {
+				// handshake is too large to replay
+				c.buffer.Release()
+				c.buffer = nil
+				c.overflowed = true
+				c.stopped.Store(true)
+			} else {
+				common.Must1(c.buffer.Write(p[:n]))
+			}
+		}
+		c.access.Unlock()
+	}
+	return
+}
+
+// Commit stops recording, it should be called once the client is authenticated.
+func (c *Conn) Commit() {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.stopped.Store(true)
+	c.overflowed = false
+	if c.buffer != nil {
+		c.buffer.Release()
+		c.buffer = nil
+	}
+}
+
+// Rewind returns a connection replaying the recorded bytes,
+// or false if the handshake is already committed or nothing is recorded.
+func (c *Conn) Rewind() (net.Conn, bool) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.stopped.Store(true)
+	buffer := c.buffer
+	c.buffer = nil
+	if buffer == nil {
+		return nil, false
+	}
+	if buffer.IsEmpty() {
+		buffer.Release()
+		return nil, false
+	}
+	return bufio.NewCachedConn(c.Conn, buffer), true
+}
+
+// Overflowed reports whether recording was stopped because the handshake exceeded the buffer.
+func (c *Conn) Overflowed() bool {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.overflowed
+}
+
+func (c *Conn) Upstream() any {
+	return c.Conn
+}
+
+// ReaderReplaceable allows copies to read from the upstream directly once recording is stopped.
+func (c *Conn) ReaderReplaceable() bool {
+	return c.stopped.Load()
+}
+
+func (c *Conn) WriterReplaceable() bool {
+	return true
+}
+
+type contextKey struct{}
+
+func ContextWithConn(ctx context.Context, conn *Conn) context.Context {
+	return context.WithValue(ctx, contextKey{}, conn)
+}
+
+// Commit stops recording of the connection in context, if any.
+func Commit(ctx context.Context) {
+	if conn, loaded := ctx.Value(contextKey{}).(*Conn); loaded {
+		conn.Commit()
+	}
+}
@@ -0,0 +1,84 @@
+package fallback
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnRewind(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go clientConn.Write([]byte("hello world"))
+	conn := NewConn(serverConn)
+	require.False(t, conn.ReaderReplaceable())
+	buffer := make([]byte, 5)
+	_, err := io.ReadFull(conn, buffer)
+	require.NoError(t, err)
+	rewindConn, rewound := conn.Rewind()
+	require.True(t, rewound)
+	require.True(t, conn.ReaderReplaceable())
+	buffer = make([]byte, 11)
+	_, err = io.ReadFull(rewindConn, buffer)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(buffer))
+}
+
+func TestConnCommit(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go clientConn.Write([]byte("hello world"))
+	conn := NewConn(serverConn)
+	buffer := make([]byte, 5)
+	_, err := io.ReadFull(conn, buffer)
+	require.NoError(t, err)
+	conn.Commit()
+	require.True(t, conn.ReaderReplaceable())
+	_, err = io.ReadFull(conn, buffer)
+	require.NoError(t, err)
+	_, rewound := conn.Rewind()
+	require.False(t, rewound)
+}
+
+type testLogger struct {
+	logger.ContextLogger
+	warnings []string
+}
+
+func (l *testLogger) WarnContext(ctx context.Context, args ...any) {
+	l.warnings = append(l.warnings, F.ToString(args...))
+}
+
+func TestConnOverflow(t *testing.T) {
+	t.Parallel()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	payload := make([]byte, buf.BufferSize+1)
+	go clientConn.Write(payload)
+	conn := NewConn(serverConn)
+	_, err := io.ReadFull(conn, payload)
+	require.NoError(t, err)
+	require.True(t, conn.Overflowed())
+	require.True(t, conn.ReaderReplaceable())
+	testLogger := &testLogger{}
+	handler := &Handler{
+		logger: testLogger,
+		target: Target{Server: M.ParseSocksaddr("127.0.0.1:80")},
+	}
+	handled := handler.HandleError(context.Background(), conn, adapter.InboundContext{}, nil, E.New("authentication failed"))
+	require.False(t, handled)
+	require.Len(t, testLogger.warnings, 1)
+	require.Contains(t, testLogger.warnings[0], "fallback skipped")
+}
@@ -0,0 +1,208 @@
+package fallback
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type Target struct {
+	Inbound string
+	Server  M.Socksaddr
+}
+
+func (t Target) IsValid() bool {
+	return t.Inbound != "" || t.Server.IsValid()
+}
+
+func (t Target) String() string {
+	if t.Inbound != "" {
+		return "inbound/" + t.Inbound
+	}
+	return t.Server.String()
+}
+
+type Handler struct {
+	inbound   adapter.Inbound
+	router    adapter.ConnectionRouterEx
+	logger    logger.ContextLogger
+	target    Target
+	rules     []rule
+	matchPath bool
+}
+
+// New creates a fallback handler, or returns nil if fallback is not configured.
+func New(inbound adapter.Inbound, router adapter.ConnectionRouterEx, logger logger.ContextLogger, options *option.InboundFallbackOptions) (*Handler, error) {
+	if options == nil {
+		return nil, nil
+	}
+	target, err := newTarget(options.InboundFallbackTarget)
+	if err != nil {
+		return nil, err
+	}
+	if !target.IsValid() && len(options.Rules) == 0 {
+		return nil, nil
+	}
+	handler := &Handler{
+		inbound: inbound,
+		router:  router,
+		logger:  logger,
+		target:  target,
+	}
+	for i, ruleOptions := range options.Rules {
+		fallbackRule, err := newRule(ruleOptions)
+		if err != nil {
+			return nil, E.Cause(err, "parse fallback rule[", i, "]")
+		}
+		if !fallbackRule.target.IsValid() {
+			return nil, E.New("parse fallback rule[", i, "]: missing `inbound` or `server`")
+		}
+		if len(fallbackRule.path) > 0 {
+			handler.matchPath = true
+		}
+		handler.rules = append(handler.rules, fallbackRule)
+	}
+	return handler, nil
+}
+
+// HasInboundTarget reports whether any fallback target is an inbound.
+func (h *Handler) HasInboundTarget() bool {
+	return h.target.Inbound != "" || common.Any(h.rules, func(it rule) bool {
+		return it.target.Inbound != ""
+	})
+}
+
+// Select returns the fallback target for the connection attributes.
+func (h *Handler) Select(serverName string, alpn string, path string) (Target, bool) {
+	serverName = tls.NormalizeServerName(serverName)
+	for _, fallbackRule := range h.rules {
+		if fallbackRule.match(serverName, alpn, path) {
+			return fallbackRule.target, true
+		}
+	}
+	return h.target, h.target.IsValid()
+}
+
+// Record starts recording the connection handshake if fallback is enabled.
+func (h *Handler) Record(ctx context.Context, conn net.Conn) (context.Context, net.Conn) {
+	if h == nil {
+		return ctx, conn
+	}
+	fallbackConn := NewConn(conn)
+	return ContextWithConn(ctx, fallbackConn), fallbackConn
+}
+
+// HandleError replays the recorded handshake to the fallback target if the handshake failed before committed.
+func (h *Handler) HandleError(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc, err error) bool {
+	if h == nil {
+		return false
+	}
+	fallbackConn, isFallbackConn := conn.(*Conn)
+	if !isFallbackConn {
+		return false
+	}
+	replayConn, loaded := fallbackConn.Rewind()
+	if !loaded {
+		if fallbackConn.Overflowed() {
+			h.logger.WarnContext(ctx, "process connection from ", metadata.Source, ": fallback skipped: handshake exceeds the replay buffer of ", buf.BufferSize, " bytes")
+		}
+		return false
+	}
+	h.logger.DebugContext(ctx, E.Cause(err, "process connection from ", metadata.Source))
+	h.NewConnectionEx(ctx, replayConn, metadata, onClose)
+	return true
+}
+
+func (h *Handler) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	var serverName, alpn, path string
+	if tlsConn, isTLS := common.Cast[tls.Conn](conn); isTLS {
+		connectionState := tlsConn.ConnectionState()
+		serverName = connectionState.ServerName
+		alpn = connectionState.NegotiatedProtocol
+	}
+	if h.matchPath {
+		conn, path = peekPath(conn)
+	}
+	target, loaded := h.Select(serverName, alpn, path)
+	if !loaded {
+		h.logger.DebugContext(ctx, "process connection from ", metadata.Source, ": no matching fallback")
+		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
+		return
+	}
+	metadata.Inbound = h.inbound.Tag()
+	metadata.InboundType = h.inbound.Type()
+	h.logger.InfoContext(ctx, "fallback connection to ", target)
+	if target.Inbound != "" {
+		//nolint:staticcheck
+		metadata.InboundDetour = target.Inbound
+	} else {
+		metadata.Destination = target.Server
+	}
+	h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
+}
+
+// peekPath reads the HTTP request line from the connection if present.
+func peekPath(conn net.Conn) (net.Conn, string) {
+	buffer := buf.NewPacket()
+	err := conn.SetReadDeadline(time.Now().Add(C.ReadPayloadTimeout))
+	if err == nil {
+		// the recorded handshake may end in the middle of the request line
+		for !bytes.Contains(buffer.Bytes(), []byte("\r\n")) && buffer.FreeLen() > 0 {
+			_, err = buffer.ReadOnceFrom(conn)
+			if err != nil {
+				break
+			}
+		}
+		_ = conn.SetReadDeadline(time.Time{})
+	}
+	if buffer.IsEmpty() {
+		buffer.Release()
+		return conn, ""
+	}
+	conn = bufio.NewCachedConn(conn, buffer)
+	requestLine, _, found := bytes.Cut(buffer.Bytes(), []byte("\r\n"))
+	if !found {
+		return conn, ""
+	}
+	fields := bytes.Fields(requestLine)
+	if len(fields) != 3 || !bytes.HasPrefix(fields[2], []byte("HTTP/")) {
+		return conn, ""
+	}
+	requestURL, err := url.ParseRequestURI(string(fields[1]))
+	if err != nil {
+		return conn, ""
+	}
+	return conn, requestURL.Path
+}
+
+func newTarget(options option.InboundFallbackTarget) (Target, error) {
+	if options.Inbound != "" {
+		if options.Server != "" {
+			return Target{}, E.New("`inbound` and `server` are mutually exclusive")
+		}
+		return Target{Inbound: options.Inbound}, nil
+	}
+	if options.Server == "" {
+		return Target{}, nil
+	}
+	server := M.ParseSocksaddrHostPort(options.Server, options.ServerPort)
+	if !server.IsValid() || server.Port == 0 {
+		return Target{}, E.New("invalid fallback address: ", server)
+	}
+	return Target{Server: server}, nil
+}
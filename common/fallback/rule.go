@@ -0,0 +1,47 @@
+package fallback
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+)
+
+type rule struct {
+	serverName []string
+	alpn       []string
+	path       []string
+	target     Target
+}
+
+func newRule(options option.InboundFallbackRule) (rule, error) {
+	target, err := newTarget(options.InboundFallbackTarget)
+	if err != nil {
+		return rule{}, err
+	}
+	return rule{
+		serverName: common.Map(options.ServerName, tls.NormalizeServerName),
+		alpn:       options.ALPN,
+		path:       options.Path,
+		target:     target,
+	}, nil
+}
+
+func (r *rule) match(serverName string, alpn string, path string) bool {
+	if len(r.serverName) > 0 && !common.Any(r.serverName, func(it string) bool {
+		return tls.MatchServerName(it, serverName)
+	}) {
+		return false
+	}
+	if len(r.alpn) > 0 && !common.Contains(r.alpn, alpn) {
+		return false
+	}
+	// paths match by prefix, like `location` of nginx
+	if len(r.path) > 0 && (path == "" || !common.Any(r.path, func(it string) bool {
+		return strings.HasPrefix(path, it)
+	})) {
+		return false
+	}
+	return true
+}
@@ -37,6 +37,8 @@ type Listener struct {
 	tcpListener          net.Listener
 	systemProxy          settings.SystemProxy
 	udpConn              *net.UDPConn
+	proxyProtocolConn    *proxyProtocolPacketConn
+	multiPortConn        net.PacketConn
 	udpAddr              M.Socksaddr
 	packetOutbound       chan *N.PacketBuffer
 	packetOutboundClosed chan struct{}
@@ -78,6 +80,10 @@ func New(
 }
 
 func (l *Listener) Start() error {
+	err := l.checkProxyProtocol()
+	if err != nil {
+		return err
+	}
 	if common.Contains(l.network, N.NetworkTCP) {
 		_, err := l.ListenTCP()
 		if err != nil {
@@ -86,6 +92,12 @@ func (l *Listener) Start() error {
 		go l.loopTCPIn()
 	}
 	if common.Contains(l.network, N.NetworkUDP) {
+		if len(l.listenOptions.ListenPorts) > 0 {
+			return E.New("`listen_ports` is only supported by QUIC based inbounds")
+		}
+		if l.oobPacketHandler != nil && l.listenOptions.ProxyProtocol {
+			return E.New("PROXY protocol is not supported by this inbound for UDP")
+		}
 		_, err := l.ListenUDP()
 		if err != nil {
 			return err
@@ -128,6 +140,7 @@ func (l *Listener) Close() error {
 	return E.Errors(err, common.Close(
 		l.tcpListener,
 		common.PtrOrNil(l.udpConn),
+		l.multiPortConn,
 	))
 }
 
@@ -9,7 +9,9 @@ import (
 	"syscall"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/porthopping"
 	"github.com/sagernet/sing-box/common/redir"
+	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/buf"
 	"github.com/sagernet/sing/common/control"
 	E "github.com/sagernet/sing/common/exceptions"
@@ -19,7 +21,58 @@ import (
 )
 
 func (l *Listener) ListenUDP() (net.PacketConn, error) {
+	err := l.checkProxyProtocol()
+	if err != nil {
+		return nil, err
+	}
+	if len(l.listenOptions.ListenPorts) > 0 {
+		if l.listenOptions.ProxyProtocol {
+			return nil, E.New("PROXY protocol is not supported with `listen_ports`")
+		}
+		return l.listenMultiPortUDP()
+	}
 	bindAddr := M.SocksaddrFrom(l.listenOptions.Listen.Build(netip.AddrFrom4([4]byte{127, 0, 0, 1})), l.listenOptions.ListenPort)
+	udpConn, err := l.listenUDP(bindAddr)
+	if err != nil {
+		return nil, err
+	}
+	l.udpConn = udpConn
+	l.udpAddr = bindAddr
+	l.logger.Info("udp server started at ", udpConn.LocalAddr())
+	if l.listenOptions.ProxyProtocol {
+		l.proxyProtocolConn = newProxyProtocolPacketConn(l, udpConn)
+		return l.proxyProtocolConn, nil
+	}
+	return udpConn, err
+}
+
+func (l *Listener) listenMultiPortUDP() (net.PacketConn, error) {
+	ports, err := porthopping.ParsePorts(l.listenOptions.ListenPorts)
+	if err != nil {
+		return nil, E.Cause(err, "parse listen_ports")
+	}
+	if l.listenOptions.ListenPort != 0 && !common.Contains(ports, l.listenOptions.ListenPort) {
+		ports = append([]uint16{l.listenOptions.ListenPort}, ports...)
+	}
+	listenAddr := l.listenOptions.Listen.Build(netip.AddrFrom4([4]byte{127, 0, 0, 1}))
+	udpConns := make([]*net.UDPConn, 0, len(ports))
+	for _, port := range ports {
+		udpConn, err := l.listenUDP(M.SocksaddrFrom(listenAddr, port))
+		if err != nil {
+			for _, conn := range udpConns {
+				conn.Close()
+			}
+			return nil, err
+		}
+		udpConns = append(udpConns, udpConn)
+	}
+	l.multiPortConn = porthopping.NewPacketConn(udpConns)
+	l.udpAddr = M.SocksaddrFrom(listenAddr, ports[0])
+	l.logger.Info("udp server started at ", l.udpAddr, " with ", len(ports), " ports")
+	return l.multiPortConn, nil
+}
+
+func (l *Listener) listenUDP(bindAddr M.Socksaddr) (*net.UDPConn, error) {
 	var listenConfig net.ListenConfig
 	if l.listenOptions.BindInterface != "" {
 		listenConfig.Control = control.Append(listenConfig.Control, control.BindToInterface(service.FromContext[adapter.NetworkManager](l.ctx).InterfaceFinder(), l.listenOptions.BindInterface, -1))
@@ -52,10 +105,7 @@ func (l *Listener) ListenUDP() (net.PacketConn, error) {
 	if err != nil {
 		return nil, err
 	}
-	l.udpConn = udpConn.(*net.UDPConn)
-	l.udpAddr = bindAddr
-	l.logger.Info("udp server started at ", udpConn.LocalAddr())
-	return udpConn, err
+	return udpConn.(*net.UDPConn), nil
 }
 
 func (l *Listener) DialContext(dialer net.Dialer, ctx context.Context, network string, address string) (net.Conn, error) {
@@ -129,13 +179,19 @@ func (l *Listener) loopUDPIn() {
 			l.oobPacketHandler.NewPacketEx(buffer, oob[:oobN], M.SocksaddrFromNetIP(addr).Unwrap())
 		}
 	} else {
+		var udpConn interface {
+			ReadFromUDPAddrPort(b []byte) (int, netip.AddrPort, error)
+		} = l.udpConn
+		if l.proxyProtocolConn != nil {
+			udpConn = l.proxyProtocolConn
+		}
 		for {
 			if l.threadUnsafePacketWriter {
 				buffer = buf.NewPacket()
 			} else {
 				buffer.Reset()
 			}
-			n, addr, err := l.udpConn.ReadFromUDPAddrPort(buffer.FreeBytes())
+			n, addr, err := udpConn.ReadFromUDPAddrPort(buffer.FreeBytes())
 			if err != nil {
 				if l.threadUnsafePacketWriter {
 					buffer.Release()
@@ -154,11 +210,17 @@ func (l *Listener) loopUDPIn() {
 }
 
 func (l *Listener) loopUDPOut() {
+	var udpConn interface {
+		WriteToUDPAddrPort(b []byte, addr netip.AddrPort) (int, error)
+	} = l.udpConn
+	if l.proxyProtocolConn != nil {
+		udpConn = l.proxyProtocolConn
+	}
 	for {
 		select {
 		case packet := <-l.packetOutbound:
 			destination := packet.Destination.AddrPort()
-			_, err := l.udpConn.WriteToUDPAddrPort(packet.Buffer.Bytes(), destination)
+			_, err := udpConn.WriteToUDPAddrPort(packet.Buffer.Bytes(), destination)
 			packet.Buffer.Release()
 			N.PutPacketBuffer(packet)
 			if err != nil {
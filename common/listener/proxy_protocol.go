@@ -0,0 +1,232 @@
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	sbufio "github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/contrab/freelru"
+	"github.com/sagernet/sing/contrab/maphash"
+
+	"github.com/pires/go-proxyproto"
+)
+
+const (
+	proxyProtocolPeerCapacity = 4096
+	proxyProtocolPeerLifetime = 5 * time.Minute
+)
+
+// checkProxyProtocol requires trusted sources to be configured, since anyone able to
+// send PROXY protocol headers can spoof the source address.
+func (l *Listener) checkProxyProtocol() error {
+	if l.listenOptions.ProxyProtocol && len(l.listenOptions.ProxyProtocolTrustedCIDR) == 0 {
+		return E.New("missing `proxy_protocol_trusted_cidr`")
+	}
+	return nil
+}
+
+func (l *Listener) isProxyProtocolTrusted(source netip.Addr) bool {
+	source = source.Unmap()
+	return common.Any(l.listenOptions.ProxyProtocolTrustedCIDR, func(it netip.Prefix) bool {
+		return it.Contains(source)
+	})
+}
+
+func (l *Listener) newProxyProtocolConnection(conn net.Conn) {
+	ctx := log.ContextWithNewID(l.ctx)
+	conn, source, destination, err := l.readProxyProtocolHeader(conn)
+	if err != nil {
+		conn.Close()
+		l.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", conn.RemoteAddr()))
+		return
+	}
+	var metadata adapter.InboundContext
+	//nolint:staticcheck
+	metadata.InboundDetour = l.listenOptions.Detour
+	metadata.Source = source
+	metadata.OriginDestination = destination
+	l.logger.InfoContext(ctx, "inbound connection from ", metadata.Source)
+	l.connHandler.NewConnectionEx(ctx, conn, metadata, nil)
+}
+
+func (l *Listener) readProxyProtocolHeader(conn net.Conn) (net.Conn, M.Socksaddr, M.Socksaddr, error) {
+	source := M.SocksaddrFromNet(conn.RemoteAddr()).Unwrap()
+	destination := M.SocksaddrFromNet(conn.LocalAddr()).Unwrap()
+	acceptNoHeader := l.listenOptions.ProxyProtocolAcceptNoHeader
+	if !l.isProxyProtocolTrusted(source.Addr) {
+		if acceptNoHeader {
+			return conn, source, destination, nil
+		}
+		return conn, M.Socksaddr{}, M.Socksaddr{}, E.New("PROXY protocol: untrusted source")
+	}
+	// clients may wait for the server to speak first if the header is optional
+	readTimeout := C.TCPTimeout
+	if acceptNoHeader {
+		readTimeout = C.ReadPayloadTimeout
+	}
+	err := conn.SetReadDeadline(time.Now().Add(readTimeout))
+	if err != nil {
+		return conn, M.Socksaddr{}, M.Socksaddr{}, err
+	}
+	reader := bufio.NewReader(conn)
+	header, err := proxyproto.Read(reader)
+	if E.IsTimeout(err) && acceptNoHeader {
+		err = proxyproto.ErrNoProxyProtocol
+	}
+	if err != nil && (err != proxyproto.ErrNoProxyProtocol || !acceptNoHeader) {
+		return conn, M.Socksaddr{}, M.Socksaddr{}, E.Cause(err, "read PROXY protocol header")
+	}
+	err = conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return conn, M.Socksaddr{}, M.Socksaddr{}, err
+	}
+	if reader.Buffered() > 0 {
+		cached := buf.NewSize(reader.Buffered())
+		common.Must1(cached.ReadFullFrom(reader, reader.Buffered()))
+		conn = sbufio.NewCachedConn(conn, cached)
+	}
+	if header != nil && header.Command.IsProxy() && header.TransportProtocol.IsStream() {
+		source = M.SocksaddrFromNet(header.SourceAddr).Unwrap()
+		destination = M.SocksaddrFromNet(header.DestinationAddr).Unwrap()
+		conn = &proxyProtocolConn{conn, header.SourceAddr, header.DestinationAddr}
+	}
+	return conn, source, destination, nil
+}
+
+type proxyProtocolConn struct {
+	net.Conn
+	remoteAddr net.Addr
+	localAddr  net.Addr
+}
+
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *proxyProtocolConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *proxyProtocolConn) Upstream() any {
+	return c.Conn
+}
+
+// proxyProtocolPacketConn strips PROXY protocol v2 headers from received packets,
+// replies to the client are sent to the proxy which last forwarded its packets.
+type proxyProtocolPacketConn struct {
+	conn     *net.UDPConn
+	listener *Listener
+	peers    *freelru.SyncedLRU[netip.AddrPort, netip.AddrPort]
+}
+
+func newProxyProtocolPacketConn(listener *Listener, conn *net.UDPConn) *proxyProtocolPacketConn {
+	peers := common.Must1(freelru.NewSynced[netip.AddrPort, netip.AddrPort](proxyProtocolPeerCapacity, maphash.NewHasher[netip.AddrPort]().Hash32))
+	peers.SetLifetime(proxyProtocolPeerLifetime)
+	return &proxyProtocolPacketConn{
+		conn:     conn,
+		listener: listener,
+		peers:    peers,
+	}
+}
+
+func (c *proxyProtocolPacketConn) ReadFromUDPAddrPort(p []byte) (int, netip.AddrPort, error) {
+	for {
+		n, addr, err := c.conn.ReadFromUDPAddrPort(p)
+		if err != nil {
+			return 0, addr, err
+		}
+		addr = netip.AddrPortFrom(addr.Addr().Unmap(), addr.Port())
+		source, headerLen, err := c.readHeader(p[:n], addr)
+		if err != nil {
+			c.listener.logger.Debug(E.Cause(err, "drop packet from ", addr))
+			continue
+		}
+		if headerLen > 0 {
+			n = copy(p, p[headerLen:n])
+		}
+		if source != addr {
+			c.peers.Add(source, addr)
+		}
+		return n, source, nil
+	}
+}
+
+func (c *proxyProtocolPacketConn) readHeader(packet []byte, addr netip.AddrPort) (netip.AddrPort, int, error) {
+	acceptNoHeader := c.listener.listenOptions.ProxyProtocolAcceptNoHeader
+	if !c.listener.isProxyProtocolTrusted(addr.Addr()) {
+		if acceptNoHeader {
+			return addr, 0, nil
+		}
+		return netip.AddrPort{}, 0, E.New("PROXY protocol: untrusted source")
+	}
+	if !bytes.HasPrefix(packet, proxyproto.SIGV2) {
+		if acceptNoHeader {
+			return addr, 0, nil
+		}
+		return netip.AddrPort{}, 0, E.New("PROXY protocol: missing v2 header")
+	}
+	reader := bufio.NewReaderSize(bytes.NewReader(packet), len(packet))
+	header, err := proxyproto.Read(reader)
+	if err != nil {
+		return netip.AddrPort{}, 0, E.Cause(err, "read PROXY protocol header")
+	}
+	headerLen := len(packet) - reader.Buffered()
+	if !header.Command.IsProxy() || !header.TransportProtocol.IsDatagram() {
+		return addr, headerLen, nil
+	}
+	source := M.AddrPortFromNet(header.SourceAddr)
+	return netip.AddrPortFrom(source.Addr().Unmap(), source.Port()), headerLen, nil
+}
+
+func (c *proxyProtocolPacketConn) WriteToUDPAddrPort(p []byte, addr netip.AddrPort) (int, error) {
+	if upstream, loaded := c.peers.Get(addr); loaded {
+		addr = upstream
+	}
+	return c.conn.WriteToUDPAddrPort(p, addr)
+}
+
+func (c *proxyProtocolPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.ReadFromUDPAddrPort(p)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, net.UDPAddrFromAddrPort(addr), nil
+}
+
+func (c *proxyProtocolPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	destination := M.AddrPortFromNet(addr)
+	if !destination.IsValid() {
+		return 0, E.New("invalid destination: ", addr)
+	}
+	return c.WriteToUDPAddrPort(p, destination)
+}
+
+func (c *proxyProtocolPacketConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *proxyProtocolPacketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *proxyProtocolPacketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *proxyProtocolPacketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *proxyProtocolPacketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,130 @@
+package mirror
+
+import (
+	"context"
+	"net"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const defaultQueueSize = 1024
+
+// Session receives copies of the data of one mirrored connection.
+// Implementations must not block.
+type Session interface {
+	Upload(payload []byte, destination M.Socksaddr)
+	Download(payload []byte, source M.Socksaddr)
+	Close()
+}
+
+type Target interface {
+	adapter.TrafficMirror
+	Start() error
+	Close() error
+	String() string
+}
+
+func NewTarget(ctx context.Context, logger log.ContextLogger, options option.RouteActionMirror) Target {
+	queueSize := options.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	if options.Outbound != "" {
+		return newOutboundTarget(ctx, logger, options, queueSize)
+	}
+	return newPcapTarget(ctx, logger, options, queueSize)
+}
+
+// RemoteAddress returns the IP address of the connection destination,
+// falling back to the original or resolved destination for domain destinations.
+func RemoteAddress(metadata adapter.InboundContext) netip.AddrPort {
+	if metadata.Destination.IsIP() {
+		return metadata.Destination.AddrPort()
+	} else if metadata.OriginDestination.IsIP() {
+		return metadata.OriginDestination.AddrPort()
+	} else if len(metadata.DestinationAddresses) > 0 {
+		return netip.AddrPortFrom(metadata.DestinationAddresses[0], metadata.Destination.Port)
+	}
+	return netip.AddrPortFrom(netip.Addr{}, metadata.Destination.Port)
+}
+
+// NewConn tees the data of conn to session.
+func NewConn(conn net.Conn, metadata adapter.InboundContext, session Session) net.Conn {
+	return &mirrorConn{
+		Conn:        conn,
+		session:     session,
+		destination: metadata.Destination,
+	}
+}
+
+type mirrorConn struct {
+	net.Conn
+	session     Session
+	destination M.Socksaddr
+}
+
+func (c *mirrorConn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	if n > 0 {
+		c.session.Upload(p[:n], c.destination)
+	}
+	return
+}
+
+func (c *mirrorConn) Write(p []byte) (n int, err error) {
+	n, err = c.Conn.Write(p)
+	if n > 0 {
+		c.session.Download(p[:n], c.destination)
+	}
+	return
+}
+
+func (c *mirrorConn) Close() error {
+	c.session.Close()
+	return c.Conn.Close()
+}
+
+func (c *mirrorConn) Upstream() any {
+	return c.Conn
+}
+
+type mirrorPacketConn struct {
+	N.PacketConn
+	session Session
+}
+
+// NewPacketConn tees the packets of conn to session.
+func NewPacketConn(conn N.PacketConn, session Session) N.PacketConn {
+	return &mirrorPacketConn{
+		PacketConn: conn,
+		session:    session,
+	}
+}
+
+func (c *mirrorPacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err == nil {
+		c.session.Upload(buffer.Bytes(), destination)
+	}
+	return
+}
+
+func (c *mirrorPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	c.session.Download(buffer.Bytes(), destination)
+	return c.PacketConn.WritePacket(buffer, destination)
+}
+
+func (c *mirrorPacketConn) Close() error {
+	c.session.Close()
+	return c.PacketConn.Close()
+}
+
+func (c *mirrorPacketConn) Upstream() any {
+	return c.PacketConn
+}
@@ -0,0 +1,246 @@
+package mirror
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+var _ Target = (*outboundTarget)(nil)
+
+type outboundTarget struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	logger         log.ContextLogger
+	outbound       string
+	server         M.Socksaddr
+	downloadServer M.Socksaddr
+	queueSize      int
+	dropped        atomic.Uint64
+}
+
+func newOutboundTarget(ctx context.Context, logger log.ContextLogger, options option.RouteActionMirror, queueSize int) *outboundTarget {
+	ctx, cancel := context.WithCancel(ctx)
+	target := &outboundTarget{
+		ctx:       ctx,
+		cancel:    cancel,
+		logger:    logger,
+		outbound:  options.Outbound,
+		queueSize: queueSize,
+	}
+	target.server = M.ParseSocksaddrHostPort(options.Server, options.ServerPort)
+	if options.DownloadServerPort != 0 {
+		target.downloadServer = M.ParseSocksaddrHostPort(options.Server, options.DownloadServerPort)
+	} else {
+		target.downloadServer = target.server
+	}
+	return target
+}
+
+func (t *outboundTarget) String() string {
+	return t.outbound + "/" + t.server.String()
+}
+
+func (t *outboundTarget) Start() error {
+	return nil
+}
+
+func (t *outboundTarget) Close() error {
+	t.cancel()
+	return nil
+}
+
+func (t *outboundTarget) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) net.Conn {
+	session := t.newSession(ctx, N.NetworkTCP)
+	go session.loop()
+	return NewConn(conn, metadata, session)
+}
+
+func (t *outboundTarget) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) N.PacketConn {
+	session := t.newSession(ctx, N.NetworkUDP)
+	go session.loop()
+	return NewPacketConn(conn, session)
+}
+
+func (t *outboundTarget) newSession(ctx context.Context, network string) *outboundSession {
+	return &outboundSession{
+		target:  t,
+		logCtx:  ctx,
+		network: network,
+		queue:   make(chan outboundPacket, t.queueSize),
+		done:    make(chan struct{}),
+	}
+}
+
+type outboundPacket struct {
+	payload  []byte
+	download bool
+}
+
+// outboundSession replays both directions of a connection to the mirror server,
+// client-to-server data to `server` and server-to-client data to `download_server_port` over a second connection.
+type outboundSession struct {
+	target    *outboundTarget
+	logCtx    context.Context
+	network   string
+	queue     chan outboundPacket
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *outboundSession) Upload(payload []byte, destination M.Socksaddr) {
+	s.push(outboundPacket{payload: common.Dup(payload)})
+}
+
+func (s *outboundSession) Download(payload []byte, source M.Socksaddr) {
+	s.push(outboundPacket{payload: common.Dup(payload), download: true})
+}
+
+func (s *outboundSession) push(packet outboundPacket) {
+	select {
+	case <-s.done:
+	case s.queue <- packet:
+	default:
+		if s.target.dropped.Add(1) == 1 {
+			s.target.logger.WarnContext(s.logCtx, "mirror queue of ", s.target.String(), " is full, dropping data")
+		}
+	}
+}
+
+func (s *outboundSession) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *outboundSession) loop() {
+	ctx := s.target.ctx
+	outboundManager := service.FromContext[adapter.OutboundManager](ctx)
+	outbound, loaded := outboundManager.Outbound(s.target.outbound)
+	if !loaded {
+		s.target.logger.ErrorContext(s.logCtx, "mirror outbound not found: ", s.target.outbound)
+		return
+	}
+	if s.network == N.NetworkTCP {
+		s.loopTCP(ctx, outbound)
+	} else {
+		s.loopUDP(ctx, outbound)
+	}
+}
+
+func (s *outboundSession) loopTCP(ctx context.Context, outbound adapter.Outbound) {
+	// dial sequentially, so that the mirror server accepts the upload connection first
+	uploadConn, err := outbound.DialContext(ctx, N.NetworkTCP, s.target.server)
+	if err != nil {
+		s.target.logger.ErrorContext(s.logCtx, E.Cause(err, "mirror to ", s.target.String()))
+		return
+	}
+	defer uploadConn.Close()
+	go io.Copy(io.Discard, uploadConn)
+	downloadConn, err := outbound.DialContext(ctx, N.NetworkTCP, s.target.downloadServer)
+	if err != nil {
+		s.target.logger.ErrorContext(s.logCtx, E.Cause(err, "mirror download to ", s.target.String()))
+		return
+	}
+	defer downloadConn.Close()
+	go io.Copy(io.Discard, downloadConn)
+	write := func(packet outboundPacket) error {
+		var writeErr error
+		if packet.download {
+			_, writeErr = downloadConn.Write(packet.payload)
+		} else {
+			_, writeErr = uploadConn.Write(packet.payload)
+		}
+		return writeErr
+	}
+	s.loopWrite(ctx, write)
+}
+
+func (s *outboundSession) loopUDP(ctx context.Context, outbound adapter.Outbound) {
+	uploadConn, err := s.listenPacket(ctx, outbound, s.target.server)
+	if err != nil {
+		s.target.logger.ErrorContext(s.logCtx, E.Cause(err, "mirror to ", s.target.String()))
+		return
+	}
+	defer uploadConn.Close()
+	downloadConn, err := s.listenPacket(ctx, outbound, s.target.downloadServer)
+	if err != nil {
+		s.target.logger.ErrorContext(s.logCtx, E.Cause(err, "mirror download to ", s.target.String()))
+		return
+	}
+	defer downloadConn.Close()
+	write := func(packet outboundPacket) error {
+		if packet.download {
+			return downloadConn.WritePacket(buf.As(packet.payload), s.target.downloadServer)
+		}
+		return uploadConn.WritePacket(buf.As(packet.payload), s.target.server)
+	}
+	s.loopWrite(ctx, write)
+}
+
+func (s *outboundSession) listenPacket(ctx context.Context, outbound adapter.Outbound, destination M.Socksaddr) (N.PacketConn, error) {
+	packetConn, err := outbound.ListenPacket(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	conn := bufio.NewPacketConn(packetConn)
+	go discardPackets(conn)
+	return conn, nil
+}
+
+func (s *outboundSession) loopWrite(ctx context.Context, write func(packet outboundPacket) error) {
+	for {
+		select {
+		case packet := <-s.queue:
+			err := write(packet)
+			if err != nil {
+				s.target.logger.DebugContext(s.logCtx, E.Cause(err, "mirror to ", s.target.String()))
+				return
+			}
+		case <-s.done:
+			s.drain(write)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drain writes data still queued when the mirrored connection is closed.
+func (s *outboundSession) drain(write func(packet outboundPacket) error) {
+	for {
+		select {
+		case packet := <-s.queue:
+			if write(packet) != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func discardPackets(conn N.PacketConn) {
+	buffer := buf.NewPacket()
+	defer buffer.Release()
+	for {
+		buffer.Reset()
+		_, err := conn.ReadPacket(buffer)
+		if err != nil {
+			return
+		}
+	}
+}
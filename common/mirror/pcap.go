@@ -0,0 +1,282 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/pcap"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+const unixRedialInterval = 5 * time.Second
+
+const (
+	pcapEventUpload = iota
+	pcapEventDownload
+	pcapEventClose
+)
+
+type pcapEvent struct {
+	timestamp time.Time
+	session   *pcapSession
+	kind      uint8
+	payload   []byte
+	address   M.Socksaddr
+}
+
+var _ Target = (*pcapTarget)(nil)
+
+type pcapTarget struct {
+	ctx       context.Context
+	logger    log.ContextLogger
+	path      string
+	unix      bool
+	queue     chan pcapEvent
+	done      chan struct{}
+	closeOnce sync.Once
+	dropped   atomic.Uint64
+	closer    io.Closer
+	buffered  *bufio.Writer
+	writer    *pcap.Writer
+	lastDial  time.Time
+}
+
+func newPcapTarget(ctx context.Context, logger log.ContextLogger, options option.RouteActionMirror, queueSize int) *pcapTarget {
+	target := &pcapTarget{
+		ctx:    ctx,
+		logger: logger,
+		queue:  make(chan pcapEvent, queueSize),
+		done:   make(chan struct{}),
+	}
+	if options.Unix != "" {
+		target.path = options.Unix
+		target.unix = true
+	} else {
+		target.path = filemanager.BasePath(ctx, options.Path)
+	}
+	return target
+}
+
+func (t *pcapTarget) String() string {
+	if t.unix {
+		return "unix:" + t.path
+	}
+	return t.path
+}
+
+func (t *pcapTarget) Start() error {
+	if t.unix {
+		err := t.dialUnix()
+		if err != nil {
+			t.logger.Warn(E.Cause(err, "connect to mirror socket ", t.path))
+		}
+	} else {
+		file, err := filemanager.OpenFile(t.ctx, t.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+		if err != nil {
+			return E.Cause(err, "open mirror file")
+		}
+		err = t.setOutput(file)
+		if err != nil {
+			file.Close()
+			return E.Cause(err, "write pcap header")
+		}
+	}
+	go t.loop()
+	return nil
+}
+
+func (t *pcapTarget) dialUnix() error {
+	t.lastDial = time.Now()
+	conn, err := net.Dial("unix", t.path)
+	if err != nil {
+		return err
+	}
+	err = t.setOutput(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (t *pcapTarget) setOutput(output io.WriteCloser) error {
+	buffered := bufio.NewWriter(output)
+	writer, err := pcap.NewWriter(buffered)
+	if err != nil {
+		return err
+	}
+	t.closer = output
+	t.buffered = buffered
+	t.writer = writer
+	return nil
+}
+
+func (t *pcapTarget) resetOutput() {
+	common.Close(t.closer)
+	t.closer = nil
+	t.buffered = nil
+	t.writer = nil
+}
+
+func (t *pcapTarget) loop() {
+	for {
+		select {
+		case <-t.done:
+			if t.buffered != nil {
+				t.buffered.Flush()
+			}
+			t.resetOutput()
+			return
+		case event := <-t.queue:
+			t.writeEvent(event)
+			if len(t.queue) == 0 && t.buffered != nil {
+				err := t.buffered.Flush()
+				if err != nil {
+					t.handleWriteError(err)
+				}
+			}
+		}
+	}
+}
+
+func (t *pcapTarget) writeEvent(event pcapEvent) {
+	session := event.session
+	var packets [][]byte
+	if session.tcpFlow != nil {
+		switch event.kind {
+		case pcapEventUpload:
+			packets = session.tcpFlow.Upload(event.payload)
+		case pcapEventDownload:
+			packets = session.tcpFlow.Download(event.payload)
+		case pcapEventClose:
+			packets = session.tcpFlow.Close()
+		}
+	} else {
+		switch event.kind {
+		case pcapEventUpload:
+			packets = [][]byte{pcap.BuildUDPPacket(session.client, session.remoteAddress(event.address), event.payload)}
+		case pcapEventDownload:
+			packets = [][]byte{pcap.BuildUDPPacket(session.remoteAddress(event.address), session.client, event.payload)}
+		}
+	}
+	if len(packets) == 0 {
+		return
+	}
+	if t.writer == nil {
+		if !t.unix || time.Since(t.lastDial) < unixRedialInterval {
+			return
+		}
+		err := t.dialUnix()
+		if err != nil {
+			t.logger.Debug(E.Cause(err, "connect to mirror socket ", t.path))
+			return
+		}
+	}
+	err := t.writer.WritePackets(event.timestamp, packets...)
+	if err != nil {
+		t.handleWriteError(err)
+	}
+}
+
+func (t *pcapTarget) handleWriteError(err error) {
+	t.logger.Warn(E.Cause(err, "write mirror ", t.String()))
+	t.resetOutput()
+}
+
+func (t *pcapTarget) enqueue(event pcapEvent) {
+	select {
+	case <-t.done:
+	case t.queue <- event:
+	default:
+		if t.dropped.Add(1) == 1 {
+			t.logger.Warn("mirror queue of ", t.String(), " is full, dropping data")
+		}
+	}
+}
+
+func (t *pcapTarget) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+	return nil
+}
+
+func (t *pcapTarget) NewConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) net.Conn {
+	session := &pcapSession{
+		target: t,
+		client: metadata.Source.AddrPort(),
+		remote: RemoteAddress(metadata),
+	}
+	session.tcpFlow = pcap.NewTCPFlow(session.client, session.remote)
+	return NewConn(conn, metadata, session)
+}
+
+func (t *pcapTarget) NewPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) N.PacketConn {
+	return NewPacketConn(conn, &pcapSession{
+		target: t,
+		client: metadata.Source.AddrPort(),
+		remote: RemoteAddress(metadata),
+	})
+}
+
+type pcapSession struct {
+	target    *pcapTarget
+	client    netip.AddrPort
+	remote    netip.AddrPort
+	tcpFlow   *pcap.TCPFlow
+	closeOnce sync.Once
+}
+
+func (s *pcapSession) remoteAddress(address M.Socksaddr) netip.AddrPort {
+	if address.IsIP() {
+		return address.AddrPort()
+	}
+	return netip.AddrPortFrom(s.remote.Addr(), address.Port)
+}
+
+func (s *pcapSession) Upload(payload []byte, destination M.Socksaddr) {
+	s.target.enqueue(pcapEvent{
+		timestamp: time.Now(),
+		session:   s,
+		kind:      pcapEventUpload,
+		payload:   common.Dup(payload),
+		address:   destination,
+	})
+}
+
+func (s *pcapSession) Download(payload []byte, source M.Socksaddr) {
+	s.target.enqueue(pcapEvent{
+		timestamp: time.Now(),
+		session:   s,
+		kind:      pcapEventDownload,
+		payload:   common.Dup(payload),
+		address:   source,
+	})
+}
+
+func (s *pcapSession) Close() {
+	s.closeOnce.Do(func() {
+		if s.tcpFlow == nil {
+			return
+		}
+		s.target.enqueue(pcapEvent{
+			timestamp: time.Now(),
+			session:   s,
+			kind:      pcapEventClose,
+		})
+	})
+}
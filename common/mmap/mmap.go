@@ -0,0 +1,14 @@
+package mmap
+
+// File is a read-only view of the content of a file.
+//
+// On supported platforms the content is memory-mapped and unmapped once the
+// File is garbage collected, so users of Bytes must keep a reference to the
+// File for as long as the returned slice is accessed.
+type File struct {
+	content []byte
+}
+
+func (f *File) Bytes() []byte {
+	return f.content
+}
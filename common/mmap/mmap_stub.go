@@ -0,0 +1,13 @@
+//go:build !unix
+
+package mmap
+
+import "os"
+
+func Open(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{content}, nil
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package mmap
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+func Open(path string) (*File, error) {
+	osFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer osFile.Close()
+	fileInfo, err := osFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fileInfo.Size()
+	if size == 0 {
+		return &File{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, E.New("file too large to map: ", size)
+	}
+	// a private mapping does not share pages written through the mapping with the file,
+	// and the file may still be replaced by rename while mapped
+	content, err := syscall.Mmap(int(osFile.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, E.Cause(err, "mmap")
+	}
+	file := &File{content}
+	runtime.AddCleanup(file, func(content []byte) {
+		_ = syscall.Munmap(content)
+	}, content)
+	return file, nil
+}
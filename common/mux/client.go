@@ -3,18 +3,46 @@ package mux
 import (
 	"context"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-mux"
+	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 )
 
-type Client = mux.Client
+const (
+	SchedulerFixed    = "fixed"
+	SchedulerAdaptive = "adaptive"
+)
+
+// adaptiveMaxConnections limits sessions of the adaptive scheduler if max_connections is not set.
+const adaptiveMaxConnections = 8
+
+// Client schedules streams to multiplex sessions, each session is a single connection to the server.
+// With the fixed scheduler, sessions are pooled by sing-mux and only tracked for status,
+// with the adaptive scheduler, every session is a sing-mux client holding exactly one connection.
+type Client struct {
+	dialer         N.Dialer
+	logger         logger.Logger
+	options        mux.Options
+	protocol       string
+	scheduler      string
+	maxConnections int
+	minStreams     int
+	maxStreams     int
+	fixed          *mux.Client
+	access         sync.Mutex
+	sessions       []*clientSession
+	sessionID      atomic.Uint64
+}
 
 func NewClientWithOptions(dialer N.Dialer, logger logger.Logger, options option.OutboundMultiplexOptions) (*Client, error) {
 	if !options.Enabled {
@@ -34,26 +62,254 @@ func NewClientWithOptions(dialer N.Dialer, logger logger.Logger, options option.
 			return nil, E.New("brutal: invalid download speed")
 		}
 	}
-	return mux.NewClient(mux.Options{
-		Dialer:         &clientDialer{dialer},
-		Logger:         logger,
-		Protocol:       options.Protocol,
-		MaxConnections: options.MaxConnections,
-		MinStreams:     options.MinStreams,
-		MaxStreams:     options.MaxStreams,
-		Padding:        options.Padding,
-		Brutal:         brutalOptions,
+	client := &Client{
+		dialer:         dialer,
+		logger:         logger,
+		protocol:       options.Protocol,
+		scheduler:      options.Scheduler,
+		maxConnections: options.MaxConnections,
+		minStreams:     options.MinStreams,
+		maxStreams:     options.MaxStreams,
+		options: mux.Options{
+			Logger:   logger,
+			Protocol: options.Protocol,
+			// every sing-mux client of the adaptive scheduler holds exactly one session
+			MaxConnections: 1,
+			Padding:        options.Padding,
+			Brutal:         brutalOptions,
+		},
+	}
+	if client.protocol == "" {
+		client.protocol = "h2mux"
+	}
+	switch client.scheduler {
+	case "", SchedulerFixed:
+		client.scheduler = SchedulerFixed
+		fixedOptions := client.options
+		fixedOptions.Dialer = &sessionDialer{dialer: dialer, client: client}
+		fixedOptions.MaxConnections = options.MaxConnections
+		fixedOptions.MinStreams = options.MinStreams
+		fixedOptions.MaxStreams = options.MaxStreams
+		fixedClient, err := mux.NewClient(fixedOptions)
+		if err != nil {
+			return nil, err
+		}
+		client.fixed = fixedClient
+	case SchedulerAdaptive:
+		if brutalOptions.Enabled {
+			return nil, E.New("adaptive scheduler is conflict with brutal")
+		}
+		if client.maxConnections == 0 {
+			client.maxConnections = adaptiveMaxConnections
+		}
+		// validate the protocol
+		_, err := mux.NewClient(client.options)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, E.New("unknown multiplex scheduler: ", options.Scheduler)
+	}
+	return client, nil
+}
+
+func (c *Client) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if c.fixed != nil {
+		return c.fixed.DialContext(ctx, network, destination)
+	}
+	session, isNew, err := c.offer()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := session.client.DialContext(ctx, network, destination)
+	if err != nil {
+		c.release(session, isNew)
+		return nil, err
+	}
+	session.bindStream(conn)
+	return newClientStream(conn, session), nil
+}
+
+func (c *Client) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	if c.fixed != nil {
+		return c.fixed.ListenPacket(ctx, destination)
+	}
+	session, isNew, err := c.offer()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := session.client.ListenPacket(ctx, destination)
+	if err != nil {
+		c.release(session, isNew)
+		return nil, err
+	}
+	session.bindStream(conn)
+	return newClientPacketStream(conn, session), nil
+}
+
+func (c *Client) offer() (*clientSession, bool, error) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.cleanup()
+	session := c.offerAdaptive()
+	isNew := session == nil
+	if isNew {
+		var err error
+		session, err = c.newSession()
+		if err != nil {
+			return nil, false, err
+		}
+		c.sessions = append(c.sessions, session)
+	}
+	session.streams.Add(1)
+	return session, isNew, nil
+}
+
+// offerAdaptive opens a new session when the least loaded session is busy,
+// which means it is already transferring near its peak throughput, or its stream
+// latency rises well above its baseline because of head-of-line blocking.
+func (c *Client) offerAdaptive() *clientSession {
+	sessions := common.Filter(c.sessions, (*clientSession).available)
+	session := common.MinBy(common.Filter(sessions, func(it *clientSession) bool {
+		return c.maxStreams == 0 || it.numStreams() < c.maxStreams
+	}), (*clientSession).numStreams)
+	if session == nil {
+		if len(c.sessions) < c.maxConnections {
+			return nil
+		}
+		return common.MinBy(sessions, (*clientSession).numStreams)
+	}
+	numStreams := session.numStreams()
+	if numStreams == 0 || len(c.sessions) >= c.maxConnections || numStreams < c.minStreams {
+		return session
+	}
+	if session.busy(time.Now()) {
+		return nil
+	}
+	return session
+}
+
+func (c *Client) newSession() (*clientSession, error) {
+	session := &clientSession{
+		id:        c.sessionID.Add(1),
+		createdAt: time.Now(),
+	}
+	options := c.options
+	options.Dialer = &sessionDialer{dialer: c.dialer, session: session}
+	client, err := mux.NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+	session.client = client
+	return session, nil
+}
+
+// release undoes offer for a stream which failed to open,
+// a new session is dropped since its connection has failed.
+func (c *Client) release(session *clientSession, isNew bool) {
+	session.streams.Add(-1)
+	if !isNew {
+		return
+	}
+	c.access.Lock()
+	defer c.access.Unlock()
+	if session.numStreams() > 0 {
+		return
+	}
+	c.sessions = common.Filter(c.sessions, func(it *clientSession) bool {
+		return it != session
+	})
+	session.client.Close()
+}
+
+// cleanup removes sessions whose connection is closed and have no streams left.
+func (c *Client) cleanup() {
+	c.sessions = common.Filter(c.sessions, func(it *clientSession) bool {
+		if it.closed() && it.numStreams() == 0 {
+			if it.client != nil {
+				it.client.Close()
+			}
+			return false
+		}
+		return true
+	})
+}
+
+// trackSession adds a session opened by the sing-mux client of the fixed scheduler.
+func (c *Client) trackSession() *clientSession {
+	session := &clientSession{
+		id:        c.sessionID.Add(1),
+		createdAt: time.Now(),
+	}
+	c.access.Lock()
+	c.cleanup()
+	c.sessions = append(c.sessions, session)
+	c.access.Unlock()
+	return session
+}
+
+func (c *Client) Status() *adapter.MultiplexStatus {
+	var fixedStreams map[*sessionConn]int
+	if c.fixed != nil {
+		fixedStreams = loadFixedStreams(c.fixed)
+	}
+	c.access.Lock()
+	c.cleanup()
+	sessions := common.Map(c.sessions, func(it *clientSession) adapter.MultiplexSessionStatus {
+		if fixedStreams != nil {
+			return it.status(time.Now(), fixedStreams[it.conn.Load()])
+		}
+		return it.status(time.Now(), it.numStreams())
 	})
+	c.access.Unlock()
+	if sessions == nil {
+		sessions = []adapter.MultiplexSessionStatus{}
+	}
+	return &adapter.MultiplexStatus{
+		Protocol:  c.protocol,
+		Scheduler: c.scheduler,
+		Sessions:  sessions,
+	}
 }
 
-type clientDialer struct {
-	N.Dialer
+func (c *Client) Reset() {
+	if c.fixed != nil {
+		c.fixed.Reset()
+	}
+	c.access.Lock()
+	defer c.access.Unlock()
+	for _, session := range c.sessions {
+		if session.client != nil {
+			session.client.Reset()
+		}
+	}
+	c.sessions = nil
+}
+
+func (c *Client) Close() error {
+	c.Reset()
+	return nil
 }
 
-func (d *clientDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
-	return d.Dialer.DialContext(adapter.OverrideContext(ctx), network, destination)
+type sessionDialer struct {
+	dialer N.Dialer
+	// client tracks a new session for every connection of the fixed scheduler
+	client  *Client
+	session *clientSession
+}
+
+func (d *sessionDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(adapter.OverrideContext(ctx), network, destination)
+	if err != nil {
+		return nil, err
+	}
+	session := d.session
+	if session == nil {
+		session = d.client.trackSession()
+	}
+	return newSessionConn(conn, session), nil
 }
 
-func (d *clientDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
-	return d.Dialer.ListenPacket(adapter.OverrideContext(ctx), destination)
+func (d *sessionDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return d.dialer.ListenPacket(adapter.OverrideContext(ctx), destination)
 }
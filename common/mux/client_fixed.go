@@ -0,0 +1,73 @@
+package mux
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"github.com/sagernet/sing-mux"
+	"github.com/sagernet/sing/common"
+)
+
+// loadFixedStreams returns the number of streams of each session pooled by the sing-mux client of the fixed scheduler,
+// by the connection of the session. sing-mux does not expose its sessions, so they are read by reflection,
+// which only happens for status and keeps streams of the fixed scheduler unwrapped.
+func loadFixedStreams(client *mux.Client) map[*sessionConn]int {
+	clientValue := reflect.ValueOf(client).Elem()
+	accessValue := clientValue.FieldByName("access")
+	connectionsValue := clientValue.FieldByName("connections")
+	if !accessValue.IsValid() || !connectionsValue.IsValid() {
+		return nil
+	}
+	access := (*sync.Mutex)(unsafe.Pointer(accessValue.UnsafeAddr()))
+	connections := reflect.NewAt(connectionsValue.Type(), unsafe.Pointer(connectionsValue.UnsafeAddr()))
+	access.Lock()
+	sessions := connections.MethodByName("Array").Call(nil)[0]
+	access.Unlock()
+	fixedStreams := make(map[*sessionConn]int)
+	for i := 0; i < sessions.Len(); i++ {
+		sessionValue := sessions.Index(i)
+		session, isSession := sessionValue.Interface().(interface{ NumStreams() int })
+		if !isSession {
+			continue
+		}
+		conn := loadSessionConn(sessionValue.Elem())
+		if conn == nil {
+			continue
+		}
+		fixedStreams[conn] += session.NumStreams()
+	}
+	return fixedStreams
+}
+
+// loadSessionConn finds the connection of a h2mux, smux or yamux session of sing-mux.
+func loadSessionConn(session reflect.Value) *sessionConn {
+	session = reflect.Indirect(session)
+	if session.Kind() != reflect.Struct {
+		return nil
+	}
+	var connValue reflect.Value
+	if clientConn := session.FieldByName("clientConn"); clientConn.IsValid() {
+		// h2mux
+		if clientConn.Kind() != reflect.Pointer || clientConn.IsNil() {
+			return nil
+		}
+		connValue = clientConn.Elem().FieldByName("tconn")
+	} else if muxSession := session.FieldByName("Session"); muxSession.IsValid() {
+		// smux and yamux
+		if muxSession.Kind() != reflect.Pointer || muxSession.IsNil() {
+			return nil
+		}
+		connValue = muxSession.Elem().FieldByName("conn")
+	}
+	if !connValue.IsValid() || connValue.Kind() != reflect.Interface {
+		return nil
+	}
+	conn, isConn := reflect.NewAt(connValue.Type(), unsafe.Pointer(connValue.UnsafeAddr())).Elem().Interface().(net.Conn)
+	if !isConn {
+		return nil
+	}
+	fixedConn, _ := common.Cast[*sessionConn](conn)
+	return fixedConn
+}
@@ -0,0 +1,277 @@
+package mux
+
+import (
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-mux"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	sessionSampleInterval = time.Second
+	// a session transferring above this fraction of its peak throughput is considered saturated
+	sessionSaturationRatio = 0.8
+	// throughput below this rate never saturates a session
+	sessionMinBusyThroughput = 128 * 1024
+	// round-trip time above this multiple of the baseline indicates head-of-line blocking
+	sessionLatencyRatio       = 2
+	sessionMinLatencyIncrease = 100 * time.Millisecond
+	sessionProbeInterval      = 5 * time.Second
+)
+
+type clientSession struct {
+	id          uint64
+	createdAt   time.Time
+	client      *mux.Client
+	streams     atomic.Int32
+	dialed      atomic.Bool
+	connections atomic.Int32
+	upload      atomic.Int64
+	download    atomic.Int64
+	yamux       atomic.Pointer[yamux.Session]
+	conn        atomic.Pointer[sessionConn]
+	probing     atomic.Bool
+
+	access         sync.Mutex
+	sampleTime     time.Time
+	sampleBytes    int64
+	throughput     float64
+	peakThroughput float64
+	latency        time.Duration
+	minLatency     time.Duration
+}
+
+func (s *clientSession) numStreams() int {
+	return int(s.streams.Load())
+}
+
+// closed reports whether the connection of the session has been closed,
+// a session that is still dialing is not closed.
+func (s *clientSession) closed() bool {
+	return s.dialed.Load() && s.connections.Load() == 0
+}
+
+func (s *clientSession) available() bool {
+	return !s.closed()
+}
+
+func (s *clientSession) sample(now time.Time) {
+	totalBytes := s.upload.Load() + s.download.Load()
+	if s.sampleTime.IsZero() {
+		s.sampleTime = now
+		s.sampleBytes = totalBytes
+		return
+	}
+	elapsed := now.Sub(s.sampleTime)
+	if elapsed < sessionSampleInterval {
+		return
+	}
+	s.throughput = float64(totalBytes-s.sampleBytes) / elapsed.Seconds()
+	if s.throughput > s.peakThroughput {
+		s.peakThroughput = s.throughput
+	}
+	s.sampleTime = now
+	s.sampleBytes = totalBytes
+}
+
+func (s *clientSession) busy(now time.Time) bool {
+	// the round-trip time is only measured for sessions checked by the adaptive scheduler
+	if conn := s.conn.Load(); conn != nil && s.probing.CompareAndSwap(false, true) {
+		go s.loopProbe(conn)
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.sample(now)
+	if s.throughput >= sessionMinBusyThroughput && s.throughput >= s.peakThroughput*sessionSaturationRatio {
+		return true
+	}
+	if s.minLatency > 0 && s.latency > s.minLatency*sessionLatencyRatio && s.latency-s.minLatency > sessionMinLatencyIncrease {
+		return true
+	}
+	return false
+}
+
+// bindStream finds the yamux session from a stream, whose pings are used to measure the round-trip time.
+func (s *clientSession) bindStream(conn any) {
+	if s.yamux.Load() != nil {
+		return
+	}
+	stream, isYAMux := common.Cast[*yamux.Stream](conn)
+	if isYAMux {
+		s.yamux.Store(stream.Session())
+	}
+}
+
+// loopProbe measures the round-trip time of the session until its connection is closed.
+func (s *clientSession) loopProbe(conn *sessionConn) {
+	ticker := time.NewTicker(sessionProbeInterval)
+	defer ticker.Stop()
+	for {
+		latency, err := s.probe(conn)
+		if err == nil {
+			s.updateLatency(latency)
+		} else if err == os.ErrInvalid {
+			return
+		}
+		select {
+		case <-conn.done:
+			// probe again if the session dials a new connection
+			s.probing.Store(false)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe pings the session with yamux, which measures the delay of the session itself
+// including streams queued before the ping. Sessions of h2mux and smux are not accessible
+// or do not support pings, the round-trip time of the TCP connection is used instead if available.
+func (s *clientSession) probe(conn *sessionConn) (time.Duration, error) {
+	if yamuxSession := s.yamux.Load(); yamuxSession != nil {
+		return yamuxSession.Ping()
+	}
+	tcpConn, isTCP := common.Cast[*net.TCPConn](conn.CounterConn.ExtendedConn)
+	if !isTCP {
+		return 0, os.ErrInvalid
+	}
+	return tcpRTT(tcpConn)
+}
+
+func (s *clientSession) updateLatency(latency time.Duration) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	if s.latency == 0 {
+		s.latency = latency
+	} else {
+		s.latency = (s.latency*7 + latency) / 8
+	}
+	if s.minLatency == 0 || latency < s.minLatency {
+		s.minLatency = latency
+	}
+}
+
+func (s *clientSession) status(now time.Time, streams int) adapter.MultiplexSessionStatus {
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.sample(now)
+	return adapter.MultiplexSessionStatus{
+		ID:         s.id,
+		CreatedAt:  s.createdAt,
+		Streams:    streams,
+		Upload:     s.upload.Load(),
+		Download:   s.download.Load(),
+		Throughput: int64(s.throughput),
+		Latency:    s.latency.Milliseconds(),
+		MinLatency: s.minLatency.Milliseconds(),
+	}
+}
+
+// sessionConn counts traffic of the underlying connection of a session.
+type sessionConn struct {
+	*bufio.CounterConn
+	session *clientSession
+	closed  atomic.Bool
+	done    chan struct{}
+}
+
+func newSessionConn(conn net.Conn, session *clientSession) *sessionConn {
+	session.dialed.Store(true)
+	session.connections.Add(1)
+	sessionConn := &sessionConn{
+		CounterConn: bufio.NewInt64CounterConn(conn, []*atomic.Int64{&session.download}, []*atomic.Int64{&session.upload}),
+		session:     session,
+		done:        make(chan struct{}),
+	}
+	session.conn.Store(sessionConn)
+	return sessionConn
+}
+
+func (c *sessionConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.session.connections.Add(-1)
+		close(c.done)
+	}
+	return c.CounterConn.Close()
+}
+
+func (c *sessionConn) Upstream() any {
+	return c.CounterConn
+}
+
+// clientStream releases the stream from its session on close.
+type clientStream struct {
+	net.Conn
+	session *clientSession
+	closed  atomic.Bool
+}
+
+func newClientStream(conn net.Conn, session *clientSession) *clientStream {
+	return &clientStream{
+		Conn:    conn,
+		session: session,
+	}
+}
+
+func (c *clientStream) NeedHandshakeForWrite() bool {
+	return N.NeedHandshakeForWrite(c.Conn)
+}
+
+func (c *clientStream) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.session.streams.Add(-1)
+	}
+	return c.Conn.Close()
+}
+
+func (c *clientStream) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *clientStream) WriterReplaceable() bool {
+	return true
+}
+
+func (c *clientStream) Upstream() any {
+	return c.Conn
+}
+
+type clientPacketStream struct {
+	net.PacketConn
+	session *clientSession
+	closed  atomic.Bool
+}
+
+func newClientPacketStream(conn net.PacketConn, session *clientSession) *clientPacketStream {
+	return &clientPacketStream{
+		PacketConn: conn,
+		session:    session,
+	}
+}
+
+func (c *clientPacketStream) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.session.streams.Add(-1)
+	}
+	return c.PacketConn.Close()
+}
+
+func (c *clientPacketStream) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *clientPacketStream) WriterReplaceable() bool {
+	return true
+}
+
+func (c *clientPacketStream) Upstream() any {
+	return c.PacketConn
+}
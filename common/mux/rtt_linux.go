@@ -0,0 +1,23 @@
+package mux
+
+import (
+	"net"
+	"time"
+
+	"github.com/sagernet/sing/common/control"
+
+	"golang.org/x/sys/unix"
+)
+
+func tcpRTT(conn *net.TCPConn) (time.Duration, error) {
+	var rtt time.Duration
+	err := control.Conn(conn, func(fd uintptr) error {
+		info, err := unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+		if err != nil {
+			return err
+		}
+		rtt = time.Duration(info.Rtt) * time.Microsecond
+		return nil
+	})
+	return rtt, err
+}
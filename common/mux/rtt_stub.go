@@ -0,0 +1,13 @@
+//go:build !(linux || darwin)
+
+package mux
+
+import (
+	"net"
+	"os"
+	"time"
+)
+
+func tcpRTT(conn *net.TCPConn) (time.Duration, error) {
+	return 0, os.ErrInvalid
+}
@@ -0,0 +1,161 @@
+package nat64
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	routerAdvertisementHeaderLength = 16
+	optionTypePREF64                = 38
+)
+
+var allRoutersAddress = &net.IPAddr{IP: net.ParseIP("ff02::2")}
+
+// Discovery learns the NAT64 prefix of the network from the PREF64 option
+// of IPv6 router advertisements, as described in RFC 8781.
+type Discovery struct {
+	logger    logger.Logger
+	conn      *icmp.PacketConn
+	access    sync.RWMutex
+	prefix    netip.Prefix
+	expiresAt time.Time
+}
+
+func NewDiscovery(logger logger.Logger) *Discovery {
+	return &Discovery{logger: logger}
+}
+
+func (d *Discovery) Start() error {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return E.Cause(err, "listen ICMPv6")
+	}
+	packetConn := conn.IPv6PacketConn()
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+	_ = packetConn.SetICMPFilter(&filter)
+	_ = packetConn.SetControlMessage(ipv6.FlagHopLimit, true)
+	_ = packetConn.SetMulticastHopLimit(255)
+	d.conn = conn
+	go d.loopRead(packetConn)
+	return nil
+}
+
+// Prefix returns the discovered NAT64 prefix if it has not expired.
+func (d *Discovery) Prefix() (netip.Prefix, bool) {
+	d.access.RLock()
+	defer d.access.RUnlock()
+	if !d.prefix.IsValid() || time.Now().After(d.expiresAt) {
+		return netip.Prefix{}, false
+	}
+	return d.prefix, true
+}
+
+// Solicit sends a router solicitation on the interface to get a router advertisement immediately.
+func (d *Discovery) Solicit(interfaceIndex int) {
+	if d.conn == nil {
+		return
+	}
+	message := icmp.Message{
+		Type: ipv6.ICMPTypeRouterSolicitation,
+		Body: &icmp.RawBody{Data: make([]byte, 4)},
+	}
+	content, err := message.Marshal(nil)
+	if err != nil {
+		return
+	}
+	_, err = d.conn.IPv6PacketConn().WriteTo(content, &ipv6.ControlMessage{HopLimit: 255, IfIndex: interfaceIndex}, allRoutersAddress)
+	if err != nil {
+		d.logger.Debug("send router solicitation: ", err)
+	}
+}
+
+func (d *Discovery) loopRead(conn *ipv6.PacketConn) {
+	buffer := make([]byte, 1500)
+	for {
+		n, controlMessage, source, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		sourceAddr, isIPAddr := source.(*net.IPAddr)
+		// router advertisements must be sent from a link-local address with hop limit 255
+		if !isIPAddr || !sourceAddr.IP.IsLinkLocalUnicast() || controlMessage != nil && controlMessage.HopLimit != 255 {
+			continue
+		}
+		d.handleRouterAdvertisement(buffer[:n])
+	}
+}
+
+func (d *Discovery) handleRouterAdvertisement(content []byte) {
+	if len(content) < routerAdvertisementHeaderLength || content[0] != byte(ipv6.ICMPTypeRouterAdvertisement) {
+		return
+	}
+	options := content[routerAdvertisementHeaderLength:]
+	for len(options) >= 2 {
+		optionLength := int(options[1]) * 8
+		if optionLength == 0 || optionLength > len(options) {
+			return
+		}
+		if options[0] == optionTypePREF64 && optionLength == 16 {
+			d.updatePrefix(options[2:optionLength])
+		}
+		options = options[optionLength:]
+	}
+}
+
+func (d *Discovery) updatePrefix(content []byte) {
+	scaledLifetime := binary.BigEndian.Uint16(content[:2])
+	var prefixLength int
+	switch scaledLifetime & 0x7 {
+	case 0:
+		prefixLength = 96
+	case 1:
+		prefixLength = 64
+	case 2:
+		prefixLength = 56
+	case 3:
+		prefixLength = 48
+	case 4:
+		prefixLength = 40
+	case 5:
+		prefixLength = 32
+	default:
+		return
+	}
+	lifetime := time.Duration(scaledLifetime>>3) * 8 * time.Second
+	var prefixBytes [16]byte
+	copy(prefixBytes[:12], content[2:14])
+	prefix := netip.PrefixFrom(netip.AddrFrom16(prefixBytes), prefixLength).Masked()
+	d.access.Lock()
+	defer d.access.Unlock()
+	if lifetime == 0 {
+		if d.prefix == prefix {
+			d.prefix = netip.Prefix{}
+			d.logger.Info("NAT64 prefix withdrawn: ", prefix)
+		}
+		return
+	}
+	if d.prefix != prefix {
+		d.logger.Info("discovered NAT64 prefix: ", prefix)
+	}
+	d.prefix = prefix
+	d.expiresAt = time.Now().Add(lifetime)
+}
+
+func (d *Discovery) Close() error {
+	if d.conn == nil {
+		return nil
+	}
+	return d.conn.Close()
+}
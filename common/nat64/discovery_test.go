@@ -0,0 +1,32 @@
+package nat64
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing/common/logger"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveryRouterAdvertisement(t *testing.T) {
+	t.Parallel()
+	discovery := NewDiscovery(logger.NOP())
+	advertisement := make([]byte, routerAdvertisementHeaderLength)
+	advertisement[0] = 134
+	// source link-layer address option
+	advertisement = append(advertisement, 1, 1, 0, 0, 0, 0, 0, 0)
+	// PREF64 option: lifetime 1800 seconds, prefix 2001:db8:64::/96
+	advertisement = append(advertisement, optionTypePREF64, 2, 0x07, 0x08)
+	advertisement = append(advertisement, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x64, 0, 0, 0, 0, 0, 0)
+	discovery.handleRouterAdvertisement(advertisement)
+	prefix, loaded := discovery.Prefix()
+	require.True(t, loaded)
+	require.Equal(t, netip.MustParsePrefix("2001:db8:64::/96"), prefix)
+	// zero lifetime withdraws the prefix
+	advertisement[len(advertisement)-14] = 0x00
+	advertisement[len(advertisement)-13] = 0x00
+	discovery.handleRouterAdvertisement(advertisement)
+	_, loaded = discovery.Prefix()
+	require.False(t, loaded)
+}
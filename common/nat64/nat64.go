@@ -0,0 +1,64 @@
+package nat64
+
+import (
+	"net/netip"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// WellKnownPrefix is the NAT64 prefix defined in RFC 6052.
+var WellKnownPrefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// ValidatePrefix checks if the prefix is a valid NAT64 prefix, whose length must be one of 32, 40, 48, 56, 64 or 96.
+func ValidatePrefix(prefix netip.Prefix) error {
+	if !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return E.New("NAT64 prefix must be an IPv6 prefix: ", prefix)
+	}
+	switch prefix.Bits() {
+	case 32, 40, 48, 56, 64, 96:
+	default:
+		return E.New("invalid NAT64 prefix length: ", prefix.Bits())
+	}
+	if prefix.Bits() > 64 && prefix.Addr().As16()[8] != 0 {
+		return E.New("bits 64 to 71 of NAT64 prefix must be zero: ", prefix)
+	}
+	return nil
+}
+
+// Synthesize embeds the IPv4 address into the prefix as described in RFC 6052 section 2.2.
+func Synthesize(prefix netip.Prefix, address netip.Addr) netip.Addr {
+	address = address.Unmap()
+	if !address.Is4() {
+		return address
+	}
+	result := prefix.Masked().Addr().As16()
+	addressBytes := address.As4()
+	index := prefix.Bits() / 8
+	for _, b := range addressBytes {
+		if index == 8 {
+			// skip the u-octet
+			index++
+		}
+		result[index] = b
+		index++
+	}
+	return netip.AddrFrom16(result)
+}
+
+// Extract returns the IPv4 address embedded in the address if it is in the prefix.
+func Extract(prefix netip.Prefix, address netip.Addr) (netip.Addr, bool) {
+	if !address.Is6() || address.Is4In6() || !prefix.Contains(address) {
+		return netip.Addr{}, false
+	}
+	addressBytes := address.As16()
+	var result [4]byte
+	index := prefix.Bits() / 8
+	for i := range result {
+		if index == 8 {
+			index++
+		}
+		result[i] = addressBytes[index]
+		index++
+	}
+	return netip.AddrFrom4(result), true
+}
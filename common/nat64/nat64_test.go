@@ -0,0 +1,37 @@
+package nat64
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynthesize(t *testing.T) {
+	t.Parallel()
+	address := netip.MustParseAddr("192.0.2.33")
+	// examples from RFC 6052 section 2.4
+	for _, testCase := range []struct {
+		prefix      string
+		synthesized string
+	}{
+		{"2001:db8::/32", "2001:db8:c000:221::"},
+		{"2001:db8:100::/40", "2001:db8:1c0:2:21::"},
+		{"2001:db8:122::/48", "2001:db8:122:c000:2:2100::"},
+		{"2001:db8:122:300::/56", "2001:db8:122:3c0:0:221::"},
+		{"2001:db8:122:344::/64", "2001:db8:122:344:c0:2:2100:0"},
+		{"2001:db8:122:344::/96", "2001:db8:122:344::c000:221"},
+		{"64:ff9b::/96", "64:ff9b::c000:221"},
+	} {
+		prefix := netip.MustParsePrefix(testCase.prefix)
+		require.NoError(t, ValidatePrefix(prefix))
+		synthesized := Synthesize(prefix, address)
+		require.Equal(t, netip.MustParseAddr(testCase.synthesized), synthesized, testCase.prefix)
+		extracted, loaded := Extract(prefix, synthesized)
+		require.True(t, loaded)
+		require.Equal(t, address, extracted)
+	}
+	_, loaded := Extract(WellKnownPrefix, netip.MustParseAddr("2001:db8::1"))
+	require.False(t, loaded)
+	require.Error(t, ValidatePrefix(netip.MustParsePrefix("64:ff9b::/80")))
+}
@@ -0,0 +1,65 @@
+package nat64
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// PacketConn extracts IPv4 addresses from addresses in the prefix on read,
+// and embeds IPv4 addresses into the prefix on write.
+type PacketConn struct {
+	N.NetPacketConn
+	prefix netip.Prefix
+}
+
+func NewPacketConn(conn N.NetPacketConn, prefix netip.Prefix) *PacketConn {
+	return &PacketConn{conn, prefix}
+}
+
+func (c *PacketConn) extract(destination M.Socksaddr) M.Socksaddr {
+	address, loaded := Extract(c.prefix, destination.Addr)
+	if !loaded {
+		return destination
+	}
+	return M.SocksaddrFrom(address, destination.Port)
+}
+
+func (c *PacketConn) synthesize(destination M.Socksaddr) M.Socksaddr {
+	if !destination.IsIPv4() {
+		return destination
+	}
+	return M.SocksaddrFrom(Synthesize(c.prefix, destination.Addr), destination.Port)
+}
+
+func (c *PacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	n, addr, err = c.NetPacketConn.ReadFrom(p)
+	if err != nil {
+		return
+	}
+	addr = c.extract(M.SocksaddrFromNet(addr)).UDPAddr()
+	return
+}
+
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	return c.NetPacketConn.WriteTo(p, c.synthesize(M.SocksaddrFromNet(addr)).UDPAddr())
+}
+
+func (c *PacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.NetPacketConn.ReadPacket(buffer)
+	if err != nil {
+		return
+	}
+	return c.extract(destination), nil
+}
+
+func (c *PacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	return c.NetPacketConn.WritePacket(buffer, c.synthesize(destination))
+}
+
+func (c *PacketConn) Upstream() any {
+	return c.NetPacketConn
+}
@@ -0,0 +1,107 @@
+package pcap
+
+import (
+	"net/netip"
+	"sync"
+)
+
+// MaxPayloadLength keeps synthesized packets below the IPv6 payload length limit.
+const MaxPayloadLength = 65535 - 40 - 20
+
+// TCPFlow synthesizes the packets of a TCP connection from its byte streams,
+// so that packet analyzers can reassemble it.
+type TCPFlow struct {
+	access               sync.Mutex
+	client               netip.AddrPort
+	server               netip.AddrPort
+	clientSequence       uint32
+	serverSequence       uint32
+	clientFinished       bool
+	serverFinished       bool
+	handshakeSynthesized bool
+}
+
+func NewTCPFlow(client netip.AddrPort, server netip.AddrPort) *TCPFlow {
+	client, server = normalizeAddresses(client, server)
+	return &TCPFlow{
+		client:         client,
+		server:         server,
+		clientSequence: 1,
+		serverSequence: 1,
+	}
+}
+
+// Handshake returns the SYN, SYN-ACK and ACK packets opening the flow.
+func (f *TCPFlow) Handshake() [][]byte {
+	f.access.Lock()
+	defer f.access.Unlock()
+	return f.handshake()
+}
+
+func (f *TCPFlow) handshake() [][]byte {
+	if f.handshakeSynthesized {
+		return nil
+	}
+	f.handshakeSynthesized = true
+	packets := [][]byte{
+		buildTCPPacket(f.client, f.server, f.clientSequence-1, 0, tcpFlagSYN, nil),
+		buildTCPPacket(f.server, f.client, f.serverSequence-1, f.clientSequence, tcpFlagSYN|tcpFlagACK, nil),
+		buildTCPPacket(f.client, f.server, f.clientSequence, f.serverSequence, tcpFlagACK, nil),
+	}
+	return packets
+}
+
+// Upload returns packets carrying payload from client to server.
+func (f *TCPFlow) Upload(payload []byte) [][]byte {
+	f.access.Lock()
+	defer f.access.Unlock()
+	packets := f.handshake()
+	for len(payload) > 0 {
+		segment := payload
+		if len(segment) > MaxPayloadLength {
+			segment = segment[:MaxPayloadLength]
+		}
+		packets = append(packets, buildTCPPacket(f.client, f.server, f.clientSequence, f.serverSequence, tcpFlagPSH|tcpFlagACK, segment))
+		f.clientSequence += uint32(len(segment))
+		payload = payload[len(segment):]
+	}
+	return packets
+}
+
+// Download returns packets carrying payload from server to client.
+func (f *TCPFlow) Download(payload []byte) [][]byte {
+	f.access.Lock()
+	defer f.access.Unlock()
+	packets := f.handshake()
+	for len(payload) > 0 {
+		segment := payload
+		if len(segment) > MaxPayloadLength {
+			segment = segment[:MaxPayloadLength]
+		}
+		packets = append(packets, buildTCPPacket(f.server, f.client, f.serverSequence, f.clientSequence, tcpFlagPSH|tcpFlagACK, segment))
+		f.serverSequence += uint32(len(segment))
+		payload = payload[len(segment):]
+	}
+	return packets
+}
+
+// Close returns the FIN exchange closing both directions of the flow.
+func (f *TCPFlow) Close() [][]byte {
+	f.access.Lock()
+	defer f.access.Unlock()
+	packets := f.handshake()
+	if !f.clientFinished {
+		f.clientFinished = true
+		packets = append(packets, buildTCPPacket(f.client, f.server, f.clientSequence, f.serverSequence, tcpFlagFIN|tcpFlagACK, nil))
+		f.clientSequence++
+	}
+	if !f.serverFinished {
+		f.serverFinished = true
+		packets = append(packets,
+			buildTCPPacket(f.server, f.client, f.serverSequence, f.clientSequence, tcpFlagFIN|tcpFlagACK, nil),
+			buildTCPPacket(f.client, f.server, f.clientSequence, f.serverSequence+1, tcpFlagACK, nil),
+		)
+		f.serverSequence++
+	}
+	return packets
+}
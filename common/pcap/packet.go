@@ -0,0 +1,182 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+const (
+	protocolTCP = 6
+	protocolUDP = 17
+
+	tcpFlagFIN = 0x01
+	tcpFlagSYN = 0x02
+	tcpFlagPSH = 0x08
+	tcpFlagACK = 0x10
+)
+
+// normalizeAddresses makes both endpoints share one address family, since a synthesized packet has a single IP header.
+func normalizeAddresses(source netip.AddrPort, destination netip.AddrPort) (netip.AddrPort, netip.AddrPort) {
+	sourceAddr := source.Addr().Unmap()
+	destinationAddr := destination.Addr().Unmap()
+	if !sourceAddr.IsValid() {
+		if destinationAddr.Is6() {
+			sourceAddr = netip.IPv6Unspecified()
+		} else {
+			sourceAddr = netip.IPv4Unspecified()
+		}
+	}
+	if !destinationAddr.IsValid() {
+		if sourceAddr.Is6() {
+			destinationAddr = netip.IPv6Unspecified()
+		} else {
+			destinationAddr = netip.IPv4Unspecified()
+		}
+	}
+	if sourceAddr.Is4() != destinationAddr.Is4() {
+		if sourceAddr.Is4() {
+			sourceAddr = netip.AddrFrom16(sourceAddr.As16())
+		} else {
+			destinationAddr = netip.AddrFrom16(destinationAddr.As16())
+		}
+	}
+	return netip.AddrPortFrom(sourceAddr, source.Port()), netip.AddrPortFrom(destinationAddr, destination.Port())
+}
+
+func buildIPPacket(source netip.Addr, destination netip.Addr, protocol byte, transport []byte) []byte {
+	var packet []byte
+	if source.Is4() {
+		packet = make([]byte, 20+len(transport))
+		packet[0] = 0x45
+		binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+		packet[8] = 64
+		packet[9] = protocol
+		sourceBytes := source.As4()
+		destinationBytes := destination.As4()
+		copy(packet[12:], sourceBytes[:])
+		copy(packet[16:], destinationBytes[:])
+		binary.BigEndian.PutUint16(packet[10:], foldChecksum(checksum(0, packet[:20])))
+		copy(packet[20:], transport)
+	} else {
+		packet = make([]byte, 40+len(transport))
+		packet[0] = 0x60
+		binary.BigEndian.PutUint16(packet[4:], uint16(len(transport)))
+		packet[6] = protocol
+		packet[7] = 64
+		sourceBytes := source.As16()
+		destinationBytes := destination.As16()
+		copy(packet[8:], sourceBytes[:])
+		copy(packet[24:], destinationBytes[:])
+		copy(packet[40:], transport)
+	}
+	return packet
+}
+
+func pseudoHeaderChecksum(source netip.Addr, destination netip.Addr, protocol byte, length int) uint32 {
+	var sum uint32
+	sum = checksum(sum, source.AsSlice())
+	sum = checksum(sum, destination.AsSlice())
+	sum += uint32(protocol)
+	sum += uint32(length)
+	return sum
+}
+
+func checksum(initial uint32, data []byte) uint32 {
+	sum := initial
+	for len(data) >= 2 {
+		sum += uint32(binary.BigEndian.Uint16(data))
+		data = data[2:]
+	}
+	if len(data) == 1 {
+		sum += uint32(data[0]) << 8
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return sum
+}
+
+func foldChecksum(sum uint32) uint16 {
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
+
+func buildTCPPacket(source netip.AddrPort, destination netip.AddrPort, sequence uint32, acknowledgment uint32, flags byte, payload []byte) []byte {
+	segment := make([]byte, 20+len(payload))
+	binary.BigEndian.PutUint16(segment[0:], source.Port())
+	binary.BigEndian.PutUint16(segment[2:], destination.Port())
+	binary.BigEndian.PutUint32(segment[4:], sequence)
+	binary.BigEndian.PutUint32(segment[8:], acknowledgment)
+	segment[12] = 5 << 4
+	segment[13] = flags
+	binary.BigEndian.PutUint16(segment[14:], 65535)
+	copy(segment[20:], payload)
+	sum := pseudoHeaderChecksum(source.Addr(), destination.Addr(), protocolTCP, len(segment))
+	binary.BigEndian.PutUint16(segment[16:], foldChecksum(checksum(sum, segment)))
+	return buildIPPacket(source.Addr(), destination.Addr(), protocolTCP, segment)
+}
+
+// BuildUDPPacket synthesizes an IP packet carrying payload in a UDP datagram.
+func BuildUDPPacket(source netip.AddrPort, destination netip.AddrPort, payload []byte) []byte {
+	source, destination = normalizeAddresses(source, destination)
+	if len(payload) > MaxPayloadLength {
+		payload = payload[:MaxPayloadLength]
+	}
+	datagram := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint16(datagram[0:], source.Port())
+	binary.BigEndian.PutUint16(datagram[2:], destination.Port())
+	binary.BigEndian.PutUint16(datagram[4:], uint16(len(datagram)))
+	copy(datagram[8:], payload)
+	sum := pseudoHeaderChecksum(source.Addr(), destination.Addr(), protocolUDP, len(datagram))
+	udpChecksum := foldChecksum(checksum(sum, datagram))
+	if udpChecksum == 0 {
+		udpChecksum = 0xffff
+	}
+	binary.BigEndian.PutUint16(datagram[6:], udpChecksum)
+	return buildIPPacket(source.Addr(), destination.Addr(), protocolUDP, datagram)
+}
+
+// ParseAddresses returns the endpoints of a raw IP packet,
+// ports are zero for protocols other than TCP and UDP and for non-first fragments.
+func ParseAddresses(packet []byte) (source netip.AddrPort, destination netip.AddrPort, ok bool) {
+	if len(packet) == 0 {
+		return
+	}
+	var (
+		sourceAddr      netip.Addr
+		destinationAddr netip.Addr
+		protocol        byte
+		transport       []byte
+	)
+	switch packet[0] >> 4 {
+	case 4:
+		headerLength := int(packet[0]&0x0f) * 4
+		if headerLength < 20 || len(packet) < headerLength {
+			return
+		}
+		sourceAddr = netip.AddrFrom4([4]byte(packet[12:16]))
+		destinationAddr = netip.AddrFrom4([4]byte(packet[16:20]))
+		if binary.BigEndian.Uint16(packet[6:])&0x1fff == 0 {
+			protocol = packet[9]
+			transport = packet[headerLength:]
+		}
+	case 6:
+		if len(packet) < 40 {
+			return
+		}
+		sourceAddr = netip.AddrFrom16([16]byte(packet[8:24]))
+		destinationAddr = netip.AddrFrom16([16]byte(packet[24:40]))
+		protocol = packet[6]
+		transport = packet[40:]
+	default:
+		return
+	}
+	var sourcePort, destinationPort uint16
+	if (protocol == protocolTCP || protocol == protocolUDP) && len(transport) >= 4 {
+		sourcePort = binary.BigEndian.Uint16(transport[0:])
+		destinationPort = binary.BigEndian.Uint16(transport[2:])
+	}
+	return netip.AddrPortFrom(sourceAddr, sourcePort), netip.AddrPortFrom(destinationAddr, destinationPort), true
+}
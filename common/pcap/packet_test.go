@@ -0,0 +1,57 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildUDPPacket(t *testing.T) {
+	t.Parallel()
+	source := netip.MustParseAddrPort("192.168.1.2:53000")
+	destination := netip.MustParseAddrPort("1.1.1.1:53")
+	payload := []byte("hello")
+	packet := BuildUDPPacket(source, destination, payload)
+	require.Len(t, packet, 20+8+len(payload))
+	require.Equal(t, uint16(0), foldChecksum(checksum(0, packet[:20])))
+	datagram := packet[20:]
+	sum := pseudoHeaderChecksum(source.Addr(), destination.Addr(), protocolUDP, len(datagram))
+	require.Equal(t, uint16(0), foldChecksum(checksum(sum, datagram)))
+	require.Equal(t, payload, datagram[8:])
+}
+
+func TestTCPFlowMixedFamily(t *testing.T) {
+	t.Parallel()
+	client := netip.MustParseAddrPort("192.168.1.2:53000")
+	server := netip.MustParseAddrPort("[2001:db8::1]:443")
+	flow := NewTCPFlow(client, server)
+	packets := flow.Upload([]byte("hello"))
+	require.Len(t, packets, 4)
+	for _, packet := range packets {
+		require.Equal(t, byte(0x60), packet[0])
+		segment := packet[40:]
+		source := netip.AddrFrom16([16]byte(packet[8:24]))
+		destination := netip.AddrFrom16([16]byte(packet[24:40]))
+		sum := pseudoHeaderChecksum(source, destination, protocolTCP, len(segment))
+		require.Equal(t, uint16(0), foldChecksum(checksum(sum, segment)))
+	}
+	dataPacket := packets[3][40:]
+	require.Equal(t, uint32(1), binary.BigEndian.Uint32(dataPacket[4:]))
+	packets = flow.Download([]byte("world"))
+	require.Len(t, packets, 1)
+	require.Equal(t, uint32(6), binary.BigEndian.Uint32(packets[0][40+8:]))
+}
+
+func TestParseAddresses(t *testing.T) {
+	t.Parallel()
+	source := netip.MustParseAddrPort("[2001:db8::2]:53000")
+	destination := netip.MustParseAddrPort("[2001:db8::1]:53")
+	parsedSource, parsedDestination, ok := ParseAddresses(BuildUDPPacket(source, destination, []byte("hello")))
+	require.True(t, ok)
+	require.Equal(t, source, parsedSource)
+	require.Equal(t, destination, parsedDestination)
+	_, _, ok = ParseAddresses([]byte{0x45})
+	require.False(t, ok)
+}
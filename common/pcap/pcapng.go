@@ -0,0 +1,136 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	blockTypeSectionHeader      = 0x0a0d0d0a
+	blockTypeInterfaceDesc      = 0x00000001
+	blockTypeEnhancedPacket     = 0x00000006
+	byteOrderMagic              = 0x1a2b3c4d
+	optionEndOfOptions          = 0
+	optionSectionUserAppl       = 4
+	optionInterfaceName         = 2
+	optionEnhancedPacketFlags   = 2
+	enhancedPacketFlagInbound   = 1
+	enhancedPacketFlagOutbound  = 2
+	enhancedPacketHeaderLength  = 20
+	enhancedPacketOptionsLength = 12
+)
+
+type Direction uint8
+
+const (
+	DirectionUnknown Direction = iota
+	DirectionInbound
+	DirectionOutbound
+)
+
+// NGWriter writes packets in the pcapng format, with one interface per name.
+type NGWriter struct {
+	access     sync.Mutex
+	writer     io.Writer
+	snapLength uint32
+	interfaces map[string]uint32
+}
+
+func NewNGWriter(writer io.Writer, application string, snapLength uint32) (*NGWriter, error) {
+	if snapLength == 0 || snapLength > SnapLength {
+		snapLength = SnapLength
+	}
+	body := make([]byte, 16)
+	binary.LittleEndian.PutUint32(body[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(body[4:], 1)
+	binary.LittleEndian.PutUint16(body[6:], 0)
+	binary.LittleEndian.PutUint64(body[8:], ^uint64(0))
+	body = appendOption(body, optionSectionUserAppl, []byte(application))
+	body = appendOption(body, optionEndOfOptions, nil)
+	_, err := writer.Write(buildBlock(blockTypeSectionHeader, body))
+	if err != nil {
+		return nil, err
+	}
+	return &NGWriter{
+		writer:     writer,
+		snapLength: snapLength,
+		interfaces: make(map[string]uint32),
+	}, nil
+}
+
+func (w *NGWriter) interfaceID(name string) (uint32, error) {
+	id, loaded := w.interfaces[name]
+	if loaded {
+		return id, nil
+	}
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint16(body[0:], LinkTypeRaw)
+	binary.LittleEndian.PutUint32(body[4:], w.snapLength)
+	body = appendOption(body, optionInterfaceName, []byte(name))
+	body = appendOption(body, optionEndOfOptions, nil)
+	_, err := w.writer.Write(buildBlock(blockTypeInterfaceDesc, body))
+	if err != nil {
+		return 0, err
+	}
+	id = uint32(len(w.interfaces))
+	w.interfaces[name] = id
+	return id, nil
+}
+
+// WritePacket writes a raw IP packet captured on the named interface,
+// the interface description is written on first use.
+func (w *NGWriter) WritePacket(interfaceName string, direction Direction, timestamp time.Time, packet []byte) error {
+	w.access.Lock()
+	defer w.access.Unlock()
+	id, err := w.interfaceID(interfaceName)
+	if err != nil {
+		return err
+	}
+	capturedLength := len(packet)
+	if capturedLength > int(w.snapLength) {
+		capturedLength = int(w.snapLength)
+	}
+	paddedLength := (capturedLength + 3) &^ 3
+	body := make([]byte, enhancedPacketHeaderLength+paddedLength, enhancedPacketHeaderLength+paddedLength+enhancedPacketOptionsLength)
+	timestampMicros := uint64(timestamp.UnixMicro())
+	binary.LittleEndian.PutUint32(body[0:], id)
+	binary.LittleEndian.PutUint32(body[4:], uint32(timestampMicros>>32))
+	binary.LittleEndian.PutUint32(body[8:], uint32(timestampMicros))
+	binary.LittleEndian.PutUint32(body[12:], uint32(capturedLength))
+	binary.LittleEndian.PutUint32(body[16:], uint32(len(packet)))
+	copy(body[enhancedPacketHeaderLength:], packet[:capturedLength])
+	if direction != DirectionUnknown {
+		var flags [4]byte
+		if direction == DirectionInbound {
+			binary.LittleEndian.PutUint32(flags[:], enhancedPacketFlagInbound)
+		} else {
+			binary.LittleEndian.PutUint32(flags[:], enhancedPacketFlagOutbound)
+		}
+		body = appendOption(body, optionEnhancedPacketFlags, flags[:])
+		body = appendOption(body, optionEndOfOptions, nil)
+	}
+	_, err = w.writer.Write(buildBlock(blockTypeEnhancedPacket, body))
+	return err
+}
+
+func appendOption(body []byte, code uint16, value []byte) []byte {
+	body = binary.LittleEndian.AppendUint16(body, code)
+	body = binary.LittleEndian.AppendUint16(body, uint16(len(value)))
+	body = append(body, value...)
+	for padding := (4 - len(value)%4) % 4; padding > 0; padding-- {
+		body = append(body, 0)
+	}
+	return body
+}
+
+func buildBlock(blockType uint32, body []byte) []byte {
+	totalLength := uint32(12 + len(body))
+	block := make([]byte, 0, totalLength)
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, totalLength)
+	block = append(block, body...)
+	block = binary.LittleEndian.AppendUint32(block, totalLength)
+	return block
+}
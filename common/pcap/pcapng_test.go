@@ -0,0 +1,41 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNGWriter(t *testing.T) {
+	t.Parallel()
+	var output bytes.Buffer
+	writer, err := NewNGWriter(&output, "test", 0)
+	require.NoError(t, err)
+	packet := BuildUDPPacket(netip.MustParseAddrPort("10.0.0.1:1000"), netip.MustParseAddrPort("10.0.0.2:53"), []byte("abc"))
+	require.NoError(t, writer.WritePacket("tun", DirectionInbound, time.Now(), packet))
+	require.NoError(t, writer.WritePacket("tun", DirectionOutbound, time.Now(), packet))
+	require.NoError(t, writer.WritePacket("mixed", DirectionUnknown, time.Now(), packet))
+	var blockTypes []uint32
+	content := output.Bytes()
+	for len(content) > 0 {
+		require.GreaterOrEqual(t, len(content), 12)
+		blockType := binary.LittleEndian.Uint32(content)
+		blockLength := binary.LittleEndian.Uint32(content[4:])
+		require.Zero(t, blockLength%4)
+		require.Equal(t, blockLength, binary.LittleEndian.Uint32(content[blockLength-4:]))
+		blockTypes = append(blockTypes, blockType)
+		content = content[blockLength:]
+	}
+	require.Equal(t, []uint32{
+		blockTypeSectionHeader,
+		blockTypeInterfaceDesc,
+		blockTypeEnhancedPacket,
+		blockTypeEnhancedPacket,
+		blockTypeInterfaceDesc,
+		blockTypeEnhancedPacket,
+	}, blockTypes)
+}
@@ -0,0 +1,57 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	LinkTypeRaw = 101
+	SnapLength  = 262144
+)
+
+type Writer struct {
+	access sync.Mutex
+	writer io.Writer
+}
+
+func NewWriter(writer io.Writer) (*Writer, error) {
+	var header [24]byte
+	binary.LittleEndian.PutUint32(header[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], SnapLength)
+	binary.LittleEndian.PutUint32(header[20:], LinkTypeRaw)
+	_, err := writer.Write(header[:])
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{writer: writer}, nil
+}
+
+func (w *Writer) WritePackets(timestamp time.Time, packets ...[]byte) error {
+	w.access.Lock()
+	defer w.access.Unlock()
+	for _, packet := range packets {
+		capturedLength := len(packet)
+		if capturedLength > SnapLength {
+			capturedLength = SnapLength
+		}
+		var header [16]byte
+		binary.LittleEndian.PutUint32(header[0:], uint32(timestamp.Unix()))
+		binary.LittleEndian.PutUint32(header[4:], uint32(timestamp.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(header[8:], uint32(capturedLength))
+		binary.LittleEndian.PutUint32(header[12:], uint32(len(packet)))
+		_, err := w.writer.Write(header[:])
+		if err != nil {
+			return err
+		}
+		_, err = w.writer.Write(packet[:capturedLength])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
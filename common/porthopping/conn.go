@@ -0,0 +1,178 @@
+package porthopping
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const packetQueueSize = 1024
+
+// hopConn is a UDP connection that redials to a random server port every interval,
+// packets from the previous connection are still accepted until the next hop.
+type hopConn struct {
+	ctx         context.Context
+	dialer      N.Dialer
+	destination M.Socksaddr
+	ports       []uint16
+	remoteAddr  net.Addr
+	access      sync.Mutex
+	prevConn    net.Conn
+	currentConn net.Conn
+	packetChan  chan *buf.Buffer
+	deadline    readDeadline
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func newHopConn(ctx context.Context, dialer N.Dialer, destination M.Socksaddr, ports []uint16, interval time.Duration) (*hopConn, error) {
+	conn := &hopConn{
+		ctx:         context.WithoutCancel(ctx),
+		dialer:      dialer,
+		destination: destination,
+		ports:       ports,
+		packetChan:  make(chan *buf.Buffer, packetQueueSize),
+		deadline:    makeReadDeadline(),
+		done:        make(chan struct{}),
+	}
+	currentConn, err := dialer.DialContext(ctx, N.NetworkUDP, conn.nextAddr())
+	if err != nil {
+		return nil, err
+	}
+	conn.currentConn = currentConn
+	conn.remoteAddr = currentConn.RemoteAddr()
+	go conn.loopRead(currentConn)
+	go conn.loopHop(interval)
+	return conn, nil
+}
+
+func (c *hopConn) nextAddr() M.Socksaddr {
+	return M.Socksaddr{
+		Addr: c.destination.Addr,
+		Fqdn: c.destination.Fqdn,
+		Port: c.ports[rand.Intn(len(c.ports))],
+	}
+}
+
+func (c *hopConn) loopRead(conn net.Conn) {
+	for {
+		buffer := buf.NewPacket()
+		_, err := buffer.ReadOnceFrom(conn)
+		if err != nil {
+			// the previous connection is closed on every hop
+			buffer.Release()
+			return
+		}
+		select {
+		case c.packetChan <- buffer:
+		default:
+			buffer.Release()
+		}
+	}
+}
+
+func (c *hopConn) loopHop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.hop()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *hopConn) hop() {
+	ctx, cancel := context.WithTimeout(c.ctx, C.TCPConnectTimeout)
+	newConn, err := c.dialer.DialContext(ctx, N.NetworkUDP, c.nextAddr())
+	cancel()
+	if err != nil {
+		return
+	}
+	c.access.Lock()
+	defer c.access.Unlock()
+	select {
+	case <-c.done:
+		newConn.Close()
+		return
+	default:
+	}
+	if c.prevConn != nil {
+		c.prevConn.Close()
+	}
+	c.prevConn = c.currentConn
+	c.currentConn = newConn
+	go c.loopRead(newConn)
+}
+
+func (c *hopConn) Read(b []byte) (n int, err error) {
+	select {
+	case packet := <-c.packetChan:
+		n = copy(b, packet.Bytes())
+		packet.Release()
+		return n, nil
+	case <-c.deadline.wait():
+		return 0, os.ErrDeadlineExceeded
+	case <-c.done:
+		return 0, net.ErrClosed
+	}
+}
+
+func (c *hopConn) Write(b []byte) (n int, err error) {
+	c.access.Lock()
+	currentConn := c.currentConn
+	c.access.Unlock()
+	select {
+	case <-c.done:
+		return 0, net.ErrClosed
+	default:
+	}
+	return currentConn.Write(b)
+}
+
+func (c *hopConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.access.Lock()
+	defer c.access.Unlock()
+	return common.Close(c.prevConn, c.currentConn)
+}
+
+func (c *hopConn) LocalAddr() net.Addr {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.currentConn.LocalAddr()
+}
+
+// RemoteAddr returns the address of the first connection, it stays the same across hops.
+func (c *hopConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *hopConn) SetDeadline(t time.Time) error {
+	c.deadline.set(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *hopConn) SetReadDeadline(t time.Time) error {
+	c.deadline.set(t)
+	return nil
+}
+
+func (c *hopConn) SetWriteDeadline(t time.Time) error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.currentConn.SetWriteDeadline(t)
+}
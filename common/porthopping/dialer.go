@@ -0,0 +1,51 @@
+package porthopping
+
+import (
+	"context"
+	"net"
+	"time"
+
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const DefaultHopInterval = 30 * time.Second
+
+var _ N.Dialer = (*Dialer)(nil)
+
+// Dialer dials UDP connections that periodically hop between a list of server ports.
+type Dialer struct {
+	dialer   N.Dialer
+	ports    []uint16
+	interval time.Duration
+}
+
+func NewDialer(dialer N.Dialer, serverPorts []string, hopInterval time.Duration) (*Dialer, error) {
+	ports, err := ParsePorts(serverPorts)
+	if err != nil {
+		return nil, err
+	}
+	if hopInterval == 0 {
+		hopInterval = DefaultHopInterval
+	}
+	return &Dialer{
+		dialer:   dialer,
+		ports:    ports,
+		interval: hopInterval,
+	}, nil
+}
+
+func (d *Dialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if N.NetworkName(network) != N.NetworkUDP {
+		return d.dialer.DialContext(ctx, network, destination)
+	}
+	return newHopConn(ctx, d.dialer, destination, d.ports, d.interval)
+}
+
+func (d *Dialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return d.dialer.ListenPacket(ctx, destination)
+}
+
+func (d *Dialer) Upstream() any {
+	return d.dialer
+}
@@ -0,0 +1,280 @@
+package porthopping
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/contrab/freelru"
+	"github.com/sagernet/sing/contrab/maphash"
+
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	clientCacheCapacity = 4096
+	clientCacheLifetime = 5 * time.Minute
+	oobBufferSize       = 128
+)
+
+type packet struct {
+	buffer *buf.Buffer
+	oob    *buf.Buffer
+	source netip.AddrPort
+}
+
+func (p packet) release() {
+	p.buffer.Release()
+	p.oob.Release()
+}
+
+// PacketConn merges UDP sockets listening on multiple ports into one packet connection,
+// replies are written from the socket that last received a packet from the destination.
+//
+// Like *net.UDPConn, it reads and writes control messages and exposes the sockets through SyscallConn,
+// so that QUIC servers keep ECN, packet info and GSO.
+type PacketConn struct {
+	conns      []*net.UDPConn
+	clients    *freelru.SyncedLRU[netip.AddrPort, *net.UDPConn]
+	packetChan chan packet
+	errChan    chan error
+	deadline   readDeadline
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+func NewPacketConn(conns []*net.UDPConn) *PacketConn {
+	clients := common.Must1(freelru.NewSynced[netip.AddrPort, *net.UDPConn](clientCacheCapacity, maphash.NewHasher[netip.AddrPort]().Hash32))
+	clients.SetLifetime(clientCacheLifetime)
+	packetConn := &PacketConn{
+		conns:      conns,
+		clients:    clients,
+		packetChan: make(chan packet, packetQueueSize),
+		errChan:    make(chan error, 1),
+		deadline:   makeReadDeadline(),
+		done:       make(chan struct{}),
+	}
+	for _, conn := range conns {
+		go packetConn.loopRead(conn)
+	}
+	return packetConn
+}
+
+func (c *PacketConn) loopRead(conn *net.UDPConn) {
+	for {
+		buffer := buf.NewPacket()
+		oob := buf.NewSize(oobBufferSize)
+		n, oobn, _, source, err := conn.ReadMsgUDPAddrPort(buffer.FreeBytes(), oob.FreeBytes())
+		if err != nil {
+			buffer.Release()
+			oob.Release()
+			select {
+			case c.errChan <- err:
+			default:
+			}
+			return
+		}
+		buffer.Truncate(n)
+		oob.Truncate(oobn)
+		source = netip.AddrPortFrom(source.Addr().Unmap(), source.Port())
+		c.clients.Add(source, conn)
+		select {
+		case c.packetChan <- packet{buffer, oob, source}:
+		default:
+			buffer.Release()
+			oob.Release()
+		}
+	}
+}
+
+func (c *PacketConn) readPacket() (packet, error) {
+	select {
+	case packet := <-c.packetChan:
+		return packet, nil
+	case err := <-c.errChan:
+		c.Close()
+		return packet{}, err
+	case <-c.deadline.wait():
+		return packet{}, os.ErrDeadlineExceeded
+	case <-c.done:
+		return packet{}, net.ErrClosed
+	}
+}
+
+func (c *PacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	packet, err := c.readPacket()
+	if err != nil {
+		return
+	}
+	defer packet.release()
+	n = copy(p, packet.buffer.Bytes())
+	return n, net.UDPAddrFromAddrPort(packet.source), nil
+}
+
+func (c *PacketConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	packet, err := c.readPacket()
+	if err != nil {
+		return
+	}
+	defer packet.release()
+	n = copy(b, packet.buffer.Bytes())
+	oobn = copy(oob, packet.oob.Bytes())
+	return n, oobn, 0, net.UDPAddrFromAddrPort(packet.source), nil
+}
+
+// ReadBatch waits for the first packet and returns queued packets without blocking further.
+func (c *PacketConn) ReadBatch(messages []ipv4.Message, flags int) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	packet, err := c.readPacket()
+	if err != nil {
+		return 0, err
+	}
+	readMessage(&messages[0], packet)
+	for i := 1; i < len(messages); i++ {
+		select {
+		case packet = <-c.packetChan:
+			readMessage(&messages[i], packet)
+		default:
+			return i, nil
+		}
+	}
+	return len(messages), nil
+}
+
+func readMessage(message *ipv4.Message, packet packet) {
+	defer packet.release()
+	data := packet.buffer.Bytes()
+	var n int
+	for _, buffer := range message.Buffers {
+		n += copy(buffer, data[n:])
+	}
+	message.N = n
+	message.NN = copy(message.OOB, packet.oob.Bytes())
+	message.Flags = 0
+	message.Addr = net.UDPAddrFromAddrPort(packet.source)
+}
+
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	destination := M.AddrPortFromNet(addr)
+	if !destination.IsValid() {
+		return 0, E.New("invalid destination: ", addr)
+	}
+	return c.loadConn(destination).WriteToUDPAddrPort(p, destination)
+}
+
+func (c *PacketConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	destination := addr.AddrPort()
+	if !destination.IsValid() {
+		return 0, 0, E.New("invalid destination: ", addr)
+	}
+	return c.loadConn(destination).WriteMsgUDP(b, oob, addr)
+}
+
+func (c *PacketConn) loadConn(destination netip.AddrPort) *net.UDPConn {
+	conn, loaded := c.clients.Get(netip.AddrPortFrom(destination.Addr().Unmap(), destination.Port()))
+	if !loaded {
+		conn = c.conns[0]
+	}
+	return conn
+}
+
+// SyscallConn returns a raw connection which applies Control to every socket.
+func (c *PacketConn) SyscallConn() (syscall.RawConn, error) {
+	rawConns := make([]syscall.RawConn, 0, len(c.conns))
+	for _, conn := range c.conns {
+		rawConn, err := conn.SyscallConn()
+		if err != nil {
+			return nil, err
+		}
+		rawConns = append(rawConns, rawConn)
+	}
+	return &multiRawConn{rawConns}, nil
+}
+
+func (c *PacketConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		for _, conn := range c.conns {
+			err = E.Errors(err, conn.Close())
+		}
+	})
+	return err
+}
+
+// LocalAddr returns the address of the first socket.
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.conns[0].LocalAddr()
+}
+
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	c.deadline.set(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	c.deadline.set(t)
+	return nil
+}
+
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	for _, conn := range c.conns {
+		err := conn.SetWriteDeadline(t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PacketConn) SetReadBuffer(bytes int) error {
+	for _, conn := range c.conns {
+		err := conn.SetReadBuffer(bytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *PacketConn) SetWriteBuffer(bytes int) error {
+	for _, conn := range c.conns {
+		err := conn.SetWriteBuffer(bytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type multiRawConn struct {
+	conns []syscall.RawConn
+}
+
+func (c *multiRawConn) Control(f func(fd uintptr)) error {
+	for _, conn := range c.conns {
+		err := conn.Control(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read is not supported since a packet may arrive at any of the sockets.
+func (c *multiRawConn) Read(f func(fd uintptr) (done bool)) error {
+	return os.ErrInvalid
+}
+
+func (c *multiRawConn) Write(f func(fd uintptr) (done bool)) error {
+	return os.ErrInvalid
+}
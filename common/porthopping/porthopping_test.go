@@ -0,0 +1,132 @@
+package porthopping
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+)
+
+func TestParsePorts(t *testing.T) {
+	t.Parallel()
+	ports, err := ParsePorts([]string{"443", "1000:1002"})
+	require.NoError(t, err)
+	require.Equal(t, []uint16{443, 1000, 1001, 1002}, ports)
+	_, err = ParsePorts([]string{"1002:1000"})
+	require.Error(t, err)
+	_, err = ParsePorts([]string{"http"})
+	require.Error(t, err)
+}
+
+func TestPortHopping(t *testing.T) {
+	t.Parallel()
+	var (
+		udpConns    []*net.UDPConn
+		serverPorts []string
+	)
+	for i := 0; i < 3; i++ {
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		udpConns = append(udpConns, udpConn)
+		serverPorts = append(serverPorts, strconv.Itoa(udpConn.LocalAddr().(*net.UDPAddr).Port))
+	}
+	serverConn := NewPacketConn(udpConns)
+	defer serverConn.Close()
+	receivedPorts := make(chan int, 64)
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, addr, err := serverConn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			receivedPorts <- addr.(*net.UDPAddr).Port
+			_, err = serverConn.WriteTo(buffer[:n], addr)
+			if err != nil {
+				return
+			}
+		}
+	}()
+	hopDialer, err := NewDialer(N.SystemDialer, serverPorts, 20*time.Millisecond)
+	require.NoError(t, err)
+	conn, err := hopDialer.DialContext(context.Background(), N.NetworkUDP, M.ParseSocksaddrHostPort("127.0.0.1", 0))
+	require.NoError(t, err)
+	defer conn.Close()
+	clientPorts := make(map[int]bool)
+	buffer := make([]byte, 2048)
+	for i := 0; i < 20; i++ {
+		message := []byte("hello " + strconv.Itoa(i))
+		_, err = conn.Write(message)
+		require.NoError(t, err)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buffer)
+		require.NoError(t, err)
+		require.Equal(t, message, buffer[:n])
+		clientPorts[<-receivedPorts] = true
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Greater(t, len(clientPorts), 1)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, err = conn.Read(buffer)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
+
+func TestPacketConnMessages(t *testing.T) {
+	t.Parallel()
+	var udpConns []*net.UDPConn
+	for i := 0; i < 2; i++ {
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		udpConns = append(udpConns, udpConn)
+	}
+	serverConn := NewPacketConn(udpConns)
+	defer serverConn.Close()
+	rawConn, err := serverConn.SyscallConn()
+	require.NoError(t, err)
+	var controlled int
+	require.NoError(t, rawConn.Control(func(fd uintptr) {
+		controlled++
+	}))
+	require.Equal(t, 2, controlled)
+
+	clientConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer clientConn.Close()
+	for _, message := range []string{"hello", "world"} {
+		_, err = clientConn.WriteTo([]byte(message), udpConns[1].LocalAddr())
+		require.NoError(t, err)
+	}
+	messages := make([]ipv4.Message, 4)
+	for i := range messages {
+		messages[i].Buffers = [][]byte{make([]byte, 2048)}
+		messages[i].OOB = make([]byte, oobBufferSize)
+	}
+	var received []string
+	require.NoError(t, serverConn.SetReadDeadline(time.Now().Add(time.Second)))
+	for len(received) < 2 {
+		n, err := serverConn.ReadBatch(messages, 0)
+		require.NoError(t, err)
+		for _, message := range messages[:n] {
+			require.Equal(t, clientConn.LocalAddr().String(), message.Addr.String())
+			received = append(received, string(message.Buffers[0][:message.N]))
+		}
+	}
+	require.Equal(t, []string{"hello", "world"}, received)
+
+	_, _, err = serverConn.WriteMsgUDP([]byte("reply"), nil, clientConn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	buffer := make([]byte, 2048)
+	require.NoError(t, clientConn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, addr, err := clientConn.ReadFrom(buffer)
+	require.NoError(t, err)
+	require.Equal(t, "reply", string(buffer[:n]))
+	require.Equal(t, udpConns[1].LocalAddr().String(), addr.String())
+}
@@ -0,0 +1,55 @@
+package porthopping
+
+import (
+	"strconv"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// ParsePorts parses a list of ports and port ranges like `443` or `2080:3000`.
+func ParsePorts(portRanges []string) ([]uint16, error) {
+	var ports []uint16
+	for _, portRange := range portRanges {
+		subIndex := strings.Index(portRange, ":")
+		if subIndex == -1 {
+			port, err := strconv.ParseUint(portRange, 10, 16)
+			if err != nil || port == 0 {
+				return nil, E.New("bad port: ", portRange)
+			}
+			ports = append(ports, uint16(port))
+			continue
+		}
+		var (
+			start, end uint64
+			err        error
+		)
+		if subIndex > 0 {
+			start, err = strconv.ParseUint(portRange[:subIndex], 10, 16)
+			if err != nil {
+				return nil, E.Cause(err, "bad port range: ", portRange)
+			}
+		}
+		if subIndex == len(portRange)-1 {
+			end = 0xFFFF
+		} else {
+			end, err = strconv.ParseUint(portRange[subIndex+1:], 10, 16)
+			if err != nil {
+				return nil, E.Cause(err, "bad port range: ", portRange)
+			}
+		}
+		if start == 0 {
+			start = 1
+		}
+		if start > end {
+			return nil, E.New("bad port range: ", portRange)
+		}
+		for port := start; port <= end; port++ {
+			ports = append(ports, uint16(port))
+		}
+	}
+	if len(ports) == 0 {
+		return nil, E.New("empty port list")
+	}
+	return ports, nil
+}
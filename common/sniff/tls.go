@@ -13,6 +13,16 @@ import (
 )
 
 func TLSClientHello(ctx context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
+	clientHello, err := ReadClientHello(ctx, reader)
+	if err != nil {
+		return err
+	}
+	metadata.Protocol = C.ProtocolTLS
+	metadata.Domain = clientHello.ServerName
+	return nil
+}
+
+func ReadClientHello(ctx context.Context, reader io.Reader) (*tls.ClientHelloInfo, error) {
 	var clientHello *tls.ClientHelloInfo
 	err := tls.Server(bufio.NewReadOnlyConn(reader), &tls.Config{
 		GetConfigForClient: func(argHello *tls.ClientHelloInfo) (*tls.Config, error) {
@@ -21,13 +31,11 @@ func TLSClientHello(ctx context.Context, metadata *adapter.InboundContext, reade
 		},
 	}).HandshakeContext(ctx)
 	if clientHello != nil {
-		metadata.Protocol = C.ProtocolTLS
-		metadata.Domain = clientHello.ServerName
-		return nil
+		return clientHello, nil
 	}
 	if errors.Is(err, io.ErrUnexpectedEOF) {
-		return E.Cause1(ErrNeedMoreData, err)
+		return nil, E.Cause1(ErrNeedMoreData, err)
 	} else {
-		return err
+		return nil, err
 	}
 }
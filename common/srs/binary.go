@@ -2,12 +2,14 @@ package srs
 
 import (
 	"bufio"
+	"bytes"
 	"compress/zlib"
 	"encoding/binary"
 	"io"
 	"net/netip"
 	"unsafe"
 
+	"github.com/sagernet/sing-box/common/compact"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
@@ -46,6 +48,9 @@ const (
 	ruleItemNetworkIsConstrained
 	ruleItemNetworkInterfaceAddress
 	ruleItemDefaultInterfaceAddress
+	ruleItemCompactDomain
+	ruleItemCompactSourceIPCIDR
+	ruleItemCompactIPCIDR
 	ruleItemFinal uint8 = 0xFF
 )
 
@@ -67,19 +72,52 @@ func Read(reader io.Reader, recover bool) (ruleSetCompat option.PlainRuleSetComp
 	if version > C.RuleSetVersionCurrent {
 		return ruleSetCompat, E.New("unsupported version: ", version)
 	}
+	if version >= C.RuleSetVersion5 {
+		var content []byte
+		content, err = io.ReadAll(reader)
+		if err != nil {
+			return
+		}
+		return readCompact(content, version, nil, recover)
+	}
 	compressReader, err := zlib.NewReader(reader)
 	if err != nil {
 		return
 	}
-	bReader := bufio.NewReader(compressReader)
-	length, err := binary.ReadUvarint(bReader)
+	return readRules(bufio.NewReader(compressReader), version, recover)
+}
+
+// ReadBytes reads a rule-set from content. Since version 5, domain and IP CIDR
+// items reference content directly instead of being copied, and owner is
+// retained by them to keep content alive (e.g. a memory-mapped file).
+func ReadBytes(content []byte, owner any, recover bool) (ruleSetCompat option.PlainRuleSetCompat, err error) {
+	if len(content) < 4 || [3]byte(content) != MagicBytes {
+		err = E.New("invalid sing-box rule-set file")
+		return
+	}
+	version := content[3]
+	if version < C.RuleSetVersion5 {
+		return Read(bytes.NewReader(content), recover)
+	}
+	if version > C.RuleSetVersionCurrent {
+		return ruleSetCompat, E.New("unsupported version: ", version)
+	}
+	return readCompact(content[4:], version, owner, recover)
+}
+
+func readCompact(content []byte, version uint8, owner any, recover bool) (ruleSetCompat option.PlainRuleSetCompat, err error) {
+	return readRules(&compactReader{bytes.NewReader(content), content, owner}, version, recover)
+}
+
+func readRules(reader varbin.Reader, version uint8, recover bool) (ruleSetCompat option.PlainRuleSetCompat, err error) {
+	length, err := binary.ReadUvarint(reader)
 	if err != nil {
 		return
 	}
 	ruleSetCompat.Version = version
 	ruleSetCompat.Options.Rules = make([]option.HeadlessRule, length)
 	for i := uint64(0); i < length; i++ {
-		ruleSetCompat.Options.Rules[i], err = readRule(bReader, recover)
+		ruleSetCompat.Options.Rules[i], err = readRule(reader, recover)
 		if err != nil {
 			err = E.Cause(err, "read rule[", i, "]")
 			return
@@ -88,6 +126,29 @@ func Read(reader io.Reader, recover bool) (ruleSetCompat option.PlainRuleSetComp
 	return
 }
 
+// compactReader reads the uncompressed rule stream of version 5 or later.
+type compactReader struct {
+	*bytes.Reader
+	content []byte
+	owner   any
+}
+
+func (r *compactReader) readBlob() ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	offset := len(r.content) - r.Len()
+	_, err = r.Seek(int64(length), io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return r.content[offset : offset+int(length)], nil
+}
+
 func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8) error {
 	_, err := writer.Write(MagicBytes[:])
 	if err != nil {
@@ -97,11 +158,15 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8)
 	if err != nil {
 		return err
 	}
-	compressWriter, err := zlib.NewWriterLevel(writer, zlib.BestCompression)
-	if err != nil {
-		return err
+	var compressWriter *zlib.Writer
+	if generateVersion < C.RuleSetVersion5 {
+		compressWriter, err = zlib.NewWriterLevel(writer, zlib.BestCompression)
+		if err != nil {
+			return err
+		}
+		writer = compressWriter
 	}
-	bWriter := bufio.NewWriter(compressWriter)
+	bWriter := bufio.NewWriter(writer)
 	_, err = varbin.WriteUvarint(bWriter, uint64(len(ruleSet.Rules)))
 	if err != nil {
 		return err
@@ -116,7 +181,10 @@ func Write(writer io.Writer, ruleSet option.PlainRuleSet, generateVersion uint8)
 	if err != nil {
 		return err
 	}
-	return compressWriter.Close()
+	if compressWriter != nil {
+		return compressWriter.Close()
+	}
+	return nil
 }
 
 func readRule(reader varbin.Reader, recover bool) (rule option.HeadlessRule, err error) {
@@ -264,6 +332,32 @@ func readDefaultRule(reader varbin.Reader, recover bool) (rule option.DefaultHea
 				}
 				rule.NetworkInterfaceAddress.Put(option.InterfaceType(key), value)
 			}
+		case ruleItemCompactDomain:
+			var set *compact.DomainSet
+			set, err = readCompactItem(reader, compact.ParseDomainSet)
+			if err != nil {
+				return
+			}
+			rule.CompactDomainSet = set
+			if recover {
+				rule.Domain, rule.DomainSuffix = set.Dump()
+			}
+		case ruleItemCompactSourceIPCIDR:
+			rule.CompactSourceIPSet, err = readCompactItem(reader, compact.ParseIPSet)
+			if err != nil {
+				return
+			}
+			if recover {
+				rule.SourceIPCIDR = common.Map(rule.CompactSourceIPSet.IPSet().Prefixes(), netip.Prefix.String)
+			}
+		case ruleItemCompactIPCIDR:
+			rule.CompactIPSet, err = readCompactItem(reader, compact.ParseIPSet)
+			if err != nil {
+				return
+			}
+			if recover {
+				rule.IPCIDR = common.Map(rule.CompactIPSet.IPSet().Prefixes(), netip.Prefix.String)
+			}
 		case ruleItemDefaultInterfaceAddress:
 			var value []*badoption.Prefixable
 			var prefixCount uint64
@@ -313,11 +407,15 @@ func writeDefaultRule(writer varbin.Writer, rule option.DefaultHeadlessRule, gen
 		}
 	}
 	if len(rule.Domain) > 0 || len(rule.DomainSuffix) > 0 {
-		err = binary.Write(writer, binary.BigEndian, ruleItemDomain)
-		if err != nil {
-			return err
+		if generateVersion >= C.RuleSetVersion5 {
+			err = writeCompactItem(writer, ruleItemCompactDomain, compact.BuildDomainSet(rule.Domain, rule.DomainSuffix))
+		} else {
+			err = binary.Write(writer, binary.BigEndian, ruleItemDomain)
+			if err != nil {
+				return err
+			}
+			err = domain.NewMatcher(rule.Domain, rule.DomainSuffix, generateVersion == C.RuleSetVersion1).Write(writer)
 		}
-		err = domain.NewMatcher(rule.Domain, rule.DomainSuffix, generateVersion == C.RuleSetVersion1).Write(writer)
 		if err != nil {
 			return err
 		}
@@ -335,13 +433,13 @@ func writeDefaultRule(writer varbin.Writer, rule option.DefaultHeadlessRule, gen
 		}
 	}
 	if len(rule.SourceIPCIDR) > 0 {
-		err = writeRuleItemCIDR(writer, ruleItemSourceIPCIDR, rule.SourceIPCIDR)
+		err = writeRuleItemCIDR(writer, ruleItemSourceIPCIDR, rule.SourceIPCIDR, generateVersion)
 		if err != nil {
 			return E.Cause(err, "source_ip_cidr")
 		}
 	}
 	if len(rule.IPCIDR) > 0 {
-		err = writeRuleItemCIDR(writer, ruleItemIPCIDR, rule.IPCIDR)
+		err = writeRuleItemCIDR(writer, ruleItemIPCIDR, rule.IPCIDR, generateVersion)
 		if err != nil {
 			return E.Cause(err, "ipcidr")
 		}
@@ -599,7 +697,7 @@ func writeRuleItemUint16(writer varbin.Writer, itemType uint8, value []uint16) e
 	return binary.Write(writer, binary.BigEndian, value)
 }
 
-func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string) error {
+func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string, generateVersion uint8) error {
 	var builder netipx.IPSetBuilder
 	for i, prefixString := range value {
 		prefix, err := netip.ParsePrefix(prefixString)
@@ -618,6 +716,15 @@ func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string) err
 	if err != nil {
 		return err
 	}
+	if generateVersion >= C.RuleSetVersion5 {
+		switch itemType {
+		case ruleItemSourceIPCIDR:
+			itemType = ruleItemCompactSourceIPCIDR
+		case ruleItemIPCIDR:
+			itemType = ruleItemCompactIPCIDR
+		}
+		return writeCompactItem(writer, itemType, compact.BuildIPSet(ipSet))
+	}
 	err = binary.Write(writer, binary.BigEndian, itemType)
 	if err != nil {
 		return err
@@ -625,6 +732,32 @@ func writeRuleItemCIDR(writer varbin.Writer, itemType uint8, value []string) err
 	return writeIPSet(writer, ipSet)
 }
 
+func readCompactItem[T any](reader varbin.Reader, parse func(content []byte, owner any) (T, error)) (value T, err error) {
+	cReader, isCompact := reader.(*compactReader)
+	if !isCompact {
+		err = E.New("compact rule items is only supported in version 5 or later")
+		return
+	}
+	content, err := cReader.readBlob()
+	if err != nil {
+		return
+	}
+	return parse(content, cReader.owner)
+}
+
+func writeCompactItem(writer varbin.Writer, itemType uint8, content []byte) error {
+	err := writer.WriteByte(itemType)
+	if err != nil {
+		return err
+	}
+	_, err = varbin.WriteUvarint(writer, uint64(len(content)))
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
+
 func readLogicalRule(reader varbin.Reader, recovery bool) (logicalRule option.LogicalHeadlessRule, err error) {
 	mode, err := reader.ReadByte()
 	if err != nil {
@@ -0,0 +1,84 @@
+package srs
+
+import (
+	"bytes"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/common/mmap"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactRuleSet(t *testing.T) {
+	t.Parallel()
+	ruleSet := option.PlainRuleSet{
+		Rules: []option.HeadlessRule{
+			{
+				Type: C.RuleTypeDefault,
+				DefaultOptions: option.DefaultHeadlessRule{
+					Domain:       badoption.Listable[string]{"example.com"},
+					DomainSuffix: badoption.Listable[string]{".example.org"},
+					IPCIDR:       badoption.Listable[string]{"10.0.0.0/8", "2001:db8::/32"},
+					Port:         badoption.Listable[uint16]{443},
+				},
+			},
+			{
+				Type: C.RuleTypeLogical,
+				LogicalOptions: option.LogicalHeadlessRule{
+					Mode: C.LogicalTypeOr,
+					Rules: []option.HeadlessRule{
+						{
+							Type: C.RuleTypeDefault,
+							DefaultOptions: option.DefaultHeadlessRule{
+								SourceIPCIDR: badoption.Listable[string]{"192.168.0.0/16"},
+							},
+						},
+						{
+							Type: C.RuleTypeDefault,
+							DefaultOptions: option.DefaultHeadlessRule{
+								DomainKeyword: badoption.Listable[string]{"sing-box"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, Write(&buffer, ruleSet, C.RuleSetVersion5))
+	path := filepath.Join(t.TempDir(), "rule-set.srs")
+	require.NoError(t, os.WriteFile(path, buffer.Bytes(), 0o644))
+	file, err := mmap.Open(path)
+	require.NoError(t, err)
+	mappedRuleSet, err := ReadBytes(file.Bytes(), file, true)
+	require.NoError(t, err)
+	readRuleSet, err := Read(bytes.NewReader(buffer.Bytes()), true)
+	require.NoError(t, err)
+	for _, compat := range []option.PlainRuleSetCompat{mappedRuleSet, readRuleSet} {
+		require.Equal(t, uint8(C.RuleSetVersion5), compat.Version)
+		require.Len(t, compat.Options.Rules, 2)
+		defaultRule := compat.Options.Rules[0].DefaultOptions
+		require.Equal(t, ruleSet.Rules[0].DefaultOptions.Domain, defaultRule.Domain)
+		require.Equal(t, ruleSet.Rules[0].DefaultOptions.DomainSuffix, defaultRule.DomainSuffix)
+		require.Equal(t, ruleSet.Rules[0].DefaultOptions.IPCIDR, defaultRule.IPCIDR)
+		require.Equal(t, ruleSet.Rules[0].DefaultOptions.Port, defaultRule.Port)
+		require.True(t, defaultRule.CompactDomainSet.Match("example.com"))
+		require.True(t, defaultRule.CompactDomainSet.Match("www.example.org"))
+		require.False(t, defaultRule.CompactDomainSet.Match("www.example.com"))
+		require.True(t, defaultRule.CompactIPSet.Contains(netip.MustParseAddr("10.1.2.3")))
+		require.True(t, defaultRule.CompactIPSet.Contains(netip.MustParseAddr("2001:db8::1")))
+		require.False(t, defaultRule.CompactIPSet.Contains(netip.MustParseAddr("11.0.0.1")))
+		logicalRule := compat.Options.Rules[1].LogicalOptions
+		require.Equal(t, C.LogicalTypeOr, logicalRule.Mode)
+		require.Len(t, logicalRule.Rules, 2)
+		require.Equal(t, ruleSet.Rules[1].LogicalOptions.Rules[0].DefaultOptions.SourceIPCIDR, logicalRule.Rules[0].DefaultOptions.SourceIPCIDR)
+		require.True(t, logicalRule.Rules[0].DefaultOptions.CompactSourceIPSet.Contains(netip.MustParseAddr("192.168.1.1")))
+		require.Equal(t, ruleSet.Rules[1].LogicalOptions.Rules[1].DefaultOptions.DomainKeyword, logicalRule.Rules[1].DefaultOptions.DomainKeyword)
+	}
+}
@@ -0,0 +1,61 @@
+package tls
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+// VerifiedClientCertificate returns the client certificate verified during the handshake,
+// certificates which were only requested but not verified are ignored.
+func VerifiedClientCertificate(config ServerConfig, conn Conn) *x509.Certificate {
+	return VerifiedClientCertificateFromState(config, conn.ConnectionState())
+}
+
+// VerifiedClientCertificateFromState is like VerifiedClientCertificate, for connections
+// where only the handshake state is exposed, such as requests of the HTTP/3 server.
+func VerifiedClientCertificateFromState(config ServerConfig, state ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		return state.VerifiedChains[0][0]
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	// pinned public keys are verified in VerifyPeerCertificate without building a chain
+	stdConfig, err := config.STDConfig()
+	if err != nil || stdConfig.VerifyPeerCertificate == nil {
+		return nil
+	}
+	return state.PeerCertificates[0]
+}
+
+// ApplyClientCertificate records the identity of the verified client certificate into metadata.
+func ApplyClientCertificate(config ServerConfig, conn Conn, metadata *adapter.InboundContext) {
+	ApplyClientCertificateFromState(config, conn.ConnectionState(), metadata)
+}
+
+// ApplyClientCertificateFromState records the identity of the verified client certificate in state into metadata.
+func ApplyClientCertificateFromState(config ServerConfig, state ConnectionState, metadata *adapter.InboundContext) {
+	certificate := VerifiedClientCertificateFromState(config, state)
+	if certificate == nil {
+		return
+	}
+	metadata.ClientCertificateCommonName = certificate.Subject.CommonName
+	metadata.ClientCertificateSAN = clientCertificateSAN(certificate)
+	publicKeyHash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	metadata.ClientCertificatePublicKeySHA256 = publicKeyHash[:]
+}
+
+func clientCertificateSAN(certificate *x509.Certificate) []string {
+	var names []string
+	names = append(names, certificate.DNSNames...)
+	names = append(names, certificate.EmailAddresses...)
+	for _, address := range certificate.IPAddresses {
+		names = append(names, address.String())
+	}
+	for _, uri := range certificate.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
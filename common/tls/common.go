@@ -1,5 +1,7 @@
 package tls
 
+import "strings"
+
 const (
 	VersionTLS10 = 0x0301
 	VersionTLS11 = 0x0302
@@ -10,3 +12,23 @@ const (
 	// supported by this package. See golang.org/issue/32716.
 	VersionSSL30 = 0x0300
 )
+
+// MatchServerName matches exact names, `*` for any non-empty name and `*.example.com` for subdomains.
+// Both pattern and server name are expected to be lower case without the trailing dot.
+func MatchServerName(pattern string, serverName string) bool {
+	if serverName == "" {
+		return false
+	}
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(serverName, pattern[1:])
+	}
+	return pattern == serverName
+}
+
+// NormalizeServerName returns the server name in lower case without the trailing dot.
+func NormalizeServerName(serverName string) string {
+	return strings.ToLower(strings.TrimSuffix(serverName, "."))
+}
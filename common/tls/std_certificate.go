@@ -0,0 +1,436 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/fswatch"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dialer"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspRetryInterval      = 10 * time.Minute
+	ocspMaxRefreshInterval = 12 * time.Hour
+	ocspMaxResponseSize    = 1024 * 1024
+)
+
+var errCertificateRevoked = E.New("certificate is revoked")
+
+type certificateEntry struct {
+	serverName      []string
+	certificate     []byte
+	key             []byte
+	certificatePath string
+	keyPath         string
+	keyPair         atomic.Pointer[tls.Certificate]
+	acmeDomain      []string
+	acmeConfig      *tls.Config
+	acmeService     adapter.SimpleLifecycle
+}
+
+func newCertificateEntry(ctx context.Context, logger log.ContextLogger, options option.InboundTLSCertificate) (*certificateEntry, error) {
+	entry := &certificateEntry{
+		serverName:      common.Map(options.ServerName, NormalizeServerName),
+		certificatePath: options.CertificatePath,
+		keyPath:         options.KeyPath,
+	}
+	if options.ACME != nil && len(options.ACME.Domain) > 0 {
+		if len(options.Certificate) > 0 || options.CertificatePath != "" || len(options.Key) > 0 || options.KeyPath != "" {
+			return nil, E.New("`acme` is conflict with certificate and key")
+		}
+		acmeConfig, acmeService, err := startACME(ctx, logger, *options.ACME)
+		if err != nil {
+			return nil, err
+		}
+		entry.acmeDomain = common.Map(options.ACME.Domain, NormalizeServerName)
+		entry.acmeConfig = acmeConfig
+		entry.acmeService = acmeService
+		return entry, nil
+	}
+	if len(options.Certificate) > 0 {
+		entry.certificate = []byte(strings.Join(options.Certificate, "\n"))
+	} else if options.CertificatePath != "" {
+		content, err := os.ReadFile(options.CertificatePath)
+		if err != nil {
+			return nil, E.Cause(err, "read certificate")
+		}
+		entry.certificate = content
+	}
+	if len(options.Key) > 0 {
+		entry.key = []byte(strings.Join(options.Key, "\n"))
+	} else if options.KeyPath != "" {
+		content, err := os.ReadFile(options.KeyPath)
+		if err != nil {
+			return nil, E.Cause(err, "read key")
+		}
+		entry.key = content
+	}
+	if entry.certificate == nil {
+		return nil, E.New("missing certificate")
+	} else if entry.key == nil {
+		return nil, E.New("missing key")
+	}
+	keyPair, err := tls.X509KeyPair(entry.certificate, entry.key)
+	if err != nil {
+		return nil, E.Cause(err, "parse x509 key pair")
+	}
+	entry.keyPair.Store(&keyPair)
+	return entry, nil
+}
+
+func (e *certificateEntry) match(serverName string) bool {
+	if serverName == "" {
+		return false
+	}
+	if len(e.serverName) > 0 {
+		return common.Any(e.serverName, func(it string) bool {
+			return MatchServerName(it, serverName)
+		})
+	}
+	if e.acmeConfig != nil {
+		return common.Any(e.acmeDomain, func(it string) bool {
+			return MatchServerName(it, serverName)
+		})
+	}
+	keyPair := e.keyPair.Load()
+	return keyPair.Leaf != nil && keyPair.Leaf.VerifyHostname(serverName) == nil
+}
+
+func (e *certificateEntry) getCertificate(info *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if e.acmeConfig != nil {
+		return e.acmeConfig.GetCertificate(info)
+	}
+	return e.keyPair.Load(), nil
+}
+
+func (e *certificateEntry) reload() error {
+	if e.certificatePath != "" {
+		certificate, err := os.ReadFile(e.certificatePath)
+		if err != nil {
+			return E.Cause(err, "reload certificate from ", e.certificatePath)
+		}
+		e.certificate = certificate
+	}
+	if e.keyPath != "" {
+		key, err := os.ReadFile(e.keyPath)
+		if err != nil {
+			return E.Cause(err, "reload key from ", e.keyPath)
+		}
+		e.key = key
+	}
+	keyPair, err := tls.X509KeyPair(e.certificate, e.key)
+	if err != nil {
+		return E.Cause(err, "reload key pair")
+	}
+	e.keyPair.Store(&keyPair)
+	return nil
+}
+
+// certificateStore selects certificates by the server name of the client,
+// and staples OCSP responses to static certificates.
+type certificateStore struct {
+	ctx          context.Context
+	cancel       context.CancelFunc
+	logger       log.ContextLogger
+	entries      []*certificateEntry
+	defaultEntry *certificateEntry
+	ocspStapling bool
+	ocspRefresh  chan struct{}
+	httpClient   *http.Client
+	timeFunc     func() time.Time
+	watcher      *fswatch.Watcher
+}
+
+func newCertificateStore(ctx context.Context, logger log.ContextLogger, options option.InboundTLSOptions) (*certificateStore, error) {
+	store := &certificateStore{
+		logger:       logger,
+		ocspStapling: options.OCSPStapling,
+		ocspRefresh:  make(chan struct{}, 1),
+		timeFunc:     ntp.TimeFuncFromContext(ctx),
+	}
+	if store.timeFunc == nil {
+		store.timeFunc = time.Now
+	}
+	if options.OCSPStapling {
+		// OCSP responders are requested through the default outbound like other downloads
+		var outboundDialer N.Dialer
+		if service.FromContext[adapter.OutboundManager](ctx) != nil {
+			outboundDialer = dialer.NewDefaultOutbound(ctx)
+		} else {
+			outboundDialer = N.SystemDialer
+		}
+		store.httpClient = &http.Client{
+			Timeout: C.TCPTimeout,
+			Transport: &http.Transport{
+				TLSHandshakeTimeout: C.TCPTimeout,
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return outboundDialer.DialContext(ctx, network, M.ParseSocksaddr(addr))
+				},
+				TLSClientConfig: &tls.Config{
+					Time:    ntp.TimeFuncFromContext(ctx),
+					RootCAs: adapter.RootPoolFromContext(ctx),
+				},
+			},
+		}
+	}
+	store.ctx, store.cancel = context.WithCancel(ctx)
+	if (options.ACME != nil && len(options.ACME.Domain) > 0) || len(options.Certificate) > 0 || options.CertificatePath != "" {
+		entry, err := newCertificateEntry(store.ctx, logger, option.InboundTLSCertificate{
+			Certificate:     options.Certificate,
+			CertificatePath: options.CertificatePath,
+			Key:             options.Key,
+			KeyPath:         options.KeyPath,
+			ACME:            options.ACME,
+		})
+		if err != nil {
+			return nil, err
+		}
+		store.entries = append(store.entries, entry)
+	}
+	for i, certificateOptions := range options.Certificates {
+		entry, err := newCertificateEntry(store.ctx, logger, certificateOptions)
+		if err != nil {
+			return nil, E.Cause(err, "parse certificates[", i, "]")
+		}
+		store.entries = append(store.entries, entry)
+	}
+	if len(store.entries) == 0 {
+		return nil, E.New("missing certificate")
+	}
+	store.defaultEntry = store.entries[0]
+	return store, nil
+}
+
+// NextProtos returns the ALPN protocols required by ACME challenges.
+func (s *certificateStore) NextProtos() []string {
+	for _, entry := range s.entries {
+		if entry.acmeConfig != nil && common.Contains(entry.acmeConfig.NextProtos, ACMETLS1Protocol) {
+			return []string{ACMETLS1Protocol}
+		}
+	}
+	return nil
+}
+
+func (s *certificateStore) GetCertificate(info *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	serverName := NormalizeServerName(info.ServerName)
+	for _, entry := range s.entries {
+		if entry.match(serverName) {
+			return entry.getCertificate(info)
+		}
+	}
+	return s.defaultEntry.getCertificate(info)
+}
+
+func (s *certificateStore) Start() error {
+	for _, entry := range s.entries {
+		if entry.acmeService != nil {
+			err := entry.acmeService.Start()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err := s.startWatcher()
+	if err != nil {
+		s.logger.Warn("create fsnotify watcher: ", err)
+	}
+	if s.ocspStapling {
+		go s.loopOCSP()
+	}
+	return nil
+}
+
+func (s *certificateStore) startWatcher() error {
+	var watchPath []string
+	for _, entry := range s.entries {
+		if entry.certificatePath != "" {
+			watchPath = append(watchPath, entry.certificatePath)
+		}
+		if entry.keyPath != "" {
+			watchPath = append(watchPath, entry.keyPath)
+		}
+	}
+	if len(watchPath) == 0 {
+		return nil
+	}
+	watcher, err := fswatch.NewWatcher(fswatch.Options{
+		Path: watchPath,
+		Callback: func(path string) {
+			for _, entry := range s.entries {
+				if path != entry.certificatePath && path != entry.keyPath {
+					continue
+				}
+				err := entry.reload()
+				if err != nil {
+					s.logger.Error(E.Cause(err, "reload certificate"))
+					continue
+				}
+				s.logger.Info("reloaded TLS certificate")
+				select {
+				case s.ocspRefresh <- struct{}{}:
+				default:
+				}
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = watcher.Start()
+	if err != nil {
+		return err
+	}
+	s.watcher = watcher
+	return nil
+}
+
+func (s *certificateStore) Close() error {
+	s.cancel()
+	var err error
+	for _, entry := range s.entries {
+		if entry.acmeService != nil {
+			err = E.Append(err, entry.acmeService.Close(), func(err error) error {
+				return E.Cause(err, "close ACME service")
+			})
+		}
+	}
+	if s.watcher != nil {
+		err = E.Append(err, s.watcher.Close(), func(err error) error {
+			return E.Cause(err, "close fsnotify watcher")
+		})
+	}
+	return err
+}
+
+func (s *certificateStore) loopOCSP() {
+	for {
+		timer := time.NewTimer(s.updateOCSP())
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-s.ocspRefresh:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *certificateStore) updateOCSP() time.Duration {
+	nextUpdate := ocspMaxRefreshInterval
+	for _, entry := range s.entries {
+		// OCSP responses of ACME certificates are stapled by certmagic
+		if entry.acmeConfig != nil {
+			continue
+		}
+		keyPair := entry.keyPair.Load()
+		response, refreshAt, err := s.fetchOCSP(keyPair)
+		if err != nil {
+			s.logger.Error(E.Cause(err, "update OCSP response"))
+			nextUpdate = min(nextUpdate, ocspRetryInterval)
+			// clients reject handshakes with revoked or expired responses stapled
+			if keyPair.OCSPStaple != nil && (errors.Is(err, errCertificateRevoked) || s.ocspExpired(keyPair.OCSPStaple)) {
+				newKeyPair := *keyPair
+				newKeyPair.OCSPStaple = nil
+				if entry.keyPair.CompareAndSwap(keyPair, &newKeyPair) {
+					s.logger.Warn("dropped OCSP response for ", keyPair.Leaf.Subject)
+				}
+			}
+			continue
+		}
+		if response == nil {
+			continue
+		}
+		newKeyPair := *keyPair
+		newKeyPair.OCSPStaple = response
+		if entry.keyPair.CompareAndSwap(keyPair, &newKeyPair) {
+			s.logger.Debug("updated OCSP response for ", keyPair.Leaf.Subject)
+		}
+		nextUpdate = min(nextUpdate, max(refreshAt.Sub(s.timeFunc()), ocspRetryInterval))
+	}
+	return nextUpdate
+}
+
+func (s *certificateStore) ocspExpired(staple []byte) bool {
+	response, err := ocsp.ParseResponse(staple, nil)
+	if err != nil {
+		return true
+	}
+	return !response.NextUpdate.IsZero() && !s.timeFunc().Before(response.NextUpdate)
+}
+
+func (s *certificateStore) fetchOCSP(keyPair *tls.Certificate) ([]byte, time.Time, error) {
+	leaf := keyPair.Leaf
+	if leaf == nil || len(leaf.OCSPServer) == 0 {
+		return nil, time.Time{}, nil
+	}
+	if len(keyPair.Certificate) < 2 {
+		return nil, time.Time{}, E.New("missing issuer certificate in chain of ", leaf.Subject)
+	}
+	issuer, err := x509.ParseCertificate(keyPair.Certificate[1])
+	if err != nil {
+		return nil, time.Time{}, E.Cause(err, "parse issuer certificate")
+	}
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, time.Time{}, E.Cause(err, "create OCSP request")
+	}
+	httpRequest, err := http.NewRequestWithContext(s.ctx, http.MethodPost, leaf.OCSPServer[0], bytes.NewReader(request))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/ocsp-request")
+	httpResponse, err := s.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, time.Time{}, E.Cause(err, "request OCSP responder ", leaf.OCSPServer[0])
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, time.Time{}, E.New("unexpected status from OCSP responder ", leaf.OCSPServer[0], ": ", httpResponse.Status)
+	}
+	responseBytes, err := io.ReadAll(io.LimitReader(httpResponse.Body, ocspMaxResponseSize))
+	if err != nil {
+		return nil, time.Time{}, E.Cause(err, "read OCSP response")
+	}
+	response, err := ocsp.ParseResponseForCert(responseBytes, leaf, issuer)
+	if err != nil {
+		return nil, time.Time{}, E.Cause(err, "parse OCSP response")
+	}
+	switch response.Status {
+	case ocsp.Good:
+	case ocsp.Revoked:
+		return nil, time.Time{}, E.Cause(errCertificateRevoked, leaf.Subject)
+	default:
+		return nil, time.Time{}, E.New("certificate of ", leaf.Subject, " is unknown to OCSP responder")
+	}
+	if !response.NextUpdate.IsZero() && !s.timeFunc().Before(response.NextUpdate) {
+		return nil, time.Time{}, E.New("expired OCSP response for ", leaf.Subject)
+	}
+	refreshAt := response.ThisUpdate.Add(ocspMaxRefreshInterval)
+	if !response.NextUpdate.IsZero() {
+		refreshAt = response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+	}
+	return responseBytes, refreshAt, nil
+}
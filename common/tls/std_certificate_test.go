@@ -0,0 +1,125 @@
+package tls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestCertificateStoreOCSP(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	var (
+		status     atomic.Int64
+		nextUpdate atomic.Int64
+	)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		request, pErr := ocsp.ParseRequest(content)
+		if pErr != nil || status.Load() < 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		response, cErr := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       int(status.Load()),
+			SerialNumber: request.SerialNumber,
+			ThisUpdate:   now.Add(-time.Minute),
+			NextUpdate:   time.Unix(nextUpdate.Load(), 0),
+			RevokedAt:    now.Add(-time.Minute),
+		}, caKey)
+		if cErr != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(response)
+	}))
+	defer responder.Close()
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		OCSPServer:   []string{responder.URL},
+	}, ca, leafKey.Public(), caKey)
+	require.NoError(t, err)
+	leafKeyDER, err := x509.MarshalECPrivateKey(leafKey)
+	require.NoError(t, err)
+	store, err := newCertificateStore(context.Background(), log.NewNOPFactory().NewLogger("tls"), option.InboundTLSOptions{
+		Certificate: []string{
+			string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})),
+			string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		},
+		Key:          []string{string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: leafKeyDER}))},
+		OCSPStapling: true,
+	})
+	require.NoError(t, err)
+	defer store.Close()
+	var clock atomic.Int64
+	clock.Store(now.Unix())
+	store.timeFunc = func() time.Time {
+		return time.Unix(clock.Load(), 0)
+	}
+	staple := func() []byte {
+		return store.defaultEntry.keyPair.Load().OCSPStaple
+	}
+
+	status.Store(ocsp.Good)
+	nextUpdate.Store(now.Add(time.Hour).Unix())
+	store.updateOCSP()
+	require.NotNil(t, staple())
+
+	status.Store(ocsp.Revoked)
+	store.updateOCSP()
+	require.Nil(t, staple())
+
+	status.Store(ocsp.Good)
+	store.updateOCSP()
+	require.NotNil(t, staple())
+	status.Store(-1)
+	store.updateOCSP()
+	require.NotNil(t, staple())
+	clock.Store(now.Add(2 * time.Hour).Unix())
+	store.updateOCSP()
+	require.Nil(t, staple())
+
+	status.Store(ocsp.Good)
+	store.updateOCSP()
+	require.Nil(t, staple())
+}
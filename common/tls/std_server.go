@@ -27,6 +27,7 @@ type STDServerConfig struct {
 	config                *tls.Config
 	logger                log.Logger
 	acmeService           adapter.SimpleLifecycle
+	certificates          *certificateStore
 	certificate           []byte
 	key                   []byte
 	certificatePath       string
@@ -53,7 +54,7 @@ func (c *STDServerConfig) SetServerName(serverName string) {
 func (c *STDServerConfig) NextProtos() []string {
 	c.access.RLock()
 	defer c.access.RUnlock()
-	if c.acmeService != nil && len(c.config.NextProtos) > 1 && c.config.NextProtos[0] == ACMETLS1Protocol {
+	if c.hasACMEChallenge() {
 		return c.config.NextProtos[1:]
 	} else {
 		return c.config.NextProtos
@@ -64,7 +65,7 @@ func (c *STDServerConfig) SetNextProtos(nextProto []string) {
 	c.access.Lock()
 	defer c.access.Unlock()
 	config := c.config.Clone()
-	if c.acmeService != nil && len(c.config.NextProtos) > 1 && c.config.NextProtos[0] == ACMETLS1Protocol {
+	if c.hasACMEChallenge() {
 		config.NextProtos = append(c.config.NextProtos[:1], nextProto...)
 	} else {
 		config.NextProtos = nextProto
@@ -72,6 +73,10 @@ func (c *STDServerConfig) SetNextProtos(nextProto []string) {
 	c.config = config
 }
 
+func (c *STDServerConfig) hasACMEChallenge() bool {
+	return (c.acmeService != nil || c.certificates != nil) && len(c.config.NextProtos) > 1 && c.config.NextProtos[0] == ACMETLS1Protocol
+}
+
 func (c *STDServerConfig) STDConfig() (*STDConfig, error) {
 	return c.config, nil
 }
@@ -94,6 +99,12 @@ func (c *STDServerConfig) Start() error {
 	if c.acmeService != nil {
 		return c.acmeService.Start()
 	} else {
+		if c.certificates != nil {
+			err := c.certificates.Start()
+			if err != nil {
+				return err
+			}
+		}
 		err := c.startWatcher()
 		if err != nil {
 			c.logger.Warn("create fsnotify watcher: ", err)
@@ -206,10 +217,14 @@ func (c *STDServerConfig) Close() error {
 	if c.acmeService != nil {
 		return c.acmeService.Close()
 	}
+	var err error
+	if c.certificates != nil {
+		err = c.certificates.Close()
+	}
 	if c.watcher != nil {
-		return c.watcher.Close()
+		err = E.Errors(err, c.watcher.Close())
 	}
-	return nil
+	return err
 }
 
 func NewSTDServer(ctx context.Context, logger log.ContextLogger, options option.InboundTLSOptions) (ServerConfig, error) {
@@ -218,8 +233,21 @@ func NewSTDServer(ctx context.Context, logger log.ContextLogger, options option.
 	}
 	var tlsConfig *tls.Config
 	var acmeService adapter.SimpleLifecycle
+	var certificates *certificateStore
 	var err error
-	if options.ACME != nil && len(options.ACME.Domain) > 0 {
+	if len(options.Certificates) > 0 || options.OCSPStapling {
+		certificates, err = newCertificateStore(ctx, logger, options)
+		if err != nil {
+			return nil, err
+		}
+		if options.Insecure {
+			return nil, errInsecureUnused
+		}
+		tlsConfig = &tls.Config{
+			GetCertificate: certificates.GetCertificate,
+			NextProtos:     certificates.NextProtos(),
+		}
+	} else if options.ACME != nil && len(options.ACME.Domain) > 0 {
 		//nolint:staticcheck
 		tlsConfig, acmeService, err = startACME(ctx, logger, common.PtrValueOrDefault(options.ACME))
 		if err != nil {
@@ -272,7 +300,7 @@ func NewSTDServer(ctx context.Context, logger log.ContextLogger, options option.
 		certificate []byte
 		key         []byte
 	)
-	if acmeService == nil {
+	if acmeService == nil && certificates == nil {
 		if len(options.Certificate) > 0 {
 			certificate = []byte(strings.Join(options.Certificate, "\n"))
 		} else if options.CertificatePath != "" {
@@ -344,6 +372,10 @@ func NewSTDServer(ctx context.Context, logger log.ContextLogger, options option.
 				tlsConfig.ClientAuth = tls.RequestClientCert
 			}
 			tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					// the client is allowed to omit its certificate in verify-if-given mode
+					return nil
+				}
 				return verifyPublicKeySHA256(options.ClientCertificatePublicKeySHA256, rawCerts, tlsConfig.Time)
 			}
 		} else {
@@ -361,13 +393,17 @@ func NewSTDServer(ctx context.Context, logger log.ContextLogger, options option.
 		config:                tlsConfig,
 		logger:                logger,
 		acmeService:           acmeService,
+		certificates:          certificates,
 		certificate:           certificate,
 		key:                   key,
-		certificatePath:       options.CertificatePath,
 		clientCertificatePath: options.ClientCertificatePath,
-		keyPath:               options.KeyPath,
 		echKeyPath:            echKeyPath,
 	}
+	// certificates in the store are watched by itself
+	if certificates == nil {
+		serverConfig.certificatePath = options.CertificatePath
+		serverConfig.keyPath = options.KeyPath
+	}
 	serverConfig.config.GetConfigForClient = func(info *tls.ClientHelloInfo) (*tls.Config, error) {
 		serverConfig.access.Lock()
 		defer serverConfig.access.Unlock()
@@ -28,6 +28,7 @@ const (
 	DNSTypeFakeIP      = "fakeip"
 	DNSTypeDHCP        = "dhcp"
 	DNSTypeTailscale   = "tailscale"
+	DNSTypeOpenVPN     = "openvpn"
 )
 
 const (
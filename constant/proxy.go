@@ -31,11 +31,15 @@ const (
 	TypeCCM          = "ccm"
 	TypeOCM          = "ocm"
 	TypeOOMKiller    = "oom-killer"
+	TypeMASQUE       = "masque"
+	TypeOpenVPN      = "openvpn"
+	TypeDemux        = "demux"
 )
 
 const (
 	TypeSelector = "selector"
 	TypeURLTest  = "urltest"
+	TypeChain    = "chain"
 )
 
 func ProxyDisplayName(proxyType string) string {
@@ -88,10 +92,18 @@ func ProxyDisplayName(proxyType string) string {
 		return "AnyTLS"
 	case TypeTailscale:
 		return "Tailscale"
+	case TypeMASQUE:
+		return "MASQUE"
+	case TypeOpenVPN:
+		return "OpenVPN"
+	case TypeDemux:
+		return "Demux"
 	case TypeSelector:
 		return "Selector"
 	case TypeURLTest:
 		return "URLTest"
+	case TypeChain:
+		return "Chain"
 	default:
 		return "Unknown"
 	}
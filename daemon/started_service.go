@@ -13,10 +13,12 @@ import (
 	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
 	"github.com/sagernet/sing-box/experimental/deprecated"
 	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/protocol/group"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/batch"
 	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/memory"
 	"github.com/sagernet/sing/common/observable"
 	"github.com/sagernet/sing/common/x/list"
@@ -1032,6 +1034,118 @@ func (s *StartedService) GetStartedAt(ctx context.Context, empty *emptypb.Empty)
 	return &StartedAt{StartedAt: s.startedAt.UnixMilli()}, nil
 }
 
+func (s *StartedService) GetRuleStatistics(ctx context.Context, empty *emptypb.Empty) (*RuleStatistics, error) {
+	s.serviceAccess.RLock()
+	if s.serviceStatus.Status != ServiceStatus_STARTED {
+		s.serviceAccess.RUnlock()
+		return nil, os.ErrInvalid
+	}
+	boxService := s.instance
+	s.serviceAccess.RUnlock()
+	return &RuleStatistics{
+		Rules:    newRuleStatisticsItems(boxService.instance.Router().Rules()),
+		DnsRules: newRuleStatisticsItems(service.FromContext[adapter.DNSRouter](boxService.ctx).Rules()),
+	}, nil
+}
+
+func newRuleStatisticsItems[T adapter.Rule](rules []T) []*RuleStatisticsItem {
+	items := make([]*RuleStatisticsItem, 0, len(rules))
+	for index, rule := range rules {
+		statistics := rule.Statistics()
+		item := &RuleStatisticsItem{
+			Index:    int32(index),
+			Type:     rule.Type(),
+			Payload:  rule.String(),
+			Action:   rule.Action().String(),
+			HitCount: statistics.Hits(),
+		}
+		if lastMatched := statistics.LastMatched(); !lastMatched.IsZero() {
+			item.LastMatchedAt = lastMatched.UnixMilli()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (s *StartedService) ResetRuleStatistics(ctx context.Context, empty *emptypb.Empty) (*emptypb.Empty, error) {
+	s.serviceAccess.RLock()
+	if s.serviceStatus.Status != ServiceStatus_STARTED {
+		s.serviceAccess.RUnlock()
+		return nil, os.ErrInvalid
+	}
+	boxService := s.instance
+	s.serviceAccess.RUnlock()
+	adapter.ResetRuleStatistics(boxService.instance.Router().Rules())
+	adapter.ResetRuleStatistics(service.FromContext[adapter.DNSRouter](boxService.ctx).Rules())
+	return &emptypb.Empty{}, nil
+}
+
+func (s *StartedService) StartCapture(ctx context.Context, request *StartCaptureRequest) (*emptypb.Empty, error) {
+	packetCapture, err := s.packetCapture()
+	if err != nil {
+		return nil, err
+	}
+	var options *option.CaptureOptions
+	if request.Options != "" {
+		options = new(option.CaptureOptions)
+		err = json.Unmarshal([]byte(request.Options), options)
+		if err != nil {
+			return nil, E.Cause(err, "decode capture options")
+		}
+	}
+	err = packetCapture.StartCapture(options)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *StartedService) StopCapture(ctx context.Context, empty *emptypb.Empty) (*emptypb.Empty, error) {
+	packetCapture, err := s.packetCapture()
+	if err != nil {
+		return nil, err
+	}
+	err = packetCapture.StopCapture()
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *StartedService) GetCaptureStatus(ctx context.Context, empty *emptypb.Empty) (*CaptureStatus, error) {
+	packetCapture, err := s.packetCapture()
+	if err != nil {
+		return nil, err
+	}
+	status := packetCapture.CaptureStatus()
+	response := &CaptureStatus{
+		Running: status.Running,
+		Path:    status.Path,
+		Packets: status.Packets,
+		Bytes:   status.Bytes,
+		Dropped: status.Dropped,
+	}
+	if !status.StartedAt.IsZero() {
+		response.StartedAt = status.StartedAt.UnixMilli()
+	}
+	return response, nil
+}
+
+func (s *StartedService) packetCapture() (adapter.PacketCapture, error) {
+	s.serviceAccess.RLock()
+	if s.serviceStatus.Status != ServiceStatus_STARTED {
+		s.serviceAccess.RUnlock()
+		return nil, os.ErrInvalid
+	}
+	boxService := s.instance
+	s.serviceAccess.RUnlock()
+	packetCapture := service.FromContext[adapter.PacketCapture](boxService.ctx)
+	if packetCapture == nil {
+		return nil, os.ErrInvalid
+	}
+	return packetCapture, nil
+}
+
 func (s *StartedService) mustEmbedUnimplementedStartedServiceServer() {
 }
 
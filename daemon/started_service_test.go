@@ -0,0 +1,73 @@
+//go:build with_clash_api
+
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/service"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestRuleStatistics(t *testing.T) {
+	t.Parallel()
+	startedService := NewStartedService(ServiceOptions{
+		Context:     service.ContextWithDefaultRegistry(context.Background()),
+		LogMaxLines: 100,
+	})
+	defer startedService.Close()
+	_, err := startedService.GetRuleStatistics(context.Background(), &emptypb.Empty{})
+	require.ErrorIs(t, err, os.ErrInvalid)
+	cachePath, err := json.Marshal(filepath.Join(t.TempDir(), "cache.db"))
+	require.NoError(t, err)
+	require.NoError(t, startedService.StartOrReloadService(`{
+  "log": {"disabled": true},
+  "experimental": {
+    "cache_file": {"enabled": true, "path": `+string(cachePath)+`}
+  },
+  "dns": {
+    "servers": [{"type": "local", "tag": "local"}],
+    "rules": [{"domain": "example.org", "server": "local"}]
+  },
+  "route": {
+    "rules": [
+      {"port": 443, "action": "reject"},
+      {"port": 80, "outbound": "direct"}
+    ]
+  }
+}`, nil))
+	defer startedService.CloseService()
+	instance := startedService.Instance()
+	rules := instance.instance.Router().Rules()
+	rules[0].Statistics().Hit()
+	rules[0].Statistics().Hit()
+	service.FromContext[adapter.DNSRouter](instance.ctx).Rules()[0].Statistics().Hit()
+
+	statistics, err := startedService.GetRuleStatistics(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, statistics.Rules, 2)
+	require.Equal(t, int32(0), statistics.Rules[0].Index)
+	require.Equal(t, "reject", statistics.Rules[0].Action)
+	require.Equal(t, uint64(2), statistics.Rules[0].HitCount)
+	require.NotZero(t, statistics.Rules[0].LastMatchedAt)
+	require.Equal(t, "route(direct)", statistics.Rules[1].Action)
+	require.Zero(t, statistics.Rules[1].HitCount)
+	require.Zero(t, statistics.Rules[1].LastMatchedAt)
+	require.Len(t, statistics.DnsRules, 1)
+	require.Equal(t, uint64(1), statistics.DnsRules[0].HitCount)
+
+	_, err = startedService.ResetRuleStatistics(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	statistics, err = startedService.GetRuleStatistics(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Zero(t, statistics.Rules[0].HitCount)
+	require.Zero(t, statistics.Rules[0].LastMatchedAt)
+	require.Zero(t, statistics.DnsRules[0].HitCount)
+}
@@ -190,8 +190,8 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 			return nil, ErrResponseRejectedCached
 		}
 	}
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	response, err := transport.Exchange(ctx, message)
+	exchangeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	response, err := transport.Exchange(exchangeCtx, message)
 	cancel()
 	if err != nil {
 		var rcodeError RcodeError
@@ -201,6 +201,11 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 			return nil, err
 		}
 	}
+	if question.Qtype == dns.TypeAAAA {
+		if dns64Transport, isDNS64 := transport.(adapter.DNS64Transport); isDNS64 && dns64Transport.DNS64Prefix().IsValid() {
+			response = c.synthesizeDNS64(ctx, transport, message, response, dns64Transport.DNS64Prefix())
+		}
+	}
 	/*if question.Qtype == dns.TypeA || question.Qtype == dns.TypeAAAA {
 		validResponse := response
 	loop:
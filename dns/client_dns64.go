@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/nat64"
+	"github.com/sagernet/sing/common"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/miekg/dns"
+)
+
+// synthesizeDNS64 synthesizes AAAA records from A records as described in RFC 6147,
+// if the response to an AAAA query contains no AAAA records.
+func (c *Client) synthesizeDNS64(ctx context.Context, transport adapter.DNSTransport, message *dns.Msg, response *dns.Msg, prefix netip.Prefix) *dns.Msg {
+	if response.Rcode != dns.RcodeSuccess || common.Any(response.Answer, func(it dns.RR) bool {
+		return it.Header().Rrtype == dns.TypeAAAA
+	}) {
+		return response
+	}
+	exchangeMessage := message.Copy()
+	exchangeMessage.Question[0].Qtype = dns.TypeA
+	exchangeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	responseA, err := transport.Exchange(exchangeCtx, exchangeMessage)
+	cancel()
+	if err != nil {
+		if c.logger != nil {
+			c.logger.DebugContext(ctx, "dns64: exchange A: ", err)
+		}
+		return response
+	}
+	if responseA.Rcode != dns.RcodeSuccess {
+		return response
+	}
+	negativeTTL, hasNegativeTTL := extractNegativeTTL(response)
+	var synthesized bool
+	answer := common.Map(responseA.Answer, func(it dns.RR) dns.RR {
+		record, isA := it.(*dns.A)
+		if !isA {
+			return it
+		}
+		synthesized = true
+		timeToLive := record.Hdr.Ttl
+		if hasNegativeTTL && negativeTTL < timeToLive {
+			timeToLive = negativeTTL
+		}
+		return &dns.AAAA{
+			Hdr: dns.RR_Header{
+				Name:   record.Hdr.Name,
+				Rrtype: dns.TypeAAAA,
+				Class:  record.Hdr.Class,
+				Ttl:    timeToLive,
+			},
+			AAAA: nat64.Synthesize(prefix, M.AddrFromIP(record.A)).AsSlice(),
+		}
+	})
+	if !synthesized {
+		return response
+	}
+	responseA.Question = message.Question
+	responseA.Answer = answer
+	responseA.Ns = nil
+	return responseA
+}
@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+type testDNS64Transport struct {
+	TransportAdapter
+	addresses map[uint16][]netip.Addr
+}
+
+func (t *testDNS64Transport) Start(stage adapter.StartStage) error {
+	return nil
+}
+
+func (t *testDNS64Transport) Close() error {
+	return nil
+}
+
+func (t *testDNS64Transport) Reset() {
+}
+
+func (t *testDNS64Transport) Exchange(ctx context.Context, message *dns.Msg) (*dns.Msg, error) {
+	question := message.Question[0]
+	return FixedResponse(message.Id, question, t.addresses[question.Qtype], 600), nil
+}
+
+func TestClientDNS64(t *testing.T) {
+	t.Parallel()
+	transport := &testDNS64Transport{
+		TransportAdapter: NewTransportAdapterWithRemoteOptions("test", "test", option.RemoteDNSServerOptions{
+			RawLocalDNSServerOptions: option.RawLocalDNSServerOptions{
+				DNS64: &option.DNS64Options{Enabled: true},
+			},
+		}),
+		addresses: map[uint16][]netip.Addr{
+			dns.TypeA: {netip.MustParseAddr("192.0.2.1")},
+		},
+	}
+	client := NewClient(ClientOptions{DisableCache: true})
+	addresses, err := client.Lookup(context.Background(), transport, "example.com", adapter.DNSQueryOptions{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("64:ff9b::c000:201"),
+	}, addresses)
+
+	// native AAAA records are not replaced
+	transport.addresses[dns.TypeAAAA] = []netip.Addr{netip.MustParseAddr("2001:db8::1")}
+	addresses, err = client.Lookup(context.Background(), transport, "example.com", adapter.DNSQueryOptions{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("2001:db8::1"),
+	}, addresses)
+}
@@ -122,6 +122,7 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 	if metadata == nil {
 		panic("no context")
 	}
+	tracer := adapter.RuleTracerFromContext(ctx)
 	var currentRuleIndex int
 	if ruleIndex != -1 {
 		currentRuleIndex = ruleIndex + 1
@@ -129,10 +130,18 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 	for ; currentRuleIndex < len(r.rules); currentRuleIndex++ {
 		currentRule := r.rules[currentRuleIndex]
 		if currentRule.WithAddressLimit() && !isAddressQuery {
+			if tracer != nil {
+				R.TraceSkippedRule(tracer, C.RuleTraceTypeDNS, currentRuleIndex, currentRule, "address limit rule on non-address query")
+			}
 			continue
 		}
 		metadata.ResetRuleCache()
-		if currentRule.Match(metadata) {
+		matched := currentRule.Match(metadata)
+		if tracer != nil {
+			R.TraceRule(tracer, C.RuleTraceTypeDNS, currentRuleIndex, currentRule, matched, metadata)
+		}
+		if matched {
+			currentRule.Statistics().Hit()
 			displayRuleIndex := currentRuleIndex
 			if displayRuleIndex != -1 {
 				displayRuleIndex += displayRuleIndex + 1
@@ -458,3 +467,7 @@ func (r *Router) ResetNetwork() {
 		transport.Reset()
 	}
 }
+
+func (r *Router) Rules() []adapter.DNSRule {
+	return r.rules
+}
@@ -4,11 +4,15 @@ import (
 	"net/netip"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/nat64"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 )
 
-var _ adapter.LegacyDNSTransport = (*TransportAdapter)(nil)
+var (
+	_ adapter.LegacyDNSTransport = (*TransportAdapter)(nil)
+	_ adapter.DNS64Transport     = (*TransportAdapter)(nil)
+)
 
 type TransportAdapter struct {
 	transportType string
@@ -16,6 +20,7 @@ type TransportAdapter struct {
 	dependencies  []string
 	strategy      C.DomainStrategy
 	clientSubnet  netip.Prefix
+	dns64Prefix   netip.Prefix
 }
 
 func NewTransportAdapter(transportType string, transportTag string, dependencies []string) TransportAdapter {
@@ -37,6 +42,7 @@ func NewTransportAdapterWithLocalOptions(transportType string, transportTag stri
 		dependencies:  dependencies,
 		strategy:      C.DomainStrategy(localOptions.LegacyStrategy),
 		clientSubnet:  localOptions.LegacyClientSubnet,
+		dns64Prefix:   dns64Prefix(localOptions.DNS64),
 	}
 }
 
@@ -54,9 +60,20 @@ func NewTransportAdapterWithRemoteOptions(transportType string, transportTag str
 		dependencies:  dependencies,
 		strategy:      C.DomainStrategy(remoteOptions.LegacyStrategy),
 		clientSubnet:  remoteOptions.LegacyClientSubnet,
+		dns64Prefix:   dns64Prefix(remoteOptions.DNS64),
 	}
 }
 
+func dns64Prefix(options *option.DNS64Options) netip.Prefix {
+	if options == nil || !options.Enabled {
+		return netip.Prefix{}
+	}
+	if options.Prefix != nil {
+		return options.Prefix.Build(netip.Prefix{})
+	}
+	return nat64.WellKnownPrefix
+}
+
 func (a *TransportAdapter) Type() string {
 	return a.transportType
 }
@@ -76,3 +93,7 @@ func (a *TransportAdapter) LegacyStrategy() C.DomainStrategy {
 func (a *TransportAdapter) LegacyClientSubnet() netip.Prefix {
 	return a.clientSubnet
 }
+
+func (a *TransportAdapter) DNS64Prefix() netip.Prefix {
+	return a.dns64Prefix
+}
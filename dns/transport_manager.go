@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/nat64"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -250,6 +251,12 @@ func (m *TransportManager) Create(ctx context.Context, logger log.ContextLogger,
 	if err != nil {
 		return err
 	}
+	if dns64Transport, isDNS64 := transport.(adapter.DNS64Transport); isDNS64 && dns64Transport.DNS64Prefix().IsValid() {
+		err = nat64.ValidatePrefix(dns64Transport.DNS64Prefix())
+		if err != nil {
+			return E.Cause(err, "dns64")
+		}
+	}
 	m.access.Lock()
 	defer m.access.Unlock()
 	if m.started {
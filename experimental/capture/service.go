@@ -0,0 +1,156 @@
+package capture
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/mirror"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.PacketCapture = (*Service)(nil)
+
+type Service struct {
+	ctx     context.Context
+	logger  log.ContextLogger
+	options option.CaptureOptions
+	access  sync.Mutex
+	current atomic.Pointer[session]
+	last    *session
+}
+
+func New(ctx context.Context, logger log.ContextLogger, options option.CaptureOptions) *Service {
+	return &Service{
+		ctx:     ctx,
+		logger:  logger,
+		options: options,
+	}
+}
+
+func (s *Service) Name() string {
+	return "capture"
+}
+
+func (s *Service) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStarted || !s.options.Enabled {
+		return nil
+	}
+	return s.StartCapture(nil)
+}
+
+func (s *Service) Close() error {
+	current := s.current.Load()
+	if current != nil {
+		s.stopSession(current, "")
+	}
+	return nil
+}
+
+func (s *Service) StartCapture(options *option.CaptureOptions) error {
+	if options == nil {
+		options = &s.options
+	}
+	s.access.Lock()
+	defer s.access.Unlock()
+	if s.current.Load() != nil {
+		return E.New("capture is already running")
+	}
+	newSession, err := newSession(s, *options)
+	if err != nil {
+		return err
+	}
+	s.current.Store(newSession)
+	s.last = newSession
+	go newSession.loop()
+	if options.MaxDuration > 0 {
+		newSession.timer = time.AfterFunc(time.Duration(options.MaxDuration), func() {
+			s.stopSession(newSession, "duration limit reached")
+		})
+	}
+	s.logger.Info("started capture to ", newSession.path)
+	return nil
+}
+
+func (s *Service) StopCapture() error {
+	current := s.current.Load()
+	if current == nil {
+		return E.New("capture is not running")
+	}
+	s.stopSession(current, "")
+	return nil
+}
+
+func (s *Service) stopSession(current *session, reason string) {
+	s.access.Lock()
+	if !s.current.CompareAndSwap(current, nil) {
+		s.access.Unlock()
+		return
+	}
+	s.access.Unlock()
+	current.close()
+	if reason != "" {
+		s.logger.Info("stopped capture to ", current.path, ": ", reason)
+	} else {
+		s.logger.Info("stopped capture to ", current.path)
+	}
+}
+
+func (s *Service) CaptureStatus() adapter.CaptureStatus {
+	s.access.Lock()
+	last := s.last
+	s.access.Unlock()
+	if last == nil {
+		return adapter.CaptureStatus{}
+	}
+	return adapter.CaptureStatus{
+		Running:   s.current.Load() == last,
+		Path:      last.path,
+		StartedAt: last.startedAt,
+		Packets:   last.packets.Load(),
+		Bytes:     last.bytes.Load(),
+		Dropped:   last.dropped.Load(),
+	}
+}
+
+func (s *Service) Capturing(inbound string) bool {
+	current := s.current.Load()
+	return current != nil && current.captureRaw(inbound)
+}
+
+func (s *Service) CapturePacket(inbound string, outgoing bool, packet []byte) {
+	current := s.current.Load()
+	if current == nil || !current.captureRaw(inbound) {
+		return
+	}
+	current.capturePacket(inbound, outgoing, packet)
+}
+
+func (s *Service) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) net.Conn {
+	current := s.current.Load()
+	if current == nil || !current.matchConnection(metadata, matchedRule) {
+		return conn
+	}
+	return mirror.NewConn(conn, metadata, current.newFlow(metadata, N.NetworkTCP))
+}
+
+func (s *Service) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) N.PacketConn {
+	current := s.current.Load()
+	if current == nil || !current.matchConnection(metadata, matchedRule) {
+		return conn
+	}
+	return mirror.NewPacketConn(conn, current.newFlow(metadata, N.NetworkUDP))
+}
+
+func interfaceName(inbound string, inboundType string) string {
+	if inbound != "" {
+		return inbound
+	}
+	return inboundType
+}
@@ -0,0 +1,338 @@
+package capture
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/mirror"
+	"github.com/sagernet/sing-box/common/pcap"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+const (
+	DefaultPath      = "capture.pcapng"
+	defaultQueueSize = 4096
+)
+
+const (
+	eventPacket = iota
+	eventUpload
+	eventDownload
+	eventClose
+)
+
+type event struct {
+	kind      uint8
+	timestamp time.Time
+	flow      *flow
+	inbound   string
+	direction pcap.Direction
+	payload   []byte
+	address   M.Socksaddr
+}
+
+type session struct {
+	service   *Service
+	path      string
+	startedAt time.Time
+	inbound   map[string]bool
+	rules     map[adapter.Rule]bool
+	ipCIDR    []netip.Prefix
+	port      map[uint16]bool
+	maxSize   uint64
+	file      *os.File
+	buffered  *bufio.Writer
+	writer    *pcap.NGWriter
+	timer     *time.Timer
+	queue     chan event
+	done      chan struct{}
+	finished  chan struct{}
+	closeOnce sync.Once
+	packets   atomic.Uint64
+	bytes     atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+func newSession(s *Service, options option.CaptureOptions) (*session, error) {
+	path := options.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	newSession := &session{
+		service:   s,
+		path:      filemanager.BasePath(s.ctx, path),
+		startedAt: time.Now(),
+		ipCIDR:    options.IPCIDR,
+		maxSize:   options.MaxSize.Value(),
+		queue:     make(chan event, defaultQueueSize),
+		done:      make(chan struct{}),
+		finished:  make(chan struct{}),
+	}
+	if len(options.Inbound) > 0 {
+		newSession.inbound = make(map[string]bool)
+		for _, inbound := range options.Inbound {
+			newSession.inbound[inbound] = true
+		}
+	}
+	if len(options.Rule) > 0 {
+		router := service.FromContext[adapter.Router](s.ctx)
+		if router == nil {
+			return nil, E.New("rule filter is not available without router")
+		}
+		rules := router.Rules()
+		newSession.rules = make(map[adapter.Rule]bool)
+		for _, index := range options.Rule {
+			if index < 0 || index >= len(rules) {
+				return nil, E.New("rule index out of range: ", index)
+			}
+			newSession.rules[rules[index]] = true
+		}
+	}
+	if len(options.Port) > 0 {
+		newSession.port = make(map[uint16]bool)
+		for _, port := range options.Port {
+			newSession.port[port] = true
+		}
+	}
+	file, err := filemanager.OpenFile(s.ctx, newSession.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, E.Cause(err, "open capture file")
+	}
+	newSession.file = file
+	newSession.buffered = bufio.NewWriter(file)
+	newSession.writer, err = pcap.NewNGWriter(&countWriter{newSession}, "sing-box "+C.Version, options.SnapLength)
+	if err != nil {
+		file.Close()
+		return nil, E.Cause(err, "write capture file")
+	}
+	return newSession, nil
+}
+
+type countWriter struct {
+	*session
+}
+
+func (w *countWriter) Write(p []byte) (n int, err error) {
+	n, err = w.buffered.Write(p)
+	w.bytes.Add(uint64(n))
+	return
+}
+
+func (s *session) captureRaw(inbound string) bool {
+	return len(s.rules) == 0 && (s.inbound == nil || s.inbound[inbound])
+}
+
+func (s *session) matchConnection(metadata adapter.InboundContext, matchedRule adapter.Rule) bool {
+	// Connections from TUN are captured as raw packets, unless filtered by rules.
+	if metadata.InboundType == C.TypeTun && len(s.rules) == 0 {
+		return false
+	}
+	if s.inbound != nil && !s.inbound[metadata.Inbound] {
+		return false
+	}
+	if s.rules != nil && (matchedRule == nil || !s.rules[matchedRule]) {
+		return false
+	}
+	return s.matchAddresses(metadata.Source.AddrPort(), mirror.RemoteAddress(metadata))
+}
+
+func (s *session) matchAddresses(source netip.AddrPort, destination netip.AddrPort) bool {
+	if len(s.ipCIDR) > 0 {
+		sourceAddr := source.Addr().Unmap()
+		destinationAddr := destination.Addr().Unmap()
+		if !common.Any(s.ipCIDR, func(prefix netip.Prefix) bool {
+			return prefix.Contains(sourceAddr) || prefix.Contains(destinationAddr)
+		}) {
+			return false
+		}
+	}
+	if s.port != nil {
+		if !(source.Port() != 0 && s.port[source.Port()]) && !(destination.Port() != 0 && s.port[destination.Port()]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *session) capturePacket(inbound string, outgoing bool, packet []byte) {
+	if len(s.ipCIDR) > 0 || s.port != nil {
+		source, destination, ok := pcap.ParseAddresses(packet)
+		if !ok || !s.matchAddresses(source, destination) {
+			return
+		}
+	}
+	direction := pcap.DirectionInbound
+	if outgoing {
+		direction = pcap.DirectionOutbound
+	}
+	s.enqueue(event{
+		kind:      eventPacket,
+		timestamp: time.Now(),
+		inbound:   inbound,
+		direction: direction,
+		payload:   common.Dup(packet),
+	})
+}
+
+func (s *session) enqueue(event event) {
+	select {
+	case <-s.done:
+	case s.queue <- event:
+	default:
+		if s.dropped.Add(1) == 1 {
+			s.service.logger.Warn("capture queue is full, dropping packets")
+		}
+	}
+}
+
+func (s *session) loop() {
+	defer close(s.finished)
+	var limited bool
+	for {
+		select {
+		case <-s.done:
+			s.buffered.Flush()
+			s.file.Close()
+			return
+		case event := <-s.queue:
+			if limited {
+				continue
+			}
+			err := s.writeEvent(event)
+			if err == nil && len(s.queue) == 0 {
+				err = s.buffered.Flush()
+			}
+			if err != nil {
+				s.service.logger.Error(E.Cause(err, "write capture file"))
+				limited = true
+				go s.service.stopSession(s, "write failed")
+			} else if s.maxSize > 0 && s.bytes.Load() >= s.maxSize {
+				limited = true
+				go s.service.stopSession(s, "size limit reached")
+			}
+		}
+	}
+}
+
+func (s *session) writeEvent(event event) error {
+	if event.kind == eventPacket {
+		s.packets.Add(1)
+		return s.writer.WritePacket(event.inbound, event.direction, event.timestamp, event.payload)
+	}
+	packets := event.flow.synthesize(event)
+	for _, packet := range packets {
+		err := s.writer.WritePacket(event.flow.inbound, pcap.DirectionUnknown, event.timestamp, packet)
+		if err != nil {
+			return err
+		}
+	}
+	s.packets.Add(uint64(len(packets)))
+	return nil
+}
+
+func (s *session) close() {
+	s.closeOnce.Do(func() {
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+		close(s.done)
+	})
+	<-s.finished
+}
+
+func (s *session) newFlow(metadata adapter.InboundContext, network string) *flow {
+	newFlow := &flow{
+		session: s,
+		inbound: interfaceName(metadata.Inbound, metadata.InboundType),
+		client:  metadata.Source.AddrPort(),
+		remote:  mirror.RemoteAddress(metadata),
+	}
+	if network == N.NetworkTCP {
+		newFlow.tcpFlow = pcap.NewTCPFlow(newFlow.client, newFlow.remote)
+	}
+	return newFlow
+}
+
+var _ mirror.Session = (*flow)(nil)
+
+// flow synthesizes packets for a connection from a stream inbound.
+type flow struct {
+	session   *session
+	inbound   string
+	client    netip.AddrPort
+	remote    netip.AddrPort
+	tcpFlow   *pcap.TCPFlow
+	closeOnce sync.Once
+}
+
+func (f *flow) synthesize(event event) [][]byte {
+	if f.tcpFlow != nil {
+		switch event.kind {
+		case eventUpload:
+			return f.tcpFlow.Upload(event.payload)
+		case eventDownload:
+			return f.tcpFlow.Download(event.payload)
+		case eventClose:
+			return f.tcpFlow.Close()
+		}
+		return nil
+	}
+	var remote netip.AddrPort
+	if event.address.IsIP() {
+		remote = event.address.AddrPort()
+	} else {
+		remote = netip.AddrPortFrom(f.remote.Addr(), event.address.Port)
+	}
+	switch event.kind {
+	case eventUpload:
+		return [][]byte{pcap.BuildUDPPacket(f.client, remote, event.payload)}
+	case eventDownload:
+		return [][]byte{pcap.BuildUDPPacket(remote, f.client, event.payload)}
+	}
+	return nil
+}
+
+func (f *flow) Upload(payload []byte, destination M.Socksaddr) {
+	f.session.enqueue(event{
+		kind:      eventUpload,
+		timestamp: time.Now(),
+		flow:      f,
+		payload:   common.Dup(payload),
+		address:   destination,
+	})
+}
+
+func (f *flow) Download(payload []byte, source M.Socksaddr) {
+	f.session.enqueue(event{
+		kind:      eventDownload,
+		timestamp: time.Now(),
+		flow:      f,
+		payload:   common.Dup(payload),
+		address:   source,
+	})
+}
+
+func (f *flow) Close() {
+	f.closeOnce.Do(func() {
+		if f.tcpFlow == nil {
+			return
+		}
+		f.session.enqueue(event{
+			kind:      eventClose,
+			timestamp: time.Now(),
+			flow:      f,
+		})
+	})
+}
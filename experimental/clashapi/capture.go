@@ -0,0 +1,87 @@
+package clashapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func captureRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getCaptureStatus(ctx))
+	r.Post("/", startCapture(ctx))
+	r.Delete("/", stopCapture(ctx))
+	return r
+}
+
+func getCaptureStatus(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		packetCapture := service.FromContext[adapter.PacketCapture](ctx)
+		if packetCapture == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		render.JSON(w, r, packetCapture.CaptureStatus())
+	}
+}
+
+func startCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		packetCapture := service.FromContext[adapter.PacketCapture](ctx)
+		if packetCapture == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		var options *option.CaptureOptions
+		if len(content) > 0 {
+			options = new(option.CaptureOptions)
+			err = json.Unmarshal(content, options)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, newError(err.Error()))
+				return
+			}
+		}
+		err = packetCapture.StartCapture(options)
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
+
+func stopCapture(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		packetCapture := service.FromContext[adapter.PacketCapture](ctx)
+		if packetCapture == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		err := packetCapture.StopCapture()
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
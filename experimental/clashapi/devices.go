@@ -0,0 +1,30 @@
+package clashapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func deviceRouter(trafficManager *trafficontrol.Manager) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getDevices(trafficManager))
+	return r
+}
+
+func getDevices(trafficManager *trafficontrol.Manager) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		devices := trafficManager.Devices()
+		slices.SortFunc(devices, func(a, b trafficontrol.DeviceSnapshot) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		render.JSON(w, r, render.M{
+			"devices": devices,
+		})
+	}
+}
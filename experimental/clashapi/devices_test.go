@@ -0,0 +1,75 @@
+package clashapi
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/experimental/clashapi/trafficontrol"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOutbound struct {
+	adapter.Outbound
+}
+
+func (o *testOutbound) Tag() string {
+	return "direct"
+}
+
+type testOutboundManager struct {
+	adapter.OutboundManager
+}
+
+func (m *testOutboundManager) Outbound(tag string) (adapter.Outbound, bool) {
+	return nil, false
+}
+
+func requestDevices(t *testing.T, handler http.Handler) []trafficontrol.DeviceSnapshot {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response struct {
+		Devices []trafficontrol.DeviceSnapshot `json:"devices"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.NotNil(t, response.Devices)
+	return response.Devices
+}
+
+func TestDeviceRouter(t *testing.T) {
+	t.Parallel()
+	trafficManager := trafficontrol.NewManager()
+	handler := deviceRouter(trafficManager)
+	require.Empty(t, requestDevices(t, handler))
+
+	laptop := &adapter.Device{Name: "laptop"}
+	phone := &adapter.Device{Name: "phone", Groups: []string{"family"}}
+	var trackers []*trafficontrol.TCPConn
+	for _, device := range []*adapter.Device{phone, laptop, phone} {
+		client, server := net.Pipe()
+		defer server.Close()
+		go io.Copy(io.Discard, server)
+		tracker := trafficontrol.NewTCPTracker(client, trafficManager, adapter.InboundContext{SourceDevice: device}, &testOutboundManager{}, nil, &testOutbound{})
+		_, err := tracker.Write([]byte("ping"))
+		require.NoError(t, err)
+		trackers = append(trackers, tracker)
+	}
+	require.Equal(t, []trafficontrol.DeviceSnapshot{
+		{Name: "laptop", Download: 4, Connections: 1},
+		{Name: "phone", Groups: []string{"family"}, Download: 8, Connections: 2},
+	}, requestDevices(t, handler))
+
+	for _, tracker := range trackers {
+		require.NoError(t, tracker.Close())
+	}
+	trafficManager.UpdateDevices([]*adapter.Device{{Name: "phone", Groups: []string{"guest"}}})
+	require.Equal(t, []trafficontrol.DeviceSnapshot{
+		{Name: "phone", Groups: []string{"guest"}, Download: 8},
+	}, requestDevices(t, handler))
+}
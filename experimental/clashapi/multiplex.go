@@ -0,0 +1,74 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func multiplexRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getMultiplexStatuses(ctx))
+	r.Get("/{tag}", getMultiplexStatus(ctx))
+	return r
+}
+
+func getMultiplexStatuses(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		statuses := make(map[string]*adapter.MultiplexStatus)
+		outboundManager := service.FromContext[adapter.OutboundManager](ctx)
+		if outboundManager != nil {
+			for _, outbound := range outboundManager.Outbounds() {
+				multiplexOutbound, isMultiplex := outbound.(adapter.MultiplexOutbound)
+				if !isMultiplex {
+					continue
+				}
+				status := multiplexOutbound.MultiplexStatus()
+				if status == nil {
+					continue
+				}
+				statuses[outbound.Tag()] = status
+			}
+		}
+		render.JSON(w, r, render.M{
+			"outbounds": statuses,
+		})
+	}
+}
+
+func getMultiplexStatus(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		outboundManager := service.FromContext[adapter.OutboundManager](ctx)
+		tag, err := url.PathUnescape(chi.URLParam(r, "tag"))
+		if outboundManager == nil || err != nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		outbound, loaded := outboundManager.Outbound(tag)
+		if !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		multiplexOutbound, isMultiplex := outbound.(adapter.MultiplexOutbound)
+		if !isMultiplex {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		status := multiplexOutbound.MultiplexStatus()
+		if status == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		render.JSON(w, r, status)
+	}
+}
@@ -2,6 +2,7 @@ package clashapi
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 
@@ -9,32 +10,68 @@ import (
 	"github.com/go-chi/render"
 )
 
-func ruleRouter(router adapter.Router) http.Handler {
+func ruleRouter(router adapter.Router, dnsRouter adapter.DNSRouter) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", getRules(router))
+	r.Get("/dns", getDNSRules(dnsRouter))
+	r.Delete("/hits", resetRuleHits(router, dnsRouter))
 	return r
 }
 
 type Rule struct {
-	Type    string `json:"type"`
-	Payload string `json:"payload"`
-	Proxy   string `json:"proxy"`
+	Index   int        `json:"index"`
+	Type    string     `json:"type"`
+	Payload string     `json:"payload"`
+	Proxy   string     `json:"proxy"`
+	Extra   *RuleExtra `json:"extra"`
+}
+
+type RuleExtra struct {
+	HitCount uint64     `json:"hitCount"`
+	HitAt    *time.Time `json:"hitAt,omitempty"`
+}
+
+func newRule[T adapter.Rule](rawRules []T) []Rule {
+	rules := make([]Rule, 0, len(rawRules))
+	for index, rule := range rawRules {
+		statistics := rule.Statistics()
+		extra := &RuleExtra{
+			HitCount: statistics.Hits(),
+		}
+		if lastMatched := statistics.LastMatched(); !lastMatched.IsZero() {
+			extra.HitAt = &lastMatched
+		}
+		rules = append(rules, Rule{
+			Index:   index,
+			Type:    rule.Type(),
+			Payload: rule.String(),
+			Proxy:   rule.Action().String(),
+			Extra:   extra,
+		})
+	}
+	return rules
 }
 
 func getRules(router adapter.Router) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rawRules := router.Rules()
-
-		var rules []Rule
-		for _, rule := range rawRules {
-			rules = append(rules, Rule{
-				Type:    rule.Type(),
-				Payload: rule.String(),
-				Proxy:   rule.Action().String(),
-			})
-		}
 		render.JSON(w, r, render.M{
-			"rules": rules,
+			"rules": newRule(router.Rules()),
 		})
 	}
 }
+
+func getDNSRules(dnsRouter adapter.DNSRouter) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, render.M{
+			"rules": newRule(dnsRouter.Rules()),
+		})
+	}
+}
+
+func resetRuleHits(router adapter.Router, dnsRouter adapter.DNSRouter) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adapter.ResetRuleStatistics(router.Rules())
+		adapter.ResetRuleStatistics(dnsRouter.Rules())
+		render.NoContent(w, r)
+	}
+}
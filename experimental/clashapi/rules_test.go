@@ -0,0 +1,106 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRouter struct {
+	adapter.Router
+	rules []adapter.Rule
+}
+
+func (r *testRouter) Rules() []adapter.Rule {
+	return r.rules
+}
+
+type testDNSRouter struct {
+	adapter.DNSRouter
+	rules []adapter.DNSRule
+}
+
+func (r *testDNSRouter) Rules() []adapter.DNSRule {
+	return r.rules
+}
+
+func newTestRuleRouter(t *testing.T) (*testRouter, *testDNSRouter) {
+	ctx := context.Background()
+	logger := log.NewNOPFactory().NewLogger("router")
+	router := &testRouter{}
+	for _, content := range []string{
+		`{"port":443,"outbound":"direct"}`,
+		`{"domain_suffix":"example.org","action":"reject"}`,
+	} {
+		ruleOptions, err := json.UnmarshalExtended[option.Rule]([]byte(content))
+		require.NoError(t, err)
+		rule, err := R.NewRule(ctx, logger, ruleOptions, false)
+		require.NoError(t, err)
+		router.rules = append(router.rules, rule)
+	}
+	dnsRouter := &testDNSRouter{}
+	dnsRuleOptions, err := json.UnmarshalExtended[option.DNSRule]([]byte(`{"domain":"example.org","server":"local"}`))
+	require.NoError(t, err)
+	dnsRule, err := R.NewDNSRule(ctx, logger, dnsRuleOptions, false)
+	require.NoError(t, err)
+	dnsRouter.rules = append(dnsRouter.rules, dnsRule)
+	return router, dnsRouter
+}
+
+func requestRules(t *testing.T, handler http.Handler, method string, path string) (int, []Rule) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	if recorder.Code != http.StatusOK {
+		return recorder.Code, nil
+	}
+	var response struct {
+		Rules []Rule `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return recorder.Code, response.Rules
+}
+
+func TestRuleRouter(t *testing.T) {
+	t.Parallel()
+	router, dnsRouter := newTestRuleRouter(t)
+	handler := ruleRouter(router, dnsRouter)
+
+	router.rules[0].Statistics().Hit()
+	router.rules[0].Statistics().Hit()
+	dnsRouter.rules[0].Statistics().Hit()
+
+	code, rules := requestRules(t, handler, http.MethodGet, "/")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, rules, 2)
+	require.Equal(t, 0, rules[0].Index)
+	require.Equal(t, router.rules[0].String(), rules[0].Payload)
+	require.Equal(t, "route(direct)", rules[0].Proxy)
+	require.Equal(t, uint64(2), rules[0].Extra.HitCount)
+	require.NotNil(t, rules[0].Extra.HitAt)
+	require.Equal(t, 1, rules[1].Index)
+	require.Equal(t, "reject", rules[1].Proxy)
+	require.Zero(t, rules[1].Extra.HitCount)
+	require.Nil(t, rules[1].Extra.HitAt)
+
+	code, rules = requestRules(t, handler, http.MethodGet, "/dns")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, rules, 1)
+	require.Equal(t, uint64(1), rules[0].Extra.HitCount)
+
+	code, _ = requestRules(t, handler, http.MethodDelete, "/hits")
+	require.Equal(t, http.StatusNoContent, code)
+	_, rules = requestRules(t, handler, http.MethodGet, "/")
+	require.Zero(t, rules[0].Extra.HitCount)
+	require.Nil(t, rules[0].Extra.HitAt)
+	_, rules = requestRules(t, handler, http.MethodGet, "/dns")
+	require.Zero(t, rules[0].Extra.HitCount)
+}
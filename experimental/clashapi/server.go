@@ -38,7 +38,11 @@ func init() {
 	experimental.RegisterClashServerConstructor(NewServer)
 }
 
-var _ adapter.ClashServer = (*Server)(nil)
+var (
+	_ adapter.ClashServer                = (*Server)(nil)
+	_ adapter.OffloadedConnectionTracker = (*Server)(nil)
+	_ adapter.DeviceConnectionTracker    = (*Server)(nil)
+)
 
 type Server struct {
 	ctx            context.Context
@@ -120,7 +124,7 @@ func NewServer(ctx context.Context, logFactory log.ObservableFactory, options op
 		r.Get("/version", version)
 		r.Mount("/configs", configRouter(s, logFactory))
 		r.Mount("/proxies", proxyRouter(s, s.router))
-		r.Mount("/rules", ruleRouter(s.router))
+		r.Mount("/rules", ruleRouter(s.router, s.dnsRouter))
 		r.Mount("/connections", connectionRouter(s.ctx, s.router, trafficManager))
 		r.Mount("/providers/proxies", proxyProviderRouter())
 		r.Mount("/providers/rules", ruleProviderRouter())
@@ -128,6 +132,11 @@ func NewServer(ctx context.Context, logFactory log.ObservableFactory, options op
 		r.Mount("/profile", profileRouter())
 		r.Mount("/cache", cacheRouter(ctx))
 		r.Mount("/dns", dnsRouter(s.dnsRouter))
+		r.Mount("/capture", captureRouter(ctx))
+		r.Mount("/wireguard", wireGuardRouter(ctx))
+		r.Mount("/multiplex", multiplexRouter(ctx))
+		r.Mount("/multi_wan", multiWANRouter(ctx))
+		r.Mount("/devices", deviceRouter(trafficManager))
 
 		s.setupMetaAPI(r)
 	})
@@ -243,6 +252,10 @@ func (s *Server) TrafficManager() *trafficontrol.Manager {
 	return s.trafficManager
 }
 
+func (s *Server) UpdateDevices(devices []*adapter.Device) {
+	s.trafficManager.UpdateDevices(devices)
+}
+
 func (s *Server) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) net.Conn {
 	return trafficontrol.NewTCPTracker(conn, s.trafficManager, metadata, s.outbound, matchedRule, matchOutbound)
 }
@@ -251,6 +264,10 @@ func (s *Server) RoutedPacketConnection(ctx context.Context, conn N.PacketConn,
 	return trafficontrol.NewUDPTracker(conn, s.trafficManager, metadata, s.outbound, matchedRule, matchOutbound)
 }
 
+func (s *Server) OffloadedConnection(ctx context.Context, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) adapter.OffloadedConnection {
+	return trafficontrol.NewOffloadedTracker(s.trafficManager, metadata, matchedRule, matchOutbound)
+}
+
 func authentication(serverSecret string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/compatible"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
@@ -40,6 +41,7 @@ type Manager struct {
 	downloadTotal atomic.Int64
 
 	connections             compatible.Map[uuid.UUID, Tracker]
+	devices                 compatible.Map[string, *DeviceStatistics]
 	closedConnectionsAccess sync.Mutex
 	closedConnections       list.List[TrackerMetadata]
 	memory                  uint64
@@ -99,6 +101,64 @@ func (m *Manager) PushDownloaded(size int64) {
 	m.downloadTotal.Add(size)
 }
 
+// Device returns the traffic statistics of the source device, or nil if the source is not a known device.
+func (m *Manager) Device(device *adapter.Device) *DeviceStatistics {
+	if device == nil {
+		return nil
+	}
+	statistics, _ := m.devices.LoadOrStore(device.Name, &DeviceStatistics{Name: device.Name})
+	statistics.access.Lock()
+	statistics.groups = device.Groups
+	statistics.access.Unlock()
+	return statistics
+}
+
+// Devices returns a snapshot of the traffic statistics of known devices.
+func (m *Manager) Devices() []DeviceSnapshot {
+	connections := make(map[string]int)
+	m.connections.Range(func(_ uuid.UUID, value Tracker) bool {
+		device := value.Metadata().Metadata.SourceDevice
+		if device != nil {
+			connections[device.Name]++
+		}
+		return true
+	})
+	devices := make([]DeviceSnapshot, 0)
+	m.devices.Range(func(name string, value *DeviceStatistics) bool {
+		value.access.Lock()
+		groups := value.groups
+		value.access.Unlock()
+		devices = append(devices, DeviceSnapshot{
+			Name:        name,
+			Groups:      groups,
+			Upload:      value.Upload.Load(),
+			Download:    value.Download.Load(),
+			Connections: connections[name],
+		})
+		return true
+	})
+	return devices
+}
+
+// UpdateDevices drops statistics of devices no longer known and updates their groups.
+func (m *Manager) UpdateDevices(devices []*adapter.Device) {
+	deviceByName := make(map[string]*adapter.Device)
+	for _, device := range devices {
+		deviceByName[device.Name] = device
+	}
+	m.devices.Range(func(name string, value *DeviceStatistics) bool {
+		device, loaded := deviceByName[name]
+		if !loaded {
+			m.devices.Delete(name)
+			return true
+		}
+		value.access.Lock()
+		value.groups = device.Groups
+		value.access.Unlock()
+		return true
+	})
+}
+
 func (m *Manager) Total() (up int64, down int64) {
 	return m.uploadTotal.Load(), m.downloadTotal.Load()
 }
@@ -162,6 +222,27 @@ func (m *Manager) Snapshot() *Snapshot {
 func (m *Manager) ResetStatistic() {
 	m.uploadTotal.Store(0)
 	m.downloadTotal.Store(0)
+	m.devices.Range(func(_ string, value *DeviceStatistics) bool {
+		value.Upload.Store(0)
+		value.Download.Store(0)
+		return true
+	})
+}
+
+type DeviceStatistics struct {
+	Name     string
+	Upload   atomic.Int64
+	Download atomic.Int64
+	access   sync.Mutex
+	groups   []string
+}
+
+type DeviceSnapshot struct {
+	Name        string   `json:"name"`
+	Groups      []string `json:"groups"`
+	Upload      int64    `json:"upload"`
+	Download    int64    `json:"download"`
+	Connections int      `json:"connections"`
 }
 
 type Snapshot struct {
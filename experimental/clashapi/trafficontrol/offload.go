@@ -0,0 +1,65 @@
+package trafficontrol
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// OffloadedConn tracks a connection forwarded by the kernel,
+// whose traffic is reported by the inbound instead of counted on read and write.
+type OffloadedConn struct {
+	metadata TrackerMetadata
+	manager  *Manager
+	device   *DeviceStatistics
+}
+
+func (c *OffloadedConn) Metadata() *TrackerMetadata {
+	return &c.metadata
+}
+
+func (c *OffloadedConn) CountTraffic(upload int64, download int64) {
+	if upload > 0 {
+		c.metadata.Upload.Add(upload)
+		c.manager.PushUploaded(upload)
+		if c.device != nil {
+			c.device.Upload.Add(upload)
+		}
+	}
+	if download > 0 {
+		c.metadata.Download.Add(download)
+		c.manager.PushDownloaded(download)
+		if c.device != nil {
+			c.device.Download.Add(download)
+		}
+	}
+}
+
+func (c *OffloadedConn) Close() error {
+	c.manager.Leave(c)
+	return nil
+}
+
+func NewOffloadedTracker(manager *Manager, metadata adapter.InboundContext, matchRule adapter.Rule, matchOutbound adapter.Outbound) *OffloadedConn {
+	id, _ := uuid.NewV4()
+	tracker := &OffloadedConn{
+		metadata: TrackerMetadata{
+			ID:           id,
+			Metadata:     metadata,
+			CreatedAt:    time.Now(),
+			Upload:       new(atomic.Int64),
+			Download:     new(atomic.Int64),
+			Chain:        []string{matchOutbound.Tag()},
+			Rule:         matchRule,
+			Outbound:     matchOutbound.Tag(),
+			OutboundType: matchOutbound.Type(),
+		},
+		manager: manager,
+		device:  manager.Device(metadata.SourceDevice),
+	}
+	manager.Join(tracker)
+	return tracker
+}
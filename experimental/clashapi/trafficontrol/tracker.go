@@ -58,6 +58,10 @@ func (t TrackerMetadata) MarshalJSON() ([]byte, error) {
 			processPath = F.ToString(processPath, " (", t.Metadata.ProcessInfo.UserId, ")")
 		}
 	}
+	var sourceDevice string
+	if t.Metadata.SourceDevice != nil {
+		sourceDevice = t.Metadata.SourceDevice.Name
+	}
 	var rule string
 	if t.Rule != nil {
 		rule = F.ToString(t.Rule, " => ", t.Rule.Action())
@@ -76,6 +80,7 @@ func (t TrackerMetadata) MarshalJSON() ([]byte, error) {
 			"host":            domain,
 			"dnsMode":         "normal",
 			"processPath":     processPath,
+			"sourceDevice":    sourceDevice,
 		},
 		"upload":      t.Upload.Load(),
 		"download":    t.Download.Load(),
@@ -139,6 +144,10 @@ func NewTCPTracker(conn net.Conn, manager *Manager, metadata adapter.InboundCont
 		chain = append(chain, next)
 		outbound = detour.Tag()
 		outboundType = detour.Type()
+		if outboundChain, isChain := detour.(adapter.OutboundChain); isChain {
+			chain = append(chain, outboundChain.Hops()...)
+			break
+		}
 		group, isGroup := detour.(adapter.OutboundGroup)
 		if !isGroup {
 			break
@@ -147,13 +156,20 @@ func NewTCPTracker(conn net.Conn, manager *Manager, metadata adapter.InboundCont
 	}
 	upload := new(atomic.Int64)
 	download := new(atomic.Int64)
+	device := manager.Device(metadata.SourceDevice)
 	tracker := &TCPConn{
 		ExtendedConn: bufio.NewCounterConn(conn, []N.CountFunc{func(n int64) {
 			upload.Add(n)
 			manager.PushUploaded(n)
+			if device != nil {
+				device.Upload.Add(n)
+			}
 		}}, []N.CountFunc{func(n int64) {
 			download.Add(n)
 			manager.PushDownloaded(n)
+			if device != nil {
+				device.Download.Add(n)
+			}
 		}}),
 		metadata: TrackerMetadata{
 			ID:           id,
@@ -220,6 +236,10 @@ func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata adapter.Inbound
 		chain = append(chain, next)
 		outbound = detour.Tag()
 		outboundType = detour.Type()
+		if outboundChain, isChain := detour.(adapter.OutboundChain); isChain {
+			chain = append(chain, outboundChain.Hops()...)
+			break
+		}
 		group, isGroup := detour.(adapter.OutboundGroup)
 		if !isGroup {
 			break
@@ -228,13 +248,20 @@ func NewUDPTracker(conn N.PacketConn, manager *Manager, metadata adapter.Inbound
 	}
 	upload := new(atomic.Int64)
 	download := new(atomic.Int64)
+	device := manager.Device(metadata.SourceDevice)
 	trackerConn := &UDPConn{
 		PacketConn: bufio.NewCounterPacketConn(conn, []N.CountFunc{func(n int64) {
 			upload.Add(n)
 			manager.PushUploaded(n)
+			if device != nil {
+				device.Upload.Add(n)
+			}
 		}}, []N.CountFunc{func(n int64) {
 			download.Add(n)
 			manager.PushDownloaded(n)
+			if device != nil {
+				device.Download.Add(n)
+			}
 		}}),
 		metadata: TrackerMetadata{
 			ID:           id,
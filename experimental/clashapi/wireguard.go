@@ -0,0 +1,139 @@
+package clashapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func wireGuardRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/{tag}/peers", func(r chi.Router) {
+		r.Get("/", getWireGuardPeers(ctx))
+		r.Put("/{publicKey}", updateWireGuardPeer(ctx))
+		r.Delete("/{publicKey}", removeWireGuardPeer(ctx))
+	})
+	return r
+}
+
+func findWireGuardEndpoint(ctx context.Context, r *http.Request) (adapter.WireGuardEndpoint, bool) {
+	endpointManager := service.FromContext[adapter.EndpointManager](ctx)
+	if endpointManager == nil {
+		return nil, false
+	}
+	tag, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil {
+		return nil, false
+	}
+	endpoint, loaded := endpointManager.Get(tag)
+	if !loaded {
+		return nil, false
+	}
+	wireGuardEndpoint, isWireGuard := endpoint.(adapter.WireGuardEndpoint)
+	return wireGuardEndpoint, isWireGuard
+}
+
+// wireGuardPublicKey returns the public key path parameter,
+// slashes in the base64 encoded key must be escaped by the client.
+func wireGuardPublicKey(r *http.Request) (string, error) {
+	return url.PathUnescape(chi.URLParam(r, "publicKey"))
+}
+
+func getWireGuardPeers(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint, loaded := findWireGuardEndpoint(ctx, r)
+		if !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		peers, err := endpoint.Peers()
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		if peers == nil {
+			peers = []adapter.WireGuardPeerStatus{}
+		}
+		render.JSON(w, r, render.M{
+			"peers": peers,
+		})
+	}
+}
+
+func updateWireGuardPeer(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint, loaded := findWireGuardEndpoint(ctx, r)
+		if !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		publicKey, err := wireGuardPublicKey(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		var peer option.WireGuardPeer
+		err = json.NewDecoder(r.Body).Decode(&peer)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		if peer.PublicKey == "" {
+			peer.PublicKey = publicKey
+		} else if peer.PublicKey != publicKey {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError("public key mismatch"))
+			return
+		}
+		err = endpoint.UpdatePeer(peer)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
+
+func removeWireGuardPeer(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint, loaded := findWireGuardEndpoint(ctx, r)
+		if !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		publicKey, err := wireGuardPublicKey(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		err = endpoint.RemovePeer(publicKey)
+		if errors.Is(err, os.ErrNotExist) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		} else if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
@@ -22,8 +22,9 @@ func init() {
 }
 
 var (
-	_ adapter.ConnectionTracker = (*StatsService)(nil)
-	_ StatsServiceServer        = (*StatsService)(nil)
+	_ adapter.ConnectionTracker          = (*StatsService)(nil)
+	_ adapter.OffloadedConnectionTracker = (*StatsService)(nil)
+	_ StatsServiceServer                 = (*StatsService)(nil)
 )
 
 type StatsService struct {
@@ -118,6 +119,34 @@ func (s *StatsService) RoutedPacketConnection(ctx context.Context, conn N.Packet
 	return bufio.NewInt64CounterPacketConn(conn, readCounter, nil, writeCounter, nil)
 }
 
+func (s *StatsService) OffloadedConnection(ctx context.Context, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) adapter.OffloadedConnection {
+	inbound := metadata.Inbound
+	user := metadata.User
+	outbound := matchOutbound.Tag()
+	var conn offloadedConn
+	countInbound := inbound != "" && s.inbounds[inbound]
+	countOutbound := outbound != "" && s.outbounds[outbound]
+	countUser := user != "" && s.users[user]
+	if !countInbound && !countOutbound && !countUser {
+		return nil
+	}
+	s.access.Lock()
+	if countInbound {
+		conn.uplink = append(conn.uplink, s.loadOrCreateCounter("inbound>>>"+inbound+">>>traffic>>>uplink"))
+		conn.downlink = append(conn.downlink, s.loadOrCreateCounter("inbound>>>"+inbound+">>>traffic>>>downlink"))
+	}
+	if countOutbound {
+		conn.uplink = append(conn.uplink, s.loadOrCreateCounter("outbound>>>"+outbound+">>>traffic>>>uplink"))
+		conn.downlink = append(conn.downlink, s.loadOrCreateCounter("outbound>>>"+outbound+">>>traffic>>>downlink"))
+	}
+	if countUser {
+		conn.uplink = append(conn.uplink, s.loadOrCreateCounter("user>>>"+user+">>>traffic>>>uplink"))
+		conn.downlink = append(conn.downlink, s.loadOrCreateCounter("user>>>"+user+">>>traffic>>>downlink"))
+	}
+	s.access.Unlock()
+	return &conn
+}
+
 func (s *StatsService) GetStats(ctx context.Context, request *GetStatsRequest) (*GetStatsResponse, error) {
 	s.access.Lock()
 	counter, loaded := s.counters[request.Name]
@@ -220,3 +249,21 @@ func (s *StatsService) loadOrCreateCounter(name string) *atomic.Int64 {
 	s.counters[name] = counter
 	return counter
 }
+
+type offloadedConn struct {
+	uplink   []*atomic.Int64
+	downlink []*atomic.Int64
+}
+
+func (c *offloadedConn) CountTraffic(upload int64, download int64) {
+	for _, counter := range c.uplink {
+		counter.Add(upload)
+	}
+	for _, counter := range c.downlink {
+		counter.Add(download)
+	}
+}
+
+func (c *offloadedConn) Close() error {
+	return nil
+}
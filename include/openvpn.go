@@ -0,0 +1,17 @@
+//go:build with_gvisor
+
+package include
+
+import (
+	"github.com/sagernet/sing-box/adapter/endpoint"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/protocol/openvpn"
+)
+
+func registerOpenVPNEndpoint(registry *endpoint.Registry) {
+	openvpn.RegisterEndpoint(registry)
+}
+
+func registerOpenVPNTransport(registry *dns.TransportRegistry) {
+	openvpn.RegisterTransport(registry)
+}
@@ -0,0 +1,27 @@
+//go:build !with_gvisor
+
+package include
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/endpoint"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+func registerOpenVPNEndpoint(registry *endpoint.Registry) {
+	endpoint.Register[option.OpenVPNEndpointOptions](registry, C.TypeOpenVPN, func(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.OpenVPNEndpointOptions) (adapter.Endpoint, error) {
+		return nil, E.New(`OpenVPN is not included in this build, rebuild with -tags with_gvisor`)
+	})
+}
+
+func registerOpenVPNTransport(registry *dns.TransportRegistry) {
+	dns.RegisterTransport[option.OpenVPNDNSServerOptions](registry, C.DNSTypeOpenVPN, func(ctx context.Context, logger log.ContextLogger, tag string, options option.OpenVPNDNSServerOptions) (adapter.DNSTransport, error) {
+		return nil, E.New(`OpenVPN is not included in this build, rebuild with -tags with_gvisor`)
+	})
+}
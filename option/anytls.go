@@ -7,6 +7,7 @@ type AnyTLSInboundOptions struct {
 	InboundTLSOptionsContainer
 	Users         []AnyTLSUser               `json:"users,omitempty"`
 	PaddingScheme badoption.Listable[string] `json:"padding_scheme,omitempty"`
+	Fallback      *InboundFallbackOptions    `json:"fallback,omitempty"`
 }
 
 type AnyTLSUser struct {
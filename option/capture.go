@@ -0,0 +1,20 @@
+package option
+
+import (
+	"net/netip"
+
+	"github.com/sagernet/sing/common/byteformats"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+type CaptureOptions struct {
+	Enabled     bool                             `json:"enabled,omitempty"`
+	Path        string                           `json:"path,omitempty"`
+	Inbound     badoption.Listable[string]       `json:"inbound,omitempty"`
+	Rule        badoption.Listable[int]          `json:"rule,omitempty"`
+	IPCIDR      badoption.Listable[netip.Prefix] `json:"ip_cidr,omitempty"`
+	Port        badoption.Listable[uint16]       `json:"port,omitempty"`
+	SnapLength  uint32                           `json:"snap_length,omitempty"`
+	MaxSize     *byteformats.MemoryBytes         `json:"max_size,omitempty"`
+	MaxDuration badoption.Duration               `json:"max_duration,omitempty"`
+}
@@ -0,0 +1,23 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type DemuxInboundOptions struct {
+	ListenOptions
+	Rules        []DemuxRule        `json:"rules,omitempty"`
+	Fallback     *DemuxTarget       `json:"fallback,omitempty"`
+	SniffTimeout badoption.Duration `json:"sniff_timeout,omitempty"`
+}
+
+type DemuxRule struct {
+	ServerName badoption.Listable[string] `json:"server_name,omitempty"`
+	ALPN       badoption.Listable[string] `json:"alpn,omitempty"`
+	Protocol   badoption.Listable[string] `json:"protocol,omitempty"`
+	DemuxTarget
+}
+
+type DemuxTarget struct {
+	Inbound    string `json:"inbound,omitempty"`
+	Server     string `json:"server,omitempty"`
+	ServerPort uint16 `json:"server_port,omitempty"`
+}
@@ -5,6 +5,7 @@ import (
 
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badoption"
 )
 
 type DirectInboundOptions struct {
@@ -16,16 +17,30 @@ type DirectInboundOptions struct {
 
 type _DirectOutboundOptions struct {
 	DialerOptions
+	NAT64    *NAT64Options    `json:"nat64,omitempty"`
+	MultiWAN *MultiWANOptions `json:"multi_wan,omitempty"`
 	// Deprecated: Use Route Action instead
 	OverrideAddress string `json:"override_address,omitempty"`
 	// Deprecated: Use Route Action instead
 	OverridePort uint16 `json:"override_port,omitempty"`
-	// Deprecated: removed
-	ProxyProtocol uint8 `json:"proxy_protocol,omitempty"`
 }
 
 type DirectOutboundOptions _DirectOutboundOptions
 
+type MultiWANOptions struct {
+	Enabled    bool                       `json:"enabled,omitempty"`
+	Strategy   string                     `json:"strategy,omitempty"`
+	Interfaces []MultiWANInterfaceOptions `json:"interfaces,omitempty"`
+	URL        string                     `json:"url,omitempty"`
+	Interval   badoption.Duration         `json:"interval,omitempty"`
+	Timeout    badoption.Duration         `json:"timeout,omitempty"`
+}
+
+type MultiWANInterfaceOptions struct {
+	Interface string `json:"interface,omitempty"`
+	Weight    uint32 `json:"weight,omitempty"`
+}
+
 func (d *DirectOutboundOptions) UnmarshalJSONContext(ctx context.Context, content []byte) error {
 	err := json.UnmarshalDisallowUnknownFields(content, (*_DirectOutboundOptions)(d))
 	if err != nil {
@@ -7,6 +7,7 @@ type ExperimentalOptions struct {
 	ClashAPI  *ClashAPIOptions  `json:"clash_api,omitempty"`
 	V2RayAPI  *V2RayAPIOptions  `json:"v2ray_api,omitempty"`
 	Debug     *DebugOptions     `json:"debug,omitempty"`
+	Capture   *CaptureOptions   `json:"capture,omitempty"`
 }
 
 type CacheFileOptions struct {
@@ -0,0 +1,21 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type InboundFallbackOptions struct {
+	InboundFallbackTarget
+	Rules []InboundFallbackRule `json:"rules,omitempty"`
+}
+
+type InboundFallbackRule struct {
+	ServerName badoption.Listable[string] `json:"server_name,omitempty"`
+	ALPN       badoption.Listable[string] `json:"alpn,omitempty"`
+	Path       badoption.Listable[string] `json:"path,omitempty"`
+	InboundFallbackTarget
+}
+
+type InboundFallbackTarget struct {
+	Inbound    string `json:"inbound,omitempty"`
+	Server     string `json:"server,omitempty"`
+	ServerPort uint16 `json:"server_port,omitempty"`
+}
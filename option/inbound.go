@@ -2,6 +2,7 @@ package option
 
 import (
 	"context"
+	"net/netip"
 	"time"
 
 	E "github.com/sagernet/sing/common/exceptions"
@@ -58,26 +59,26 @@ type InboundOptions struct {
 }
 
 type ListenOptions struct {
-	Listen               *badoption.Addr    `json:"listen,omitempty"`
-	ListenPort           uint16             `json:"listen_port,omitempty"`
-	BindInterface        string             `json:"bind_interface,omitempty"`
-	RoutingMark          FwMark             `json:"routing_mark,omitempty"`
-	ReuseAddr            bool               `json:"reuse_addr,omitempty"`
-	NetNs                string             `json:"netns,omitempty"`
-	DisableTCPKeepAlive  bool               `json:"disable_tcp_keep_alive,omitempty"`
-	TCPKeepAlive         badoption.Duration `json:"tcp_keep_alive,omitempty"`
-	TCPKeepAliveInterval badoption.Duration `json:"tcp_keep_alive_interval,omitempty"`
-	TCPFastOpen          bool               `json:"tcp_fast_open,omitempty"`
-	TCPMultiPath         bool               `json:"tcp_multi_path,omitempty"`
-	UDPFragment          *bool              `json:"udp_fragment,omitempty"`
-	UDPFragmentDefault   bool               `json:"-"`
-	UDPTimeout           UDPTimeoutCompat   `json:"udp_timeout,omitempty"`
-	Detour               string             `json:"detour,omitempty"`
-
-	// Deprecated: removed
-	ProxyProtocol bool `json:"proxy_protocol,omitempty"`
-	// Deprecated: removed
-	ProxyProtocolAcceptNoHeader bool `json:"proxy_protocol_accept_no_header,omitempty"`
+	Listen               *badoption.Addr            `json:"listen,omitempty"`
+	ListenPort           uint16                     `json:"listen_port,omitempty"`
+	ListenPorts          badoption.Listable[string] `json:"listen_ports,omitempty"`
+	BindInterface        string                     `json:"bind_interface,omitempty"`
+	RoutingMark          FwMark                     `json:"routing_mark,omitempty"`
+	ReuseAddr            bool                       `json:"reuse_addr,omitempty"`
+	NetNs                string                     `json:"netns,omitempty"`
+	DisableTCPKeepAlive  bool                       `json:"disable_tcp_keep_alive,omitempty"`
+	TCPKeepAlive         badoption.Duration         `json:"tcp_keep_alive,omitempty"`
+	TCPKeepAliveInterval badoption.Duration         `json:"tcp_keep_alive_interval,omitempty"`
+	TCPFastOpen          bool                       `json:"tcp_fast_open,omitempty"`
+	TCPMultiPath         bool                       `json:"tcp_multi_path,omitempty"`
+	UDPFragment          *bool                      `json:"udp_fragment,omitempty"`
+	UDPFragmentDefault   bool                       `json:"-"`
+	UDPTimeout           UDPTimeoutCompat           `json:"udp_timeout,omitempty"`
+	Detour               string                     `json:"detour,omitempty"`
+
+	ProxyProtocol               bool                             `json:"proxy_protocol,omitempty"`
+	ProxyProtocolAcceptNoHeader bool                             `json:"proxy_protocol_accept_no_header,omitempty"`
+	ProxyProtocolTrustedCIDR    badoption.Listable[netip.Prefix] `json:"proxy_protocol_trusted_cidr,omitempty"`
 	InboundOptions
 }
 
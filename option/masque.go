@@ -0,0 +1,23 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+type MASQUEInboundOptions struct {
+	ListenOptions
+	Users []auth.User `json:"users,omitempty"`
+	Path  string      `json:"path,omitempty"`
+	InboundTLSOptionsContainer
+}
+
+type MASQUEOutboundOptions struct {
+	DialerOptions
+	ServerOptions
+	Username string               `json:"username,omitempty"`
+	Password string               `json:"password,omitempty"`
+	Path     string               `json:"path,omitempty"`
+	Headers  badoption.HTTPHeader `json:"headers,omitempty"`
+	OutboundTLSOptionsContainer
+}
@@ -12,6 +12,7 @@ type OutboundMultiplexOptions struct {
 	MaxConnections int            `json:"max_connections,omitempty"`
 	MinStreams     int            `json:"min_streams,omitempty"`
 	MaxStreams     int            `json:"max_streams,omitempty"`
+	Scheduler      string         `json:"scheduler,omitempty"`
 	Padding        bool           `json:"padding,omitempty"`
 	Brutal         *BrutalOptions `json:"brutal,omitempty"`
 }
@@ -0,0 +1,13 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type DNS64Options struct {
+	Enabled bool              `json:"enabled,omitempty"`
+	Prefix  *badoption.Prefix `json:"prefix,omitempty"`
+}
+
+type NAT64Options struct {
+	Enabled bool              `json:"enabled,omitempty"`
+	Prefix  *badoption.Prefix `json:"prefix,omitempty"`
+}
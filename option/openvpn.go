@@ -0,0 +1,17 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type OpenVPNEndpointOptions struct {
+	Config     badoption.Listable[string] `json:"config,omitempty"`
+	ConfigPath string                     `json:"config_path,omitempty"`
+	Username   string                     `json:"username,omitempty"`
+	Password   string                     `json:"password,omitempty"`
+	MTU        uint32                     `json:"mtu,omitempty"`
+	UDPTimeout badoption.Duration         `json:"udp_timeout,omitempty"`
+	DialerOptions
+}
+
+type OpenVPNDNSServerOptions struct {
+	Endpoint string `json:"endpoint,omitempty"`
+}
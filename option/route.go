@@ -11,6 +11,8 @@ type RouteOptions struct {
 	FindProcess                bool                              `json:"find_process,omitempty"`
 	FindNeighbor               bool                              `json:"find_neighbor,omitempty"`
 	DHCPLeaseFiles             badoption.Listable[string]        `json:"dhcp_lease_files,omitempty"`
+	Devices                    []DeviceOptions                   `json:"devices,omitempty"`
+	DevicesPath                string                            `json:"devices_path,omitempty"`
 	AutoDetectInterface        bool                              `json:"auto_detect_interface,omitempty"`
 	OverrideAndroidVPN         bool                              `json:"override_android_vpn,omitempty"`
 	DefaultInterface           string                            `json:"default_interface,omitempty"`
@@ -33,3 +35,13 @@ type GeositeOptions struct {
 	DownloadURL    string `json:"download_url,omitempty"`
 	DownloadDetour string `json:"download_detour,omitempty"`
 }
+
+type DeviceOptions struct {
+	Name       string                     `json:"name,omitempty"`
+	MACAddress badoption.Listable[string] `json:"mac_address,omitempty"`
+	Group      badoption.Listable[string] `json:"group,omitempty"`
+}
+
+type DeviceFile struct {
+	Devices []DeviceOptions `json:"devices,omitempty"`
+}
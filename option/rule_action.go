@@ -22,6 +22,7 @@ type _RuleAction struct {
 	RejectOptions       RejectActionOptions       `json:"-"`
 	SniffOptions        RouteActionSniff          `json:"-"`
 	ResolveOptions      RouteActionResolve        `json:"-"`
+	MirrorOptions       RouteActionMirror         `json:"-"`
 }
 
 type RuleAction _RuleAction
@@ -49,6 +50,8 @@ func (r RuleAction) MarshalJSON() ([]byte, error) {
 		v = r.SniffOptions
 	case C.RuleActionTypeResolve:
 		v = r.ResolveOptions
+	case C.RuleActionTypeMirror:
+		v = r.MirrorOptions
 	default:
 		return nil, E.New("unknown rule action: " + r.Action)
 	}
@@ -82,6 +85,8 @@ func (r *RuleAction) UnmarshalJSON(data []byte) error {
 		v = &r.SniffOptions
 	case C.RuleActionTypeResolve:
 		v = &r.ResolveOptions
+	case C.RuleActionTypeMirror:
+		v = &r.MirrorOptions
 	default:
 		return E.New("unknown rule action: " + r.Action)
 	}
@@ -314,6 +319,51 @@ type RouteActionResolve struct {
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
 }
 
+type _RouteActionMirror struct {
+	Path               string `json:"path,omitempty"`
+	Unix               string `json:"unix,omitempty"`
+	Outbound           string `json:"outbound,omitempty"`
+	Server             string `json:"server,omitempty"`
+	ServerPort         uint16 `json:"server_port,omitempty"`
+	DownloadServerPort uint16 `json:"download_server_port,omitempty"`
+	QueueSize          int    `json:"queue_size,omitempty"`
+}
+
+type RouteActionMirror _RouteActionMirror
+
+func (r *RouteActionMirror) UnmarshalJSON(bytes []byte) error {
+	err := json.Unmarshal(bytes, (*_RouteActionMirror)(r))
+	if err != nil {
+		return err
+	}
+	var targets int
+	if r.Path != "" {
+		targets++
+	}
+	if r.Unix != "" {
+		targets++
+	}
+	if r.Outbound != "" {
+		targets++
+	}
+	switch targets {
+	case 0:
+		return E.New("missing mirror target: one of `path`, `unix` or `outbound` is required")
+	case 1:
+	default:
+		return E.New("`path`, `unix` and `outbound` are mutually exclusive")
+	}
+	if r.Outbound == "" {
+		if r.Server != "" || r.ServerPort != 0 || r.DownloadServerPort != 0 {
+			return E.New("`server`, `server_port` and `download_server_port` are only available with `outbound`")
+		}
+	} else if r.Server == "" || r.ServerPort == 0 {
+		// replaying to the original destination would repeat the side effects of client requests
+		return E.New("missing mirror server: `server` and `server_port` are required with `outbound`")
+	}
+	return nil
+}
+
 type DNSRouteActionPredefined struct {
 	Rcode  *DNSRCode                            `json:"rcode,omitempty"`
 	Answer badoption.Listable[DNSRecordOptions] `json:"answer,omitempty"`
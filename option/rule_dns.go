@@ -68,50 +68,55 @@ func (r DNSRule) IsValid() bool {
 }
 
 type RawDefaultDNSRule struct {
-	Inbound                  badoption.Listable[string]                                                  `json:"inbound,omitempty"`
-	IPVersion                int                                                                         `json:"ip_version,omitempty"`
-	QueryType                badoption.Listable[DNSQueryType]                                            `json:"query_type,omitempty"`
-	Network                  badoption.Listable[string]                                                  `json:"network,omitempty"`
-	AuthUser                 badoption.Listable[string]                                                  `json:"auth_user,omitempty"`
-	Protocol                 badoption.Listable[string]                                                  `json:"protocol,omitempty"`
-	Domain                   badoption.Listable[string]                                                  `json:"domain,omitempty"`
-	DomainSuffix             badoption.Listable[string]                                                  `json:"domain_suffix,omitempty"`
-	DomainKeyword            badoption.Listable[string]                                                  `json:"domain_keyword,omitempty"`
-	DomainRegex              badoption.Listable[string]                                                  `json:"domain_regex,omitempty"`
-	Geosite                  badoption.Listable[string]                                                  `json:"geosite,omitempty"`
-	SourceGeoIP              badoption.Listable[string]                                                  `json:"source_geoip,omitempty"`
-	GeoIP                    badoption.Listable[string]                                                  `json:"geoip,omitempty"`
-	IPCIDR                   badoption.Listable[string]                                                  `json:"ip_cidr,omitempty"`
-	IPIsPrivate              bool                                                                        `json:"ip_is_private,omitempty"`
-	IPAcceptAny              bool                                                                        `json:"ip_accept_any,omitempty"`
-	SourceIPCIDR             badoption.Listable[string]                                                  `json:"source_ip_cidr,omitempty"`
-	SourceIPIsPrivate        bool                                                                        `json:"source_ip_is_private,omitempty"`
-	SourcePort               badoption.Listable[uint16]                                                  `json:"source_port,omitempty"`
-	SourcePortRange          badoption.Listable[string]                                                  `json:"source_port_range,omitempty"`
-	Port                     badoption.Listable[uint16]                                                  `json:"port,omitempty"`
-	PortRange                badoption.Listable[string]                                                  `json:"port_range,omitempty"`
-	ProcessName              badoption.Listable[string]                                                  `json:"process_name,omitempty"`
-	ProcessPath              badoption.Listable[string]                                                  `json:"process_path,omitempty"`
-	ProcessPathRegex         badoption.Listable[string]                                                  `json:"process_path_regex,omitempty"`
-	PackageName              badoption.Listable[string]                                                  `json:"package_name,omitempty"`
-	User                     badoption.Listable[string]                                                  `json:"user,omitempty"`
-	UserID                   badoption.Listable[int32]                                                   `json:"user_id,omitempty"`
-	Outbound                 badoption.Listable[string]                                                  `json:"outbound,omitempty"`
-	ClashMode                string                                                                      `json:"clash_mode,omitempty"`
-	NetworkType              badoption.Listable[InterfaceType]                                           `json:"network_type,omitempty"`
-	NetworkIsExpensive       bool                                                                        `json:"network_is_expensive,omitempty"`
-	NetworkIsConstrained     bool                                                                        `json:"network_is_constrained,omitempty"`
-	WIFISSID                 badoption.Listable[string]                                                  `json:"wifi_ssid,omitempty"`
-	WIFIBSSID                badoption.Listable[string]                                                  `json:"wifi_bssid,omitempty"`
-	InterfaceAddress         *badjson.TypedMap[string, badoption.Listable[*badoption.Prefixable]]        `json:"interface_address,omitempty"`
-	NetworkInterfaceAddress  *badjson.TypedMap[InterfaceType, badoption.Listable[*badoption.Prefixable]] `json:"network_interface_address,omitempty"`
-	DefaultInterfaceAddress  badoption.Listable[*badoption.Prefixable]                                   `json:"default_interface_address,omitempty"`
-	SourceMACAddress         badoption.Listable[string]                                                  `json:"source_mac_address,omitempty"`
-	SourceHostname           badoption.Listable[string]                                                  `json:"source_hostname,omitempty"`
-	RuleSet                  badoption.Listable[string]                                                  `json:"rule_set,omitempty"`
-	RuleSetIPCIDRMatchSource bool                                                                        `json:"rule_set_ip_cidr_match_source,omitempty"`
-	RuleSetIPCIDRAcceptEmpty bool                                                                        `json:"rule_set_ip_cidr_accept_empty,omitempty"`
-	Invert                   bool                                                                        `json:"invert,omitempty"`
+	Inbound                          badoption.Listable[string]                                                  `json:"inbound,omitempty"`
+	IPVersion                        int                                                                         `json:"ip_version,omitempty"`
+	QueryType                        badoption.Listable[DNSQueryType]                                            `json:"query_type,omitempty"`
+	Network                          badoption.Listable[string]                                                  `json:"network,omitempty"`
+	AuthUser                         badoption.Listable[string]                                                  `json:"auth_user,omitempty"`
+	ClientCertificateCommonName      badoption.Listable[string]                                                  `json:"client_certificate_common_name,omitempty"`
+	ClientCertificateSAN             badoption.Listable[string]                                                  `json:"client_certificate_san,omitempty"`
+	ClientCertificatePublicKeySHA256 badoption.Listable[[]byte]                                                  `json:"client_certificate_public_key_sha256,omitempty"`
+	Protocol                         badoption.Listable[string]                                                  `json:"protocol,omitempty"`
+	Domain                           badoption.Listable[string]                                                  `json:"domain,omitempty"`
+	DomainSuffix                     badoption.Listable[string]                                                  `json:"domain_suffix,omitempty"`
+	DomainKeyword                    badoption.Listable[string]                                                  `json:"domain_keyword,omitempty"`
+	DomainRegex                      badoption.Listable[string]                                                  `json:"domain_regex,omitempty"`
+	Geosite                          badoption.Listable[string]                                                  `json:"geosite,omitempty"`
+	SourceGeoIP                      badoption.Listable[string]                                                  `json:"source_geoip,omitempty"`
+	GeoIP                            badoption.Listable[string]                                                  `json:"geoip,omitempty"`
+	IPCIDR                           badoption.Listable[string]                                                  `json:"ip_cidr,omitempty"`
+	IPIsPrivate                      bool                                                                        `json:"ip_is_private,omitempty"`
+	IPAcceptAny                      bool                                                                        `json:"ip_accept_any,omitempty"`
+	SourceIPCIDR                     badoption.Listable[string]                                                  `json:"source_ip_cidr,omitempty"`
+	SourceIPIsPrivate                bool                                                                        `json:"source_ip_is_private,omitempty"`
+	SourcePort                       badoption.Listable[uint16]                                                  `json:"source_port,omitempty"`
+	SourcePortRange                  badoption.Listable[string]                                                  `json:"source_port_range,omitempty"`
+	Port                             badoption.Listable[uint16]                                                  `json:"port,omitempty"`
+	PortRange                        badoption.Listable[string]                                                  `json:"port_range,omitempty"`
+	ProcessName                      badoption.Listable[string]                                                  `json:"process_name,omitempty"`
+	ProcessPath                      badoption.Listable[string]                                                  `json:"process_path,omitempty"`
+	ProcessPathRegex                 badoption.Listable[string]                                                  `json:"process_path_regex,omitempty"`
+	PackageName                      badoption.Listable[string]                                                  `json:"package_name,omitempty"`
+	User                             badoption.Listable[string]                                                  `json:"user,omitempty"`
+	UserID                           badoption.Listable[int32]                                                   `json:"user_id,omitempty"`
+	Outbound                         badoption.Listable[string]                                                  `json:"outbound,omitempty"`
+	ClashMode                        string                                                                      `json:"clash_mode,omitempty"`
+	NetworkType                      badoption.Listable[InterfaceType]                                           `json:"network_type,omitempty"`
+	NetworkIsExpensive               bool                                                                        `json:"network_is_expensive,omitempty"`
+	NetworkIsConstrained             bool                                                                        `json:"network_is_constrained,omitempty"`
+	WIFISSID                         badoption.Listable[string]                                                  `json:"wifi_ssid,omitempty"`
+	WIFIBSSID                        badoption.Listable[string]                                                  `json:"wifi_bssid,omitempty"`
+	InterfaceAddress                 *badjson.TypedMap[string, badoption.Listable[*badoption.Prefixable]]        `json:"interface_address,omitempty"`
+	NetworkInterfaceAddress          *badjson.TypedMap[InterfaceType, badoption.Listable[*badoption.Prefixable]] `json:"network_interface_address,omitempty"`
+	DefaultInterfaceAddress          badoption.Listable[*badoption.Prefixable]                                   `json:"default_interface_address,omitempty"`
+	SourceMACAddress                 badoption.Listable[string]                                                  `json:"source_mac_address,omitempty"`
+	SourceHostname                   badoption.Listable[string]                                                  `json:"source_hostname,omitempty"`
+	SourceDevice                     badoption.Listable[string]                                                  `json:"source_device,omitempty"`
+	SourceDeviceGroup                badoption.Listable[string]                                                  `json:"source_device_group,omitempty"`
+	RuleSet                          badoption.Listable[string]                                                  `json:"rule_set,omitempty"`
+	RuleSetIPCIDRMatchSource         bool                                                                        `json:"rule_set_ip_cidr_match_source,omitempty"`
+	RuleSetIPCIDRAcceptEmpty         bool                                                                        `json:"rule_set_ip_cidr_accept_empty,omitempty"`
+	Invert                           bool                                                                        `json:"invert,omitempty"`
 
 	// Deprecated: renamed to rule_set_ip_cidr_match_source
 	Deprecated_RulesetIPCIDRMatchSource bool `json:"rule_set_ipcidr_match_source,omitempty"`
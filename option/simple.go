@@ -9,6 +9,7 @@ type SocksInboundOptions struct {
 	ListenOptions
 	Users          []auth.User           `json:"users,omitempty"`
 	DomainResolver *DomainResolveOptions `json:"domain_resolver,omitempty"`
+	InboundTLSOptionsContainer
 }
 
 type HTTPMixedInboundOptions struct {
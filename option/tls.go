@@ -26,6 +26,8 @@ type InboundTLSOptions struct {
 	ClientCertificatePublicKeySHA256 badoption.Listable[[]byte]          `json:"client_certificate_public_key_sha256,omitempty"`
 	Key                              badoption.Listable[string]          `json:"key,omitempty"`
 	KeyPath                          string                              `json:"key_path,omitempty"`
+	Certificates                     []InboundTLSCertificate             `json:"certificates,omitempty"`
+	OCSPStapling                     bool                                `json:"ocsp_stapling,omitempty"`
 	KernelTx                         bool                                `json:"kernel_tx,omitempty"`
 	KernelRx                         bool                                `json:"kernel_rx,omitempty"`
 	ACME                             *InboundACMEOptions                 `json:"acme,omitempty"`
@@ -33,6 +35,15 @@ type InboundTLSOptions struct {
 	Reality                          *InboundRealityOptions              `json:"reality,omitempty"`
 }
 
+type InboundTLSCertificate struct {
+	ServerName      badoption.Listable[string] `json:"server_name,omitempty"`
+	Certificate     badoption.Listable[string] `json:"certificate,omitempty"`
+	CertificatePath string                     `json:"certificate_path,omitempty"`
+	Key             badoption.Listable[string] `json:"key,omitempty"`
+	KeyPath         string                     `json:"key_path,omitempty"`
+	ACME            *InboundACMEOptions        `json:"acme,omitempty"`
+}
+
 type ClientAuthType tls.ClientAuthType
 
 func (t ClientAuthType) MarshalJSON() ([]byte, error) {
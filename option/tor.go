@@ -1,5 +1,7 @@
 package option
 
+import "github.com/sagernet/sing/common/json/badoption"
+
 type TorOutboundOptions struct {
 	DialerOptions
 	ExecutablePath string            `json:"executable_path,omitempty"`
@@ -7,3 +9,18 @@ type TorOutboundOptions struct {
 	DataDirectory  string            `json:"data_directory,omitempty"`
 	Options        map[string]string `json:"torrc,omitempty"`
 }
+
+type TorInboundOptions struct {
+	Outbound       string                   `json:"outbound,omitempty"`
+	ExecutablePath string                   `json:"executable_path,omitempty"`
+	ExtraArgs      []string                 `json:"extra_args,omitempty"`
+	DataDirectory  string                   `json:"data_directory,omitempty"`
+	Options        map[string]string        `json:"torrc,omitempty"`
+	KeyDirectory   string                   `json:"key_directory,omitempty"`
+	Services       []TorOnionServiceOptions `json:"services,omitempty"`
+}
+
+type TorOnionServiceOptions struct {
+	Name  string                     `json:"name,omitempty"`
+	Ports badoption.Listable[uint16] `json:"ports,omitempty"`
+}
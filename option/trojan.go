@@ -4,7 +4,7 @@ type TrojanInboundOptions struct {
 	ListenOptions
 	Users []TrojanUser `json:"users,omitempty"`
 	InboundTLSOptionsContainer
-	Fallback        *ServerOptions            `json:"fallback,omitempty"`
+	Fallback        *InboundFallbackOptions   `json:"fallback,omitempty"`
 	FallbackForALPN map[string]*ServerOptions `json:"fallback_for_alpn,omitempty"`
 	Multiplex       *InboundMultiplexOptions  `json:"multiplex,omitempty"`
 	Transport       *V2RayTransportOptions    `json:"transport,omitempty"`
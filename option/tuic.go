@@ -21,13 +21,15 @@ type TUICUser struct {
 type TUICOutboundOptions struct {
 	DialerOptions
 	ServerOptions
-	UUID              string             `json:"uuid,omitempty"`
-	Password          string             `json:"password,omitempty"`
-	CongestionControl string             `json:"congestion_control,omitempty"`
-	UDPRelayMode      string             `json:"udp_relay_mode,omitempty"`
-	UDPOverStream     bool               `json:"udp_over_stream,omitempty"`
-	ZeroRTTHandshake  bool               `json:"zero_rtt_handshake,omitempty"`
-	Heartbeat         badoption.Duration `json:"heartbeat,omitempty"`
-	Network           NetworkList        `json:"network,omitempty"`
+	ServerPorts       badoption.Listable[string] `json:"server_ports,omitempty"`
+	HopInterval       badoption.Duration         `json:"hop_interval,omitempty"`
+	UUID              string                     `json:"uuid,omitempty"`
+	Password          string                     `json:"password,omitempty"`
+	CongestionControl string                     `json:"congestion_control,omitempty"`
+	UDPRelayMode      string                     `json:"udp_relay_mode,omitempty"`
+	UDPOverStream     bool                       `json:"udp_over_stream,omitempty"`
+	ZeroRTTHandshake  bool                       `json:"zero_rtt_handshake,omitempty"`
+	Heartbeat         badoption.Duration         `json:"heartbeat,omitempty"`
+	Network           NetworkList                `json:"network,omitempty"`
 	OutboundTLSOptionsContainer
 }
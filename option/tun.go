@@ -23,6 +23,8 @@ type TunInboundOptions struct {
 	AutoRedirectResetMark         FwMark                           `json:"auto_redirect_reset_mark,omitempty"`
 	AutoRedirectNFQueue           uint16                           `json:"auto_redirect_nfqueue,omitempty"`
 	AutoRedirectFallbackRuleIndex int                              `json:"auto_redirect_iproute2_fallback_rule_index,omitempty"`
+	AutoRedirectOffload           bool                             `json:"auto_redirect_offload,omitempty"`
+	NAT64                         *NAT64Options                    `json:"nat64,omitempty"`
 	ExcludeMPTCP                  bool                             `json:"exclude_mptcp,omitempty"`
 	LoopbackAddress               badoption.Listable[netip.Addr]   `json:"loopback_address,omitempty"`
 	StrictRoute                   bool                             `json:"strict_route,omitempty"`
@@ -15,6 +15,7 @@ type _V2RayTransportOptions struct {
 	QUICOptions        V2RayQUICOptions        `json:"-"`
 	GRPCOptions        V2RayGRPCOptions        `json:"-"`
 	HTTPUpgradeOptions V2RayHTTPUpgradeOptions `json:"-"`
+	XHTTPOptions       V2RayXHTTPOptions       `json:"-"`
 }
 
 type V2RayTransportOptions _V2RayTransportOptions
@@ -32,6 +33,8 @@ func (o V2RayTransportOptions) MarshalJSON() ([]byte, error) {
 		v = o.GRPCOptions
 	case C.V2RayTransportTypeHTTPUpgrade:
 		v = o.HTTPUpgradeOptions
+	case C.V2RayTransportTypeXHTTP:
+		v = o.XHTTPOptions
 	case "":
 		return nil, E.New("missing transport type")
 	default:
@@ -57,6 +60,8 @@ func (o *V2RayTransportOptions) UnmarshalJSON(bytes []byte) error {
 		v = &o.GRPCOptions
 	case C.V2RayTransportTypeHTTPUpgrade:
 		v = &o.HTTPUpgradeOptions
+	case C.V2RayTransportTypeXHTTP:
+		v = &o.XHTTPOptions
 	default:
 		return E.New("unknown transport type: " + o.Type)
 	}
@@ -83,7 +88,10 @@ type V2RayWebsocketOptions struct {
 	EarlyDataHeaderName string               `json:"early_data_header_name,omitempty"`
 }
 
-type V2RayQUICOptions struct{}
+type V2RayQUICOptions struct {
+	ServerPorts badoption.Listable[string] `json:"server_ports,omitempty"`
+	HopInterval badoption.Duration         `json:"hop_interval,omitempty"`
+}
 
 type V2RayGRPCOptions struct {
 	ServiceName         string             `json:"service_name,omitempty"`
@@ -98,3 +106,16 @@ type V2RayHTTPUpgradeOptions struct {
 	Path    string               `json:"path,omitempty"`
 	Headers badoption.HTTPHeader `json:"headers,omitempty"`
 }
+
+type V2RayXHTTPOptions struct {
+	Host               string               `json:"host,omitempty"`
+	Path               string               `json:"path,omitempty"`
+	Mode               string               `json:"mode,omitempty"`
+	Headers            badoption.HTTPHeader `json:"headers,omitempty"`
+	XPaddingBytes      string               `json:"x_padding_bytes,omitempty"`
+	NoGRPCHeader       bool                 `json:"no_grpc_header,omitempty"`
+	NoSSEHeader        bool                 `json:"no_sse_header,omitempty"`
+	ScMaxEachPostBytes uint32               `json:"sc_max_each_post_bytes,omitempty"`
+	ScMinPostsInterval badoption.Duration   `json:"sc_min_posts_interval,omitempty"`
+	ScMaxBufferedPosts uint32               `json:"sc_max_buffered_posts,omitempty"`
+}
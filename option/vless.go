@@ -6,6 +6,7 @@ type VLESSInboundOptions struct {
 	InboundTLSOptionsContainer
 	Multiplex *InboundMultiplexOptions `json:"multiplex,omitempty"`
 	Transport *V2RayTransportOptions   `json:"transport,omitempty"`
+	Fallback  *InboundFallbackOptions  `json:"fallback,omitempty"`
 }
 
 type VLESSUser struct {
@@ -6,6 +6,7 @@ type VMessInboundOptions struct {
 	InboundTLSOptionsContainer
 	Multiplex *InboundMultiplexOptions `json:"multiplex,omitempty"`
 	Transport *V2RayTransportOptions   `json:"transport,omitempty"`
+	Fallback  *InboundFallbackOptions  `json:"fallback,omitempty"`
 }
 
 type VMessUser struct {
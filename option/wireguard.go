@@ -16,6 +16,8 @@ type WireGuardEndpointOptions struct {
 	Peers      []WireGuardPeer                  `json:"peers,omitempty"`
 	UDPTimeout badoption.Duration               `json:"udp_timeout,omitempty"`
 	Workers    int                              `json:"workers,omitempty"`
+
+	PeerResolveInterval *badoption.Duration `json:"peer_resolve_interval,omitempty"`
 	DialerOptions
 }
 
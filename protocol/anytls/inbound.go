@@ -7,6 +7,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/tls"
 	"github.com/sagernet/sing-box/common/uot"
@@ -52,6 +53,15 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		inbound.tlsConfig = tlsConfig
 	}
 
+	fallbackHandler, err := fallback.New(inbound, router, logger, options.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	var serviceFallbackHandler N.TCPConnectionHandlerEx
+	if fallbackHandler != nil {
+		serviceFallbackHandler = adapter.NewUpstreamContextHandlerEx(fallbackHandler.NewConnectionEx, nil)
+	}
+
 	paddingScheme := padding.DefaultPaddingScheme
 	if len(options.PaddingScheme) > 0 {
 		paddingScheme = []byte(strings.Join(options.PaddingScheme, "\n"))
@@ -61,9 +71,10 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		Users: common.Map(options.Users, func(it option.AnyTLSUser) anytls.User {
 			return (anytls.User)(it)
 		}),
-		PaddingScheme: paddingScheme,
-		Handler:       (*inboundHandler)(inbound),
-		Logger:        logger,
+		PaddingScheme:   paddingScheme,
+		Handler:         (*inboundHandler)(inbound),
+		FallbackHandler: serviceFallbackHandler,
+		Logger:          logger,
 	})
 	if err != nil {
 		return nil, err
@@ -104,6 +115,7 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata a
 			h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source, ": TLS handshake"))
 			return
 		}
+		tls.ApplyClientCertificate(h.tlsConfig, tlsConn, &metadata)
 		conn = tlsConn
 	}
 	err := h.service.NewConnection(adapter.WithContext(ctx, &metadata), conn, metadata.Source, onClose)
@@ -0,0 +1,209 @@
+package demux
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/sniff"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.DemuxInboundOptions](registry, C.TypeDemux, NewInbound)
+}
+
+type Inbound struct {
+	inbound.Adapter
+	ctx          context.Context
+	router       adapter.ConnectionRouterEx
+	logger       log.ContextLogger
+	listener     *listener.Listener
+	dialer       N.Dialer
+	rules        []rule
+	fallback     *target
+	sniffTimeout time.Duration
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.DemuxInboundOptions) (adapter.Inbound, error) {
+	if len(options.Rules) == 0 && options.Fallback == nil {
+		return nil, E.New("missing rules")
+	}
+	inbound := &Inbound{
+		Adapter:      inbound.NewAdapter(C.TypeDemux, tag),
+		ctx:          ctx,
+		router:       router,
+		logger:       logger,
+		sniffTimeout: time.Duration(options.SniffTimeout),
+	}
+	for i, ruleOptions := range options.Rules {
+		demuxRule, err := newRule(ruleOptions)
+		if err != nil {
+			return nil, E.Cause(err, "parse rule[", i, "]")
+		}
+		inbound.rules = append(inbound.rules, demuxRule)
+	}
+	if options.Fallback != nil {
+		fallback, err := newTarget(*options.Fallback)
+		if err != nil {
+			return nil, E.Cause(err, "parse fallback")
+		}
+		inbound.fallback = &fallback
+	}
+	outboundDialer, err := dialer.NewDefault(ctx, option.DialerOptions{})
+	if err != nil {
+		return nil, err
+	}
+	inbound.dialer = outboundDialer
+	inbound.listener = listener.New(listener.Options{
+		Context:           ctx,
+		Logger:            logger,
+		Network:           []string{N.NetworkTCP},
+		Listen:            options.ListenOptions,
+		ConnectionHandler: inbound,
+	})
+	return inbound, nil
+}
+
+func (h *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	inboundManager := service.FromContext[adapter.InboundManager](h.ctx)
+	targets := make([]target, 0, len(h.rules)+1)
+	for _, demuxRule := range h.rules {
+		targets = append(targets, demuxRule.target)
+	}
+	if h.fallback != nil {
+		targets = append(targets, *h.fallback)
+	}
+	for _, demuxTarget := range targets {
+		if demuxTarget.inbound == "" {
+			continue
+		}
+		if demuxTarget.inbound == h.Tag() {
+			return E.New("demux to self: ", demuxTarget.inbound)
+		}
+		detour, loaded := inboundManager.Get(demuxTarget.inbound)
+		if !loaded {
+			return E.New("inbound not found: ", demuxTarget.inbound)
+		}
+		if _, isInjectable := detour.(adapter.TCPInjectableInbound); !isInjectable {
+			return E.New("inbound is not TCP injectable: ", demuxTarget.inbound)
+		}
+	}
+	return h.listener.Start()
+}
+
+func (h *Inbound) Close() error {
+	return h.listener.Close()
+}
+
+func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	var alpn []string
+	buffer := buf.NewPacket()
+	err := sniff.PeekStream(ctx, &metadata, conn, nil, buffer, h.sniffTimeout, func(ctx context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
+		clientHello, err := sniff.ReadClientHello(ctx, reader)
+		if err != nil {
+			return err
+		}
+		metadata.Protocol = C.ProtocolTLS
+		metadata.Domain = clientHello.ServerName
+		alpn = clientHello.SupportedProtos
+		return nil
+	}, sniff.HTTPHost, sniff.SSH)
+	if err != nil {
+		h.logger.DebugContext(ctx, "sniff failed: ", err)
+	} else if metadata.Domain != "" {
+		h.logger.DebugContext(ctx, "sniffed protocol: ", metadata.Protocol, ", server name: ", metadata.Domain)
+	} else {
+		h.logger.DebugContext(ctx, "sniffed protocol: ", metadata.Protocol)
+	}
+	if !buffer.IsEmpty() {
+		conn = bufio.NewCachedConn(conn, buffer)
+	} else {
+		buffer.Release()
+	}
+	demuxTarget, matched := h.match(metadata.Protocol, metadata.Domain, alpn)
+	if !matched {
+		err = E.New("no matching rule for connection from ", metadata.Source)
+		N.CloseOnHandshakeFailure(conn, onClose, err)
+		h.logger.ErrorContext(ctx, err)
+		return
+	}
+	// sniffed results only select the target, the next inbound sniffs by itself
+	metadata.Protocol = ""
+	metadata.Domain = ""
+	if demuxTarget.inbound != "" {
+		h.logger.InfoContext(ctx, "inbound connection from ", metadata.Source, " to inbound/", demuxTarget.inbound)
+		//nolint:staticcheck
+		metadata.InboundDetour = demuxTarget.inbound
+		h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
+		return
+	}
+	h.logger.InfoContext(ctx, "inbound connection from ", metadata.Source, " to ", demuxTarget.server)
+	remoteConn, err := h.dialer.DialContext(ctx, N.NetworkTCP, demuxTarget.server)
+	if err != nil {
+		err = E.Cause(err, "open connection to ", demuxTarget.server)
+		N.CloseOnHandshakeFailure(conn, onClose, err)
+		h.logger.ErrorContext(ctx, err)
+		return
+	}
+	err = bufio.CopyConn(ctx, conn, remoteConn)
+	if err != nil && !E.IsClosedOrCanceled(err) {
+		h.logger.DebugContext(ctx, E.Cause(err, "connection to ", demuxTarget.server))
+	}
+	if onClose != nil {
+		onClose(err)
+	}
+}
+
+func (h *Inbound) match(protocol string, serverName string, alpn []string) (target, bool) {
+	serverName = tls.NormalizeServerName(serverName)
+	for _, demuxRule := range h.rules {
+		if demuxRule.match(protocol, serverName, alpn) {
+			return demuxRule.target, true
+		}
+	}
+	if h.fallback != nil {
+		return *h.fallback, true
+	}
+	return target{}, false
+}
+
+type target struct {
+	inbound string
+	server  M.Socksaddr
+}
+
+func newTarget(options option.DemuxTarget) (target, error) {
+	if options.Inbound != "" {
+		if options.Server != "" {
+			return target{}, E.New("`inbound` and `server` are mutually exclusive")
+		}
+		return target{inbound: options.Inbound}, nil
+	}
+	if options.Server == "" {
+		return target{}, E.New("missing `inbound` or `server`")
+	}
+	if options.ServerPort == 0 {
+		return target{}, E.New("missing `server_port`")
+	}
+	return target{server: M.ParseSocksaddrHostPort(options.Server, options.ServerPort)}, nil
+}
@@ -0,0 +1,53 @@
+package demux
+
+import (
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+type rule struct {
+	serverName []string
+	alpn       []string
+	protocol   []string
+	target     target
+}
+
+func newRule(options option.DemuxRule) (rule, error) {
+	for _, protocol := range options.Protocol {
+		switch protocol {
+		case C.ProtocolTLS, C.ProtocolHTTP, C.ProtocolSSH:
+		default:
+			return rule{}, E.New("unsupported protocol: ", protocol)
+		}
+	}
+	demuxTarget, err := newTarget(options.DemuxTarget)
+	if err != nil {
+		return rule{}, err
+	}
+	return rule{
+		serverName: common.Map(options.ServerName, tls.NormalizeServerName),
+		alpn:       options.ALPN,
+		protocol:   options.Protocol,
+		target:     demuxTarget,
+	}, nil
+}
+
+func (r *rule) match(protocol string, serverName string, alpn []string) bool {
+	if len(r.protocol) > 0 && !common.Contains(r.protocol, protocol) {
+		return false
+	}
+	if len(r.serverName) > 0 && !common.Any(r.serverName, func(it string) bool {
+		return tls.MatchServerName(it, serverName)
+	}) {
+		return false
+	}
+	if len(r.alpn) > 0 && !common.Any(alpn, func(it string) bool {
+		return common.Contains(r.alpn, it)
+	}) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,463 @@
+package direct
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/urltest"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const (
+	multiWANStrategyWeight     = "weight"
+	multiWANStrategyThroughput = "throughput"
+
+	multiWANDefaultInterval = 30 * time.Second
+	multiWANDefaultTimeout  = 5 * time.Second
+	multiWANSampleInterval  = time.Second
+
+	// smoothing factor of the throughput average per sample
+	multiWANThroughputSmoothing = 0.3
+	// the measured capacity halves every period unless reached again
+	multiWANCapacityHalfLife = 10 * time.Minute
+	// uplinks keep at least this fraction of the largest capacity as headroom,
+	// so that idle or saturated uplinks still get new connections to measure them
+	multiWANMinHeadroom = 0.1
+)
+
+// multiWAN distributes new connections across uplinks bound to different interfaces.
+type multiWAN struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	logger   logger.ContextLogger
+	strategy string
+	link     string
+	interval time.Duration
+	timeout  time.Duration
+	uplinks  []*wanUplink
+	access   sync.Mutex
+}
+
+type wanUplink struct {
+	name        string
+	weight      uint32
+	dialer      dialer.ParallelInterfaceDialer
+	healthy     atomic.Bool
+	latency     atomic.Uint32
+	connections atomic.Int32
+	upload      atomic.Int64
+	download    atomic.Int64
+
+	// protected by multiWAN.access
+	currentWeight float64
+	sampleTime    time.Time
+	sampleBytes   int64
+	throughput    float64
+	capacity      float64
+}
+
+func newMultiWAN(ctx context.Context, logger logger.ContextLogger, dialerOptions option.DialerOptions, options option.MultiWANOptions) (*multiWAN, error) {
+	if dialerOptions.BindInterface != "" || dialerOptions.Inet4BindAddress != nil || dialerOptions.Inet6BindAddress != nil {
+		return nil, E.New("`bind_interface`, `inet4_bind_address` and `inet6_bind_address` is conflict with `multi_wan`")
+	}
+	if dialerOptions.NetworkStrategy != nil || len(dialerOptions.NetworkType) > 0 || len(dialerOptions.FallbackNetworkType) > 0 {
+		return nil, E.New("`network_strategy`, `network_type` and `fallback_network_type` is conflict with `multi_wan`")
+	}
+	if len(options.Interfaces) == 0 {
+		return nil, E.New("missing interfaces")
+	}
+	m := &multiWAN{
+		ctx:      ctx,
+		logger:   logger,
+		link:     options.URL,
+		interval: time.Duration(options.Interval),
+		timeout:  time.Duration(options.Timeout),
+	}
+	switch options.Strategy {
+	case "", multiWANStrategyWeight:
+		m.strategy = multiWANStrategyWeight
+	case multiWANStrategyThroughput:
+		m.strategy = multiWANStrategyThroughput
+	default:
+		return nil, E.New("unknown strategy: ", options.Strategy)
+	}
+	if m.interval == 0 {
+		m.interval = multiWANDefaultInterval
+	}
+	if m.timeout == 0 {
+		m.timeout = multiWANDefaultTimeout
+	}
+	for i, interfaceOptions := range options.Interfaces {
+		if interfaceOptions.Interface == "" {
+			return nil, E.New("interfaces[", i, "]: missing interface")
+		}
+		uplinkOptions := dialerOptions
+		uplinkOptions.BindInterface = interfaceOptions.Interface
+		uplinkDialer, err := dialer.NewWithOptions(dialer.Options{
+			Context:        ctx,
+			Options:        uplinkOptions,
+			RemoteIsDomain: true,
+			DirectOutbound: true,
+		})
+		if err != nil {
+			return nil, E.Cause(err, "interfaces[", i, "]")
+		}
+		uplink := &wanUplink{
+			name:   interfaceOptions.Interface,
+			weight: interfaceOptions.Weight,
+			dialer: uplinkDialer.(dialer.ParallelInterfaceDialer),
+		}
+		if uplink.weight == 0 {
+			uplink.weight = 1
+		}
+		uplink.healthy.Store(true)
+		m.uplinks = append(m.uplinks, uplink)
+	}
+	return m, nil
+}
+
+func (m *multiWAN) Start() {
+	m.ctx, m.cancel = context.WithCancel(m.ctx)
+	for _, uplink := range m.uplinks {
+		go m.loopCheck(uplink)
+	}
+}
+
+func (m *multiWAN) loopCheck(uplink *wanUplink) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.check(uplink)
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *multiWAN) check(uplink *wanUplink) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.timeout)
+	defer cancel()
+	latency, err := urltest.URLTest(ctx, m.link, uplink.dialer)
+	if m.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		if uplink.healthy.Swap(false) {
+			m.logger.Warn("uplink ", uplink.name, " is down: ", err)
+		}
+		return
+	}
+	uplink.latency.Store(uint32(latency))
+	if !uplink.healthy.Swap(true) {
+		m.logger.Info("uplink ", uplink.name, " is up, latency ", latency, "ms")
+	}
+}
+
+// candidates returns healthy uplinks not yet tried, or all untried uplinks if none is healthy.
+func (m *multiWAN) candidates(tried []*wanUplink) []*wanUplink {
+	var healthy, untried []*wanUplink
+	for _, uplink := range m.uplinks {
+		if common.Contains(tried, uplink) {
+			continue
+		}
+		untried = append(untried, uplink)
+		if uplink.healthy.Load() {
+			healthy = append(healthy, uplink)
+		}
+	}
+	if len(healthy) > 0 {
+		return healthy
+	}
+	return untried
+}
+
+// pick selects the uplink for a new connection by smooth weighted round-robin.
+// With the throughput strategy, weights are scaled by the headroom of each uplink,
+// which is its measured capacity minus its current throughput, so that connections
+// opened between two samples are still spread in proportion.
+func (m *multiWAN) pick(tried []*wanUplink) *wanUplink {
+	m.access.Lock()
+	defer m.access.Unlock()
+	candidates := m.candidates(tried)
+	if len(candidates) == 0 {
+		return nil
+	}
+	weights := make([]float64, len(candidates))
+	for i, uplink := range candidates {
+		weights[i] = float64(uplink.weight)
+	}
+	if m.strategy == multiWANStrategyThroughput {
+		now := time.Now()
+		var maxCapacity float64
+		for _, uplink := range candidates {
+			uplink.sample(now)
+			maxCapacity = max(maxCapacity, uplink.capacity)
+		}
+		if maxCapacity > 0 {
+			for i, uplink := range candidates {
+				weights[i] *= max(uplink.capacity-uplink.throughput, maxCapacity*multiWANMinHeadroom)
+			}
+		}
+	}
+	var (
+		selected    *wanUplink
+		totalWeight float64
+	)
+	for i, uplink := range candidates {
+		uplink.currentWeight += weights[i]
+		totalWeight += weights[i]
+		if selected == nil || uplink.currentWeight > selected.currentWeight {
+			selected = uplink
+		}
+	}
+	selected.currentWeight -= totalWeight
+	return selected
+}
+
+// pickSticky selects the uplink for a UDP flow by weighted rendezvous hashing,
+// so that flows with the same key stay on the same uplink while it is healthy.
+func (m *multiWAN) pickSticky(key string, tried []*wanUplink) *wanUplink {
+	var (
+		selected      *wanUplink
+		selectedScore float64
+	)
+	for _, uplink := range m.candidates(tried) {
+		hash := fnv.New64a()
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(uplink.name))
+		point := (float64(hash.Sum64()>>11) + 0.5) / (1 << 53)
+		score := float64(uplink.weight) / -math.Log(point)
+		if selected == nil || score > selectedScore {
+			selected = uplink
+			selectedScore = score
+		}
+	}
+	return selected
+}
+
+// stickyKey identifies a UDP flow by source address and destination, ignoring the source port.
+func stickyKey(ctx context.Context, destination M.Socksaddr) string {
+	metadata := adapter.ContextFrom(ctx)
+	if metadata == nil || !metadata.Source.IsValid() {
+		return destination.String()
+	}
+	return metadata.Source.Addr.String() + " " + destination.String()
+}
+
+func tryUplinks[T any](ctx context.Context, m *multiWAN, sticky bool, destination M.Socksaddr, dial func(uplink *wanUplink) (T, error)) (*wanUplink, T, error) {
+	var (
+		key    string
+		tried  []*wanUplink
+		errors []error
+	)
+	if sticky {
+		key = stickyKey(ctx, destination)
+	}
+	for {
+		var uplink *wanUplink
+		if sticky {
+			uplink = m.pickSticky(key, tried)
+		} else {
+			uplink = m.pick(tried)
+		}
+		if uplink == nil {
+			var zero T
+			return nil, zero, E.Errors(errors...)
+		}
+		conn, err := dial(uplink)
+		if err == nil {
+			m.logger.DebugContext(ctx, "outbound through ", uplink.name)
+			return uplink, conn, nil
+		}
+		errors = append(errors, E.Cause(err, "dial through ", uplink.name))
+		if ctx.Err() != nil {
+			var zero T
+			return nil, zero, E.Errors(errors...)
+		}
+		tried = append(tried, uplink)
+	}
+}
+
+func (m *multiWAN) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	uplink, conn, err := tryUplinks(ctx, m, network == N.NetworkUDP, destination, func(uplink *wanUplink) (net.Conn, error) {
+		return uplink.dialer.DialContext(ctx, network, destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uplink.newConn(conn), nil
+}
+
+func (m *multiWAN) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	uplink, conn, err := tryUplinks(ctx, m, true, destination, func(uplink *wanUplink) (net.PacketConn, error) {
+		return uplink.dialer.ListenPacket(ctx, destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uplink.newPacketConn(conn), nil
+}
+
+func (m *multiWAN) DialParallel(ctx context.Context, network string, destination M.Socksaddr, destinationAddresses []netip.Addr, fallbackDelay time.Duration) (net.Conn, error) {
+	uplink, conn, err := tryUplinks(ctx, m, network == N.NetworkUDP, destination, func(uplink *wanUplink) (net.Conn, error) {
+		return dialer.DialParallelNetwork(ctx, uplink.dialer, network, destination, destinationAddresses, len(destinationAddresses) > 0 && destinationAddresses[0].Is6(), nil, nil, nil, fallbackDelay)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uplink.newConn(conn), nil
+}
+
+func (m *multiWAN) ListenSerialPacket(ctx context.Context, destination M.Socksaddr, destinationAddresses []netip.Addr, fallbackDelay time.Duration) (net.PacketConn, netip.Addr, error) {
+	var newDestination netip.Addr
+	uplink, conn, err := tryUplinks(ctx, m, true, destination, func(uplink *wanUplink) (net.PacketConn, error) {
+		conn, address, err := dialer.ListenSerialNetworkPacket(ctx, uplink.dialer, destination, destinationAddresses, nil, nil, nil, fallbackDelay)
+		newDestination = address
+		return conn, err
+	})
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	return uplink.newPacketConn(conn), newDestination, nil
+}
+
+// Status reports the throughput and capacity of the last sample taken by pick,
+// reading the status does not advance the sampler.
+func (m *multiWAN) Status() *adapter.MultiWANStatus {
+	m.access.Lock()
+	defer m.access.Unlock()
+	status := &adapter.MultiWANStatus{
+		Strategy: m.strategy,
+	}
+	for _, uplink := range m.uplinks {
+		status.Interfaces = append(status.Interfaces, adapter.MultiWANInterfaceStatus{
+			Interface:   uplink.name,
+			Weight:      uplink.weight,
+			Healthy:     uplink.healthy.Load(),
+			Connections: uplink.connections.Load(),
+			Upload:      uplink.upload.Load(),
+			Download:    uplink.download.Load(),
+			Throughput:  int64(uplink.throughput),
+			Capacity:    int64(uplink.capacity),
+			Latency:     uint16(uplink.latency.Load()),
+		})
+	}
+	return status
+}
+
+func (m *multiWAN) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	return nil
+}
+
+// sample updates the smoothed throughput and the capacity, which is the decaying peak of the throughput.
+func (u *wanUplink) sample(now time.Time) {
+	totalBytes := u.upload.Load() + u.download.Load()
+	if u.sampleTime.IsZero() {
+		u.sampleTime = now
+		u.sampleBytes = totalBytes
+		return
+	}
+	elapsed := now.Sub(u.sampleTime)
+	if elapsed < multiWANSampleInterval {
+		return
+	}
+	rate := float64(totalBytes-u.sampleBytes) / elapsed.Seconds()
+	u.throughput += (rate - u.throughput) * multiWANThroughputSmoothing
+	u.capacity = max(u.throughput, u.capacity*math.Exp2(-float64(elapsed)/float64(multiWANCapacityHalfLife)))
+	u.sampleTime = now
+	u.sampleBytes = totalBytes
+}
+
+func (u *wanUplink) newConn(conn net.Conn) net.Conn {
+	u.connections.Add(1)
+	return &wanConn{
+		ExtendedConn: bufio.NewCounterConn(conn, []N.CountFunc{func(n int64) {
+			u.download.Add(n)
+		}}, []N.CountFunc{func(n int64) {
+			u.upload.Add(n)
+		}}),
+		uplink: u,
+	}
+}
+
+func (u *wanUplink) newPacketConn(conn net.PacketConn) net.PacketConn {
+	u.connections.Add(1)
+	return &wanPacketConn{
+		NetPacketConn: bufio.NewNetPacketConn(bufio.NewCounterPacketConn(bufio.NewPacketConn(conn), []N.CountFunc{func(n int64) {
+			u.download.Add(n)
+		}}, []N.CountFunc{func(n int64) {
+			u.upload.Add(n)
+		}})),
+		uplink: u,
+	}
+}
+
+type wanConn struct {
+	N.ExtendedConn
+	uplink    *wanUplink
+	closeOnce sync.Once
+}
+
+func (c *wanConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.uplink.connections.Add(-1)
+	})
+	return c.ExtendedConn.Close()
+}
+
+func (c *wanConn) Upstream() any {
+	return c.ExtendedConn
+}
+
+func (c *wanConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *wanConn) WriterReplaceable() bool {
+	return true
+}
+
+type wanPacketConn struct {
+	N.NetPacketConn
+	uplink    *wanUplink
+	closeOnce sync.Once
+}
+
+func (c *wanPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.uplink.connections.Add(-1)
+	})
+	return c.NetPacketConn.Close()
+}
+
+func (c *wanPacketConn) Upstream() any {
+	return c.NetPacketConn
+}
+
+func (c *wanPacketConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *wanPacketConn) WriterReplaceable() bool {
+	return true
+}
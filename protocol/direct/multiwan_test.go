@@ -0,0 +1,211 @@
+package direct
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/common/dialer"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMultiWAN(strategy string, weights ...uint32) *multiWAN {
+	m := &multiWAN{
+		logger:   logger.NOP(),
+		strategy: strategy,
+	}
+	for i, weight := range weights {
+		uplink := &wanUplink{
+			name:   string(rune('a' + i)),
+			weight: weight,
+		}
+		uplink.healthy.Store(true)
+		m.uplinks = append(m.uplinks, uplink)
+	}
+	return m
+}
+
+func TestMultiWANPickWeight(t *testing.T) {
+	t.Parallel()
+	m := newTestMultiWAN(multiWANStrategyWeight, 3, 1)
+	counts := make(map[string]int)
+	for range 8 {
+		counts[m.pick(nil).name]++
+	}
+	require.Equal(t, map[string]int{"a": 6, "b": 2}, counts)
+	m.uplinks[0].healthy.Store(false)
+	for range 4 {
+		require.Equal(t, "b", m.pick(nil).name)
+	}
+	require.Nil(t, m.pick(m.uplinks))
+}
+
+func TestMultiWANPickSticky(t *testing.T) {
+	t.Parallel()
+	m := newTestMultiWAN(multiWANStrategyWeight, 1, 1, 1)
+	uplink := m.pickSticky("10.0.0.2 1.1.1.1:443", nil)
+	for range 8 {
+		require.Equal(t, uplink, m.pickSticky("10.0.0.2 1.1.1.1:443", nil))
+	}
+	uplink.healthy.Store(false)
+	require.NotEqual(t, uplink, m.pickSticky("10.0.0.2 1.1.1.1:443", nil))
+	counts := make(map[string]int)
+	for i := range 300 {
+		counts[m.pickSticky(strconv.Itoa(i), []*wanUplink{uplink}).name]++
+	}
+	require.Len(t, counts, 2)
+}
+
+func TestMultiWANPickThroughput(t *testing.T) {
+	t.Parallel()
+	m := newTestMultiWAN(multiWANStrategyThroughput, 1, 1)
+	now := time.Now()
+	for _, uplink := range m.uplinks {
+		uplink.sampleTime = now
+		uplink.capacity = 100
+	}
+	m.uplinks[0].throughput = 90
+	m.uplinks[1].throughput = 10
+	counts := make(map[string]int)
+	for i := range 100 {
+		counts[m.pick(nil).name]++
+		if i == 9 {
+			// connections opened within the same sample are still spread
+			require.Equal(t, map[string]int{"a": 1, "b": 9}, counts)
+		}
+	}
+	require.Equal(t, map[string]int{"a": 10, "b": 90}, counts)
+}
+
+func TestMultiWANPickThroughputUnmeasured(t *testing.T) {
+	t.Parallel()
+	m := newTestMultiWAN(multiWANStrategyThroughput, 2, 1)
+	counts := make(map[string]int)
+	for range 6 {
+		counts[m.pick(nil).name]++
+	}
+	require.Equal(t, map[string]int{"a": 4, "b": 2}, counts)
+}
+
+func TestMultiWANSample(t *testing.T) {
+	t.Parallel()
+	uplink := &wanUplink{}
+	now := time.Now()
+	uplink.sample(now)
+	uplink.download.Add(1000)
+	uplink.sample(now.Add(time.Second / 2))
+	require.Zero(t, uplink.throughput)
+	uplink.sample(now.Add(time.Second))
+	require.InDelta(t, 1000*multiWANThroughputSmoothing, uplink.throughput, 1)
+	require.Equal(t, uplink.throughput, uplink.capacity)
+	uplink.capacity = 1000
+	uplink.sample(now.Add(time.Second + multiWANCapacityHalfLife))
+	require.Less(t, uplink.throughput, 500.0)
+	require.InDelta(t, 500, uplink.capacity, 1)
+}
+
+func TestMultiWANStatusReadOnly(t *testing.T) {
+	t.Parallel()
+	m := newTestMultiWAN(multiWANStrategyThroughput, 1)
+	uplink := m.uplinks[0]
+	sampleTime := time.Now().Add(-time.Minute)
+	uplink.sampleTime = sampleTime
+	uplink.throughput = 100
+	uplink.capacity = 200
+	uplink.download.Add(1 << 20)
+	status := m.Status()
+	require.EqualValues(t, 100, status.Interfaces[0].Throughput)
+	require.EqualValues(t, 200, status.Interfaces[0].Capacity)
+	require.Equal(t, sampleTime, uplink.sampleTime)
+	require.Zero(t, uplink.sampleBytes)
+	m.pick(nil)
+	require.NotEqual(t, sampleTime, uplink.sampleTime)
+	require.Greater(t, uplink.throughput, 100.0)
+}
+
+type testUplinkDialer struct {
+	dialer.ParallelInterfaceDialer
+	err error
+}
+
+func (d *testUplinkDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return N.SystemDialer.DialContext(ctx, network, destination)
+}
+
+func TestMultiWANCheck(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	m := newTestMultiWAN(multiWANStrategyWeight, 1, 1)
+	m.ctx = context.Background()
+	m.link = server.URL
+	m.timeout = multiWANDefaultTimeout
+	uplinkDialer := &testUplinkDialer{err: E.New("network unreachable")}
+	m.uplinks[0].dialer = uplinkDialer
+	m.uplinks[1].dialer = &testUplinkDialer{}
+	m.check(m.uplinks[0])
+	m.check(m.uplinks[1])
+	require.False(t, m.uplinks[0].healthy.Load())
+	require.True(t, m.uplinks[1].healthy.Load())
+	for range 4 {
+		require.Equal(t, "b", m.pick(nil).name)
+	}
+	uplinkDialer.err = nil
+	m.check(m.uplinks[0])
+	require.True(t, m.uplinks[0].healthy.Load())
+	counts := make(map[string]int)
+	for range 4 {
+		counts[m.pick(nil).name]++
+	}
+	require.Equal(t, map[string]int{"a": 2, "b": 2}, counts)
+}
+
+func TestMultiWANDialFailover(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, aErr := listener.Accept()
+		if aErr != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+	m := newTestMultiWAN(multiWANStrategyWeight, 1, 1)
+	m.uplinks[0].dialer = &testUplinkDialer{err: E.New("network unreachable")}
+	m.uplinks[1].dialer = &testUplinkDialer{}
+	conn, err := m.DialContext(context.Background(), N.NetworkTCP, M.SocksaddrFromNet(listener.Addr()))
+	require.NoError(t, err)
+	require.Zero(t, m.uplinks[0].connections.Load())
+	require.EqualValues(t, 1, m.uplinks[1].connections.Load())
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(conn, make([]byte, 4))
+	require.NoError(t, err)
+	require.EqualValues(t, 4, m.uplinks[1].upload.Load())
+	require.EqualValues(t, 4, m.uplinks[1].download.Load())
+	require.NoError(t, conn.Close())
+	conn.Close()
+	require.Zero(t, m.uplinks[1].connections.Load())
+	status := m.Status()
+	require.Equal(t, int64(4), status.Interfaces[1].Upload)
+	require.Equal(t, int64(4), status.Interfaces[1].Download)
+	require.Zero(t, status.Interfaces[1].Connections)
+}
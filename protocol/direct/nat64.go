@@ -0,0 +1,118 @@
+package direct
+
+import (
+	"net/netip"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/nat64"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/control"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/common/x/list"
+)
+
+// nat64Translator dials IPv4 destinations through the NAT64 prefix of the network
+// if the default interface has no IPv4 address.
+type nat64Translator struct {
+	logger           logger.ContextLogger
+	prefix           netip.Prefix
+	interfaceMonitor tun.DefaultInterfaceMonitor
+	discovery        *nat64.Discovery
+	callback         *list.Element[tun.DefaultInterfaceUpdateCallback]
+}
+
+func newNAT64Translator(logger logger.ContextLogger, networkManager adapter.NetworkManager, options option.NAT64Options) (*nat64Translator, error) {
+	translator := &nat64Translator{
+		logger: logger,
+	}
+	if networkManager != nil {
+		translator.interfaceMonitor = networkManager.InterfaceMonitor()
+	}
+	if options.Prefix != nil {
+		translator.prefix = options.Prefix.Build(netip.Prefix{})
+		err := nat64.ValidatePrefix(translator.prefix)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		translator.discovery = nat64.NewDiscovery(logger)
+	}
+	return translator, nil
+}
+
+func (t *nat64Translator) Start() {
+	if t.discovery == nil {
+		return
+	}
+	err := t.discovery.Start()
+	if err != nil {
+		t.logger.Warn("PREF64 discovery unavailable, using ", nat64.WellKnownPrefix, ": ", err)
+		t.discovery = nil
+		return
+	}
+	if t.interfaceMonitor != nil {
+		t.callback = t.interfaceMonitor.RegisterCallback(t.onInterfaceUpdate)
+		if defaultInterface := t.interfaceMonitor.DefaultInterface(); defaultInterface != nil {
+			t.discovery.Solicit(defaultInterface.Index)
+		}
+	}
+}
+
+func (t *nat64Translator) onInterfaceUpdate(defaultInterface *control.Interface, flags int) {
+	if defaultInterface != nil {
+		t.discovery.Solicit(defaultInterface.Index)
+	}
+}
+
+// Prefix returns the NAT64 prefix to dial IPv4 destinations with, if IPv4 is unavailable.
+func (t *nat64Translator) Prefix() (netip.Prefix, bool) {
+	if t.ipv4Available() {
+		return netip.Prefix{}, false
+	}
+	if t.prefix.IsValid() {
+		return t.prefix, true
+	}
+	if t.discovery != nil {
+		prefix, loaded := t.discovery.Prefix()
+		if loaded {
+			return prefix, true
+		}
+	}
+	return nat64.WellKnownPrefix, true
+}
+
+func (t *nat64Translator) ipv4Available() bool {
+	if t.interfaceMonitor == nil {
+		return true
+	}
+	defaultInterface := t.interfaceMonitor.DefaultInterface()
+	if defaultInterface == nil {
+		return true
+	}
+	return common.Any(defaultInterface.Addresses, func(it netip.Prefix) bool {
+		address := it.Addr()
+		return address.Is4() && address.IsGlobalUnicast()
+	})
+}
+
+func (t *nat64Translator) translateDestination(prefix netip.Prefix, destination M.Socksaddr, destinationAddresses []netip.Addr) (M.Socksaddr, []netip.Addr) {
+	if destination.IsIPv4() {
+		destination = M.SocksaddrFrom(nat64.Synthesize(prefix, destination.Addr), destination.Port)
+	}
+	return destination, common.Map(destinationAddresses, func(it netip.Addr) netip.Addr {
+		return nat64.Synthesize(prefix, it)
+	})
+}
+
+func (t *nat64Translator) Close() error {
+	if t.callback != nil {
+		t.interfaceMonitor.UnregisterCallback(t.callback)
+	}
+	if t.discovery != nil {
+		return t.discovery.Close()
+	}
+	return nil
+}
@@ -10,16 +10,19 @@ import (
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/outbound"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/nat64"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-tun"
 	"github.com/sagernet/sing-tun/ping"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
 )
 
 func RegisterOutbound(registry *outbound.Registry) {
@@ -31,6 +34,7 @@ var (
 	_ dialer.ParallelNetworkDialer = (*Outbound)(nil)
 	_ dialer.DirectDialer          = (*Outbound)(nil)
 	_ adapter.DirectRouteOutbound  = (*Outbound)(nil)
+	_ adapter.MultiWANOutbound     = (*Outbound)(nil)
 )
 
 type Outbound struct {
@@ -41,6 +45,8 @@ type Outbound struct {
 	domainStrategy C.DomainStrategy
 	fallbackDelay  time.Duration
 	isEmpty        bool
+	nat64          *nat64Translator
+	multiWAN       *multiWAN
 	// loopBack *loopBackDetector
 }
 
@@ -66,16 +72,68 @@ func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextL
 		domainStrategy: C.DomainStrategy(options.DomainStrategy),
 		fallbackDelay:  time.Duration(options.FallbackDelay),
 		dialer:         outboundDialer.(dialer.ParallelInterfaceDialer),
-		isEmpty:        reflect.DeepEqual(options.DialerOptions, option.DialerOptions{UDPFragmentDefault: true}),
+		isEmpty:        reflect.DeepEqual(options.DialerOptions, option.DialerOptions{UDPFragmentDefault: true}) && (options.NAT64 == nil || !options.NAT64.Enabled) && (options.MultiWAN == nil || !options.MultiWAN.Enabled),
 		// loopBack:       newLoopBackDetector(router),
 	}
-	//nolint:staticcheck
-	if options.ProxyProtocol != 0 {
-		return nil, E.New("Proxy Protocol is deprecated and removed in sing-box 1.6.0")
+	if options.NAT64 != nil && options.NAT64.Enabled {
+		outbound.nat64, err = newNAT64Translator(logger, service.FromContext[adapter.NetworkManager](ctx), *options.NAT64)
+		if err != nil {
+			return nil, E.Cause(err, "nat64")
+		}
+	}
+	if options.MultiWAN != nil && options.MultiWAN.Enabled {
+		outbound.multiWAN, err = newMultiWAN(ctx, logger, options.DialerOptions, *options.MultiWAN)
+		if err != nil {
+			return nil, E.Cause(err, "multi_wan")
+		}
 	}
 	return outbound, nil
 }
 
+func (h *Outbound) Start(stage adapter.StartStage) error {
+	switch stage {
+	case adapter.StartStateStart:
+		if h.nat64 != nil {
+			h.nat64.Start()
+		}
+	case adapter.StartStateStarted:
+		if h.multiWAN != nil {
+			h.multiWAN.Start()
+		}
+	}
+	return nil
+}
+
+func (h *Outbound) Close() error {
+	var err error
+	if h.nat64 != nil {
+		err = E.Append(err, h.nat64.Close(), func(err error) error {
+			return E.Cause(err, "close nat64")
+		})
+	}
+	if h.multiWAN != nil {
+		err = E.Append(err, h.multiWAN.Close(), func(err error) error {
+			return E.Cause(err, "close multi_wan")
+		})
+	}
+	return err
+}
+
+func (h *Outbound) MultiWANStatus() *adapter.MultiWANStatus {
+	if h.multiWAN == nil {
+		return nil
+	}
+	return h.multiWAN.Status()
+}
+
+// nat64Prefix returns the NAT64 prefix to dial IPv4 destinations with, if NAT64 is enabled and IPv4 is unavailable.
+func (h *Outbound) nat64Prefix() (netip.Prefix, bool) {
+	if h.nat64 == nil {
+		return netip.Prefix{}, false
+	}
+	return h.nat64.Prefix()
+}
+
 func (h *Outbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
 	ctx, metadata := adapter.ExtendContext(ctx)
 	metadata.Outbound = h.Tag()
@@ -92,6 +150,12 @@ func (h *Outbound) DialContext(ctx context.Context, network string, destination
 		return nil, err
 	}
 	return h.loopBack.NewConn(conn), nil*/
+	if prefix, loaded := h.nat64Prefix(); loaded && destination.IsIPv4() {
+		destination = M.SocksaddrFrom(nat64.Synthesize(prefix, destination.Addr), destination.Port)
+	}
+	if h.multiWAN != nil {
+		return h.multiWAN.DialContext(ctx, network, destination)
+	}
 	return h.dialer.DialContext(ctx, network, destination)
 }
 
@@ -100,11 +164,26 @@ func (h *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (n
 	metadata.Outbound = h.Tag()
 	metadata.Destination = destination
 	h.logger.InfoContext(ctx, "outbound packet connection")
-	conn, err := h.dialer.ListenPacket(ctx, destination)
+	prefix, translate := h.nat64Prefix()
+	if translate && destination.IsIPv4() {
+		destination = M.SocksaddrFrom(nat64.Synthesize(prefix, destination.Addr), destination.Port)
+	}
+	var (
+		conn net.PacketConn
+		err  error
+	)
+	if h.multiWAN != nil {
+		conn, err = h.multiWAN.ListenPacket(ctx, destination)
+	} else {
+		conn, err = h.dialer.ListenPacket(ctx, destination)
+	}
 	if err != nil {
 		return nil, err
 	}
 	// conn = h.loopBack.NewPacketConn(bufio.NewPacketConn(conn), destination)
+	if translate {
+		return nat64.NewPacketConn(bufio.NewPacketConn(conn), prefix), nil
+	}
 	return conn, nil
 }
 
@@ -129,6 +208,12 @@ func (h *Outbound) DialParallel(ctx context.Context, network string, destination
 	case N.NetworkUDP:
 		h.logger.InfoContext(ctx, "outbound packet connection to ", destination)
 	}
+	if prefix, loaded := h.nat64Prefix(); loaded {
+		destination, destinationAddresses = h.nat64.translateDestination(prefix, destination, destinationAddresses)
+	}
+	if h.multiWAN != nil {
+		return h.multiWAN.DialParallel(ctx, network, destination, destinationAddresses, h.fallbackDelay)
+	}
 	return dialer.DialParallelNetwork(ctx, h.dialer, network, destination, destinationAddresses, len(destinationAddresses) > 0 && destinationAddresses[0].Is6(), nil, nil, nil, h.fallbackDelay)
 }
 
@@ -143,6 +228,16 @@ func (h *Outbound) DialParallelNetwork(ctx context.Context, network string, dest
 	case N.NetworkUDP:
 		h.logger.InfoContext(ctx, "outbound packet connection to ", destination)
 	}
+	if prefix, loaded := h.nat64Prefix(); loaded {
+		destination, destinationAddresses = h.nat64.translateDestination(prefix, destination, destinationAddresses)
+	}
+	if h.multiWAN != nil {
+		err := checkMultiWANNetwork(networkStrategy, networkType, fallbackNetworkType)
+		if err != nil {
+			return nil, err
+		}
+		return h.multiWAN.DialParallel(ctx, network, destination, destinationAddresses, fallbackDelay)
+	}
 	return dialer.DialParallelNetwork(ctx, h.dialer, network, destination, destinationAddresses, len(destinationAddresses) > 0 && destinationAddresses[0].Is6(), networkStrategy, networkType, fallbackNetworkType, fallbackDelay)
 }
 
@@ -151,13 +246,44 @@ func (h *Outbound) ListenSerialNetworkPacket(ctx context.Context, destination M.
 	metadata.Outbound = h.Tag()
 	metadata.Destination = destination
 	h.logger.InfoContext(ctx, "outbound packet connection")
-	conn, newDestination, err := dialer.ListenSerialNetworkPacket(ctx, h.dialer, destination, destinationAddresses, networkStrategy, networkType, fallbackNetworkType, fallbackDelay)
+	prefix, translate := h.nat64Prefix()
+	if translate {
+		destination, destinationAddresses = h.nat64.translateDestination(prefix, destination, destinationAddresses)
+	}
+	var (
+		conn           net.PacketConn
+		newDestination netip.Addr
+		err            error
+	)
+	if h.multiWAN != nil {
+		err = checkMultiWANNetwork(networkStrategy, networkType, fallbackNetworkType)
+		if err != nil {
+			return nil, netip.Addr{}, err
+		}
+		conn, newDestination, err = h.multiWAN.ListenSerialPacket(ctx, destination, destinationAddresses, fallbackDelay)
+	} else {
+		conn, newDestination, err = dialer.ListenSerialNetworkPacket(ctx, h.dialer, destination, destinationAddresses, networkStrategy, networkType, fallbackNetworkType, fallbackDelay)
+	}
 	if err != nil {
 		return nil, netip.Addr{}, err
 	}
+	if translate {
+		if address, loaded := nat64.Extract(prefix, newDestination); loaded {
+			newDestination = address
+		}
+		return nat64.NewPacketConn(bufio.NewPacketConn(conn), prefix), newDestination, nil
+	}
 	return conn, newDestination, nil
 }
 
+// checkMultiWANNetwork rejects network strategies from route options, since uplinks are selected by multi-WAN.
+func checkMultiWANNetwork(networkStrategy *C.NetworkStrategy, networkType []C.InterfaceType, fallbackNetworkType []C.InterfaceType) error {
+	if networkStrategy != nil || len(networkType) > 0 || len(fallbackNetworkType) > 0 {
+		return E.New("`network_strategy`, `network_type` and `fallback_network_type` in route options is conflict with `multi_wan`")
+	}
+	return nil
+}
+
 func (h *Outbound) IsEmpty() bool {
 	return h.isEmpty
 }
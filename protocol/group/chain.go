@@ -0,0 +1,195 @@
+package group
+
+import (
+	"context"
+	"net"
+	"reflect"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterChain(registry *outbound.Registry) {
+	outbound.Register[option.ChainOutboundOptions](registry, C.TypeChain, NewChain)
+}
+
+var (
+	_ adapter.OutboundChain             = (*Chain)(nil)
+	_ adapter.ConnectionHandlerEx       = (*Chain)(nil)
+	_ adapter.PacketConnectionHandlerEx = (*Chain)(nil)
+)
+
+// Chain dials through its hops in sequence. The first hop is the outbound itself,
+// every following hop is a private copy of the outbound, dialing through the previous hop.
+type Chain struct {
+	outbound.Adapter
+	ctx        context.Context
+	router     adapter.Router
+	outbound   adapter.OutboundManager
+	connection adapter.ConnectionManager
+	logger     logger.ContextLogger
+	tags       []string
+	hops       []adapter.Outbound
+}
+
+func NewChain(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ChainOutboundOptions) (adapter.Outbound, error) {
+	if len(options.Outbounds) < 2 {
+		return nil, E.New("at least two outbounds are required")
+	}
+	return &Chain{
+		Adapter:    outbound.NewAdapter(C.TypeChain, tag, nil, options.Outbounds),
+		ctx:        ctx,
+		router:     router,
+		outbound:   service.FromContext[adapter.OutboundManager](ctx),
+		connection: service.FromContext[adapter.ConnectionManager](ctx),
+		logger:     logger,
+		tags:       options.Outbounds,
+	}, nil
+}
+
+func (c *Chain) Network() []string {
+	network := []string{N.NetworkTCP, N.NetworkUDP}
+	for _, hop := range c.hops {
+		network = common.Filter(network, func(it string) bool {
+			return common.Contains(hop.Network(), it)
+		})
+	}
+	return network
+}
+
+func (c *Chain) Start(stage adapter.StartStage) error {
+	if stage == adapter.StartStateInitialize {
+		err := c.createHops()
+		if err != nil {
+			return err
+		}
+	}
+	// the first hop is started by the outbound manager
+	for i, hop := range c.hops[1:] {
+		err := adapter.LegacyStart(hop, stage)
+		if err != nil {
+			return E.Cause(err, stage, " hop[", i+1, "]: ", c.tags[i+1])
+		}
+	}
+	return nil
+}
+
+func (c *Chain) createHops() error {
+	firstHop, loaded := c.outbound.Outbound(c.tags[0])
+	if !loaded {
+		return E.New("hop[0]: outbound not found: ", c.tags[0])
+	}
+	registry := service.FromContext[adapter.OutboundRegistry](c.ctx)
+	hopManager := &chainOutboundManager{
+		OutboundManager: c.outbound,
+		hops:            make(map[string]adapter.Outbound),
+	}
+	hopCtx := service.ContextWith[adapter.OutboundManager](c.ctx, hopManager)
+	c.hops = []adapter.Outbound{firstHop}
+	previousTag := firstHop.Tag()
+	for i, tag := range c.tags[1:] {
+		hopIndex := i + 1
+		outboundType, rawOptions, loaded := c.outbound.OutboundOptions(tag)
+		if !loaded {
+			return E.New("hop[", hopIndex, "]: outbound not found: ", tag)
+		}
+		rawOptionsValue := reflect.ValueOf(rawOptions)
+		if rawOptionsValue.Kind() != reflect.Pointer || rawOptionsValue.IsNil() {
+			return E.New("hop[", hopIndex, "]: outbound can not be chained: ", tag)
+		}
+		// copy options, so that the detour of the outbound itself is not changed
+		optionsValue := reflect.New(rawOptionsValue.Type().Elem())
+		optionsValue.Elem().Set(rawOptionsValue.Elem())
+		dialerOptionsWrapper, isWrapper := optionsValue.Interface().(option.DialerOptionsWrapper)
+		if !isWrapper {
+			return E.New("hop[", hopIndex, "]: outbound can not be chained: ", tag)
+		}
+		dialerOptions := dialerOptionsWrapper.TakeDialerOptions()
+		dialerOptions.Detour = previousTag
+		dialerOptionsWrapper.ReplaceDialerOptions(dialerOptions)
+		hopTag := F.ToString(c.Tag(), "[", hopIndex, "]/", tag)
+		hop, err := registry.CreateOutbound(adapter.WithContext(hopCtx, &adapter.InboundContext{
+			Outbound: hopTag,
+		}), c.router, c.logger, hopTag, outboundType, optionsValue.Interface())
+		if err != nil {
+			return E.Cause(err, "hop[", hopIndex, "]: ", tag)
+		}
+		hopManager.hops[hopTag] = hop
+		c.hops = append(c.hops, hop)
+		previousTag = hopTag
+	}
+	return nil
+}
+
+func (c *Chain) Hops() []string {
+	return c.tags
+}
+
+func (c *Chain) lastHop() adapter.Outbound {
+	return c.hops[len(c.hops)-1]
+}
+
+func (c *Chain) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if !common.Contains(c.Network(), N.NetworkName(network)) {
+		return nil, E.New(network, " is not supported by chain: ", c.Tag())
+	}
+	return c.lastHop().DialContext(ctx, network, destination)
+}
+
+func (c *Chain) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	if !common.Contains(c.Network(), N.NetworkUDP) {
+		return nil, E.New("udp is not supported by chain: ", c.Tag())
+	}
+	return c.lastHop().ListenPacket(ctx, destination)
+}
+
+func (c *Chain) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	lastHop := c.lastHop()
+	if outboundHandler, isHandler := lastHop.(adapter.ConnectionHandlerEx); isHandler {
+		outboundHandler.NewConnectionEx(ctx, conn, metadata, onClose)
+	} else {
+		c.connection.NewConnection(ctx, lastHop, conn, metadata, onClose)
+	}
+}
+
+func (c *Chain) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	lastHop := c.lastHop()
+	if outboundHandler, isHandler := lastHop.(adapter.PacketConnectionHandlerEx); isHandler {
+		outboundHandler.NewPacketConnectionEx(ctx, conn, metadata, onClose)
+	} else {
+		c.connection.NewPacketConnection(ctx, lastHop, conn, metadata, onClose)
+	}
+}
+
+func (c *Chain) Close() error {
+	var err error
+	for i := len(c.hops) - 1; i > 0; i-- {
+		err = E.Append(err, common.Close(c.hops[i]), func(err error) error {
+			return E.Cause(err, "close hop[", i, "]: ", c.tags[i])
+		})
+	}
+	return err
+}
+
+// chainOutboundManager resolves detours of hops to the private previous hops.
+type chainOutboundManager struct {
+	adapter.OutboundManager
+	hops map[string]adapter.Outbound
+}
+
+func (m *chainOutboundManager) Outbound(tag string) (adapter.Outbound, bool) {
+	if hop, loaded := m.hops[tag]; loaded {
+		return hop, true
+	}
+	return m.OutboundManager.Outbound(tag)
+}
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	sHTTP "github.com/sagernet/sing/protocol/http"
+
+	"golang.org/x/net/http2"
+)
+
+type RoundTripCloser interface {
+	http.RoundTripper
+	io.Closer
+}
+
+var NewHTTP3TransportFunc func(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (RoundTripCloser, error)
+
+// dialConnect opens a tunnel in a CONNECT stream of a multiplexed HTTP/2 or HTTP/3 connection.
+func dialConnect(ctx context.Context, roundTripper http.RoundTripper, headers http.Header, destination M.Socksaddr) (net.Conn, error) {
+	// the stream lives longer than the dial context, which only bounds the handshake
+	streamCtx, cancel := context.WithCancel(v2rayhttp.DupContext(ctx))
+	pipeReader, pipeWriter := io.Pipe()
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Scheme: "https", Host: destination.String()},
+		Host:   destination.String(),
+		Header: headers.Clone(),
+		Body:   pipeReader,
+	}
+	request = request.WithContext(streamCtx)
+	type roundTripResult struct {
+		response *http.Response
+		err      error
+	}
+	done := make(chan roundTripResult, 1)
+	go func() {
+		response, err := roundTripper.RoundTrip(request)
+		done <- roundTripResult{response, err}
+	}()
+	var result roundTripResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		cancel()
+		pipeWriter.Close()
+		result = <-done
+		if result.response != nil {
+			result.response.Body.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if result.err != nil {
+		cancel()
+		pipeWriter.Close()
+		return nil, result.err
+	}
+	if result.response.StatusCode != http.StatusOK {
+		result.response.Body.Close()
+		cancel()
+		pipeWriter.Close()
+		switch result.response.StatusCode {
+		case http.StatusProxyAuthRequired:
+			return nil, E.New("authentication required")
+		case http.StatusMethodNotAllowed:
+			return nil, E.New("method not allowed")
+		default:
+			return nil, E.New("unexpected status: ", result.response.Status)
+		}
+	}
+	return &streamConn{
+		HTTP2Conn: v2rayhttp.NewHTTPConn(result.response.Body, pipeWriter),
+		cancel:    cancel,
+	}, nil
+}
+
+type streamConn struct {
+	v2rayhttp.HTTP2Conn
+	cancel context.CancelFunc
+}
+
+func (c *streamConn) Close() error {
+	c.cancel()
+	return c.HTTP2Conn.Close()
+}
+
+var _ N.Dialer = (*h2Client)(nil)
+
+// http1FallbackInterval is how long the client keeps using HTTP/1.1 after the server
+// did not negotiate h2, before probing it again.
+const http1FallbackInterval = 10 * time.Minute
+
+// h2Client tunnels connections in CONNECT streams of a shared HTTP/2 connection,
+// and falls back to HTTP/1.1 if the server does not negotiate h2.
+type h2Client struct {
+	dialer      tls.Dialer
+	serverAddr  M.Socksaddr
+	headers     http.Header
+	options     sHTTP.Options
+	http1Client *sHTTP.Client
+	transport   *http2.Transport
+	access      sync.Mutex
+	conn        *http2.ClientConn
+	dialing     *h2Dial
+	http1Until  time.Time
+}
+
+// h2Dial is a connection attempt shared by all callers which need a connection meanwhile.
+type h2Dial struct {
+	done chan struct{}
+	conn *http2.ClientConn
+	err  error
+}
+
+func newH2Client(dialer tls.Dialer, options sHTTP.Options) *h2Client {
+	return &h2Client{
+		dialer:      dialer,
+		serverAddr:  options.Server,
+		headers:     buildConnectHeaders(options),
+		options:     options,
+		http1Client: sHTTP.NewClient(cloneOptions(options, dialer)),
+		transport:   &http2.Transport{},
+	}
+}
+
+func (c *h2Client) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if N.NetworkName(network) != N.NetworkTCP {
+		return nil, os.ErrInvalid
+	}
+	clientConn, fallbackConn, err := c.offer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fallbackConn != nil {
+		return sHTTP.NewClient(cloneOptions(c.options, &connDialer{fallbackConn})).DialContext(ctx, network, destination)
+	}
+	if clientConn == nil {
+		return c.http1Client.DialContext(ctx, network, destination)
+	}
+	return dialConnect(ctx, clientConn, c.headers, destination)
+}
+
+// offer returns the shared HTTP/2 connection, or the TLS connection to use for HTTP/1.1
+// if the dial of this caller was not negotiated h2, or neither if HTTP/1.1 is in use.
+func (c *h2Client) offer(ctx context.Context) (*http2.ClientConn, net.Conn, error) {
+	c.access.Lock()
+	if c.conn != nil && c.conn.CanTakeNewRequest() {
+		clientConn := c.conn
+		c.access.Unlock()
+		return clientConn, nil, nil
+	}
+	if time.Now().Before(c.http1Until) {
+		c.access.Unlock()
+		return nil, nil, nil
+	}
+	dial := c.dialing
+	if dial == nil {
+		dial = &h2Dial{done: make(chan struct{})}
+		c.dialing = dial
+		c.access.Unlock()
+		return c.dial(ctx, dial)
+	}
+	c.access.Unlock()
+	select {
+	case <-dial.done:
+		return dial.conn, nil, dial.err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (c *h2Client) dial(ctx context.Context, dial *h2Dial) (*http2.ClientConn, net.Conn, error) {
+	var fallbackConn net.Conn
+	conn, err := c.dialer.DialTLSContext(ctx, c.serverAddr)
+	if err == nil {
+		if conn.ConnectionState().NegotiatedProtocol != http2.NextProtoTLS {
+			fallbackConn = conn
+		} else {
+			dial.conn, err = c.transport.NewClientConn(conn)
+			if err != nil {
+				conn.Close()
+			}
+		}
+	}
+	dial.err = err
+	c.access.Lock()
+	if c.dialing != dial {
+		// closed while dialing
+		if dial.conn != nil {
+			dial.conn.Close()
+			dial.conn = nil
+		}
+		if fallbackConn != nil {
+			fallbackConn.Close()
+			fallbackConn = nil
+		}
+		if dial.err == nil {
+			dial.err = net.ErrClosed
+		}
+	} else {
+		c.dialing = nil
+		if dial.conn != nil {
+			c.conn = dial.conn
+		} else if fallbackConn != nil {
+			c.http1Until = time.Now().Add(http1FallbackInterval)
+		}
+	}
+	c.access.Unlock()
+	close(dial.done)
+	return dial.conn, fallbackConn, dial.err
+}
+
+func (c *h2Client) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, os.ErrInvalid
+}
+
+func (c *h2Client) Close() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	c.dialing = nil
+	c.http1Until = time.Time{}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return nil
+}
+
+var _ N.Dialer = (*h3Client)(nil)
+
+type h3Client struct {
+	transport RoundTripCloser
+	headers   http.Header
+}
+
+func (c *h3Client) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	if N.NetworkName(network) != N.NetworkTCP {
+		return nil, os.ErrInvalid
+	}
+	return dialConnect(ctx, c.transport, c.headers, destination)
+}
+
+func (c *h3Client) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, os.ErrInvalid
+}
+
+func (c *h3Client) Close() error {
+	return c.transport.Close()
+}
+
+// connDialer hands an already established connection to the HTTP/1.1 client.
+type connDialer struct {
+	conn net.Conn
+}
+
+func (d *connDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	return d.conn, nil
+}
+
+func (d *connDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, os.ErrInvalid
+}
+
+func cloneOptions(options sHTTP.Options, dialer N.Dialer) sHTTP.Options {
+	options.Dialer = dialer
+	// the client takes the Host header out of the map
+	options.Headers = options.Headers.Clone()
+	return options
+}
+
+func buildConnectHeaders(options sHTTP.Options) http.Header {
+	headers := options.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Del("Host")
+	if options.Username != "" {
+		headers.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(options.Username+":"+options.Password)))
+	}
+	return headers
+}
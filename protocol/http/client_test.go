@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/common/tls"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	sHTTP "github.com/sagernet/sing/protocol/http"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+type testConn struct {
+	net.Conn
+	protocol string
+}
+
+func (c *testConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *testConn) HandshakeContext(ctx context.Context) error {
+	return nil
+}
+
+func (c *testConn) ConnectionState() tls.ConnectionState {
+	return tls.ConnectionState{NegotiatedProtocol: c.protocol}
+}
+
+// testDialer negotiates protocol on every dial, and holds each dial until release is closed.
+type testDialer struct {
+	N.Dialer
+	protocol string
+	release  chan struct{}
+	dialed   chan struct{}
+	dials    atomic.Int32
+}
+
+func newTestDialer(protocol string) *testDialer {
+	return &testDialer{
+		protocol: protocol,
+		release:  make(chan struct{}),
+		dialed:   make(chan struct{}, 16),
+	}
+}
+
+func (d *testDialer) DialTLSContext(ctx context.Context, destination M.Socksaddr) (tls.Conn, error) {
+	d.dials.Add(1)
+	d.dialed <- struct{}{}
+	<-d.release
+	clientConn, serverConn := net.Pipe()
+	if d.protocol == http2.NextProtoTLS {
+		go (&http2.Server{}).ServeConn(serverConn, &http2.ServeConnOpts{
+			Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}),
+		})
+	} else {
+		go func() {
+			serverConn.Read(make([]byte, 1))
+			serverConn.Close()
+		}()
+	}
+	return &testConn{Conn: clientConn, protocol: d.protocol}, nil
+}
+
+func TestH2ClientSingleFlight(t *testing.T) {
+	dialer := newTestDialer(http2.NextProtoTLS)
+	client := newH2Client(dialer, sHTTP.Options{})
+	defer client.Close()
+	type offerResult struct {
+		clientConn   *http2.ClientConn
+		fallbackConn net.Conn
+		err          error
+	}
+	var (
+		wg      sync.WaitGroup
+		results = make(chan offerResult, 8)
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clientConn, fallbackConn, err := client.offer(context.Background())
+			results <- offerResult{clientConn, fallbackConn, err}
+		}()
+	}
+	<-dialer.dialed
+	// the client is not locked while dialing
+	client.access.Lock()
+	require.NotNil(t, client.dialing)
+	client.access.Unlock()
+	close(dialer.release)
+	wg.Wait()
+	close(results)
+	var first *http2.ClientConn
+	for result := range results {
+		require.NoError(t, result.err)
+		require.Nil(t, result.fallbackConn)
+		require.NotNil(t, result.clientConn)
+		if first == nil {
+			first = result.clientConn
+		}
+		require.Same(t, first, result.clientConn)
+	}
+	require.EqualValues(t, 1, dialer.dials.Load())
+}
+
+func TestH2ClientCloseWhileDialing(t *testing.T) {
+	dialer := newTestDialer(http2.NextProtoTLS)
+	client := newH2Client(dialer, sHTTP.Options{})
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.offer(context.Background())
+		done <- err
+	}()
+	<-dialer.dialed
+	require.NoError(t, client.Close())
+	close(dialer.release)
+	require.ErrorIs(t, <-done, net.ErrClosed)
+	require.Nil(t, client.conn)
+}
+
+func TestH2ClientHTTP1Fallback(t *testing.T) {
+	dialer := newTestDialer("http/1.1")
+	close(dialer.release)
+	client := newH2Client(dialer, sHTTP.Options{})
+	defer client.Close()
+	clientConn, fallbackConn, err := client.offer(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, clientConn)
+	require.NotNil(t, fallbackConn)
+	fallbackConn.Close()
+	clientConn, fallbackConn, err = client.offer(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, clientConn)
+	require.Nil(t, fallbackConn)
+	require.EqualValues(t, 1, dialer.dials.Load())
+	client.access.Lock()
+	client.http1Until = time.Now()
+	client.access.Unlock()
+	_, fallbackConn, err = client.offer(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, fallbackConn)
+	fallbackConn.Close()
+	require.EqualValues(t, 2, dialer.dials.Load())
+}
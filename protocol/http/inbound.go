@@ -3,7 +3,9 @@ package http
 import (
 	std_bufio "bufio"
 	"context"
+	"io"
 	"net"
+	"net/http"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
@@ -13,13 +15,20 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/auth"
 	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
-	"github.com/sagernet/sing/protocol/http"
+	sHTTP "github.com/sagernet/sing/protocol/http"
+
+	"golang.org/x/net/http2"
 )
 
+var ConfigureHTTP3ListenerFunc func(ctx context.Context, logger logger.Logger, listener *listener.Listener, handler http.Handler, tlsConfig tls.ServerConfig) (io.Closer, error)
+
 func RegisterInbound(registry *inbound.Registry) {
 	inbound.Register[option.HTTPMixedInboundOptions](registry, C.TypeHTTP, NewInbound)
 }
@@ -28,19 +37,26 @@ var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
 
 type Inbound struct {
 	inbound.Adapter
+	ctx           context.Context
 	router        adapter.ConnectionRouterEx
 	logger        log.ContextLogger
 	listener      *listener.Listener
+	users         []auth.User
 	authenticator *auth.Authenticator
 	tlsConfig     tls.ServerConfig
+	h2Server      *http2.Server
+	h3Server      io.Closer
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) (adapter.Inbound, error) {
 	inbound := &Inbound{
 		Adapter:       inbound.NewAdapter(C.TypeHTTP, tag),
+		ctx:           ctx,
 		router:        uot.NewRouter(router, logger),
 		logger:        logger,
+		users:         options.Users,
 		authenticator: auth.NewAuthenticator(options.Users),
+		h2Server:      &http2.Server{},
 	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServerWithOptions(tls.ServerOptions{
@@ -76,12 +92,23 @@ func (h *Inbound) Start(stage adapter.StartStage) error {
 			return E.Cause(err, "create TLS config")
 		}
 	}
-	return h.listener.Start()
+	err := h.listener.Start()
+	if err != nil {
+		return err
+	}
+	if h.tlsConfig != nil && common.Contains(h.tlsConfig.NextProtos(), "h3") {
+		h.h3Server, err = ConfigureHTTP3ListenerFunc(h.ctx, h.logger, h.listener, h, h.tlsConfig)
+		if err != nil {
+			return E.Cause(err, "create HTTP/3 server")
+		}
+	}
+	return nil
 }
 
 func (h *Inbound) Close() error {
 	return common.Close(
 		h.listener,
+		h.h3Server,
 		h.tlsConfig,
 	)
 }
@@ -94,15 +121,85 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata a
 			h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source, ": TLS handshake"))
 			return
 		}
+		tls.ApplyClientCertificate(h.tlsConfig, tlsConn, &metadata)
+		if tlsConn.ConnectionState().NegotiatedProtocol == http2.NextProtoTLS {
+			h.h2Server.ServeConn(tlsConn, &http2.ServeConnOpts{
+				Context: adapter.WithContext(ctx, &metadata),
+				Handler: h,
+			})
+			if onClose != nil {
+				onClose(nil)
+			}
+			return
+		}
 		conn = tlsConn
 	}
-	err := http.HandleConnectionEx(ctx, conn, std_bufio.NewReader(conn), h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), metadata.Source, onClose)
+	authenticator := h.authenticator
+	if user, loaded := inbound.ClientCertificateUser(&metadata, h.users); loaded {
+		ctx = auth.ContextWithUser(ctx, user)
+		authenticator = nil
+	}
+	err := sHTTP.HandleConnectionEx(ctx, conn, std_bufio.NewReader(conn), authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), metadata.Source, onClose)
 	if err != nil {
 		N.CloseOnHandshakeFailure(conn, onClose, err)
 		h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source))
 	}
 }
 
+// ServeHTTP handles CONNECT requests of HTTP/2 and HTTP/3 connections, each stream tunnels one connection.
+func (h *Inbound) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ctx := log.ContextWithNewID(request.Context())
+	var metadata adapter.InboundContext
+	if inboundMetadata := adapter.ContextFrom(ctx); inboundMetadata != nil {
+		metadata = *inboundMetadata
+	} else {
+		// requests of the HTTP/3 server are not wrapped by NewConnectionEx
+		metadata.Source = sHTTP.SourceAddress(request)
+		if h.tlsConfig != nil && request.TLS != nil {
+			tls.ApplyClientCertificateFromState(h.tlsConfig, *request.TLS, &metadata)
+		}
+	}
+	if request.Method != http.MethodConnect {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		h.logger.ErrorContext(ctx, E.New("process connection from ", metadata.Source, ": unsupported method: ", request.Method))
+		return
+	}
+	if user, loaded := inbound.ClientCertificateUser(&metadata, h.users); loaded {
+		ctx = auth.ContextWithUser(ctx, user)
+	} else if h.authenticator != nil {
+		userName, password, authOk := sHTTP.ParseBasicAuth(request.Header.Get("Proxy-Authorization"))
+		if authOk {
+			authOk = h.authenticator.Verify(userName, password)
+		}
+		if !authOk {
+			writer.Header().Set("Proxy-Authenticate", `Basic realm="sing-box" charset="UTF-8"`)
+			writer.WriteHeader(http.StatusProxyAuthRequired)
+			h.logger.ErrorContext(ctx, E.New("process connection from ", metadata.Source, ": authentication failed"))
+			return
+		}
+		ctx = auth.ContextWithUser(ctx, userName)
+	}
+	metadata.Destination = M.ParseSocksaddr(request.Host)
+	if !metadata.Destination.IsValid() || metadata.Destination.Port == 0 {
+		writer.WriteHeader(http.StatusBadRequest)
+		h.logger.ErrorContext(ctx, E.New("process connection from ", metadata.Source, ": invalid destination: ", request.Host))
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+	flusher := writer.(http.Flusher)
+	flusher.Flush()
+	done := make(chan struct{})
+	conn := v2rayhttp.NewHTTP2Wrapper(&v2rayhttp.ServerHTTPConn{
+		HTTP2Conn: v2rayhttp.NewHTTPConn(request.Body, writer),
+		Flusher:   flusher,
+	})
+	h.newUserConnection(ctx, conn, metadata, N.OnceClose(func(it error) {
+		close(done)
+	}))
+	<-done
+	conn.CloseWrapper()
+}
+
 func (h *Inbound) newUserConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
 	metadata.Inbound = h.Tag()
 	metadata.InboundType = h.Type()
@@ -13,20 +13,28 @@ import (
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
 	sHTTP "github.com/sagernet/sing/protocol/http"
+
+	"golang.org/x/net/http2"
 )
 
 func RegisterOutbound(registry *outbound.Registry) {
 	outbound.Register[option.HTTPOutboundOptions](registry, C.TypeHTTP, NewOutbound)
 }
 
+var (
+	_ adapter.Outbound                = (*Outbound)(nil)
+	_ adapter.InterfaceUpdateListener = (*Outbound)(nil)
+)
+
 type Outbound struct {
 	outbound.Adapter
 	logger logger.ContextLogger
-	client *sHTTP.Client
+	client N.Dialer
 }
 
 func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPOutboundOptions) (adapter.Outbound, error) {
@@ -34,21 +42,50 @@ func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextL
 	if err != nil {
 		return nil, err
 	}
-	detour, err := tls.NewDialerFromOptions(ctx, logger, outboundDialer, options.Server, common.PtrValueOrDefault(options.TLS))
-	if err != nil {
-		return nil, err
+	clientOptions := sHTTP.Options{
+		Server:   options.ServerOptions.Build(),
+		Username: options.Username,
+		Password: options.Password,
+		Path:     options.Path,
+		Headers:  options.Headers.Build(),
+	}
+	tlsOptions := common.PtrValueOrDefault(options.TLS)
+	if tlsOptions.Enabled && options.Path != "" && (common.Contains(tlsOptions.ALPN, "h3") || common.Contains(tlsOptions.ALPN, http2.NextProtoTLS)) {
+		return nil, E.New("`path` is not supported with HTTP/2 or HTTP/3")
+	}
+	var client N.Dialer
+	switch {
+	case tlsOptions.Enabled && common.Contains(tlsOptions.ALPN, "h3"):
+		tlsConfig, err := tls.NewClient(ctx, logger, options.Server, tlsOptions)
+		if err != nil {
+			return nil, err
+		}
+		transport, err := NewHTTP3TransportFunc(ctx, outboundDialer, clientOptions.Server, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		client = &h3Client{
+			transport: transport,
+			headers:   buildConnectHeaders(clientOptions),
+		}
+	case tlsOptions.Enabled && common.Contains(tlsOptions.ALPN, http2.NextProtoTLS):
+		tlsConfig, err := tls.NewClient(ctx, logger, options.Server, tlsOptions)
+		if err != nil {
+			return nil, err
+		}
+		client = newH2Client(tls.NewDialer(outboundDialer, tlsConfig), clientOptions)
+	default:
+		detour, err := tls.NewDialerFromOptions(ctx, logger, outboundDialer, options.Server, tlsOptions)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.Dialer = detour
+		client = sHTTP.NewClient(clientOptions)
 	}
 	return &Outbound{
 		Adapter: outbound.NewAdapterWithDialerOptions(C.TypeHTTP, tag, []string{N.NetworkTCP}, options.DialerOptions),
 		logger:  logger,
-		client: sHTTP.NewClient(sHTTP.Options{
-			Dialer:   detour,
-			Server:   options.ServerOptions.Build(),
-			Username: options.Username,
-			Password: options.Password,
-			Path:     options.Path,
-			Headers:  options.Headers.Build(),
-		}),
+		client:  client,
 	}, nil
 }
 
@@ -63,3 +100,11 @@ func (h *Outbound) DialContext(ctx context.Context, network string, destination
 func (h *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
 	return nil, os.ErrInvalid
 }
+
+func (h *Outbound) InterfaceUpdated() {
+	common.Close(h.client)
+}
+
+func (h *Outbound) Close() error {
+	return common.Close(h.client)
+}
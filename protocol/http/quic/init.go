@@ -0,0 +1,124 @@
+package quic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/quic-go/http3"
+	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/log"
+	protocolHTTP "github.com/sagernet/sing-box/protocol/http"
+	"github.com/sagernet/sing-quic"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+func init() {
+	protocolHTTP.ConfigureHTTP3ListenerFunc = func(ctx context.Context, logger logger.Logger, listener *listener.Listener, handler http.Handler, tlsConfig tls.ServerConfig) (io.Closer, error) {
+		err := qtls.ConfigureHTTP3(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		udpConn, err := listener.ListenUDP()
+		if err != nil {
+			return nil, err
+		}
+		quicListener, err := qtls.ListenEarly(udpConn, tlsConfig, &quic.Config{
+			MaxIncomingStreams: 1 << 60,
+		})
+		if err != nil {
+			udpConn.Close()
+			return nil, err
+		}
+		h3Server := &http3.Server{
+			Handler: handler,
+			ConnContext: func(ctx context.Context, conn *quic.Conn) context.Context {
+				return log.ContextWithNewID(ctx)
+			},
+		}
+		go func() {
+			sErr := h3Server.ServeListener(quicListener)
+			udpConn.Close()
+			if sErr != nil && !E.IsClosedOrCanceled(sErr) && sErr != http.ErrServerClosed {
+				logger.Error("http3 server closed: ", sErr)
+			}
+		}()
+		return quicListener, nil
+	}
+	protocolHTTP.NewHTTP3TransportFunc = func(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, tlsConfig tls.Config) (protocolHTTP.RoundTripCloser, error) {
+		tlsConfig.SetNextProtos([]string{http3.NextProtoH3})
+		return &clientTransport{
+			dialer:     dialer,
+			serverAddr: serverAddr,
+			tlsConfig:  tlsConfig,
+			quicConfig: &quic.Config{
+				KeepAlivePeriod: 15 * time.Second,
+			},
+			transport: &http3.Transport{},
+		}, nil
+	}
+}
+
+// clientTransport sends all requests over a single QUIC connection to the proxy server,
+// instead of pooling connections by the request host as http3.Transport does.
+type clientTransport struct {
+	dialer     N.Dialer
+	serverAddr M.Socksaddr
+	tlsConfig  tls.Config
+	quicConfig *quic.Config
+	transport  *http3.Transport
+	access     sync.Mutex
+	quicConn   *quic.Conn
+	h3Conn     *http3.ClientConn
+}
+
+func (t *clientTransport) offer(ctx context.Context) (*http3.ClientConn, error) {
+	t.access.Lock()
+	defer t.access.Unlock()
+	if t.quicConn != nil && t.quicConn.Context().Err() == nil {
+		return t.h3Conn, nil
+	}
+	conn, err := t.dialer.DialContext(ctx, N.NetworkUDP, t.serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	quicConn, err := qtls.DialEarly(ctx, bufio.NewUnbindPacketConn(conn), conn.RemoteAddr(), t.tlsConfig, t.quicConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go func() {
+		<-quicConn.Context().Done()
+		conn.Close()
+	}()
+	t.quicConn = quicConn
+	t.h3Conn = t.transport.NewClientConn(quicConn)
+	return t.h3Conn, nil
+}
+
+func (t *clientTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	h3Conn, err := t.offer(request.Context())
+	if err != nil {
+		return nil, err
+	}
+	return h3Conn.RoundTrip(request)
+}
+
+func (t *clientTransport) Close() error {
+	t.access.Lock()
+	defer t.access.Unlock()
+	if t.quicConn != nil {
+		t.quicConn.CloseWithError(quic.ApplicationErrorCode(http3.ErrCodeNoError), "")
+		t.quicConn = nil
+		t.h3Conn = nil
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package masque
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/quic-go/http3"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-quic"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const capsuleProtocolHeader = "Capsule-Protocol"
+
+type client struct {
+	dialer     N.Dialer
+	serverAddr M.Socksaddr
+	tlsConfig  tls.Config
+	quicConfig *quic.Config
+	transport  *http3.Transport
+	authority  string
+	path       string
+	headers    http.Header
+	access     sync.Mutex
+	conn       *clientConn
+}
+
+type clientConn struct {
+	quicConn *quic.Conn
+	h3Conn   *http3.ClientConn
+}
+
+func (c *client) offer(ctx context.Context) (*http3.ClientConn, error) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.conn != nil && c.conn.quicConn.Context().Err() == nil {
+		return c.conn.h3Conn, nil
+	}
+	conn, err := c.dialer.DialContext(ctx, N.NetworkUDP, c.serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	quicConn, err := qtls.DialEarly(ctx, bufio.NewUnbindPacketConn(conn), conn.RemoteAddr(), c.tlsConfig, c.quicConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go func() {
+		<-quicConn.Context().Done()
+		conn.Close()
+	}()
+	c.conn = &clientConn{
+		quicConn: quicConn,
+		h3Conn:   c.transport.NewClientConn(quicConn),
+	}
+	return c.conn.h3Conn, nil
+}
+
+func (c *client) openSession(ctx context.Context, destination M.Socksaddr) (*http3.RequestStream, error) {
+	h3Conn, err := c.offer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requestURL, err := url.Parse("https://" + c.authority + expandPath(c.path, destination))
+	if err != nil {
+		return nil, err
+	}
+	stream, err := h3Conn.OpenRequestStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request := &http.Request{
+		Method: http.MethodConnect,
+		Proto:  "connect-udp",
+		Host:   c.authority,
+		URL:    requestURL,
+		Header: c.headers.Clone(),
+	}
+	request.Header.Set(capsuleProtocolHeader, "?1")
+	request = request.WithContext(ctx)
+	err = stream.SendRequestHeader(request)
+	if err != nil {
+		closeRequestStream(stream)
+		return nil, err
+	}
+	if deadline, loaded := ctx.Deadline(); loaded {
+		stream.SetReadDeadline(deadline)
+	}
+	response, err := stream.ReadResponse()
+	if err != nil {
+		closeRequestStream(stream)
+		return nil, err
+	}
+	stream.SetReadDeadline(time.Time{})
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		closeRequestStream(stream)
+		return nil, E.New("masque: unexpected status: ", response.Status)
+	}
+	return stream, nil
+}
+
+func (c *client) Close() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.conn != nil {
+		c.conn.quicConn.CloseWithError(quic.ApplicationErrorCode(http3.ErrCodeNoError), "")
+		c.conn = nil
+	}
+	return nil
+}
+
+func newAuthority(serverName string, serverAddr M.Socksaddr) string {
+	if serverName == "" {
+		serverName = serverAddr.AddrString()
+	}
+	// the default port is omitted, IPv6 literals keep their brackets
+	return strings.TrimSuffix(net.JoinHostPort(serverName, strconv.Itoa(int(serverAddr.Port))), ":443")
+}
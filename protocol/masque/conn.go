@@ -0,0 +1,313 @@
+package masque
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/quic-go/http3"
+	"github.com/sagernet/quic-go/quicvarint"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// HTTP Datagrams of CONNECT-UDP are prefixed with a Context ID, and zero is
+// reserved for UDP payloads, see RFC 9298 section 4.
+const contextIDUDPPayload = 0
+
+type datagramStream interface {
+	io.Reader
+	SendDatagram(b []byte) error
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+}
+
+func sendDatagram(stream datagramStream, buffer *buf.Buffer) error {
+	defer buffer.Release()
+	var payload []byte
+	if buffer.Start() >= 1 {
+		buffer.ExtendHeader(1)[0] = contextIDUDPPayload
+		payload = buffer.Bytes()
+	} else {
+		payload = append([]byte{contextIDUDPPayload}, buffer.Bytes()...)
+	}
+	err := stream.SendDatagram(payload)
+	var tooLargeErr *quic.DatagramTooLargeError
+	if errors.As(err, &tooLargeErr) {
+		// UDP packets which do not fit into a QUIC datagram are dropped
+		return nil
+	}
+	return err
+}
+
+func receiveDatagram(ctx context.Context, stream datagramStream) ([]byte, error) {
+	for {
+		data, err := stream.ReceiveDatagram(ctx)
+		if err != nil {
+			return nil, err
+		}
+		contextID, n, err := quicvarint.Parse(data)
+		if err != nil || contextID != contextIDUDPPayload {
+			continue
+		}
+		return data[n:], nil
+	}
+}
+
+// drainCapsules reads and discards capsules of the request stream, which
+// also lets the stream notice when the peer closes it.
+func drainCapsules(stream io.Reader, onClose func()) {
+	_, _ = io.Copy(io.Discard, stream)
+	onClose()
+}
+
+var _ N.PacketConn = (*serverPacketConn)(nil)
+
+type serverPacketConn struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stream      *http3.Stream
+	destination M.Socksaddr
+	localAddr   net.Addr
+	closeOnce   sync.Once
+}
+
+func newServerPacketConn(stream *http3.Stream, destination M.Socksaddr, localAddr net.Addr) *serverPacketConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &serverPacketConn{
+		ctx:         ctx,
+		cancel:      cancel,
+		stream:      stream,
+		destination: destination,
+		localAddr:   localAddr,
+	}
+	go drainCapsules(stream, cancel)
+	return conn
+}
+
+func (c *serverPacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	payload, err := receiveDatagram(c.ctx, c.stream)
+	if err != nil {
+		return M.Socksaddr{}, net.ErrClosed
+	}
+	_, err = buffer.Write(payload)
+	if err != nil {
+		return M.Socksaddr{}, err
+	}
+	return c.destination, nil
+}
+
+func (c *serverPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	return sendDatagram(c.stream, buffer)
+}
+
+func (c *serverPacketConn) FrontHeadroom() int {
+	return 1
+}
+
+func (c *serverPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.stream.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+		c.stream.Close()
+	})
+	return nil
+}
+
+func (c *serverPacketConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *serverPacketConn) SetDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *serverPacketConn) SetReadDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *serverPacketConn) SetWriteDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *serverPacketConn) NeedAdditionalReadDeadline() bool {
+	return true
+}
+
+var _ N.PacketConn = (*clientPacketConn)(nil)
+
+// clientPacketConn opens a CONNECT-UDP session for each destination on demand,
+// since every session is bound to a single target.
+type clientPacketConn struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	client    *client
+	access    sync.Mutex
+	sessions  map[M.Socksaddr]*clientSession
+	packets   chan *clientPacket
+	closeOnce sync.Once
+}
+
+type clientSession struct {
+	stream *http3.RequestStream
+	err    error
+	done   chan struct{}
+}
+
+type clientPacket struct {
+	payload []byte
+	source  M.Socksaddr
+}
+
+func newClientPacketConn(ctx context.Context, client *client) *clientPacketConn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &clientPacketConn{
+		ctx:      ctx,
+		cancel:   cancel,
+		client:   client,
+		sessions: make(map[M.Socksaddr]*clientSession),
+		packets:  make(chan *clientPacket, 64),
+	}
+}
+
+func (c *clientPacketConn) loadSession(destination M.Socksaddr) (*clientSession, error) {
+	c.access.Lock()
+	if c.ctx.Err() != nil {
+		c.access.Unlock()
+		return nil, net.ErrClosed
+	}
+	session, loaded := c.sessions[destination]
+	if !loaded {
+		session = &clientSession{done: make(chan struct{})}
+		c.sessions[destination] = session
+	}
+	c.access.Unlock()
+	if loaded {
+		select {
+		case <-session.done:
+		case <-c.ctx.Done():
+			return nil, net.ErrClosed
+		}
+		if session.err != nil {
+			return nil, session.err
+		}
+		return session, nil
+	}
+	session.stream, session.err = c.client.openSession(c.ctx, destination)
+	if session.err == nil && c.ctx.Err() != nil {
+		closeRequestStream(session.stream)
+		session.stream, session.err = nil, net.ErrClosed
+	}
+	close(session.done)
+	if session.err != nil {
+		c.removeSession(destination, session)
+		return nil, session.err
+	}
+	go drainCapsules(session.stream, func() {
+		c.removeSession(destination, session)
+	})
+	go c.loopReceive(destination, session)
+	return session, nil
+}
+
+func (c *clientPacketConn) removeSession(destination M.Socksaddr, session *clientSession) {
+	c.access.Lock()
+	if c.sessions[destination] == session {
+		delete(c.sessions, destination)
+	}
+	c.access.Unlock()
+	if session.stream != nil {
+		closeRequestStream(session.stream)
+	}
+}
+
+func (c *clientPacketConn) loopReceive(destination M.Socksaddr, session *clientSession) {
+	for {
+		payload, err := receiveDatagram(c.ctx, session.stream)
+		if err != nil {
+			c.removeSession(destination, session)
+			return
+		}
+		select {
+		case c.packets <- &clientPacket{payload, destination}:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *clientPacketConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	select {
+	case packet := <-c.packets:
+		_, err = buffer.Write(packet.payload)
+		if err != nil {
+			return M.Socksaddr{}, err
+		}
+		return packet.source, nil
+	case <-c.ctx.Done():
+		return M.Socksaddr{}, net.ErrClosed
+	}
+}
+
+func (c *clientPacketConn) WritePacket(buffer *buf.Buffer, destination M.Socksaddr) error {
+	session, err := c.loadSession(destination)
+	if err != nil {
+		buffer.Release()
+		return err
+	}
+	return sendDatagram(session.stream, buffer)
+}
+
+func (c *clientPacketConn) FrontHeadroom() int {
+	return 1
+}
+
+func (c *clientPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.access.Lock()
+		c.cancel()
+		sessions := c.sessions
+		c.sessions = nil
+		c.access.Unlock()
+		for _, session := range sessions {
+			select {
+			case <-session.done:
+				if session.stream != nil {
+					closeRequestStream(session.stream)
+				}
+			default:
+			}
+		}
+	})
+	return nil
+}
+
+func (c *clientPacketConn) LocalAddr() net.Addr {
+	return M.Socksaddr{}
+}
+
+func (c *clientPacketConn) SetDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *clientPacketConn) SetReadDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *clientPacketConn) SetWriteDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *clientPacketConn) NeedAdditionalReadDeadline() bool {
+	return true
+}
+
+func closeRequestStream(stream *http3.RequestStream) {
+	stream.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+	stream.Close()
+}
@@ -0,0 +1,177 @@
+package masque
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/quic-go/http3"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-quic"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/auth"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+	sHttp "github.com/sagernet/sing/protocol/http"
+)
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.MASQUEInboundOptions](registry, C.TypeMASQUE, NewInbound)
+}
+
+var _ adapter.Inbound = (*Inbound)(nil)
+
+type Inbound struct {
+	inbound.Adapter
+	ctx           context.Context
+	router        adapter.ConnectionRouterEx
+	logger        log.ContextLogger
+	listener      *listener.Listener
+	tlsConfig     tls.ServerConfig
+	authenticator *auth.Authenticator
+	pathMatcher   *pathMatcher
+	quicListener  qtls.EarlyListener
+	h3Server      *http3.Server
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.MASQUEInboundOptions) (adapter.Inbound, error) {
+	if options.TLS == nil || !options.TLS.Enabled {
+		return nil, C.ErrTLSRequired
+	}
+	tlsConfig, err := tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
+	if err != nil {
+		return nil, err
+	}
+	path, err := checkPath(options.Path)
+	if err != nil {
+		return nil, err
+	}
+	inbound := &Inbound{
+		Adapter:       inbound.NewAdapter(C.TypeMASQUE, tag),
+		ctx:           ctx,
+		router:        router,
+		logger:        logger,
+		tlsConfig:     tlsConfig,
+		authenticator: auth.NewAuthenticator(options.Users),
+		pathMatcher:   newPathMatcher(path),
+		listener: listener.New(listener.Options{
+			Context: ctx,
+			Logger:  logger,
+			Network: []string{N.NetworkUDP},
+			Listen:  options.ListenOptions,
+		}),
+	}
+	return inbound, nil
+}
+
+func (h *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	err := h.tlsConfig.Start()
+	if err != nil {
+		return E.Cause(err, "create TLS config")
+	}
+	err = qtls.ConfigureHTTP3(h.tlsConfig)
+	if err != nil {
+		return err
+	}
+	udpConn, err := h.listener.ListenUDP()
+	if err != nil {
+		return err
+	}
+	h.quicListener, err = qtls.ListenEarly(udpConn, h.tlsConfig, &quic.Config{
+		EnableDatagrams:    true,
+		MaxIncomingStreams: 1 << 60,
+	})
+	if err != nil {
+		udpConn.Close()
+		return err
+	}
+	h.h3Server = &http3.Server{
+		Handler:         h,
+		EnableDatagrams: true,
+		ConnContext: func(ctx context.Context, conn *quic.Conn) context.Context {
+			return log.ContextWithNewID(ctx)
+		},
+	}
+	go func() {
+		sErr := h.h3Server.ServeListener(h.quicListener)
+		udpConn.Close()
+		if sErr != nil && !E.IsClosedOrCanceled(sErr) && sErr != http.ErrServerClosed {
+			h.logger.Error("http3 server closed: ", sErr)
+		}
+	}()
+	return nil
+}
+
+func (h *Inbound) Close() error {
+	return common.Close(
+		common.PtrOrNil(h.h3Server),
+		h.quicListener,
+		h.listener,
+		h.tlsConfig,
+	)
+}
+
+func (h *Inbound) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	ctx := request.Context()
+	if request.Method != http.MethodConnect || request.Proto != "connect-udp" {
+		writer.WriteHeader(http.StatusNotImplemented)
+		h.logger.ErrorContext(ctx, E.New("process connection from ", request.RemoteAddr, ": unsupported request: ", request.Method, " ", request.Proto))
+		return
+	}
+	var userName string
+	if h.authenticator != nil {
+		var password string
+		var authOk bool
+		userName, password, authOk = sHttp.ParseBasicAuth(request.Header.Get("Proxy-Authorization"))
+		if authOk {
+			authOk = h.authenticator.Verify(userName, password)
+		}
+		if !authOk {
+			writer.Header().Set("Proxy-Authenticate", `Basic realm="sing-box"`)
+			writer.WriteHeader(http.StatusProxyAuthRequired)
+			h.logger.ErrorContext(ctx, E.New("process connection from ", request.RemoteAddr, ": authorization failed"))
+			return
+		}
+	}
+	destination, err := h.pathMatcher.Match(request.URL)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", request.RemoteAddr))
+		return
+	}
+	streamer, isStreamer := writer.(http3.HTTPStreamer)
+	if !isStreamer {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set(capsuleProtocolHeader, "?1")
+	writer.WriteHeader(http.StatusOK)
+	stream := streamer.HTTPStream()
+	// the request context is canceled once the handler returns
+	ctx = log.ContextWithNewID(h.ctx)
+	var metadata adapter.InboundContext
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	metadata.Source = sHttp.SourceAddress(request)
+	metadata.Destination = destination
+	metadata.User = userName
+	if userName != "" {
+		h.logger.InfoContext(ctx, "[", userName, "] inbound packet connection from ", metadata.Source)
+	} else {
+		h.logger.InfoContext(ctx, "inbound packet connection from ", metadata.Source)
+	}
+	h.logger.InfoContext(ctx, "inbound packet connection to ", metadata.Destination)
+	localAddr, _ := request.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	conn := newServerPacketConn(stream, destination, localAddr)
+	h.router.RoutePacketConnectionEx(ctx, conn, metadata, nil)
+}
@@ -0,0 +1,119 @@
+package masque
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"time"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/quic-go/http3"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+func RegisterOutbound(registry *outbound.Registry) {
+	outbound.Register[option.MASQUEOutboundOptions](registry, C.TypeMASQUE, NewOutbound)
+}
+
+var (
+	_ adapter.Outbound                = (*Outbound)(nil)
+	_ adapter.InterfaceUpdateListener = (*Outbound)(nil)
+)
+
+type Outbound struct {
+	outbound.Adapter
+	ctx    context.Context
+	logger logger.ContextLogger
+	client *client
+}
+
+func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.MASQUEOutboundOptions) (adapter.Outbound, error) {
+	if options.TLS == nil || !options.TLS.Enabled {
+		return nil, C.ErrTLSRequired
+	}
+	tlsConfig, err := tls.NewClient(ctx, logger, options.Server, common.PtrValueOrDefault(options.TLS))
+	if err != nil {
+		return nil, err
+	}
+	if len(tlsConfig.NextProtos()) == 0 {
+		tlsConfig.SetNextProtos([]string{http3.NextProtoH3})
+	}
+	path, err := checkPath(options.Path)
+	if err != nil {
+		return nil, err
+	}
+	outboundDialer, err := dialer.New(ctx, options.DialerOptions, options.ServerIsDomain())
+	if err != nil {
+		return nil, err
+	}
+	headers := options.Headers.Build()
+	if options.Username != "" {
+		headers.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(options.Username+":"+options.Password)))
+	}
+	serverAddr := options.ServerOptions.Build()
+	return &Outbound{
+		Adapter: outbound.NewAdapterWithDialerOptions(C.TypeMASQUE, tag, []string{N.NetworkUDP}, options.DialerOptions),
+		ctx:     ctx,
+		logger:  logger,
+		client: &client{
+			dialer:     outboundDialer,
+			serverAddr: serverAddr,
+			tlsConfig:  tlsConfig,
+			quicConfig: &quic.Config{
+				EnableDatagrams: true,
+				KeepAlivePeriod: 15 * time.Second,
+			},
+			transport: &http3.Transport{
+				EnableDatagrams: true,
+			},
+			authority: newAuthority(tlsConfig.ServerName(), serverAddr),
+			path:      path,
+			headers:   headers,
+		},
+	}, nil
+}
+
+func (h *Outbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	switch N.NetworkName(network) {
+	case N.NetworkUDP:
+		conn, err := h.ListenPacket(ctx, destination)
+		if err != nil {
+			return nil, err
+		}
+		return bufio.NewBindPacketConn(conn, destination), nil
+	default:
+		return nil, E.New("unsupported network: ", network)
+	}
+}
+
+func (h *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	h.logger.InfoContext(ctx, "outbound packet connection to ", destination)
+	conn := newClientPacketConn(h.ctx, h.client)
+	// open the session to the initial destination now, so that failures are reported to the caller
+	_, err := conn.loadSession(destination)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return bufio.NewNetPacketConn(conn), nil
+}
+
+func (h *Outbound) InterfaceUpdated() {
+	h.client.Close()
+}
+
+func (h *Outbound) Close() error {
+	return h.client.Close()
+}
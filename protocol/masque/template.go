@@ -0,0 +1,81 @@
+package masque
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+// DefaultPath is the default URI template path of RFC 9298.
+const DefaultPath = "/.well-known/masque/udp/{target_host}/{target_port}/"
+
+const (
+	variableTargetHost = "{target_host}"
+	variableTargetPort = "{target_port}"
+)
+
+func checkPath(path string) (string, error) {
+	if path == "" {
+		return DefaultPath, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", E.New("path must start with /")
+	}
+	if !strings.Contains(path, variableTargetHost) || !strings.Contains(path, variableTargetPort) {
+		return "", E.New("path must contain both ", variableTargetHost, " and ", variableTargetPort)
+	}
+	return path, nil
+}
+
+func expandPath(path string, destination M.Socksaddr) string {
+	// colons of IPv6 addresses must be percent-encoded, see RFC 9298 section 2
+	host := strings.ReplaceAll(url.PathEscape(destination.AddrString()), ":", "%3A")
+	return strings.NewReplacer(
+		variableTargetHost, host,
+		variableTargetPort, strconv.Itoa(int(destination.Port)),
+	).Replace(path)
+}
+
+type pathMatcher struct {
+	regexp   *regexp.Regexp
+	hasQuery bool
+}
+
+func newPathMatcher(path string) *pathMatcher {
+	pattern := strings.NewReplacer(
+		regexp.QuoteMeta(variableTargetHost), `(?P<host>[^/?&]+)`,
+		regexp.QuoteMeta(variableTargetPort), `(?P<port>[0-9]+)`,
+	).Replace(regexp.QuoteMeta(path))
+	return &pathMatcher{
+		regexp:   regexp.MustCompile("^" + pattern + "$"),
+		hasQuery: strings.Contains(path, "?"),
+	}
+}
+
+func (m *pathMatcher) Match(requestURL *url.URL) (M.Socksaddr, error) {
+	requestPath := requestURL.EscapedPath()
+	if m.hasQuery {
+		requestPath += "?" + requestURL.RawQuery
+	}
+	matches := m.regexp.FindStringSubmatch(requestPath)
+	if matches == nil {
+		return M.Socksaddr{}, E.New("path mismatch: ", requestPath)
+	}
+	host, err := url.PathUnescape(matches[m.regexp.SubexpIndex("host")])
+	if err != nil {
+		return M.Socksaddr{}, E.Cause(err, "unescape target host")
+	}
+	port, err := strconv.ParseUint(matches[m.regexp.SubexpIndex("port")], 10, 16)
+	if err != nil || port == 0 {
+		return M.Socksaddr{}, E.New("invalid target port: ", matches[m.regexp.SubexpIndex("port")])
+	}
+	destination := M.ParseSocksaddrHostPort(host, uint16(port))
+	if !destination.IsValid() {
+		return M.Socksaddr{}, E.New("invalid target host: ", host)
+	}
+	return destination, nil
+}
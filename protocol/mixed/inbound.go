@@ -35,6 +35,7 @@ type Inbound struct {
 	router        adapter.ConnectionRouterEx
 	logger        log.ContextLogger
 	listener      *listener.Listener
+	users         []auth.User
 	authenticator *auth.Authenticator
 	tlsConfig     tls.ServerConfig
 	udpTimeout    time.Duration
@@ -51,6 +52,7 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		Adapter:       inbound.NewAdapter(C.TypeMixed, tag),
 		router:        uot.NewRouter(router, logger),
 		logger:        logger,
+		users:         options.Users,
 		authenticator: auth.NewAuthenticator(options.Users),
 		udpTimeout:    udpTimeout,
 	}
@@ -116,8 +118,14 @@ func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata ada
 		if err != nil {
 			return E.Cause(err, "TLS handshake")
 		}
+		tls.ApplyClientCertificate(h.tlsConfig, tlsConn, &metadata)
 		conn = tlsConn
 	}
+	authenticator := h.authenticator
+	if user, loaded := inbound.ClientCertificateUser(&metadata, h.users); loaded {
+		ctx = auth.ContextWithUser(ctx, user)
+		authenticator = nil
+	}
 	reader := std_bufio.NewReader(conn)
 	headerBytes, err := reader.Peek(1)
 	if err != nil {
@@ -125,9 +133,9 @@ func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata ada
 	}
 	switch headerBytes[0] {
 	case socks4.Version, socks5.Version:
-		return socks.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), h.listener, h.udpTimeout, metadata.Source, onClose)
+		return socks.HandleConnectionEx(ctx, conn, reader, authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), h.listener, h.udpTimeout, metadata.Source, onClose)
 	default:
-		return http.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), metadata.Source, onClose)
+		return http.HandleConnectionEx(ctx, conn, reader, authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), metadata.Source, onClose)
 	}
 }
 
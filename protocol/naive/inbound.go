@@ -6,9 +6,12 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httputil"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/tls"
 	"github.com/sagernet/sing-box/common/uot"
@@ -48,6 +51,8 @@ type Inbound struct {
 	tlsConfig        tls.ServerConfig
 	httpServer       *http.Server
 	h3Server         io.Closer
+	fallback         *fallback.Handler
+	fallbackClient   *http.Transport
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.NaiveInboundOptions) (adapter.Inbound, error) {
@@ -73,6 +78,25 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 	if len(options.Users) == 0 {
 		return nil, E.New("missing users")
 	}
+	fallbackHandler, err := fallback.New(inbound, router, logger, options.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	if fallbackHandler != nil {
+		if fallbackHandler.HasInboundTarget() {
+			return nil, E.New("fallback to inbound is not supported by naive")
+		}
+		fallbackDialer, err := dialer.NewDefault(ctx, option.DialerOptions{})
+		if err != nil {
+			return nil, err
+		}
+		inbound.fallback = fallbackHandler
+		inbound.fallbackClient = &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return fallbackDialer.DialContext(ctx, network, M.ParseSocksaddr(address))
+			},
+		}
+	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
 		if err != nil {
@@ -136,6 +160,9 @@ func (n *Inbound) Start(stage adapter.StartStage) error {
 }
 
 func (n *Inbound) Close() error {
+	if n.fallbackClient != nil {
+		n.fallbackClient.CloseIdleConnections()
+	}
 	return common.Close(
 		&n.listener,
 		common.PtrOrNil(n.httpServer),
@@ -147,12 +174,10 @@ func (n *Inbound) Close() error {
 func (n *Inbound) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	ctx := log.ContextWithNewID(request.Context())
 	if request.Method != "CONNECT" {
-		rejectHTTP(writer, http.StatusBadRequest)
-		n.badRequest(ctx, request, E.New("not CONNECT request"))
+		n.rejectOrFallback(ctx, writer, request, http.StatusBadRequest, E.New("not CONNECT request"))
 		return
 	} else if request.Header.Get("Padding") == "" {
-		rejectHTTP(writer, http.StatusBadRequest)
-		n.badRequest(ctx, request, E.New("missing naive padding"))
+		n.rejectOrFallback(ctx, writer, request, http.StatusBadRequest, E.New("missing naive padding"))
 		return
 	}
 	userName, password, authOk := sHttp.ParseBasicAuth(request.Header.Get("Proxy-Authorization"))
@@ -160,8 +185,7 @@ func (n *Inbound) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		authOk = n.authenticator.Verify(userName, password)
 	}
 	if !authOk {
-		rejectHTTP(writer, http.StatusProxyAuthRequired)
-		n.badRequest(ctx, request, E.New("authorization failed"))
+		n.rejectOrFallback(ctx, writer, request, http.StatusProxyAuthRequired, E.New("authorization failed"))
 		return
 	}
 	writer.Header().Set("Padding", generatePaddingHeader())
@@ -226,6 +250,37 @@ func (n *Inbound) newConnection(ctx context.Context, waitForClose bool, conn net
 	}
 }
 
+func (n *Inbound) rejectOrFallback(ctx context.Context, writer http.ResponseWriter, request *http.Request, statusCode int, err error) {
+	if n.fallback != nil {
+		var serverName, nextProto string
+		if request.TLS != nil {
+			serverName = request.TLS.ServerName
+			nextProto = request.TLS.NegotiatedProtocol
+		}
+		target, loaded := n.fallback.Select(serverName, nextProto, request.URL.Path)
+		if loaded {
+			n.logger.DebugContext(ctx, E.Cause(err, "process connection from ", request.RemoteAddr))
+			n.logger.InfoContext(ctx, "fallback request to ", target)
+			reverseProxy := &httputil.ReverseProxy{
+				Rewrite: func(proxyRequest *httputil.ProxyRequest) {
+					proxyRequest.Out.URL.Scheme = "http"
+					proxyRequest.Out.URL.Host = target.Server.String()
+					proxyRequest.Out.Host = proxyRequest.In.Host
+				},
+				Transport: n.fallbackClient,
+				ErrorHandler: func(writer http.ResponseWriter, request *http.Request, err error) {
+					n.logger.DebugContext(ctx, E.Cause(err, "fallback request to ", target))
+					writer.WriteHeader(http.StatusBadGateway)
+				},
+			}
+			reverseProxy.ServeHTTP(writer, request)
+			return
+		}
+	}
+	rejectHTTP(writer, statusCode)
+	n.badRequest(ctx, request, err)
+}
+
 func (n *Inbound) badRequest(ctx context.Context, request *http.Request, err error) {
 	n.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", request.RemoteAddr))
 }
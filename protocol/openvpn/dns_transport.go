@@ -0,0 +1,116 @@
+package openvpn
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/dns/transport"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/service"
+
+	mDNS "github.com/miekg/dns"
+)
+
+func RegisterTransport(registry *dns.TransportRegistry) {
+	dns.RegisterTransport[option.OpenVPNDNSServerOptions](registry, C.DNSTypeOpenVPN, NewDNSTransport)
+}
+
+type DNSTransport struct {
+	dns.TransportAdapter
+	logger          logger.ContextLogger
+	endpointTag     string
+	endpointManager adapter.EndpointManager
+	endpoint        *Endpoint
+	access          sync.Mutex
+	transports      map[netip.Addr]*transport.UDPTransport
+}
+
+func NewDNSTransport(ctx context.Context, logger log.ContextLogger, tag string, options option.OpenVPNDNSServerOptions) (adapter.DNSTransport, error) {
+	if options.Endpoint == "" {
+		return nil, E.New("missing OpenVPN endpoint tag")
+	}
+	return &DNSTransport{
+		TransportAdapter: dns.NewTransportAdapter(C.DNSTypeOpenVPN, tag, nil),
+		logger:           logger,
+		endpointTag:      options.Endpoint,
+		endpointManager:  service.FromContext[adapter.EndpointManager](ctx),
+		transports:       make(map[netip.Addr]*transport.UDPTransport),
+	}, nil
+}
+
+func (t *DNSTransport) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateInitialize {
+		return nil
+	}
+	rawEndpoint, loaded := t.endpointManager.Get(t.endpointTag)
+	if !loaded {
+		return E.New("endpoint not found: ", t.endpointTag)
+	}
+	ep, isOpenVPN := rawEndpoint.(*Endpoint)
+	if !isOpenVPN {
+		return E.New("endpoint is not OpenVPN: ", t.endpointTag)
+	}
+	t.endpoint = ep
+	return nil
+}
+
+func (t *DNSTransport) Close() error {
+	t.access.Lock()
+	defer t.access.Unlock()
+	for _, serverTransport := range t.transports {
+		serverTransport.Close()
+	}
+	clear(t.transports)
+	return nil
+}
+
+func (t *DNSTransport) Reset() {
+	t.access.Lock()
+	defer t.access.Unlock()
+	for _, serverTransport := range t.transports {
+		serverTransport.Reset()
+	}
+}
+
+func (t *DNSTransport) Raw() bool {
+	return true
+}
+
+func (t *DNSTransport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	servers, err := t.endpoint.DNSServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, E.New("no DNS servers pushed by the OpenVPN server")
+	}
+	var lastErr error
+	for _, server := range servers {
+		response, err := t.loadTransport(server).Exchange(ctx, message)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return response, nil
+	}
+	return nil, lastErr
+}
+
+func (t *DNSTransport) loadTransport(server netip.Addr) *transport.UDPTransport {
+	t.access.Lock()
+	defer t.access.Unlock()
+	serverTransport, loaded := t.transports[server]
+	if !loaded {
+		serverTransport = transport.NewUDPRaw(t.logger, t.TransportAdapter, t.endpoint, M.SocksaddrFrom(server, 53))
+		t.transports[server] = serverTransport
+	}
+	return serverTransport
+}
@@ -0,0 +1,487 @@
+package openvpn
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/endpoint"
+	"github.com/sagernet/sing-box/common/dialer"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing-box/transport/openvpn"
+	"github.com/sagernet/sing-box/transport/wireguard"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+var (
+	_ adapter.OutboundWithPreferredRoutes = (*Endpoint)(nil)
+	_ adapter.DirectRouteOutbound         = (*Endpoint)(nil)
+	_ dialer.PacketDialerWithDestination  = (*Endpoint)(nil)
+)
+
+const (
+	connectRetryInitial = 5 * time.Second
+	connectRetryMax     = 5 * time.Minute
+)
+
+func RegisterEndpoint(registry *endpoint.Registry) {
+	endpoint.Register[option.OpenVPNEndpointOptions](registry, C.TypeOpenVPN, NewEndpoint)
+}
+
+type Endpoint struct {
+	endpoint.Adapter
+	ctx        context.Context
+	cancel     context.CancelFunc
+	router     adapter.Router
+	dnsRouter  adapter.DNSRouter
+	logger     logger.ContextLogger
+	dialer     N.Dialer
+	config     *openvpn.Config
+	username   string
+	password   string
+	mtu        uint32
+	udpTimeout time.Duration
+	ready      chan struct{}
+	readyOnce  sync.Once
+	access     sync.RWMutex
+	client     *openvpn.Client
+	device     wireguard.NatDevice
+	addresses  []netip.Prefix
+	routes     []netip.Prefix
+	dnsServers []netip.Addr
+}
+
+func NewEndpoint(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.OpenVPNEndpointOptions) (adapter.Endpoint, error) {
+	var (
+		content string
+		baseDir string
+	)
+	if len(options.Config) > 0 {
+		if options.ConfigPath != "" {
+			return nil, E.New("`config` is conflict with `config_path`")
+		}
+		content = strings.Join(options.Config, "\n")
+	} else if options.ConfigPath != "" {
+		configPath := os.ExpandEnv(options.ConfigPath)
+		configContent, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, E.Cause(err, "read config")
+		}
+		content = string(configContent)
+		baseDir = filepath.Dir(configPath)
+	} else {
+		return nil, E.New("missing config")
+	}
+	config, err := openvpn.ParseConfig(content, baseDir)
+	if err != nil {
+		return nil, E.Cause(err, "parse config")
+	}
+	outboundDialer, err := dialer.NewWithOptions(dialer.Options{
+		Context: ctx,
+		Options: options.DialerOptions,
+		RemoteIsDomain: common.Any(config.Remotes, func(it openvpn.Remote) bool {
+			return !M.ParseAddr(it.Host).IsValid()
+		}),
+		ResolverOnDetour: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var udpTimeout time.Duration
+	if options.UDPTimeout != 0 {
+		udpTimeout = time.Duration(options.UDPTimeout)
+	} else {
+		udpTimeout = C.UDPTimeout
+	}
+	ep := &Endpoint{
+		Adapter:    endpoint.NewAdapterWithDialerOptions(C.TypeOpenVPN, tag, []string{N.NetworkTCP, N.NetworkUDP, N.NetworkICMP}, options.DialerOptions),
+		router:     router,
+		dnsRouter:  service.FromContext[adapter.DNSRouter](ctx),
+		logger:     logger,
+		dialer:     outboundDialer,
+		config:     config,
+		username:   options.Username,
+		password:   options.Password,
+		mtu:        options.MTU,
+		udpTimeout: udpTimeout,
+		ready:      make(chan struct{}),
+	}
+	ep.ctx, ep.cancel = context.WithCancel(ctx)
+	if config.AuthUserPass && ep.username == "" && config.Username == "" {
+		return nil, E.New("missing username")
+	}
+	return ep, nil
+}
+
+func (e *Endpoint) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	go e.loopConnect()
+	return nil
+}
+
+func (e *Endpoint) Close() error {
+	e.cancel()
+	e.access.Lock()
+	defer e.access.Unlock()
+	if e.client != nil {
+		e.client.Close()
+	}
+	if e.device != nil {
+		e.device.Close()
+	}
+	return nil
+}
+
+func (e *Endpoint) loopConnect() {
+	remotes := slices.Clone(e.config.Remotes)
+	if e.config.RemoteRandom {
+		rand.Shuffle(len(remotes), func(i, j int) {
+			remotes[i], remotes[j] = remotes[j], remotes[i]
+		})
+	}
+	retryDelay := connectRetryInitial
+	var index int
+	for {
+		remote := remotes[index]
+		connected, err := e.connect(remote)
+		if e.ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// reconnect to the same remote first
+			retryDelay = connectRetryInitial
+		} else {
+			index = (index + 1) % len(remotes)
+		}
+		if err != nil {
+			e.logger.Error(E.Cause(err, "connection to ", remote), ", retrying in ", retryDelay)
+		} else {
+			e.logger.Info("disconnected from ", remote, ", reconnecting in ", retryDelay)
+		}
+		select {
+		case <-time.After(retryDelay):
+		case <-e.ctx.Done():
+			return
+		}
+		if !connected {
+			retryDelay = min(retryDelay*2, connectRetryMax)
+		}
+	}
+}
+
+func (e *Endpoint) connect(remote openvpn.Remote) (bool, error) {
+	e.logger.Info("connecting to ", remote)
+	client, err := openvpn.NewClient(openvpn.ClientOptions{
+		Context:  e.ctx,
+		Logger:   e.logger,
+		Dialer:   e.dialer,
+		Config:   e.config,
+		Remote:   remote,
+		Username: e.username,
+		Password: e.password,
+		Handler:  e.handlePacket,
+	})
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, openvpn.HandshakeTimeout)
+	pushReply, err := client.Start(ctx)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	err = e.updateDevice(client, pushReply)
+	if err != nil {
+		client.Close()
+		return false, err
+	}
+	e.logger.Info("connected to ", remote, ", local address: ", pushReply.Address)
+	<-client.Done()
+	e.access.Lock()
+	if e.client == client {
+		e.client = nil
+	}
+	e.access.Unlock()
+	return true, client.Err()
+}
+
+func (e *Endpoint) updateDevice(client *openvpn.Client, pushReply *openvpn.PushReply) error {
+	mtu := e.mtu
+	if mtu == 0 {
+		if pushReply.TunMTU > 0 {
+			mtu = uint32(pushReply.TunMTU)
+		} else {
+			mtu = uint32(e.config.TunMTU)
+		}
+	}
+	var routes []netip.Prefix
+	if !e.config.RouteNoPull {
+		routes = append(routes, pushReply.Routes...)
+	}
+	routes = append(routes, e.config.Routes...)
+	routes = append(routes, common.Map(pushReply.Address, netip.Prefix.Masked)...)
+	e.access.Lock()
+	defer e.access.Unlock()
+	if e.device == nil || !slices.Equal(e.addresses, pushReply.Address) {
+		device, err := wireguard.NewDevice(wireguard.DeviceOptions{
+			Context:    e.ctx,
+			Logger:     e.logger,
+			Handler:    e,
+			UDPTimeout: e.udpTimeout,
+			MTU:        mtu,
+			Address:    pushReply.Address,
+		})
+		if err != nil {
+			return E.Cause(err, "create device")
+		}
+		if e.device != nil {
+			e.device.Close()
+		}
+		e.device = device.(wireguard.NatDevice)
+		e.addresses = pushReply.Address
+		go e.loopDevice(e.device)
+	}
+	e.client = client
+	e.routes = routes
+	e.dnsServers = pushReply.DNSServers
+	e.readyOnce.Do(func() {
+		close(e.ready)
+	})
+	return nil
+}
+
+func (e *Endpoint) loopDevice(device wireguard.Device) {
+	mtu, _ := device.MTU()
+	buffers := [][]byte{make([]byte, mtu)}
+	sizes := make([]int, 1)
+	for {
+		_, err := device.Read(buffers, sizes, 0)
+		if err != nil {
+			return
+		}
+		e.access.RLock()
+		client := e.client
+		e.access.RUnlock()
+		if client == nil {
+			continue
+		}
+		err = client.WritePacket(buffers[0][:sizes[0]])
+		if err != nil {
+			e.logger.Trace(E.Cause(err, "write packet"))
+		}
+	}
+}
+
+func (e *Endpoint) handlePacket(packet []byte) {
+	e.access.RLock()
+	device := e.device
+	e.access.RUnlock()
+	if device == nil {
+		return
+	}
+	_, err := device.Write([][]byte{packet}, 0)
+	if err != nil {
+		e.logger.Trace(E.Cause(err, "write packet to device"))
+	}
+}
+
+func (e *Endpoint) loadDevice(ctx context.Context) (wireguard.NatDevice, error) {
+	select {
+	case <-e.ready:
+	case <-ctx.Done():
+		return nil, E.Cause(ctx.Err(), "OpenVPN endpoint not ready")
+	}
+	e.access.RLock()
+	defer e.access.RUnlock()
+	if e.device == nil {
+		return nil, os.ErrClosed
+	}
+	return e.device, nil
+}
+
+// DNSServers returns the DNS servers pushed by the server.
+func (e *Endpoint) DNSServers(ctx context.Context) ([]netip.Addr, error) {
+	_, err := e.loadDevice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.access.RLock()
+	defer e.access.RUnlock()
+	return e.dnsServers, nil
+}
+
+func (e *Endpoint) PrepareConnection(network string, source M.Socksaddr, destination M.Socksaddr, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
+	var ipVersion uint8
+	if !destination.IsIPv6() {
+		ipVersion = 4
+	} else {
+		ipVersion = 6
+	}
+	routeDestination, err := e.router.PreMatch(adapter.InboundContext{
+		Inbound:     e.Tag(),
+		InboundType: e.Type(),
+		IPVersion:   ipVersion,
+		Network:     network,
+		Source:      source,
+		Destination: destination,
+	}, routeContext, timeout, false)
+	if err != nil {
+		switch {
+		case rule.IsBypassed(err):
+			err = nil
+		case rule.IsRejected(err):
+			e.logger.Trace("reject ", network, " connection from ", source.AddrString(), " to ", destination.AddrString())
+		default:
+			if network == N.NetworkICMP {
+				e.logger.Warn(E.Cause(err, "link ", network, " connection from ", source.AddrString(), " to ", destination.AddrString()))
+			}
+		}
+	}
+	return routeDestination, err
+}
+
+func (e *Endpoint) isLocalAddress(address netip.Addr) bool {
+	e.access.RLock()
+	defer e.access.RUnlock()
+	return common.Any(e.addresses, func(it netip.Prefix) bool {
+		return it.Addr() == address
+	})
+}
+
+func (e *Endpoint) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	var metadata adapter.InboundContext
+	metadata.Inbound = e.Tag()
+	metadata.InboundType = e.Type()
+	metadata.Source = source
+	if e.isLocalAddress(destination.Addr) {
+		metadata.OriginDestination = destination
+		if destination.Addr.Is4() {
+			destination.Addr = netip.AddrFrom4([4]uint8{127, 0, 0, 1})
+		} else {
+			destination.Addr = netip.IPv6Loopback()
+		}
+	}
+	metadata.Destination = destination
+	e.logger.InfoContext(ctx, "inbound connection from ", source)
+	e.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
+	e.router.RouteConnectionEx(ctx, conn, metadata, onClose)
+}
+
+func (e *Endpoint) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
+	var metadata adapter.InboundContext
+	metadata.Inbound = e.Tag()
+	metadata.InboundType = e.Type()
+	metadata.Source = source
+	metadata.Destination = destination
+	if e.isLocalAddress(destination.Addr) {
+		metadata.OriginDestination = destination
+		if destination.Addr.Is4() {
+			metadata.Destination.Addr = netip.AddrFrom4([4]uint8{127, 0, 0, 1})
+		} else {
+			metadata.Destination.Addr = netip.IPv6Loopback()
+		}
+		conn = bufio.NewNATPacketConn(bufio.NewNetPacketConn(conn), metadata.OriginDestination, metadata.Destination)
+	}
+	e.logger.InfoContext(ctx, "inbound packet connection from ", source)
+	e.logger.InfoContext(ctx, "inbound packet connection to ", destination)
+	e.router.RoutePacketConnectionEx(ctx, conn, metadata, onClose)
+}
+
+func (e *Endpoint) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	switch network {
+	case N.NetworkTCP:
+		e.logger.InfoContext(ctx, "outbound connection to ", destination)
+	case N.NetworkUDP:
+		e.logger.InfoContext(ctx, "outbound packet connection to ", destination)
+	}
+	device, err := e.loadDevice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if destination.IsFqdn() {
+		destinationAddresses, err := e.dnsRouter.Lookup(ctx, destination.Fqdn, adapter.DNSQueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return N.DialSerial(ctx, device, network, destination, destinationAddresses)
+	} else if !destination.Addr.IsValid() {
+		return nil, E.New("invalid destination: ", destination)
+	}
+	return device.DialContext(ctx, network, destination)
+}
+
+func (e *Endpoint) ListenPacketWithDestination(ctx context.Context, destination M.Socksaddr) (net.PacketConn, netip.Addr, error) {
+	e.logger.InfoContext(ctx, "outbound packet connection to ", destination)
+	device, err := e.loadDevice(ctx)
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	if destination.IsFqdn() {
+		destinationAddresses, err := e.dnsRouter.Lookup(ctx, destination.Fqdn, adapter.DNSQueryOptions{})
+		if err != nil {
+			return nil, netip.Addr{}, err
+		}
+		return N.ListenSerial(ctx, device, destination, destinationAddresses)
+	}
+	packetConn, err := device.ListenPacket(ctx, destination)
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	if destination.IsIP() {
+		return packetConn, destination.Addr, nil
+	}
+	return packetConn, netip.Addr{}, nil
+}
+
+func (e *Endpoint) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	packetConn, destinationAddress, err := e.ListenPacketWithDestination(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	if destinationAddress.IsValid() && destination != M.SocksaddrFrom(destinationAddress, destination.Port) {
+		return bufio.NewNATPacketConn(bufio.NewPacketConn(packetConn), M.SocksaddrFrom(destinationAddress, destination.Port), destination), nil
+	}
+	return packetConn, nil
+}
+
+func (e *Endpoint) PreferredDomain(domain string) bool {
+	return false
+}
+
+func (e *Endpoint) PreferredAddress(address netip.Addr) bool {
+	e.access.RLock()
+	defer e.access.RUnlock()
+	return common.Any(e.routes, func(it netip.Prefix) bool {
+		return it.Contains(address)
+	})
+}
+
+func (e *Endpoint) NewDirectRouteConnection(metadata adapter.InboundContext, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
+	e.access.RLock()
+	device := e.device
+	e.access.RUnlock()
+	if device == nil {
+		return nil, E.New("OpenVPN endpoint not ready")
+	}
+	return device.CreateDestination(metadata, routeContext, timeout)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
 	"github.com/sagernet/sing-box/common/uot"
@@ -53,6 +54,7 @@ type Inbound struct {
 	logger   logger.ContextLogger
 	listener *listener.Listener
 	service  shadowsocks.Service
+	fallback *fallback.Handler
 }
 
 func newInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*Inbound, error) {
@@ -63,6 +65,10 @@ func newInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		logger:  logger,
 	}
 	var err error
+	inbound.fallback, err = fallback.New(inbound, router, logger, options.Fallback)
+	if err != nil {
+		return nil, err
+	}
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
 	if err != nil {
 		return nil, err
@@ -108,7 +114,11 @@ func (h *Inbound) Close() error {
 
 //nolint:staticcheck
 func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx, conn = h.fallback.Record(ctx, conn)
 	err := h.service.NewConnection(ctx, conn, adapter.UpstreamMetadata(metadata))
+	if err != nil && h.fallback.HandleError(ctx, conn, metadata, onClose, err) {
+		return
+	}
 	N.CloseOnHandshakeFailure(conn, onClose, err)
 	if err != nil {
 		if E.IsClosedOrCanceled(err) {
@@ -128,6 +138,7 @@ func (h *Inbound) NewPacketEx(buffer *buf.Buffer, source M.Socksaddr) {
 }
 
 func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	fallback.Commit(ctx)
 	h.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
 	metadata.Inbound = h.Tag()
 	metadata.InboundType = h.Type()
@@ -8,6 +8,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
 	"github.com/sagernet/sing-box/common/uot"
@@ -42,6 +43,7 @@ type MultiInbound struct {
 	service  shadowsocks.MultiService[int]
 	users    []option.ShadowsocksUser
 	tracker  adapter.SSMTracker
+	fallback *fallback.Handler
 }
 
 func newMultiInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*MultiInbound, error) {
@@ -52,6 +54,10 @@ func newMultiInbound(ctx context.Context, router adapter.Router, logger log.Cont
 		logger:  logger,
 	}
 	var err error
+	inbound.fallback, err = fallback.New(inbound, router, logger, options.Fallback)
+	if err != nil {
+		return nil, err
+	}
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
 	if err != nil {
 		return nil, err
@@ -139,7 +145,11 @@ func (h *MultiInbound) UpdateUsers(users []string, uPSKs []string) error {
 
 //nolint:staticcheck
 func (h *MultiInbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx, conn = h.fallback.Record(ctx, conn)
 	err := h.service.NewConnection(ctx, conn, adapter.UpstreamMetadata(metadata))
+	if err != nil && h.fallback.HandleError(ctx, conn, metadata, onClose, err) {
+		return
+	}
 	N.CloseOnHandshakeFailure(conn, onClose, err)
 	if err != nil {
 		if E.IsClosedOrCanceled(err) {
@@ -159,6 +169,7 @@ func (h *MultiInbound) NewPacketEx(buffer *buf.Buffer, source M.Socksaddr) {
 }
 
 func (h *MultiInbound) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	fallback.Commit(ctx)
 	userIndex, loaded := auth.UserFromContext[int](ctx)
 	if !loaded {
 		return os.ErrInvalid
@@ -8,6 +8,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
 	"github.com/sagernet/sing-box/common/uot"
@@ -35,6 +36,7 @@ type RelayInbound struct {
 	listener     *listener.Listener
 	service      *shadowaead_2022.RelayService[int]
 	destinations []option.ShadowsocksDestination
+	fallback     *fallback.Handler
 }
 
 func newRelayInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*RelayInbound, error) {
@@ -46,6 +48,10 @@ func newRelayInbound(ctx context.Context, router adapter.Router, logger log.Cont
 		destinations: options.Destinations,
 	}
 	var err error
+	inbound.fallback, err = fallback.New(inbound, router, logger, options.Fallback)
+	if err != nil {
+		return nil, err
+	}
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
 	if err != nil {
 		return nil, err
@@ -99,7 +105,11 @@ func (h *RelayInbound) Close() error {
 
 //nolint:staticcheck
 func (h *RelayInbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx, conn = h.fallback.Record(ctx, conn)
 	err := h.service.NewConnection(ctx, conn, adapter.UpstreamMetadata(metadata))
+	if err != nil && h.fallback.HandleError(ctx, conn, metadata, onClose, err) {
+		return
+	}
 	N.CloseOnHandshakeFailure(conn, onClose, err)
 	if err != nil {
 		if E.IsClosedOrCanceled(err) {
@@ -119,6 +129,7 @@ func (h *RelayInbound) NewPacketEx(buffer *buf.Buffer, source M.Socksaddr) {
 }
 
 func (h *RelayInbound) newConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) error {
+	fallback.Commit(ctx)
 	destinationIndex, loaded := auth.UserFromContext[int](ctx)
 	if !loaded {
 		return os.ErrInvalid
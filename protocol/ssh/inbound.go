@@ -0,0 +1,345 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/pem"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/listener"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.SSHInboundOptions](registry, C.TypeSSH, NewInbound)
+}
+
+var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
+
+type Inbound struct {
+	inbound.Adapter
+	router   adapter.ConnectionRouterEx
+	logger   log.ContextLogger
+	listener *listener.Listener
+	users    map[string]*sshUser
+	config   *ssh.ServerConfig
+}
+
+type sshUser struct {
+	password       string
+	authorizedKeys [][]byte
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.SSHInboundOptions) (adapter.Inbound, error) {
+	if len(options.Users) == 0 {
+		return nil, E.New("missing users")
+	}
+	inbound := &Inbound{
+		Adapter: inbound.NewAdapter(C.TypeSSH, tag),
+		router:  router,
+		logger:  logger,
+		users:   make(map[string]*sshUser),
+	}
+	for i, user := range options.Users {
+		if user.Name == "" {
+			return nil, E.New("missing name for user[", i, "]")
+		}
+		if user.Password == "" && len(user.AuthorizedKeys) == 0 {
+			return nil, E.New("missing password or authorized keys for user ", user.Name)
+		}
+		if inbound.users[user.Name] != nil {
+			return nil, E.New("duplicate user: ", user.Name)
+		}
+		userEntry := &sshUser{password: user.Password}
+		for _, authorizedKey := range user.AuthorizedKeys {
+			publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+			if err != nil {
+				return nil, E.Cause(err, "parse authorized key for user ", user.Name)
+			}
+			userEntry.authorizedKeys = append(userEntry.authorizedKeys, publicKey.Marshal())
+		}
+		inbound.users[user.Name] = userEntry
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback:  inbound.passwordCallback,
+		PublicKeyCallback: inbound.publicKeyCallback,
+		ServerVersion:     options.ServerVersion,
+	}
+	if config.ServerVersion == "" {
+		config.ServerVersion = randomVersion()
+	}
+	hostKeys, err := loadHostKeys(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostKeys) == 0 {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.NewSignerFromKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		logger.Warn("host key not configured, generated ephemeral host key ", ssh.FingerprintSHA256(signer.PublicKey()))
+		hostKeys = append(hostKeys, signer)
+	}
+	for _, hostKey := range hostKeys {
+		config.AddHostKey(hostKey)
+	}
+	inbound.config = config
+	inbound.listener = listener.New(listener.Options{
+		Context:           ctx,
+		Logger:            logger,
+		Network:           []string{N.NetworkTCP},
+		Listen:            options.ListenOptions,
+		ConnectionHandler: inbound,
+	})
+	return inbound, nil
+}
+
+func loadHostKeys(ctx context.Context, options option.SSHInboundOptions) ([]ssh.Signer, error) {
+	var hostKeys []ssh.Signer
+	if len(options.HostKey) > 0 {
+		signers, err := parseHostKeys([]byte(strings.Join(options.HostKey, "\n")))
+		if err != nil {
+			return nil, E.Cause(err, "parse host key")
+		}
+		hostKeys = append(hostKeys, signers...)
+	}
+	for _, hostKeyPath := range options.HostKeyPath {
+		content, err := os.ReadFile(filemanager.BasePath(ctx, hostKeyPath))
+		if err != nil {
+			return nil, E.Cause(err, "read host key")
+		}
+		signers, err := parseHostKeys(content)
+		if err != nil {
+			return nil, E.Cause(err, "parse host key ", hostKeyPath)
+		}
+		hostKeys = append(hostKeys, signers...)
+	}
+	return hostKeys, nil
+}
+
+func parseHostKeys(content []byte) ([]ssh.Signer, error) {
+	var signers []ssh.Signer
+	for {
+		block, rest := pem.Decode(content)
+		if block == nil {
+			break
+		}
+		signer, err := ssh.ParsePrivateKey(pem.EncodeToMemory(block))
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+		content = rest
+	}
+	if len(signers) == 0 {
+		return nil, E.New("no PEM encoded private key found")
+	}
+	return signers, nil
+}
+
+func (h *Inbound) passwordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	user := h.users[conn.User()]
+	if user == nil || user.password == "" || subtle.ConstantTimeCompare([]byte(user.password), password) != 1 {
+		return nil, E.New("password rejected for ", conn.User())
+	}
+	return nil, nil
+}
+
+func (h *Inbound) publicKeyCallback(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	user := h.users[conn.User()]
+	if user != nil {
+		publicKey := key.Marshal()
+		for _, authorizedKey := range user.authorizedKeys {
+			if bytes.Equal(authorizedKey, publicKey) {
+				return nil, nil
+			}
+		}
+	}
+	return nil, E.New("unknown public key for ", conn.User())
+}
+
+func (h *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	return h.listener.Start()
+}
+
+func (h *Inbound) Close() error {
+	return h.listener.Close()
+}
+
+func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, h.config)
+	if err != nil {
+		N.CloseOnHandshakeFailure(conn, onClose, err)
+		h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source))
+		return
+	}
+	// global requests such as tcpip-forward (ssh -R) are not supported
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "direct-tcpip":
+			go h.newDirectTCPIP(ctx, serverConn, newChannel, metadata)
+		case "session":
+			h.logger.DebugContext(ctx, "[", serverConn.User(), "] rejected session from ", metadata.Source)
+			newChannel.Reject(ssh.Prohibited, "shell and exec sessions are not supported")
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type: "+newChannel.ChannelType())
+		}
+	}
+	serverConn.Close()
+	if onClose != nil {
+		onClose(nil)
+	}
+}
+
+// RFC 4254 7.2
+type directTCPIPPayload struct {
+	Host       string
+	Port       uint32
+	OriginHost string
+	OriginPort uint32
+}
+
+func (h *Inbound) newDirectTCPIP(ctx context.Context, serverConn *ssh.ServerConn, newChannel ssh.NewChannel, metadata adapter.InboundContext) {
+	var payload directTCPIPPayload
+	err := ssh.Unmarshal(newChannel.ExtraData(), &payload)
+	if err != nil || payload.Port > 65535 {
+		newChannel.Reject(ssh.ConnectionFailed, "invalid direct-tcpip payload")
+		return
+	}
+	ctx = log.ContextWithNewID(ctx)
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	metadata.Destination = M.ParseSocksaddrHostPort(payload.Host, uint16(payload.Port))
+	metadata.User = serverConn.User()
+	h.logger.InfoContext(ctx, "[", metadata.User, "] inbound connection to ", metadata.Destination)
+	conn := &channelConn{
+		newChannel: newChannel,
+		localAddr:  serverConn.LocalAddr(),
+		remoteAddr: serverConn.RemoteAddr(),
+	}
+	h.router.RouteConnectionEx(ctx, conn, metadata, nil)
+}
+
+// channelConn accepts the direct-tcpip channel once the route succeeds,
+// so that clients see a rejected channel instead of a closed one when the destination is not reachable.
+type channelConn struct {
+	newChannel ssh.NewChannel
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	access     sync.Mutex
+	channel    ssh.Channel
+	err        error
+}
+
+func (c *channelConn) accept() (ssh.Channel, error) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.channel != nil || c.err != nil {
+		return c.channel, c.err
+	}
+	channel, requests, err := c.newChannel.Accept()
+	if err != nil {
+		c.err = err
+		return nil, err
+	}
+	go ssh.DiscardRequests(requests)
+	c.channel = channel
+	return channel, nil
+}
+
+func (c *channelConn) HandshakeSuccess() error {
+	_, err := c.accept()
+	return err
+}
+
+func (c *channelConn) HandshakeFailure(err error) error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.channel != nil || c.err != nil {
+		return os.ErrInvalid
+	}
+	c.err = net.ErrClosed
+	return c.newChannel.Reject(ssh.ConnectionFailed, err.Error())
+}
+
+func (c *channelConn) Read(p []byte) (n int, err error) {
+	channel, err := c.accept()
+	if err != nil {
+		return
+	}
+	return channel.Read(p)
+}
+
+func (c *channelConn) Write(p []byte) (n int, err error) {
+	channel, err := c.accept()
+	if err != nil {
+		return
+	}
+	return channel.Write(p)
+}
+
+func (c *channelConn) CloseWrite() error {
+	channel, err := c.accept()
+	if err != nil {
+		return err
+	}
+	return channel.CloseWrite()
+}
+
+func (c *channelConn) Close() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.channel != nil {
+		return c.channel.Close()
+	}
+	if c.err == nil {
+		c.err = net.ErrClosed
+		return c.newChannel.Reject(ssh.ConnectionFailed, "connection closed")
+	}
+	return nil
+}
+
+func (c *channelConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *channelConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *channelConn) SetDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *channelConn) SetReadDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
+
+func (c *channelConn) SetWriteDeadline(t time.Time) error {
+	return os.ErrInvalid
+}
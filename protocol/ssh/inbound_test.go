@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+type testRouter struct {
+	adapter.Router
+}
+
+// RouteConnectionEx echoes connections to port 7 and rejects the others.
+func (r *testRouter) RouteConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	if metadata.Destination.Port != 7 {
+		N.CloseOnHandshakeFailure(conn, onClose, E.New("connection refused"))
+		return
+	}
+	err := N.ReportHandshakeSuccess(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go func() {
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+}
+
+// newTestConn connects a client to the inbound over loopback,
+// since the version exchange of SSH deadlocks on synchronous pipes.
+func newTestConn(t *testing.T, inbound adapter.Inbound) net.Conn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		clientConn.Close()
+	})
+	serverConn, err := listener.Accept()
+	require.NoError(t, err)
+	go inbound.(*Inbound).NewConnectionEx(context.Background(), serverConn, adapter.InboundContext{}, nil)
+	return clientConn
+}
+
+func writeHostKey(t *testing.T, path string) ssh.PublicKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	return signer.PublicKey()
+}
+
+func TestHostKeyPath(t *testing.T) {
+	t.Parallel()
+	basePath := t.TempDir()
+	publicKey := writeHostKey(t, filepath.Join(basePath, "host_key"))
+	ctx := filemanager.WithDefault(context.Background(), basePath, "", os.Getuid(), os.Getgid())
+	hostKeys, err := loadHostKeys(ctx, option.SSHInboundOptions{HostKeyPath: []string{"host_key"}})
+	require.NoError(t, err)
+	require.Len(t, hostKeys, 1)
+	require.Equal(t, publicKey.Marshal(), hostKeys[0].PublicKey().Marshal())
+	_, err = loadHostKeys(context.Background(), option.SSHInboundOptions{HostKeyPath: []string{"host_key"}})
+	require.ErrorContains(t, err, "read host key")
+}
+
+func TestDirectTCPIP(t *testing.T) {
+	t.Parallel()
+	inbound, err := NewInbound(context.Background(), &testRouter{}, log.NewNOPFactory().NewLogger("inbound"), "ssh-in", option.SSHInboundOptions{
+		Users: []option.SSHUser{{Name: "sekai", Password: "password"}},
+	})
+	require.NoError(t, err)
+	sshConn, channels, requests, err := ssh.NewClientConn(newTestConn(t, inbound), "127.0.0.1:22", &ssh.ClientConfig{
+		User:            "sekai",
+		Auth:            []ssh.AuthMethod{ssh.Password("password")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	require.NoError(t, err)
+	client := ssh.NewClient(sshConn, channels, requests)
+	defer client.Close()
+
+	conn, err := client.Dial("tcp", "127.0.0.1:7")
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	response := make([]byte, 4)
+	_, err = io.ReadFull(conn, response)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(response))
+	require.NoError(t, conn.Close())
+
+	_, err = client.Dial("tcp", "127.0.0.1:80")
+	var openChannelErr *ssh.OpenChannelError
+	require.ErrorAs(t, err, &openChannelErr)
+	require.Equal(t, ssh.ConnectionFailed, openChannelErr.Reason)
+	require.Equal(t, "connection refused", openChannelErr.Message)
+
+	_, _, err = client.OpenChannel("session", nil)
+	require.ErrorAs(t, err, &openChannelErr)
+	require.Equal(t, ssh.Prohibited, openChannelErr.Reason)
+}
+
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	inbound, err := NewInbound(context.Background(), &testRouter{}, log.NewNOPFactory().NewLogger("inbound"), "ssh-in", option.SSHInboundOptions{
+		Users: []option.SSHUser{
+			{Name: "sekai", Password: "password"},
+			{Name: "key", AuthorizedKeys: []string{string(ssh.MarshalAuthorizedKey(signer.PublicKey()))}},
+		},
+	})
+	require.NoError(t, err)
+	for _, testCase := range []struct {
+		user    string
+		auth    ssh.AuthMethod
+		success bool
+	}{
+		{"sekai", ssh.Password("password"), true},
+		{"sekai", ssh.Password("wrong"), false},
+		{"key", ssh.PublicKeys(signer), true},
+		{"key", ssh.Password("password"), false},
+		{"sekai", ssh.PublicKeys(signer), false},
+	} {
+		clientConn := newTestConn(t, inbound)
+		sshConn, _, _, err := ssh.NewClientConn(clientConn, "127.0.0.1:22", &ssh.ClientConfig{
+			User:            testCase.user,
+			Auth:            []ssh.AuthMethod{testCase.auth},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		if testCase.success {
+			require.NoError(t, err)
+			sshConn.Close()
+		} else {
+			require.Error(t, err)
+		}
+	}
+}
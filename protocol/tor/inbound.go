@@ -0,0 +1,212 @@
+package tor
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"github.com/cretz/bine/control"
+	"github.com/cretz/bine/tor"
+)
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.TorInboundOptions](registry, C.TypeTor, NewInbound)
+}
+
+var _ adapter.Inbound = (*Inbound)(nil)
+
+type Inbound struct {
+	inbound.Adapter
+	ctx          context.Context
+	router       adapter.ConnectionRouterEx
+	logger       logger.ContextLogger
+	outboundTag  string
+	instance     *instance
+	tor          *tor.Tor
+	keyDirectory string
+	services     []option.TorOnionServiceOptions
+	serviceIDs   []string
+	listeners    []net.Listener
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.TorInboundOptions) (adapter.Inbound, error) {
+	if options.KeyDirectory == "" {
+		return nil, E.New("missing key_directory")
+	}
+	if len(options.Services) == 0 {
+		return nil, E.New("missing services")
+	}
+	serviceNames := make(map[string]bool)
+	for index, serviceOptions := range options.Services {
+		if serviceOptions.Name == "" || serviceOptions.Name != filepath.Base(serviceOptions.Name) {
+			return nil, E.New("services[", index, "]: invalid name: ", serviceOptions.Name)
+		}
+		if serviceNames[serviceOptions.Name] {
+			return nil, E.New("services[", index, "]: duplicate name: ", serviceOptions.Name)
+		}
+		serviceNames[serviceOptions.Name] = true
+		if len(serviceOptions.Ports) == 0 || common.Contains(serviceOptions.Ports, 0) {
+			return nil, E.New("services[", index, "]: missing ports")
+		}
+	}
+	inbound := &Inbound{
+		Adapter:      inbound.NewAdapter(C.TypeTor, tag),
+		ctx:          ctx,
+		router:       router,
+		logger:       logger,
+		outboundTag:  options.Outbound,
+		keyDirectory: filemanager.BasePath(ctx, options.KeyDirectory),
+		services:     options.Services,
+	}
+	if options.Outbound == "" {
+		torInstance, err := newInstance(ctx, logger, options.ExecutablePath, options.ExtraArgs, options.DataDirectory, options.Options)
+		if err != nil {
+			return nil, err
+		}
+		inbound.instance = torInstance
+	}
+	return inbound, nil
+}
+
+func (h *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	if h.instance != nil {
+		err := h.instance.start()
+		if err != nil {
+			return err
+		}
+		h.tor = h.instance.tor
+	} else {
+		outboundManager := service.FromContext[adapter.OutboundManager](h.ctx)
+		detour, loaded := outboundManager.Outbound(h.outboundTag)
+		if !loaded {
+			return E.New("outbound not found: ", h.outboundTag)
+		}
+		torOutbound, isTor := detour.(*Outbound)
+		if !isTor {
+			return E.New("outbound is not a tor outbound: ", h.outboundTag)
+		}
+		h.tor = torOutbound.Tor()
+		if h.tor == nil {
+			return E.New("tor outbound is not started: ", h.outboundTag)
+		}
+	}
+	return h.startServices()
+}
+
+func (h *Inbound) startServices() error {
+	for _, serviceOptions := range h.services {
+		err := h.startService(serviceOptions)
+		if err != nil {
+			h.closeServices()
+			return E.Cause(err, "start onion service ", serviceOptions.Name)
+		}
+	}
+	return nil
+}
+
+func (h *Inbound) startService(options option.TorOnionServiceOptions) error {
+	serviceDirectory := filepath.Join(h.keyDirectory, options.Name)
+	keyPair, err := loadOnionKey(h.ctx, serviceDirectory)
+	if err != nil {
+		return err
+	}
+	request := &control.AddOnionRequest{
+		Key: &control.ED25519Key{KeyPair: keyPair},
+	}
+	// every virtual port has its own local listener, so that the port connected to is known
+	listeners := make(map[uint16]net.Listener)
+	for _, port := range options.Ports {
+		if listeners[port] != nil {
+			continue
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			for _, it := range listeners {
+				it.Close()
+			}
+			return err
+		}
+		listeners[port] = listener
+		request.Ports = append(request.Ports, control.NewKeyVal(strconv.Itoa(int(port)), listener.Addr().String()))
+	}
+	response, err := h.tor.Control.AddOnion(request)
+	if err != nil {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		return err
+	}
+	h.serviceIDs = append(h.serviceIDs, response.ServiceID)
+	hostname := response.ServiceID + ".onion"
+	err = writeOnionHostname(h.ctx, serviceDirectory, hostname)
+	if err != nil {
+		h.logger.Warn(E.Cause(err, "write onion service hostname"))
+	}
+	h.logger.Info("onion service ", options.Name, " created at ", hostname)
+	for port, listener := range listeners {
+		h.listeners = append(h.listeners, listener)
+		go h.loopAccept(listener, M.Socksaddr{Fqdn: hostname, Port: port})
+	}
+	return nil
+}
+
+func (h *Inbound) loopAccept(listener net.Listener, onionAddr M.Socksaddr) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !E.IsClosed(err) {
+				h.logger.Error("accept onion connection: ", err)
+			}
+			return
+		}
+		go h.newConnection(conn, onionAddr)
+	}
+}
+
+func (h *Inbound) newConnection(conn net.Conn, onionAddr M.Socksaddr) {
+	ctx := log.ContextWithNewID(h.ctx)
+	var metadata adapter.InboundContext
+	metadata.Inbound = h.Tag()
+	metadata.InboundType = h.Type()
+	metadata.Source = M.SocksaddrFromNet(conn.RemoteAddr()).Unwrap()
+	metadata.Destination = onionAddr
+	h.logger.InfoContext(ctx, "inbound onion connection to ", metadata.Destination)
+	h.router.RouteConnectionEx(ctx, conn, metadata, nil)
+}
+
+func (h *Inbound) Close() error {
+	return E.Errors(h.closeServices(), common.Close(common.PtrOrNil(h.instance)))
+}
+
+// closeServices deletes onion services and closes their listeners,
+// since Tor keeps publishing onion services until deleted or the control connection is closed.
+func (h *Inbound) closeServices() error {
+	var err error
+	if h.tor != nil {
+		for _, serviceID := range h.serviceIDs {
+			err = E.Errors(err, h.tor.Control.DelOnion(serviceID))
+		}
+	}
+	for _, listener := range h.listeners {
+		err = E.Errors(err, listener.Close())
+	}
+	h.serviceIDs = nil
+	h.listeners = nil
+	return err
+}
@@ -0,0 +1,164 @@
+package tor
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/cretz/bine/control"
+	"github.com/cretz/bine/tor"
+	"github.com/stretchr/testify/require"
+)
+
+// testControl answers control commands of an inbound like Tor does,
+// failing ADD_ONION requests after the given count.
+type testControl struct {
+	access    sync.Mutex
+	commands  []string
+	targets   []string
+	maxOnions int
+}
+
+func newTestTor(t *testing.T, testControl *testControl) *tor.Tor {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+	go testControl.serve(textproto.NewConn(serverConn))
+	return &tor.Tor{Control: control.NewConn(textproto.NewConn(clientConn))}
+}
+
+func (c *testControl) serve(conn *textproto.Conn) {
+	var onions int
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "ADD_ONION":
+			c.access.Lock()
+			c.commands = append(c.commands, fields[0])
+			for _, field := range fields[2:] {
+				if strings.HasPrefix(field, "Port=") {
+					c.targets = append(c.targets, field[strings.Index(field, ",")+1:])
+				}
+			}
+			c.access.Unlock()
+			if onions == c.maxOnions {
+				conn.PrintfLine("512 Invalid argument")
+				continue
+			}
+			onions++
+			conn.PrintfLine("250-ServiceID=service%d", onions)
+			conn.PrintfLine("250 OK")
+		default:
+			c.access.Lock()
+			c.commands = append(c.commands, line)
+			c.access.Unlock()
+			conn.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (c *testControl) Commands() []string {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.commands
+}
+
+// requireClosed checks that local listeners of all onion services requested are closed.
+func (c *testControl) requireClosed(t *testing.T) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	require.NotEmpty(t, c.targets)
+	for _, target := range c.targets {
+		_, err := net.Dial("tcp", target)
+		require.Error(t, err)
+	}
+}
+
+func newTestInbound(t *testing.T, services []option.TorOnionServiceOptions) *Inbound {
+	inbound, err := NewInbound(context.Background(), nil, log.NewNOPFactory().NewLogger("inbound"), "tor-in", option.TorInboundOptions{
+		Outbound:     "tor",
+		KeyDirectory: t.TempDir(),
+		Services:     services,
+	})
+	require.NoError(t, err)
+	return inbound.(*Inbound)
+}
+
+func TestInboundOptions(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		options option.TorInboundOptions
+		err     string
+	}{
+		{option.TorInboundOptions{Services: []option.TorOnionServiceOptions{{Name: "web", Ports: []uint16{80}}}}, "missing key_directory"},
+		{option.TorInboundOptions{KeyDirectory: "keys"}, "missing services"},
+		{option.TorInboundOptions{KeyDirectory: "keys", Services: []option.TorOnionServiceOptions{{Name: "../web", Ports: []uint16{80}}}}, "services[0]: invalid name"},
+		{option.TorInboundOptions{KeyDirectory: "keys", Services: []option.TorOnionServiceOptions{{Name: "web", Ports: []uint16{80}}, {Name: "web", Ports: []uint16{443}}}}, "services[1]: duplicate name"},
+		{option.TorInboundOptions{KeyDirectory: "keys", Services: []option.TorOnionServiceOptions{{Name: "web"}}}, "services[0]: missing ports"},
+	} {
+		testCase.options.Outbound = "tor"
+		_, err := NewInbound(context.Background(), nil, log.NewNOPFactory().NewLogger("inbound"), "tor-in", testCase.options)
+		require.ErrorContains(t, err, testCase.err)
+	}
+}
+
+func TestOnionKey(t *testing.T) {
+	t.Parallel()
+	serviceDirectory := filepath.Join(t.TempDir(), "web")
+	keyPair, err := loadOnionKey(context.Background(), serviceDirectory)
+	require.NoError(t, err)
+	loadedKeyPair, err := loadOnionKey(context.Background(), serviceDirectory)
+	require.NoError(t, err)
+	require.Equal(t, keyPair.PrivateKey(), loadedKeyPair.PrivateKey())
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDirectory, onionSecretKeyFile), []byte("invalid"), 0o600))
+	_, err = loadOnionKey(context.Background(), serviceDirectory)
+	require.ErrorContains(t, err, "invalid onion service key")
+}
+
+func TestInboundServices(t *testing.T) {
+	t.Parallel()
+	inbound := newTestInbound(t, []option.TorOnionServiceOptions{
+		{Name: "web", Ports: []uint16{80, 443, 80}},
+		{Name: "ssh", Ports: []uint16{22}},
+	})
+	control := &testControl{maxOnions: 2}
+	inbound.tor = newTestTor(t, control)
+	require.NoError(t, inbound.startServices())
+	require.Equal(t, []string{"service1", "service2"}, inbound.serviceIDs)
+	require.Len(t, inbound.listeners, 3)
+	hostname, err := os.ReadFile(filepath.Join(inbound.keyDirectory, "web", onionHostnameFile))
+	require.NoError(t, err)
+	require.Equal(t, "service1.onion\n", string(hostname))
+	require.NoError(t, inbound.Close())
+	require.Equal(t, []string{"ADD_ONION", "ADD_ONION", "DEL_ONION service1", "DEL_ONION service2"}, control.Commands())
+	control.requireClosed(t)
+}
+
+func TestInboundServicesFailure(t *testing.T) {
+	t.Parallel()
+	inbound := newTestInbound(t, []option.TorOnionServiceOptions{
+		{Name: "web", Ports: []uint16{80}},
+		{Name: "ssh", Ports: []uint16{22}},
+	})
+	control := &testControl{maxOnions: 1}
+	inbound.tor = newTestTor(t, control)
+	require.ErrorContains(t, inbound.startServices(), "start onion service ssh")
+	require.Empty(t, inbound.serviceIDs)
+	require.Empty(t, inbound.listeners)
+	require.Equal(t, []string{"ADD_ONION", "ADD_ONION", "DEL_ONION service1"}, control.Commands())
+	control.requireClosed(t)
+}
@@ -0,0 +1,139 @@
+package tor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/common/rw"
+
+	"github.com/cretz/bine/control"
+	"github.com/cretz/bine/tor"
+)
+
+type instance struct {
+	ctx       context.Context
+	logger    logger.ContextLogger
+	startConf *tor.StartConf
+	options   map[string]string
+	events    chan control.Event
+	tor       *tor.Tor
+}
+
+func newInstance(ctx context.Context, logger logger.ContextLogger, executablePath string, extraArgs []string, dataDirectory string, options map[string]string) (*instance, error) {
+	var startConf tor.StartConf
+	startConf.DataDir = os.ExpandEnv(dataDirectory)
+	startConf.TempDataDirBase = os.TempDir()
+	if dataDirectory != "" {
+		dataDirAbs, _ := filepath.Abs(startConf.DataDir)
+		if geoIPPath := filepath.Join(dataDirAbs, "geoip"); rw.IsFile(geoIPPath) && !common.Contains(extraArgs, "--GeoIPFile") {
+			extraArgs = append(extraArgs, "--GeoIPFile", geoIPPath)
+		}
+		if geoIP6Path := filepath.Join(dataDirAbs, "geoip6"); rw.IsFile(geoIP6Path) && !common.Contains(extraArgs, "--GeoIPv6File") {
+			extraArgs = append(extraArgs, "--GeoIPv6File", geoIP6Path)
+		}
+	}
+	startConf.ExtraArgs = extraArgs
+	if executablePath != "" {
+		startConf.ExePath = executablePath
+		startConf.ProcessCreator = nil
+		startConf.UseEmbeddedControlConn = false
+	}
+	if startConf.DataDir != "" {
+		torrcFile := filepath.Join(startConf.DataDir, "torrc")
+		err := rw.MkdirParent(torrcFile)
+		if err != nil {
+			return nil, err
+		}
+		if !rw.IsFile(torrcFile) {
+			err := os.WriteFile(torrcFile, []byte(""), 0o600)
+			if err != nil {
+				return nil, err
+			}
+		}
+		startConf.TorrcFile = torrcFile
+	}
+	return &instance{
+		ctx:       ctx,
+		logger:    logger,
+		startConf: &startConf,
+		options:   options,
+	}, nil
+}
+
+var torLogEvents = []control.EventCode{
+	control.EventCodeLogDebug,
+	control.EventCodeLogErr,
+	control.EventCodeLogInfo,
+	control.EventCodeLogNotice,
+	control.EventCodeLogWarn,
+}
+
+// start launches Tor with the reserved options, which can not be overridden by torrc options.
+func (t *instance) start(reservedOptions ...*control.KeyVal) error {
+	torInstance, err := tor.Start(t.ctx, t.startConf)
+	if err != nil {
+		return E.New(strings.ToLower(err.Error()))
+	}
+	t.tor = torInstance
+	t.events = make(chan control.Event, 8)
+	err = torInstance.Control.AddEventListener(t.events, torLogEvents...)
+	if err != nil {
+		return err
+	}
+	go t.recvLoop()
+	if len(reservedOptions) > 0 {
+		err = torInstance.Control.ResetConf(reservedOptions...)
+		if err != nil {
+			return err
+		}
+	}
+	for key, value := range t.options {
+		if common.Any(reservedOptions, func(it *control.KeyVal) bool {
+			return it.Key == key
+		}) {
+			continue
+		}
+		err = torInstance.Control.SetConf(control.NewKeyVal(key, value))
+		if err != nil {
+			return E.Cause(err, "set ", key, "=", value)
+		}
+	}
+	return torInstance.EnableNetwork(t.ctx, true)
+}
+
+func (t *instance) recvLoop() {
+	for rawEvent := range t.events {
+		switch event := rawEvent.(type) {
+		case *control.LogEvent:
+			event.Raw = strings.ToLower(event.Raw)
+			switch event.Severity {
+			case control.EventCodeLogDebug, control.EventCodeLogInfo:
+				t.logger.Trace(event.Raw)
+			case control.EventCodeLogNotice:
+				if strings.Contains(event.Raw, "disablenetwork") || strings.Contains(event.Raw, "socks listener") {
+					t.logger.Trace(event.Raw)
+					continue
+				}
+				t.logger.Info(event.Raw)
+			case control.EventCodeLogWarn:
+				t.logger.Warn(event.Raw)
+			case control.EventCodeLogErr:
+				t.logger.Error(event.Raw)
+			}
+		}
+	}
+}
+
+func (t *instance) Close() error {
+	err := common.Close(common.PtrOrNil(t.tor))
+	if t.events != nil {
+		close(t.events)
+		t.events = nil
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package tor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/rw"
+	"github.com/sagernet/sing/service/filemanager"
+
+	"github.com/cretz/bine/torutil/ed25519"
+)
+
+const (
+	onionSecretKeyFile = "hs_ed25519_secret_key"
+	onionHostnameFile  = "hostname"
+)
+
+// onionSecretKeyHeader is the header of hs_ed25519_secret_key files written by Tor,
+// so that keys of existing HiddenServiceDir directories can be reused.
+var onionSecretKeyHeader = append([]byte("== ed25519v1-secret: type0 =="), 0, 0, 0)
+
+func loadOnionKey(ctx context.Context, serviceDirectory string) (ed25519.KeyPair, error) {
+	keyPath := filepath.Join(serviceDirectory, onionSecretKeyFile)
+	if rw.IsFile(keyPath) {
+		content, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(content) != len(onionSecretKeyHeader)+64 || !bytes.Equal(content[:len(onionSecretKeyHeader)], onionSecretKeyHeader) {
+			return nil, E.New("invalid onion service key: ", keyPath)
+		}
+		return ed25519.PrivateKey(content[len(onionSecretKeyHeader):]).KeyPair(), nil
+	}
+	keyPair, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	err = filemanager.MkdirAll(ctx, serviceDirectory, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	err = filemanager.WriteFile(ctx, keyPath, append(bytes.Clone(onionSecretKeyHeader), keyPair.PrivateKey()...), 0o600)
+	if err != nil {
+		return nil, E.Cause(err, "write onion service key")
+	}
+	return keyPair, nil
+}
+
+func writeOnionHostname(ctx context.Context, serviceDirectory string, hostname string) error {
+	return filemanager.WriteFile(ctx, filepath.Join(serviceDirectory, onionHostnameFile), []byte(hostname+"\n"), 0o600)
+}
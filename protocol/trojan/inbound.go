@@ -7,6 +7,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/fallback"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
 	"github.com/sagernet/sing-box/common/tls"
@@ -37,7 +38,7 @@ type Inbound struct {
 	service                  *trojan.Service[int]
 	users                    []option.TrojanUser
 	tlsConfig                tls.ServerConfig
-	fallbackAddr             M.Socksaddr
+	fallback                 *fallback.Handler
 	fallbackAddrTLSNextProto map[string]M.Socksaddr
 	transport                adapter.V2RayServerTransport
 }
@@ -62,14 +63,13 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		}
 		inbound.tlsConfig = tlsConfig
 	}
+	var err error
+	inbound.fallback, err = fallback.New(inbound, router, logger, options.Fallback)
+	if err != nil {
+		return nil, err
+	}
 	var fallbackHandler N.TCPConnectionHandlerEx
-	if options.Fallback != nil && options.Fallback.Server != "" || len(options.FallbackForALPN) > 0 {
-		if options.Fallback != nil && options.Fallback.Server != "" {
-			inbound.fallbackAddr = options.Fallback.Build()
-			if !inbound.fallbackAddr.IsValid() {
-				return nil, E.New("invalid fallback address: ", inbound.fallbackAddr)
-			}
-		}
+	if inbound.fallback != nil || len(options.FallbackForALPN) > 0 {
 		if len(options.FallbackForALPN) > 0 {
 			if inbound.tlsConfig == nil {
 				return nil, E.New("fallback for ALPN is not supported without TLS")
@@ -87,7 +87,7 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		fallbackHandler = adapter.NewUpstreamContextHandlerEx(inbound.fallbackConnection, nil)
 	}
 	service := trojan.NewService[int](adapter.NewUpstreamContextHandlerEx(inbound.newConnection, inbound.newPacketConnection), fallbackHandler, logger)
-	err := service.UpdateUsers(common.MapIndexed(options.Users, func(index int, it option.TrojanUser) int {
+	err = service.UpdateUsers(common.MapIndexed(options.Users, func(index int, it option.TrojanUser) int {
 		return index
 	}), common.Map(options.Users, func(it option.TrojanUser) string {
 		return it.Password
@@ -172,6 +172,7 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata a
 			h.logger.ErrorContext(ctx, E.Cause(err, "process connection from ", metadata.Source, ": TLS handshake"))
 			return
 		}
+		tls.ApplyClientCertificate(h.tlsConfig, tlsConn, &metadata)
 		conn = tlsConn
 	}
 	err := h.service.NewConnection(adapter.WithContext(ctx, &metadata), conn, metadata.Source, onClose)
@@ -232,12 +233,13 @@ func (h *Inbound) fallbackConnection(ctx context.Context, conn net.Conn, metadat
 		}
 	}
 	if !fallbackAddr.IsValid() {
-		if !h.fallbackAddr.IsValid() {
+		if h.fallback == nil {
 			h.logger.DebugContext(ctx, "process connection from ", metadata.Source, ": fallback disabled by default")
 			N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 			return
 		}
-		fallbackAddr = h.fallbackAddr
+		h.fallback.NewConnectionEx(ctx, conn, metadata, onClose)
+		return
 	}
 	metadata.Inbound = h.Tag()
 	metadata.InboundType = h.Type()
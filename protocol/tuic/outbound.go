@@ -9,6 +9,7 @@ import (
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/outbound"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/porthopping"
 	"github.com/sagernet/sing-box/common/tls"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -60,10 +61,17 @@ func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextL
 	case "quic":
 		tuicUDPStream = true
 	}
-	outboundDialer, err := dialer.New(ctx, options.DialerOptions, options.ServerIsDomain())
+	var outboundDialer N.Dialer
+	outboundDialer, err = dialer.New(ctx, options.DialerOptions, options.ServerIsDomain())
 	if err != nil {
 		return nil, err
 	}
+	if len(options.ServerPorts) > 0 {
+		outboundDialer, err = porthopping.NewDialer(outboundDialer, options.ServerPorts, time.Duration(options.HopInterval))
+		if err != nil {
+			return nil, E.Cause(err, "server_ports")
+		}
+	}
 	client, err := tuic.NewClient(tuic.ClientOptions{
 		Context:           ctx,
 		Dialer:            outboundDialer,
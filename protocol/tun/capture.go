@@ -0,0 +1,122 @@
+package tun
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common/buf"
+)
+
+// newCaptureTun wraps the interface to record packets for the packet capture,
+// preserving the platform specific interfaces used by stacks.
+func newCaptureTun(tunInterface tun.Tun, capture adapter.PacketCapture, inbound string) tun.Tun {
+	base := &captureTun{
+		Tun:     tunInterface,
+		capture: capture,
+		inbound: inbound,
+	}
+	var wrapper tun.Tun
+	if winTun, isWinTun := tunInterface.(tun.WinTun); isWinTun {
+		wrapper = &captureWinTun{base, winTun}
+	} else if linuxTUN, isLinuxTUN := tunInterface.(tun.LinuxTUN); isLinuxTUN {
+		wrapper = &captureLinuxTUN{base, linuxTUN}
+	} else if darwinTUN, isDarwinTUN := tunInterface.(tun.DarwinTUN); isDarwinTUN {
+		wrapper = &captureDarwinTUN{base, darwinTUN}
+	} else {
+		wrapper = base
+	}
+	return newCaptureGVisorTun(wrapper, base)
+}
+
+type captureTun struct {
+	tun.Tun
+	capture adapter.PacketCapture
+	inbound string
+}
+
+func (t *captureTun) Read(p []byte) (n int, err error) {
+	n, err = t.Tun.Read(p)
+	if n > tun.PacketOffset && t.capture.Capturing(t.inbound) {
+		t.capture.CapturePacket(t.inbound, false, p[tun.PacketOffset:n])
+	}
+	return
+}
+
+func (t *captureTun) Write(p []byte) (n int, err error) {
+	if len(p) > tun.PacketOffset && t.capture.Capturing(t.inbound) {
+		t.capture.CapturePacket(t.inbound, true, p[tun.PacketOffset:])
+	}
+	return t.Tun.Write(p)
+}
+
+type captureWinTun struct {
+	*captureTun
+	winTun tun.WinTun
+}
+
+func (t *captureWinTun) ReadPacket() ([]byte, func(), error) {
+	packet, release, err := t.winTun.ReadPacket()
+	if err == nil && t.capture.Capturing(t.inbound) {
+		t.capture.CapturePacket(t.inbound, false, packet)
+	}
+	return packet, release, err
+}
+
+type captureLinuxTUN struct {
+	*captureTun
+	linuxTUN tun.LinuxTUN
+}
+
+func (t *captureLinuxTUN) FrontHeadroom() int {
+	return t.linuxTUN.FrontHeadroom()
+}
+
+func (t *captureLinuxTUN) BatchSize() int {
+	return t.linuxTUN.BatchSize()
+}
+
+func (t *captureLinuxTUN) BatchRead(buffers [][]byte, offset int, readN []int) (n int, err error) {
+	n, err = t.linuxTUN.BatchRead(buffers, offset, readN)
+	if n > 0 && t.capture.Capturing(t.inbound) {
+		for i := 0; i < n; i++ {
+			t.capture.CapturePacket(t.inbound, false, buffers[i][offset:offset+readN[i]])
+		}
+	}
+	return
+}
+
+func (t *captureLinuxTUN) BatchWrite(buffers [][]byte, offset int) (int, error) {
+	if t.capture.Capturing(t.inbound) {
+		for _, buffer := range buffers {
+			t.capture.CapturePacket(t.inbound, true, buffer[offset:])
+		}
+	}
+	return t.linuxTUN.BatchWrite(buffers, offset)
+}
+
+func (t *captureLinuxTUN) TXChecksumOffload() bool {
+	return t.linuxTUN.TXChecksumOffload()
+}
+
+type captureDarwinTUN struct {
+	*captureTun
+	darwinTUN tun.DarwinTUN
+}
+
+func (t *captureDarwinTUN) BatchRead() ([]*buf.Buffer, error) {
+	buffers, err := t.darwinTUN.BatchRead()
+	if len(buffers) > 0 && t.capture.Capturing(t.inbound) {
+		for _, buffer := range buffers {
+			t.capture.CapturePacket(t.inbound, false, buffer.Bytes())
+		}
+	}
+	return buffers, err
+}
+
+func (t *captureDarwinTUN) BatchWrite(buffers []*buf.Buffer) error {
+	if t.capture.Capturing(t.inbound) {
+		for _, buffer := range buffers {
+			t.capture.CapturePacket(t.inbound, true, buffer.Bytes())
+		}
+	}
+	return t.darwinTUN.BatchWrite(buffers)
+}
@@ -0,0 +1,109 @@
+//go:build with_gvisor
+
+package tun
+
+import (
+	"github.com/sagernet/gvisor/pkg/tcpip"
+	"github.com/sagernet/gvisor/pkg/tcpip/link/nested"
+	"github.com/sagernet/gvisor/pkg/tcpip/stack"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-tun"
+)
+
+func newCaptureGVisorTun(wrapper tun.Tun, base *captureTun) tun.Tun {
+	gTun, isGTun := base.Tun.(tun.GVisorTun)
+	if !isGTun {
+		return wrapper
+	}
+	gWrapper := &captureGVisor{base, gTun}
+	switch wrapper := wrapper.(type) {
+	case *captureWinTun:
+		return &captureWinGVisorTun{wrapper, gWrapper}
+	case *captureLinuxTUN:
+		return &captureLinuxGVisorTun{wrapper, gWrapper}
+	case *captureDarwinTUN:
+		return &captureDarwinGVisorTun{wrapper, gWrapper}
+	default:
+		return &captureGVisorTun{base, gWrapper}
+	}
+}
+
+type captureGVisor struct {
+	base *captureTun
+	gTun tun.GVisorTun
+}
+
+func (t *captureGVisor) WritePacket(pkt *stack.PacketBuffer) (int, error) {
+	if t.base.capture.Capturing(t.base.inbound) {
+		t.base.capture.CapturePacket(t.base.inbound, true, packetBufferBytes(pkt))
+	}
+	return t.gTun.WritePacket(pkt)
+}
+
+func (t *captureGVisor) NewEndpoint() (stack.LinkEndpoint, stack.NICOptions, error) {
+	endpoint, nicOptions, err := t.gTun.NewEndpoint()
+	if err != nil {
+		return nil, nicOptions, err
+	}
+	captureEndpoint := &captureEndpoint{
+		capture: t.base.capture,
+		inbound: t.base.inbound,
+	}
+	captureEndpoint.Endpoint.Init(endpoint, captureEndpoint)
+	return captureEndpoint, nicOptions, nil
+}
+
+type captureGVisorTun struct {
+	*captureTun
+	*captureGVisor
+}
+
+type captureWinGVisorTun struct {
+	*captureWinTun
+	*captureGVisor
+}
+
+type captureLinuxGVisorTun struct {
+	*captureLinuxTUN
+	*captureGVisor
+}
+
+type captureDarwinGVisorTun struct {
+	*captureDarwinTUN
+	*captureGVisor
+}
+
+var (
+	_ stack.LinkEndpoint      = (*captureEndpoint)(nil)
+	_ stack.NetworkDispatcher = (*captureEndpoint)(nil)
+)
+
+type captureEndpoint struct {
+	nested.Endpoint
+	capture adapter.PacketCapture
+	inbound string
+}
+
+func (e *captureEndpoint) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
+	if e.capture.Capturing(e.inbound) {
+		e.capture.CapturePacket(e.inbound, false, packetBufferBytes(pkt))
+	}
+	e.Endpoint.DeliverNetworkPacket(protocol, pkt)
+}
+
+func (e *captureEndpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	if e.capture.Capturing(e.inbound) {
+		for _, pkt := range pkts.AsSlice() {
+			e.capture.CapturePacket(e.inbound, true, packetBufferBytes(pkt))
+		}
+	}
+	return e.Endpoint.WritePackets(pkts)
+}
+
+func packetBufferBytes(pkt *stack.PacketBuffer) []byte {
+	packetBuffer := pkt.ToBuffer()
+	defer packetBuffer.Release()
+	packetBuffer.TrimFront(int64(len(pkt.VirtioNetHeader().Slice())))
+	packetBuffer.TrimFront(int64(len(pkt.LinkHeader().Slice())))
+	return packetBuffer.Flatten()
+}
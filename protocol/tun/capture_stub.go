@@ -0,0 +1,9 @@
+//go:build !with_gvisor
+
+package tun
+
+import "github.com/sagernet/sing-tun"
+
+func newCaptureGVisorTun(wrapper tun.Tun, base *captureTun) tun.Tun {
+	return wrapper
+}
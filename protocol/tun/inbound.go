@@ -12,6 +12,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/nat64"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -19,6 +20,7 @@ import (
 	"github.com/sagernet/sing-box/route/rule"
 	"github.com/sagernet/sing-tun"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json/badoption"
 	M "github.com/sagernet/sing/common/metadata"
@@ -48,6 +50,8 @@ type Inbound struct {
 	platformInterface           adapter.PlatformInterface
 	platformOptions             option.TunPlatformOptions
 	autoRedirect                tun.AutoRedirect
+	directOffload               *directOffload
+	nat64Prefix                 netip.Prefix
 	routeRuleSet                []adapter.RuleSet
 	routeRuleSetCallback        []*list.Element[adapter.RuleSetUpdateCallback]
 	routeExcludeRuleSet         []adapter.RuleSet
@@ -260,10 +264,37 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 				return nil, err
 			}
 		}
+		if options.AutoRedirectOffload {
+			inbound.directOffload = newDirectOffload(ctx, logger, inbound.tunOptions.AutoRedirectOutputMark)
+		}
+	} else if options.AutoRedirectOffload {
+		return nil, E.New("`auto_redirect` is required by `auto_redirect_offload`")
+	}
+	if options.NAT64 != nil && options.NAT64.Enabled {
+		inbound.nat64Prefix = nat64.WellKnownPrefix
+		if options.NAT64.Prefix != nil {
+			inbound.nat64Prefix = options.NAT64.Prefix.Build(netip.Prefix{})
+			err = nat64.ValidatePrefix(inbound.nat64Prefix)
+			if err != nil {
+				return nil, E.Cause(err, "nat64")
+			}
+		}
 	}
 	return inbound, nil
 }
 
+// translateNAT64 replaces destinations synthesized with the NAT64 prefix with their embedded IPv4 addresses.
+func translateNAT64(prefix netip.Prefix, network string, destination M.Socksaddr) M.Socksaddr {
+	if !prefix.IsValid() || network == N.NetworkICMP {
+		return destination
+	}
+	address, loaded := nat64.Extract(prefix, destination.Addr)
+	if !loaded {
+		return destination
+	}
+	return M.SocksaddrFrom(address, destination.Port)
+}
+
 func uidToRange(uidList badoption.Listable[uint32]) []ranges.Range[uint32] {
 	return common.Map(uidList, func(uid uint32) ranges.Range[uint32] {
 		return ranges.NewSingle(uid)
@@ -379,6 +410,13 @@ func (t *Inbound) Start(stage adapter.StartStage) error {
 		if err != nil {
 			return E.Cause(err, "configure tun interface")
 		}
+		if packetCapture := service.FromContext[adapter.PacketCapture](t.ctx); packetCapture != nil {
+			tag := t.tag
+			if tag == "" {
+				tag = C.TypeTun
+			}
+			tunInterface = newCaptureTun(tunInterface, packetCapture, tag)
+		}
 		t.logger.Trace("creating stack")
 		t.tunIf = tunInterface
 		var (
@@ -426,6 +464,9 @@ func (t *Inbound) Start(stage adapter.StartStage) error {
 			if err != nil {
 				return E.Cause(err, "auto-redirect")
 			}
+			if t.directOffload != nil {
+				t.directOffload.Start()
+			}
 		}
 		t.routeAddressSet = nil
 		t.routeExcludeAddressSet = nil
@@ -442,6 +483,9 @@ func (t *Inbound) updateRouteAddressSet(it adapter.RuleSet) {
 }
 
 func (t *Inbound) Close() error {
+	if t.directOffload != nil {
+		t.directOffload.Close()
+	}
 	return common.Close(
 		t.tunStack,
 		t.tunIf,
@@ -450,6 +494,7 @@ func (t *Inbound) Close() error {
 }
 
 func (t *Inbound) PrepareConnection(network string, source M.Socksaddr, destination M.Socksaddr, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
+	destination = translateNAT64(t.nat64Prefix, network, destination)
 	var ipVersion uint8
 	if !destination.IsIPv6() {
 		ipVersion = 4
@@ -485,7 +530,7 @@ func (t *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, source M.S
 	metadata.Inbound = t.tag
 	metadata.InboundType = C.TypeTun
 	metadata.Source = source
-	metadata.Destination = destination
+	metadata.Destination = translateNAT64(t.nat64Prefix, N.NetworkTCP, destination)
 
 	t.logger.InfoContext(ctx, "inbound connection from ", metadata.Source)
 	t.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
@@ -498,7 +543,10 @@ func (t *Inbound) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 	metadata.Inbound = t.tag
 	metadata.InboundType = C.TypeTun
 	metadata.Source = source
-	metadata.Destination = destination
+	metadata.Destination = translateNAT64(t.nat64Prefix, N.NetworkUDP, destination)
+	if metadata.Destination != destination {
+		conn = nat64.NewPacketConn(bufio.NewNetPacketConn(conn), t.nat64Prefix)
+	}
 
 	t.logger.InfoContext(ctx, "inbound packet connection from ", metadata.Source)
 	t.logger.InfoContext(ctx, "inbound packet connection to ", metadata.Destination)
@@ -508,20 +556,27 @@ func (t *Inbound) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 type autoRedirectHandler Inbound
 
 func (t *autoRedirectHandler) PrepareConnection(network string, source M.Socksaddr, destination M.Socksaddr, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
+	originDestination := destination
+	destination = translateNAT64(t.nat64Prefix, network, destination)
 	var ipVersion uint8
 	if !destination.IsIPv6() {
 		ipVersion = 4
 	} else {
 		ipVersion = 6
 	}
-	routeDestination, err := t.router.PreMatch(adapter.InboundContext{
+	metadata := adapter.InboundContext{
 		Inbound:     t.tag,
 		InboundType: C.TypeTun,
 		IPVersion:   ipVersion,
 		Network:     network,
 		Source:      source,
 		Destination: destination,
-	}, routeContext, timeout, true)
+	}
+	// translated connections must be handled in userspace
+	if t.directOffload != nil && network == N.NetworkTCP && destination == originDestination {
+		return nil, t.prepareOffload(metadata)
+	}
+	routeDestination, err := t.router.PreMatch(metadata, routeContext, timeout, true)
 	if err != nil {
 		switch {
 		case rule.IsBypassed(err):
@@ -537,13 +592,38 @@ func (t *autoRedirectHandler) PrepareConnection(network string, source M.Socksad
 	return routeDestination, err
 }
 
+// prepareOffload bypasses connections routed to a plain direct outbound,
+// so that they are forwarded by the kernel instead of the redirect server.
+func (t *autoRedirectHandler) prepareOffload(metadata adapter.InboundContext) error {
+	if t.directOffload.loaded(metadata.Source, metadata.Destination) {
+		return &rule.BypassedError{Cause: tun.ErrBypass}
+	}
+	ctx := log.ContextWithNewID(t.ctx)
+	conn, err := t.router.PreMatchOffload(ctx, metadata)
+	if err != nil {
+		switch {
+		case rule.IsBypassed(err):
+			t.logger.Trace("bypass ", metadata.Network, " connection from ", metadata.Source.AddrString(), " to ", metadata.Destination.AddrString())
+		case rule.IsRejected(err):
+			t.logger.Trace("reject ", metadata.Network, " connection from ", metadata.Source.AddrString(), " to ", metadata.Destination.AddrString())
+		}
+		return err
+	}
+	if conn == nil {
+		return nil
+	}
+	t.directOffload.add(metadata.Source, metadata.Destination, conn)
+	t.logger.InfoContext(ctx, "offloaded connection from ", metadata.Source, " to ", metadata.Destination)
+	return &rule.BypassedError{Cause: tun.ErrBypass}
+}
+
 func (t *autoRedirectHandler) NewConnectionEx(ctx context.Context, conn net.Conn, source M.Socksaddr, destination M.Socksaddr, onClose N.CloseHandlerFunc) {
 	ctx = log.ContextWithNewID(ctx)
 	var metadata adapter.InboundContext
 	metadata.Inbound = t.tag
 	metadata.InboundType = C.TypeTun
 	metadata.Source = source
-	metadata.Destination = destination
+	metadata.Destination = translateNAT64(t.nat64Prefix, N.NetworkTCP, destination)
 
 	t.logger.InfoContext(ctx, "inbound redirect connection from ", metadata.Source)
 	t.logger.InfoContext(ctx, "inbound connection to ", metadata.Destination)
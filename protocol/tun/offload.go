@@ -0,0 +1,147 @@
+package tun
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+const (
+	offloadPollInterval = 5 * time.Second
+	// a flow not found in conntrack within this duration is considered failed to establish
+	offloadEstablishTimeout = 30 * time.Second
+)
+
+type offloadFlowKey struct {
+	source      netip.AddrPort
+	destination netip.AddrPort
+}
+
+func newOffloadFlowKey(source M.Socksaddr, destination M.Socksaddr) offloadFlowKey {
+	return offloadFlowKey{
+		source:      netip.AddrPortFrom(source.Addr.Unmap(), source.Port),
+		destination: netip.AddrPortFrom(destination.Addr.Unmap(), destination.Port),
+	}
+}
+
+type offloadFlow struct {
+	conn      adapter.OffloadedConnection
+	createdAt time.Time
+	seen      bool
+	upload    uint64
+	download  uint64
+}
+
+// directOffload tracks TCP flows bypassed to the kernel by auto-redirect,
+// and reports their traffic read from conntrack to the connection trackers.
+type directOffload struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger log.ContextLogger
+	mark   uint32
+	access sync.Mutex
+	flows  map[offloadFlowKey]*offloadFlow
+}
+
+func newDirectOffload(ctx context.Context, logger log.ContextLogger, mark uint32) *directOffload {
+	ctx, cancel := context.WithCancel(ctx)
+	return &directOffload{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+		mark:   mark,
+		flows:  make(map[offloadFlowKey]*offloadFlow),
+	}
+}
+
+func (o *directOffload) Start() {
+	err := enableConntrackAccounting()
+	if err != nil {
+		o.logger.Warn("enable conntrack accounting, traffic of offloaded connections will not be counted: ", err)
+	}
+	go o.loopPoll()
+}
+
+// loaded reports whether the flow is already offloaded, since the SYN packet may be retransmitted.
+func (o *directOffload) loaded(source M.Socksaddr, destination M.Socksaddr) bool {
+	o.access.Lock()
+	defer o.access.Unlock()
+	_, loaded := o.flows[newOffloadFlowKey(source, destination)]
+	return loaded
+}
+
+func (o *directOffload) add(source M.Socksaddr, destination M.Socksaddr, conn adapter.OffloadedConnection) {
+	o.access.Lock()
+	defer o.access.Unlock()
+	o.flows[newOffloadFlowKey(source, destination)] = &offloadFlow{
+		conn:      conn,
+		createdAt: time.Now(),
+	}
+}
+
+func (o *directOffload) loopPoll() {
+	ticker := time.NewTicker(offloadPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := o.poll()
+		if err != nil {
+			o.logger.Debug("poll offloaded connections: ", err)
+		}
+	}
+}
+
+// update applies the counters of a flow in conntrack,
+// which are totals since the flow is created.
+func (o *directOffload) update(key offloadFlowKey, upload uint64, download uint64) {
+	flow := o.flows[key]
+	if flow == nil {
+		return
+	}
+	flow.seen = true
+	if upload < flow.upload {
+		upload = flow.upload
+	}
+	if download < flow.download {
+		download = flow.download
+	}
+	if upload > flow.upload || download > flow.download {
+		flow.conn.CountTraffic(int64(upload-flow.upload), int64(download-flow.download))
+		flow.upload = upload
+		flow.download = download
+	}
+}
+
+// cleanup closes flows which are no longer in conntrack.
+func (o *directOffload) cleanup(activeFlows map[offloadFlowKey]bool) {
+	now := time.Now()
+	for key, flow := range o.flows {
+		if activeFlows[key] {
+			continue
+		}
+		if flow.seen || now.Sub(flow.createdAt) > offloadEstablishTimeout {
+			flow.conn.Close()
+			delete(o.flows, key)
+		}
+	}
+}
+
+func (o *directOffload) Close() error {
+	o.cancel()
+	o.access.Lock()
+	defer o.access.Unlock()
+	for key, flow := range o.flows {
+		flow.conn.Close()
+		delete(o.flows, key)
+	}
+	return nil
+}
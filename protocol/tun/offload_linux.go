@@ -0,0 +1,194 @@
+package tun
+
+import (
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"os"
+	"syscall"
+
+	"github.com/sagernet/netlink/nl"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	conntrackAccountingPath = "/proc/sys/net/netfilter/nf_conntrack_acct"
+	ctaMarkMask             = 21
+)
+
+// enableConntrackAccounting turns on byte counters of conntrack,
+// which only apply to flows created afterwards.
+func enableConntrackAccounting() error {
+	content, err := os.ReadFile(conntrackAccountingPath)
+	if err != nil {
+		return err
+	}
+	if len(content) > 0 && content[0] == '1' {
+		return nil
+	}
+	return os.WriteFile(conntrackAccountingPath, []byte("1"), 0o644)
+}
+
+func (o *directOffload) poll() error {
+	var conntrackFlows []conntrackFlow
+	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+		familyFlows, err := listConntrack(family, o.mark)
+		if err != nil {
+			return E.Cause(err, "list conntrack")
+		}
+		conntrackFlows = append(conntrackFlows, familyFlows...)
+	}
+	activeFlows := make(map[offloadFlowKey]bool)
+	o.access.Lock()
+	defer o.access.Unlock()
+	for _, flow := range conntrackFlows {
+		activeFlows[flow.key] = true
+		o.update(flow.key, flow.upload, flow.download)
+	}
+	o.cleanup(activeFlows)
+	return nil
+}
+
+type conntrackFlow struct {
+	key      offloadFlowKey
+	protocol uint8
+	mark     uint32
+	upload   uint64
+	download uint64
+}
+
+// listConntrack dumps TCP flows with the mark, which are filtered by the kernel,
+// so that the cost does not grow with unrelated connections.
+func listConntrack(family uint8, mark uint32) ([]conntrackFlow, error) {
+	request := nl.NewNetlinkRequest(unix.NFNL_SUBSYS_CTNETLINK<<8|nl.IPCTNL_MSG_CT_GET, unix.NLM_F_DUMP)
+	request.AddData(&nl.Nfgenmsg{
+		NfgenFamily: family,
+		Version:     nl.NFNETLINK_V0,
+	})
+	request.AddData(nl.NewRtAttr(nl.CTA_MARK, binary.BigEndian.AppendUint32(nil, mark)))
+	request.AddData(nl.NewRtAttr(ctaMarkMask, binary.BigEndian.AppendUint32(nil, math.MaxUint32)))
+	messages, err := request.Execute(unix.NETLINK_NETFILTER, 0)
+	if err != nil {
+		return nil, err
+	}
+	var flows []conntrackFlow
+	for _, message := range messages {
+		flow, err := parseConntrackFlow(message)
+		if err != nil {
+			return nil, err
+		}
+		// kernels without mark filtering return the whole table
+		if flow.mark != mark || flow.protocol != unix.IPPROTO_TCP {
+			continue
+		}
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+// parseConntrackFlow parses a conntrack message after the netfilter header.
+func parseConntrackFlow(message []byte) (flow conntrackFlow, err error) {
+	if len(message) < nl.SizeofNfgenmsg {
+		return flow, E.New("short conntrack message")
+	}
+	attributes, err := nl.ParseRouteAttr(message[nl.SizeofNfgenmsg:])
+	if err != nil {
+		return
+	}
+	for _, attribute := range attributes {
+		switch attribute.Attr.Type & nl.NLA_TYPE_MASK {
+		case nl.CTA_TUPLE_ORIG:
+			flow.key, flow.protocol, err = parseConntrackTuple(attribute.Value)
+			if err != nil {
+				return
+			}
+		case nl.CTA_MARK:
+			if len(attribute.Value) >= 4 {
+				flow.mark = binary.BigEndian.Uint32(attribute.Value)
+			}
+		case nl.CTA_COUNTERS_ORIG:
+			flow.upload, err = parseConntrackBytes(attribute.Value)
+			if err != nil {
+				return
+			}
+		case nl.CTA_COUNTERS_REPLY:
+			flow.download, err = parseConntrackBytes(attribute.Value)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func parseConntrackTuple(data []byte) (key offloadFlowKey, protocol uint8, err error) {
+	attributes, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return
+	}
+	var (
+		sourceAddr      netip.Addr
+		destinationAddr netip.Addr
+		sourcePort      uint16
+		destinationPort uint16
+	)
+	for _, attribute := range attributes {
+		attributeType := attribute.Attr.Type & nl.NLA_TYPE_MASK
+		if attributeType != nl.CTA_TUPLE_IP && attributeType != nl.CTA_TUPLE_PROTO {
+			continue
+		}
+		var children []syscall.NetlinkRouteAttr
+		children, err = nl.ParseRouteAttr(attribute.Value)
+		if err != nil {
+			return
+		}
+		switch attributeType {
+		case nl.CTA_TUPLE_IP:
+			for _, child := range children {
+				switch child.Attr.Type & nl.NLA_TYPE_MASK {
+				case nl.CTA_IP_V4_SRC, nl.CTA_IP_V6_SRC:
+					sourceAddr, _ = netip.AddrFromSlice(child.Value)
+				case nl.CTA_IP_V4_DST, nl.CTA_IP_V6_DST:
+					destinationAddr, _ = netip.AddrFromSlice(child.Value)
+				}
+			}
+		case nl.CTA_TUPLE_PROTO:
+			for _, child := range children {
+				switch child.Attr.Type & nl.NLA_TYPE_MASK {
+				case nl.CTA_PROTO_NUM:
+					if len(child.Value) >= 1 {
+						protocol = child.Value[0]
+					}
+				case nl.CTA_PROTO_SRC_PORT:
+					if len(child.Value) >= 2 {
+						sourcePort = binary.BigEndian.Uint16(child.Value)
+					}
+				case nl.CTA_PROTO_DST_PORT:
+					if len(child.Value) >= 2 {
+						destinationPort = binary.BigEndian.Uint16(child.Value)
+					}
+				}
+			}
+		}
+	}
+	key = offloadFlowKey{
+		source:      netip.AddrPortFrom(sourceAddr.Unmap(), sourcePort),
+		destination: netip.AddrPortFrom(destinationAddr.Unmap(), destinationPort),
+	}
+	return
+}
+
+func parseConntrackBytes(data []byte) (uint64, error) {
+	attributes, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return 0, err
+	}
+	for _, attribute := range attributes {
+		if attribute.Attr.Type&nl.NLA_TYPE_MASK == nl.CTA_COUNTERS_BYTES && len(attribute.Value) >= 8 {
+			return binary.BigEndian.Uint64(attribute.Value), nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,55 @@
+package tun
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/netlink/nl"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestParseConntrackFlow(t *testing.T) {
+	t.Parallel()
+	message := (&nl.Nfgenmsg{NfgenFamily: unix.AF_INET, Version: nl.NFNETLINK_V0}).Serialize()
+	message = append(message, newTestConntrackTuple(nl.CTA_TUPLE_ORIG, "192.168.1.2", "1.1.1.1", 50000, 443).Serialize()...)
+	message = append(message, newTestConntrackTuple(nl.CTA_TUPLE_REPLY, "1.1.1.1", "10.0.0.2", 443, 50000).Serialize()...)
+	message = append(message, nl.NewRtAttr(nl.CTA_MARK, binary.BigEndian.AppendUint32(nil, 0x2023)).Serialize()...)
+	message = append(message, newTestConntrackCounters(nl.CTA_COUNTERS_ORIG, 100).Serialize()...)
+	message = append(message, newTestConntrackCounters(nl.CTA_COUNTERS_REPLY, 2000).Serialize()...)
+	flow, err := parseConntrackFlow(message)
+	require.NoError(t, err)
+	require.Equal(t, conntrackFlow{
+		key: offloadFlowKey{
+			source:      netip.MustParseAddrPort("192.168.1.2:50000"),
+			destination: netip.MustParseAddrPort("1.1.1.1:443"),
+		},
+		protocol: unix.IPPROTO_TCP,
+		mark:     0x2023,
+		upload:   100,
+		download: 2000,
+	}, flow)
+	_, err = parseConntrackFlow(message[:2])
+	require.Error(t, err)
+}
+
+func newTestConntrackTuple(attrType int, source string, destination string, sourcePort uint16, destinationPort uint16) *nl.RtAttr {
+	tuple := nl.NewRtAttr(attrType|int(nl.NLA_F_NESTED), nil)
+	ip := tuple.AddRtAttr(nl.CTA_TUPLE_IP|int(nl.NLA_F_NESTED), nil)
+	ip.AddRtAttr(nl.CTA_IP_V4_SRC, netip.MustParseAddr(source).AsSlice())
+	ip.AddRtAttr(nl.CTA_IP_V4_DST, netip.MustParseAddr(destination).AsSlice())
+	proto := tuple.AddRtAttr(nl.CTA_TUPLE_PROTO|int(nl.NLA_F_NESTED), nil)
+	proto.AddRtAttr(nl.CTA_PROTO_NUM, []byte{unix.IPPROTO_TCP})
+	proto.AddRtAttr(nl.CTA_PROTO_SRC_PORT, binary.BigEndian.AppendUint16(nil, sourcePort))
+	proto.AddRtAttr(nl.CTA_PROTO_DST_PORT, binary.BigEndian.AppendUint16(nil, destinationPort))
+	return tuple
+}
+
+func newTestConntrackCounters(attrType int, bytes uint64) *nl.RtAttr {
+	counters := nl.NewRtAttr(attrType|int(nl.NLA_F_NESTED), nil)
+	counters.AddRtAttr(nl.CTA_COUNTERS_PACKETS, binary.BigEndian.AppendUint64(nil, 1))
+	counters.AddRtAttr(nl.CTA_COUNTERS_BYTES, binary.BigEndian.AppendUint64(nil, bytes))
+	return counters
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package tun
+
+import "os"
+
+func enableConntrackAccounting() error {
+	return os.ErrInvalid
+}
+
+func (o *directOffload) poll() error {
+	return os.ErrInvalid
+}
@@ -0,0 +1,101 @@
+package tun
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/log"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOffloadedConnection struct {
+	upload   int64
+	download int64
+	closed   bool
+}
+
+func (c *testOffloadedConnection) CountTraffic(upload int64, download int64) {
+	c.upload += upload
+	c.download += download
+}
+
+func (c *testOffloadedConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+func newTestOffload(t *testing.T) *directOffload {
+	offload := newDirectOffload(context.Background(), log.NewNOPFactory().NewLogger("offload"), 0)
+	t.Cleanup(func() {
+		offload.Close()
+	})
+	return offload
+}
+
+func TestDirectOffloadUpdate(t *testing.T) {
+	t.Parallel()
+	offload := newTestOffload(t)
+	source := M.ParseSocksaddr("[::ffff:192.168.1.2]:50000")
+	destination := M.ParseSocksaddr("1.1.1.1:443")
+	conn := &testOffloadedConnection{}
+	offload.add(source, destination, conn)
+	require.True(t, offload.loaded(source, destination))
+	key := offloadFlowKey{
+		source:      netip.MustParseAddrPort("192.168.1.2:50000"),
+		destination: netip.MustParseAddrPort("1.1.1.1:443"),
+	}
+	offload.update(key, 100, 1000)
+	require.Equal(t, int64(100), conn.upload)
+	require.Equal(t, int64(1000), conn.download)
+	offload.update(key, 150, 1000)
+	require.Equal(t, int64(150), conn.upload)
+	require.Equal(t, int64(1000), conn.download)
+	// conntrack counters are totals, a smaller value must not be counted negatively
+	offload.update(key, 120, 900)
+	require.Equal(t, int64(150), conn.upload)
+	require.Equal(t, int64(1000), conn.download)
+	offload.update(key, 200, 1500)
+	require.Equal(t, int64(200), conn.upload)
+	require.Equal(t, int64(1500), conn.download)
+	offload.update(offloadFlowKey{}, 1, 1)
+	require.Equal(t, int64(200), conn.upload)
+}
+
+func TestDirectOffloadCleanup(t *testing.T) {
+	t.Parallel()
+	offload := newTestOffload(t)
+	destination := M.ParseSocksaddr("1.1.1.1:443")
+	activeSource := M.ParseSocksaddr("192.168.1.2:50000")
+	closedSource := M.ParseSocksaddr("192.168.1.2:50001")
+	pendingSource := M.ParseSocksaddr("192.168.1.2:50002")
+	expiredSource := M.ParseSocksaddr("192.168.1.2:50003")
+	activeConn := &testOffloadedConnection{}
+	closedConn := &testOffloadedConnection{}
+	pendingConn := &testOffloadedConnection{}
+	expiredConn := &testOffloadedConnection{}
+	offload.add(activeSource, destination, activeConn)
+	offload.add(closedSource, destination, closedConn)
+	offload.add(pendingSource, destination, pendingConn)
+	offload.add(expiredSource, destination, expiredConn)
+	activeKey := newOffloadFlowKey(activeSource, destination)
+	closedKey := newOffloadFlowKey(closedSource, destination)
+	offload.flows[newOffloadFlowKey(expiredSource, destination)].createdAt = time.Now().Add(-offloadEstablishTimeout - time.Second)
+	offload.update(activeKey, 10, 10)
+	offload.update(closedKey, 10, 10)
+	offload.cleanup(map[offloadFlowKey]bool{activeKey: true})
+	require.False(t, activeConn.closed)
+	require.True(t, closedConn.closed)
+	require.False(t, pendingConn.closed)
+	require.True(t, expiredConn.closed)
+	require.True(t, offload.loaded(activeSource, destination))
+	require.False(t, offload.loaded(closedSource, destination))
+	require.True(t, offload.loaded(pendingSource, destination))
+	require.False(t, offload.loaded(expiredSource, destination))
+	offload.Close()
+	require.True(t, activeConn.closed)
+	require.True(t, pendingConn.closed)
+}
@@ -26,6 +26,7 @@ import (
 
 var (
 	_ adapter.OutboundWithPreferredRoutes = (*Endpoint)(nil)
+	_ adapter.WireGuardEndpoint           = (*Endpoint)(nil)
 	_ dialer.PacketDialerWithDestination  = (*Endpoint)(nil)
 )
 
@@ -72,6 +73,12 @@ func NewEndpoint(ctx context.Context, router adapter.Router, logger log.ContextL
 	} else {
 		udpTimeout = C.UDPTimeout
 	}
+	var resolveInterval time.Duration
+	if options.PeerResolveInterval != nil {
+		resolveInterval = time.Duration(*options.PeerResolveInterval)
+	} else {
+		resolveInterval = wireguard.DefaultPeerResolveInterval
+	}
 	wgEndpoint, err := wireguard.NewEndpoint(wireguard.EndpointOptions{
 		Context:    ctx,
 		Logger:     logger,
@@ -90,23 +97,20 @@ func NewEndpoint(ctx context.Context, router adapter.Router, logger log.ContextL
 		PrivateKey: options.PrivateKey,
 		ListenPort: options.ListenPort,
 		ResolvePeer: func(domain string) (netip.Addr, error) {
-			endpointAddresses, lookupErr := ep.dnsRouter.Lookup(ctx, domain, outboundDialer.(dialer.ResolveDialer).QueryOptions())
+			var queryOptions adapter.DNSQueryOptions
+			// peers added at runtime may use domains even if configured peers do not
+			if resolveDialer, isResolveDialer := outboundDialer.(dialer.ResolveDialer); isResolveDialer {
+				queryOptions = resolveDialer.QueryOptions()
+			}
+			endpointAddresses, lookupErr := ep.dnsRouter.Lookup(ctx, domain, queryOptions)
 			if lookupErr != nil {
 				return netip.Addr{}, lookupErr
 			}
 			return endpointAddresses[0], nil
 		},
-		Peers: common.Map(options.Peers, func(it option.WireGuardPeer) wireguard.PeerOptions {
-			return wireguard.PeerOptions{
-				Endpoint:                    M.ParseSocksaddrHostPort(it.Address, it.Port),
-				PublicKey:                   it.PublicKey,
-				PreSharedKey:                it.PreSharedKey,
-				AllowedIPs:                  it.AllowedIPs,
-				PersistentKeepaliveInterval: it.PersistentKeepaliveInterval,
-				Reserved:                    it.Reserved,
-			}
-		}),
-		Workers: options.Workers,
+		ResolveInterval: resolveInterval,
+		Peers:           common.Map(options.Peers, newPeerOptions),
+		Workers:         options.Workers,
 	})
 	if err != nil {
 		return nil, err
@@ -115,6 +119,17 @@ func NewEndpoint(ctx context.Context, router adapter.Router, logger log.ContextL
 	return ep, nil
 }
 
+func newPeerOptions(peer option.WireGuardPeer) wireguard.PeerOptions {
+	return wireguard.PeerOptions{
+		Endpoint:                    M.ParseSocksaddrHostPort(peer.Address, peer.Port),
+		PublicKey:                   peer.PublicKey,
+		PreSharedKey:                peer.PreSharedKey,
+		AllowedIPs:                  peer.AllowedIPs,
+		PersistentKeepaliveInterval: peer.PersistentKeepaliveInterval,
+		Reserved:                    peer.Reserved,
+	}
+}
+
 func (w *Endpoint) Start(stage adapter.StartStage) error {
 	switch stage {
 	case adapter.StartStateStart:
@@ -129,6 +144,28 @@ func (w *Endpoint) Close() error {
 	return w.endpoint.Close()
 }
 
+func (w *Endpoint) Peers() ([]adapter.WireGuardPeerStatus, error) {
+	return w.endpoint.Peers()
+}
+
+func (w *Endpoint) UpdatePeer(peer option.WireGuardPeer) error {
+	err := w.endpoint.UpdatePeer(newPeerOptions(peer))
+	if err != nil {
+		return err
+	}
+	w.logger.Info("updated peer ", peer.PublicKey)
+	return nil
+}
+
+func (w *Endpoint) RemovePeer(publicKey string) error {
+	err := w.endpoint.RemovePeer(publicKey)
+	if err != nil {
+		return err
+	}
+	w.logger.Info("removed peer ", publicKey)
+	return nil
+}
+
 func (w *Endpoint) PrepareConnection(network string, source M.Socksaddr, destination M.Socksaddr, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
 	var ipVersion uint8
 	if !destination.IsIPv6() {
@@ -0,0 +1,142 @@
+package route
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sagernet/fswatch"
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+// deviceTable maps MAC addresses to named devices from the configuration and an optional device file.
+type deviceTable struct {
+	logger        logger.ContextLogger
+	staticDevices []option.DeviceOptions
+	onReload      func(devices []*adapter.Device)
+	access        sync.RWMutex
+	devices       []*adapter.Device
+	macToDevice   map[string]*adapter.Device
+	watcher       *fswatch.Watcher
+}
+
+func newDeviceTable(ctx context.Context, logger logger.ContextLogger, devices []option.DeviceOptions, path string, onReload func(devices []*adapter.Device)) (*deviceTable, error) {
+	table := &deviceTable{
+		logger:        logger,
+		staticDevices: devices,
+		onReload:      onReload,
+	}
+	if path == "" {
+		err := table.update(nil)
+		if err != nil {
+			return nil, err
+		}
+		return table, nil
+	}
+	filePath, _ := filepath.Abs(filemanager.BasePath(ctx, path))
+	err := table.reloadFile(filePath)
+	if err != nil {
+		return nil, E.Cause(err, "load devices file")
+	}
+	watcher, err := fswatch.NewWatcher(fswatch.Options{
+		Path:   []string{filePath},
+		Logger: logger,
+		Callback: func(path string) {
+			uErr := table.reloadFile(path)
+			if uErr != nil {
+				logger.Error(E.Cause(uErr, "reload devices file"))
+				return
+			}
+			logger.Info("reloaded devices file")
+			if table.onReload != nil {
+				table.onReload(table.Devices())
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	table.watcher = watcher
+	return table, nil
+}
+
+func (t *deviceTable) Start() error {
+	if t.watcher != nil {
+		err := t.watcher.Start()
+		if err != nil {
+			t.logger.Error(E.Cause(err, "watch devices file"))
+		}
+	}
+	return nil
+}
+
+func (t *deviceTable) reloadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	deviceFile, err := json.UnmarshalExtended[option.DeviceFile](content)
+	if err != nil {
+		return err
+	}
+	return t.update(deviceFile.Devices)
+}
+
+func (t *deviceTable) update(fileDevices []option.DeviceOptions) error {
+	var devices []*adapter.Device
+	macToDevice := make(map[string]*adapter.Device)
+	for i, options := range append(append([]option.DeviceOptions(nil), t.staticDevices...), fileDevices...) {
+		if options.Name == "" {
+			return E.New("device[", i, "]: missing name")
+		}
+		if len(options.MACAddress) == 0 {
+			return E.New("device[", i, "]: missing MAC address")
+		}
+		device := &adapter.Device{
+			Name:   options.Name,
+			Groups: options.Group,
+		}
+		for _, address := range options.MACAddress {
+			macAddress, err := net.ParseMAC(address)
+			if err != nil {
+				return E.Cause(err, "device[", i, "]: parse MAC address")
+			}
+			if existing, loaded := macToDevice[macAddress.String()]; loaded {
+				return E.New("device[", i, "]: MAC address ", macAddress, " already assigned to ", existing.Name)
+			}
+			macToDevice[macAddress.String()] = device
+		}
+		devices = append(devices, device)
+	}
+	t.access.Lock()
+	t.devices = devices
+	t.macToDevice = macToDevice
+	t.access.Unlock()
+	return nil
+}
+
+func (t *deviceTable) Devices() []*adapter.Device {
+	t.access.RLock()
+	defer t.access.RUnlock()
+	return t.devices
+}
+
+func (t *deviceTable) Lookup(macAddress net.HardwareAddr) *adapter.Device {
+	t.access.RLock()
+	defer t.access.RUnlock()
+	return t.macToDevice[macAddress.String()]
+}
+
+func (t *deviceTable) Close() error {
+	if t.watcher != nil {
+		return t.watcher.Close()
+	}
+	return nil
+}
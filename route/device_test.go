@@ -0,0 +1,71 @@
+package route
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceTable(t *testing.T) {
+	t.Parallel()
+	logger := log.NewNOPFactory().NewLogger("router")
+	devicesPath := filepath.Join(t.TempDir(), "devices.json")
+	require.NoError(t, os.WriteFile(devicesPath, []byte(`{"devices":[{"name":"phone","mac_address":"AA:BB:CC:DD:EE:01","group":"family"}]}`), 0o644))
+	table, err := newDeviceTable(context.Background(), logger, []option.DeviceOptions{{
+		Name:       "laptop",
+		MACAddress: []string{"aa:bb:cc:dd:ee:00", "aa-bb-cc-dd-ee-10"},
+	}}, devicesPath, nil)
+	require.NoError(t, err)
+	defer table.Close()
+	require.Len(t, table.Devices(), 2)
+	require.Equal(t, "laptop", lookupDevice(t, table, "aa:bb:cc:dd:ee:10").Name)
+	phone := lookupDevice(t, table, "aa:bb:cc:dd:ee:01")
+	require.Equal(t, "phone", phone.Name)
+	require.Equal(t, []string{"family"}, phone.Groups)
+
+	require.NoError(t, os.WriteFile(devicesPath, []byte(`{"devices":[{"name":"tablet","mac_address":"aa:bb:cc:dd:ee:02"}]}`), 0o644))
+	require.NoError(t, table.reloadFile(devicesPath))
+	require.Nil(t, lookupDevice(t, table, "aa:bb:cc:dd:ee:01"))
+	require.Equal(t, "tablet", lookupDevice(t, table, "aa:bb:cc:dd:ee:02").Name)
+	require.Equal(t, "laptop", lookupDevice(t, table, "aa:bb:cc:dd:ee:00").Name)
+
+	require.NoError(t, os.WriteFile(devicesPath, []byte(`{"devices":[{"name":"tablet","mac_address":"aa:bb:cc:dd:ee:00"}]}`), 0o644))
+	require.ErrorContains(t, table.reloadFile(devicesPath), "already assigned to laptop")
+	require.Equal(t, "tablet", lookupDevice(t, table, "aa:bb:cc:dd:ee:02").Name)
+}
+
+func TestDeviceTableInvalid(t *testing.T) {
+	t.Parallel()
+	logger := log.NewNOPFactory().NewLogger("router")
+	for _, testCase := range []struct {
+		devices []option.DeviceOptions
+		err     string
+	}{
+		{[]option.DeviceOptions{{MACAddress: []string{"aa:bb:cc:dd:ee:00"}}}, "device[0]: missing name"},
+		{[]option.DeviceOptions{{Name: "laptop"}}, "device[0]: missing MAC address"},
+		{[]option.DeviceOptions{{Name: "laptop", MACAddress: []string{"laptop"}}}, "device[0]: parse MAC address"},
+		{[]option.DeviceOptions{
+			{Name: "laptop", MACAddress: []string{"aa:bb:cc:dd:ee:00"}},
+			{Name: "phone", MACAddress: []string{"AA:BB:CC:DD:EE:00"}},
+		}, "device[1]: MAC address aa:bb:cc:dd:ee:00 already assigned to laptop"},
+	} {
+		_, err := newDeviceTable(context.Background(), logger, testCase.devices, "", nil)
+		require.ErrorContains(t, err, testCase.err)
+	}
+	_, err := newDeviceTable(context.Background(), logger, nil, filepath.Join(t.TempDir(), "missing.json"), nil)
+	require.ErrorContains(t, err, "load devices file")
+}
+
+func lookupDevice(t *testing.T, table *deviceTable, address string) *adapter.Device {
+	macAddress, err := net.ParseMAC(address)
+	require.NoError(t, err)
+	return table.Lookup(macAddress)
+}
@@ -0,0 +1,117 @@
+package route
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dialer"
+	C "github.com/sagernet/sing-box/constant"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+)
+
+func (r *Router) PreMatchOffload(ctx context.Context, metadata adapter.InboundContext) (adapter.OffloadedConnection, error) {
+	selectedRule, selectedRuleIndex, _, _, err := r.matchRule(ctx, &metadata, true, true, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var selectedOutbound adapter.Outbound
+	if selectedRule != nil {
+		switch action := selectedRule.Action().(type) {
+		case *R.RuleActionReject:
+			if action.Method == C.RuleActionRejectMethodReply {
+				return nil, E.New("reject method `reply` is not supported for ", metadata.Network, " connections")
+			}
+			return nil, action.Error(ctx)
+		case *R.RuleActionBypass:
+			return nil, &R.BypassedError{Cause: tun.ErrBypass}
+		case *R.RuleActionRoute:
+			outbound, loaded := r.outbound.Outbound(action.Outbound)
+			if !loaded {
+				return nil, E.New("outbound not found: ", action.Outbound)
+			}
+			selectedOutbound = outbound
+		default:
+			return nil, nil
+		}
+	} else {
+		selectedOutbound = r.outbound.Default()
+	}
+	if !canOffload(&metadata, selectedOutbound) {
+		return nil, nil
+	}
+	if selectedRule == nil {
+		selectedRuleIndex = len(r.rules)
+	}
+	if r.matchMirror(&metadata, selectedRuleIndex) {
+		return nil, nil
+	}
+	if selectedRule != nil {
+		selectedRule.Statistics().Hit()
+	}
+	var connections []adapter.OffloadedConnection
+	for _, tracker := range r.trackers {
+		offloadTracker, isOffloadTracker := tracker.(adapter.OffloadedConnectionTracker)
+		if !isOffloadTracker {
+			continue
+		}
+		conn := offloadTracker.OffloadedConnection(ctx, metadata, selectedRule, selectedOutbound)
+		if conn != nil {
+			connections = append(connections, conn)
+		}
+	}
+	return offloadedConnections(connections), nil
+}
+
+// canOffload checks if the connection can be forwarded by the kernel with the same result
+// as the outbound, which requires a direct outbound without dialer options, and no route options,
+// or fake-ip destination which have to be handled in userspace.
+func canOffload(metadata *adapter.InboundContext, outbound adapter.Outbound) bool {
+	if metadata.Network != N.NetworkTCP || !metadata.Destination.IsIP() {
+		return false
+	}
+	directDialer, isDirect := outbound.(dialer.DirectDialer)
+	if !isDirect || !directDialer.IsEmpty() {
+		return false
+	}
+	return !metadata.FakeIP &&
+		!metadata.RouteOriginalDestination.IsValid() &&
+		metadata.NetworkStrategy == nil &&
+		len(metadata.NetworkType) == 0 &&
+		len(metadata.FallbackNetworkType) == 0 &&
+		!metadata.TLSFragment &&
+		!metadata.TLSRecordFragment
+}
+
+// matchMirror reports whether any mirror rule before the selected rule matches,
+// since mirrors are not recorded by pre-match and require the connection to be handled in userspace.
+func (r *Router) matchMirror(metadata *adapter.InboundContext, selectedRuleIndex int) bool {
+	for _, rule := range r.rules[:selectedRuleIndex] {
+		_, isMirror := rule.Action().(*R.RuleActionMirror)
+		if !isMirror {
+			continue
+		}
+		metadata.ResetRuleCache()
+		if rule.Match(metadata) {
+			return true
+		}
+	}
+	return false
+}
+
+type offloadedConnections []adapter.OffloadedConnection
+
+func (c offloadedConnections) CountTraffic(upload int64, download int64) {
+	for _, conn := range c {
+		conn.CountTraffic(upload, download)
+	}
+}
+
+func (c offloadedConnections) Close() error {
+	return common.Close(common.Map(c, func(it adapter.OffloadedConnection) any {
+		return it
+	})...)
+}
@@ -0,0 +1,238 @@
+package route
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOutbound struct {
+	tag   string
+	empty bool
+}
+
+func (o *testOutbound) Type() string           { return C.TypeDirect }
+func (o *testOutbound) Tag() string            { return o.tag }
+func (o *testOutbound) Network() []string      { return []string{N.NetworkTCP, N.NetworkUDP} }
+func (o *testOutbound) Dependencies() []string { return nil }
+func (o *testOutbound) IsEmpty() bool          { return o.empty }
+
+func (o *testOutbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	return nil, net.ErrClosed
+}
+
+func (o *testOutbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, net.ErrClosed
+}
+
+type testBlockOutbound struct {
+	testOutbound
+}
+
+func (o *testBlockOutbound) Type() string { return C.TypeBlock }
+
+type testOutboundManager struct {
+	adapter.OutboundManager
+	outbounds       map[string]adapter.Outbound
+	defaultOutbound adapter.Outbound
+}
+
+func (m *testOutboundManager) Outbound(tag string) (adapter.Outbound, bool) {
+	outbound, loaded := m.outbounds[tag]
+	return outbound, loaded
+}
+
+func (m *testOutboundManager) Default() adapter.Outbound {
+	return m.defaultOutbound
+}
+
+type testDNSRouter struct {
+	adapter.DNSRouter
+}
+
+func (r *testDNSRouter) LookupReverseMapping(ip netip.Addr) (string, bool) {
+	return "", false
+}
+
+type testDNSTransportManager struct {
+	adapter.DNSTransportManager
+}
+
+func (m *testDNSTransportManager) FakeIP() adapter.FakeIPTransport {
+	return nil
+}
+
+type testOffloadTracker struct {
+	connections int
+}
+
+func (t *testOffloadTracker) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) net.Conn {
+	return conn
+}
+
+func (t *testOffloadTracker) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) N.PacketConn {
+	return conn
+}
+
+func (t *testOffloadTracker) OffloadedConnection(ctx context.Context, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) adapter.OffloadedConnection {
+	t.connections++
+	return &testOffloadedConnection{}
+}
+
+type testOffloadedConnection struct{}
+
+func (c *testOffloadedConnection) CountTraffic(upload int64, download int64) {}
+
+func (c *testOffloadedConnection) Close() error { return nil }
+
+func newTestOffloadRouter(t *testing.T, rules ...option.Rule) (*Router, *testOffloadTracker) {
+	ctx := context.Background()
+	logger := log.NewNOPFactory().NewLogger("router")
+	direct := &testOutbound{tag: "direct", empty: true}
+	tracker := &testOffloadTracker{}
+	router := &Router{
+		ctx:    ctx,
+		logger: logger,
+		outbound: &testOutboundManager{
+			outbounds: map[string]adapter.Outbound{
+				"direct": direct,
+				"bound":  &testOutbound{tag: "bound"},
+				"block":  &testBlockOutbound{testOutbound{tag: "block"}},
+			},
+			defaultOutbound: direct,
+		},
+		dns:          &testDNSRouter{},
+		dnsTransport: &testDNSTransportManager{},
+		trackers:     []adapter.ConnectionTracker{tracker},
+	}
+	for _, ruleOptions := range rules {
+		rule, err := R.NewRule(ctx, logger, ruleOptions, false)
+		require.NoError(t, err)
+		router.rules = append(router.rules, rule)
+	}
+	return router, tracker
+}
+
+func newTestRule(port uint16, action option.RuleAction) option.Rule {
+	return option.Rule{
+		Type: C.RuleTypeDefault,
+		DefaultOptions: option.DefaultRule{
+			RawDefaultRule: option.RawDefaultRule{
+				Port: badoption.Listable[uint16]{port},
+			},
+			RuleAction: action,
+		},
+	}
+}
+
+func newTestOffloadMetadata(destination string) adapter.InboundContext {
+	return adapter.InboundContext{
+		Network:     N.NetworkTCP,
+		Source:      M.ParseSocksaddr("192.168.1.2:50000"),
+		Destination: M.ParseSocksaddr(destination),
+	}
+}
+
+func TestCanOffload(t *testing.T) {
+	t.Parallel()
+	direct := &testOutbound{tag: "direct", empty: true}
+	for _, testCase := range []struct {
+		name     string
+		outbound adapter.Outbound
+		update   func(metadata *adapter.InboundContext)
+		expected bool
+	}{
+		{name: "direct", outbound: direct, expected: true},
+		{name: "direct with dialer options", outbound: &testOutbound{tag: "bound"}},
+		{name: "not direct", outbound: &testBlockOutbound{}},
+		{name: "udp", outbound: direct, update: func(metadata *adapter.InboundContext) {
+			metadata.Network = N.NetworkUDP
+		}},
+		{name: "domain", outbound: direct, update: func(metadata *adapter.InboundContext) {
+			metadata.Destination = M.ParseSocksaddrHostPort("example.org", 443)
+		}},
+		{name: "fake-ip", outbound: direct, update: func(metadata *adapter.InboundContext) {
+			metadata.FakeIP = true
+		}},
+		{name: "override destination", outbound: direct, update: func(metadata *adapter.InboundContext) {
+			metadata.RouteOriginalDestination = M.ParseSocksaddr("1.0.0.1:443")
+		}},
+		{name: "network type", outbound: direct, update: func(metadata *adapter.InboundContext) {
+			metadata.NetworkType = []C.InterfaceType{C.InterfaceTypeWIFI}
+		}},
+		{name: "tls fragment", outbound: direct, update: func(metadata *adapter.InboundContext) {
+			metadata.TLSFragment = true
+		}},
+	} {
+		metadata := newTestOffloadMetadata("1.1.1.1:443")
+		if testCase.update != nil {
+			testCase.update(&metadata)
+		}
+		require.Equal(t, testCase.expected, canOffload(&metadata, testCase.outbound), testCase.name)
+	}
+}
+
+func TestPreMatchOffload(t *testing.T) {
+	t.Parallel()
+	router, tracker := newTestOffloadRouter(t,
+		newTestRule(80, option.RuleAction{
+			Action: C.RuleActionTypeMirror,
+			MirrorOptions: option.RouteActionMirror{
+				Outbound:   "direct",
+				Server:     "127.0.0.1",
+				ServerPort: 9000,
+			},
+		}),
+		newTestRule(22, option.RuleAction{
+			Action:        C.RuleActionTypeReject,
+			RejectOptions: option.RejectActionOptions{Method: C.RuleActionRejectMethodDefault},
+		}),
+		newTestRule(8080, option.RuleAction{
+			Action:       C.RuleActionTypeRoute,
+			RouteOptions: option.RouteActionOptions{Outbound: "bound"},
+		}),
+		newTestRule(443, option.RuleAction{
+			Action:       C.RuleActionTypeRoute,
+			RouteOptions: option.RouteActionOptions{Outbound: "direct"},
+		}),
+	)
+	ctx := context.Background()
+
+	conn, err := router.PreMatchOffload(ctx, newTestOffloadMetadata("1.1.1.1:443"))
+	require.NoError(t, err)
+	require.NotNil(t, conn)
+	require.Equal(t, 1, tracker.connections)
+	require.Equal(t, uint64(1), router.rules[3].Statistics().Hits())
+
+	// the default outbound is used when no rule matches
+	conn, err = router.PreMatchOffload(ctx, newTestOffloadMetadata("1.1.1.1:53"))
+	require.NoError(t, err)
+	require.NotNil(t, conn)
+	require.Equal(t, 2, tracker.connections)
+
+	// mirrored connections have to be handled in userspace
+	conn, err = router.PreMatchOffload(ctx, newTestOffloadMetadata("1.1.1.1:80"))
+	require.NoError(t, err)
+	require.Nil(t, conn)
+
+	conn, err = router.PreMatchOffload(ctx, newTestOffloadMetadata("1.1.1.1:8080"))
+	require.NoError(t, err)
+	require.Nil(t, conn)
+
+	_, err = router.PreMatchOffload(ctx, newTestOffloadMetadata("1.1.1.1:22"))
+	require.Error(t, err)
+	require.Equal(t, 2, tracker.connections)
+	require.Zero(t, router.rules[2].Statistics().Hits())
+}
@@ -150,6 +150,9 @@ func (r *Router) routeConnection(ctx context.Context, conn net.Conn, metadata ad
 	for _, buffer := range buffers {
 		conn = bufio.NewCachedConn(conn, buffer)
 	}
+	for _, mirror := range metadata.Mirrors {
+		conn = mirror.NewConnection(ctx, conn, metadata)
+	}
 	for _, tracker := range r.trackers {
 		conn = tracker.RoutedConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
@@ -276,6 +279,9 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 		conn = bufio.NewCachedPacketConn(conn, buffer.Buffer, buffer.Destination)
 		N.PutPacketBuffer(buffer)
 	}
+	for _, mirror := range metadata.Mirrors {
+		conn = mirror.NewPacketConnection(ctx, conn, metadata)
+	}
 	for _, tracker := range r.trackers {
 		conn = tracker.RoutedPacketConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
@@ -456,6 +462,19 @@ func (r *Router) matchRule(
 			r.logger.InfoContext(ctx, "found neighbor: ", mac)
 		}
 	}
+	if r.devices != nil && metadata.SourceDevice == nil && metadata.SourceMACAddress != nil {
+		device := r.devices.Lookup(metadata.SourceMACAddress)
+		if device != nil {
+			metadata.SourceDevice = device
+			r.logger.InfoContext(ctx, "found device: ", device.Name)
+		}
+	}
+	if r.needFindDestMAC && r.neighborResolver != nil && metadata.DestinationMACAddress == nil && metadata.Destination.IsIP() {
+		mac, macFound := r.neighborResolver.LookupMAC(metadata.Destination.Addr)
+		if macFound {
+			metadata.DestinationMACAddress = mac
+		}
+	}
 	if metadata.Destination.Addr.IsValid() && r.dnsTransport.FakeIP() != nil && r.dnsTransport.FakeIP().Store().Contains(metadata.Destination.Addr) {
 		domain, loaded := r.dnsTransport.FakeIP().Store().Lookup(metadata.Destination.Addr)
 		if !loaded {
@@ -484,13 +503,19 @@ func (r *Router) matchRule(
 		metadata.IPVersion = 6
 	}
 
+	tracer := adapter.RuleTracerFromContext(ctx)
 match:
 	for currentRuleIndex, currentRule := range r.rules {
 		metadata.ResetRuleCache()
-		if !currentRule.Match(metadata) {
+		matched := currentRule.Match(metadata)
+		if tracer != nil {
+			R.TraceRule(tracer, C.RuleTraceTypeRoute, currentRuleIndex, currentRule, matched, metadata)
+		}
+		if !matched {
 			continue
 		}
 		if !preMatch {
+			currentRule.Statistics().Hit()
 			ruleDescription := currentRule.String()
 			if ruleDescription != "" {
 				r.logger.DebugContext(ctx, "match[", currentRuleIndex, "] ", currentRule, " => ", currentRule.Action())
@@ -566,7 +591,9 @@ match:
 		}
 		switch action := currentRule.Action().(type) {
 		case *R.RuleActionSniff:
-			if !preMatch {
+			if tracer != nil && inputConn == nil && inputPacketConn == nil {
+				r.simulateSniff(ctx, metadata, action, tracer)
+			} else if !preMatch {
 				newBuffer, newPacketBuffers, newErr := r.actionSniff(ctx, metadata, action, inputConn, inputPacketConn, buffers, packetBuffers)
 				if newBuffer != nil {
 					buffers = append(buffers, newBuffer)
@@ -587,6 +614,10 @@ match:
 			if fatalErr != nil {
 				return
 			}
+		case *R.RuleActionMirror:
+			if !preMatch {
+				metadata.Mirrors = append(metadata.Mirrors, action.Target)
+			}
 		}
 		actionType := currentRule.Action().Type()
 		if actionType == C.RuleActionTypeRoute ||
@@ -0,0 +1,133 @@
+package route
+
+import (
+	"context"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+var _ adapter.RuleTracer = (*explainTracer)(nil)
+
+type explainTracer struct {
+	explanation *adapter.RouteExplanation
+	sniffResult adapter.SniffResult
+}
+
+func (t *explainTracer) TraceRule(trace adapter.RuleTrace) {
+	t.explanation.Rules = append(t.explanation.Rules, trace)
+}
+
+func (t *explainTracer) SimulateSniff(metadata *adapter.InboundContext) {
+	metadata.Protocol = t.sniffResult.Protocol
+	metadata.Domain = t.sniffResult.Domain
+	metadata.Client = t.sniffResult.Client
+}
+
+func (r *Router) simulateSniff(ctx context.Context, metadata *adapter.InboundContext, action *R.RuleActionSniff, tracer adapter.RuleTracer) {
+	if sniff.Skip(metadata) {
+		r.logger.DebugContext(ctx, "sniff skipped due to port considered as server-first")
+		return
+	} else if metadata.Protocol != "" {
+		r.logger.DebugContext(ctx, "duplicate sniff skipped")
+		return
+	}
+	tracer.SimulateSniff(metadata)
+	metadata.SnifferNames = action.SnifferNames
+	if metadata.Protocol == "" {
+		return
+	}
+	//goland:noinspection GoDeprecation
+	if action.OverrideDestination && M.IsDomainName(metadata.Domain) {
+		metadata.Destination = M.Socksaddr{
+			Fqdn: metadata.Domain,
+			Port: metadata.Destination.Port,
+		}
+	}
+	r.logger.DebugContext(ctx, "simulated sniffed protocol: ", metadata.Protocol)
+}
+
+func (r *Router) ExplainRoute(ctx context.Context, metadata adapter.InboundContext, sniffResult adapter.SniffResult) (*adapter.RouteExplanation, error) {
+	if metadata.Network != N.NetworkTCP && metadata.Network != N.NetworkUDP {
+		return nil, E.New("unsupported network: ", metadata.Network)
+	}
+	explanation := &adapter.RouteExplanation{
+		RuleIndex: -1,
+	}
+	ctx = adapter.WithRuleTracer(ctx, &explainTracer{
+		explanation: explanation,
+		sniffResult: sniffResult,
+	})
+	ctx = adapter.WithContext(ctx, &metadata)
+	selectedRule, selectedRuleIndex, _, _, err := r.matchRule(ctx, &metadata, false, false, nil, nil)
+	explanation.Protocol = metadata.Protocol
+	explanation.Domain = metadata.Domain
+	explanation.Destination = metadata.Destination.String()
+	explanation.DestinationAddresses = F.MapToString(metadata.DestinationAddresses)
+	if err != nil {
+		explanation.Error = err.Error()
+		return explanation, nil
+	}
+	var selectedOutbound adapter.Outbound
+	if selectedRule != nil {
+		explanation.RuleIndex = selectedRuleIndex
+		explanation.Action = selectedRule.Action().Type()
+		switch action := selectedRule.Action().(type) {
+		case *R.RuleActionRoute:
+			var loaded bool
+			selectedOutbound, loaded = r.outbound.Outbound(action.Outbound)
+			if !loaded {
+				explanation.Error = F.ToString("outbound not found: ", action.Outbound)
+				return explanation, nil
+			}
+		case *R.RuleActionBypass:
+			if action.Outbound == "" {
+				break
+			}
+			var loaded bool
+			selectedOutbound, loaded = r.outbound.Outbound(action.Outbound)
+			if !loaded {
+				explanation.Error = F.ToString("outbound not found: ", action.Outbound)
+				return explanation, nil
+			}
+		case *R.RuleActionReject:
+			explanation.Error = action.Error(ctx).Error()
+			return explanation, nil
+		case *R.RuleActionHijackDNS:
+			return explanation, nil
+		}
+	}
+	if selectedOutbound == nil {
+		explanation.Action = C.RuleActionTypeRoute
+		selectedOutbound = r.outbound.Default()
+	}
+	if !common.Contains(selectedOutbound.Network(), metadata.Network) {
+		explanation.Error = F.ToString(metadata.Network, " is not supported by outbound: ", selectedOutbound.Tag())
+	}
+	explanation.Outbound = selectedOutbound.Tag()
+	next := selectedOutbound.Tag()
+	for {
+		detour, loaded := r.outbound.Outbound(next)
+		if !loaded {
+			break
+		}
+		explanation.Chain = append(explanation.Chain, next)
+		if outboundChain, isChain := detour.(adapter.OutboundChain); isChain {
+			explanation.Chain = append(explanation.Chain, outboundChain.Hops()...)
+			break
+		}
+		group, isGroup := detour.(adapter.OutboundGroup)
+		if !isGroup {
+			break
+		}
+		next = group.Now()
+	}
+	return explanation, nil
+}
@@ -32,7 +32,11 @@ type Router struct {
 	rules             []adapter.Rule
 	needFindProcess   bool
 	needFindNeighbor  bool
+	needFindDestMAC   bool
 	leaseFiles        []string
+	deviceOptions     []option.DeviceOptions
+	devicesPath       string
+	devices           *deviceTable
 	ruleSets          []adapter.RuleSet
 	ruleSetMap        map[string]adapter.RuleSet
 	processSearcher   process.Searcher
@@ -56,8 +60,11 @@ func NewRouter(ctx context.Context, logFactory log.Factory, options option.Route
 		rules:             make([]adapter.Rule, 0, len(options.Rules)),
 		ruleSetMap:        make(map[string]adapter.RuleSet),
 		needFindProcess:   hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
-		needFindNeighbor:  hasRule(options.Rules, isNeighborRule) || hasDNSRule(dnsOptions.Rules, isNeighborDNSRule) || options.FindNeighbor,
+		needFindNeighbor:  hasRule(options.Rules, isNeighborRule) || hasDNSRule(dnsOptions.Rules, isNeighborDNSRule) || options.FindNeighbor || len(options.Devices) > 0 || options.DevicesPath != "",
+		needFindDestMAC:   hasRule(options.Rules, isDestinationMACRule),
 		leaseFiles:        options.DHCPLeaseFiles,
+		deviceOptions:     options.Devices,
+		devicesPath:       options.DevicesPath,
 		pauseManager:      service.FromContext[pause.Manager](ctx),
 		platformInterface: service.FromContext[adapter.PlatformInterface](ctx),
 	}
@@ -82,6 +89,13 @@ func (r *Router) Initialize(rules []option.Rule, ruleSets []option.RuleSet) erro
 		r.ruleSets = append(r.ruleSets, ruleSet)
 		r.ruleSetMap[options.Tag] = ruleSet
 	}
+	if len(r.deviceOptions) > 0 || r.devicesPath != "" {
+		devices, err := newDeviceTable(r.ctx, r.logger, r.deviceOptions, r.devicesPath, r.updateDevices)
+		if err != nil {
+			return E.Cause(err, "parse devices")
+		}
+		r.devices = devices
+	}
 	return nil
 }
 
@@ -147,6 +161,9 @@ func (r *Router) Start(stage adapter.StartStage) error {
 				}
 			}
 		}
+		if r.devices != nil {
+			r.devices.Start()
+		}
 		r.needFindNeighbor = needFindNeighbor
 		if needFindNeighbor {
 			if r.platformInterface != nil && r.platformInterface.UsePlatformNeighborResolver() {
@@ -215,6 +232,11 @@ func (r *Router) Close() error {
 		})
 		monitor.Finish()
 	}
+	if r.devices != nil {
+		err = E.Append(err, r.devices.Close(), func(closeErr error) error {
+			return E.Cause(closeErr, "close devices")
+		})
+	}
 	for i, rule := range r.rules {
 		monitor.Start("close rule[", i, "]")
 		err = E.Append(err, rule.Close(), func(err error) error {
@@ -245,6 +267,15 @@ func (r *Router) AppendTracker(tracker adapter.ConnectionTracker) {
 	r.trackers = append(r.trackers, tracker)
 }
 
+func (r *Router) updateDevices(devices []*adapter.Device) {
+	for _, tracker := range r.trackers {
+		deviceTracker, isDeviceTracker := tracker.(adapter.DeviceConnectionTracker)
+		if isDeviceTracker {
+			deviceTracker.UpdateDevices(devices)
+		}
+	}
+}
+
 func (r *Router) NeedFindProcess() bool {
 	return r.needFindProcess
 }
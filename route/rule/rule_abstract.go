@@ -21,6 +21,7 @@ type abstractDefaultRule struct {
 	ruleSetItem             RuleItem
 	invert                  bool
 	action                  adapter.RuleAction
+	statistics              adapter.RuleStatistics
 }
 
 func (r *abstractDefaultRule) Type() string {
@@ -38,7 +39,7 @@ func (r *abstractDefaultRule) Start() error {
 			}
 		}
 	}
-	return nil
+	return startAction(r.action)
 }
 
 func (r *abstractDefaultRule) Close() error {
@@ -48,7 +49,7 @@ func (r *abstractDefaultRule) Close() error {
 			return err
 		}
 	}
-	return nil
+	return common.Close(r.action)
 }
 
 func (r *abstractDefaultRule) Match(metadata *adapter.InboundContext) bool {
@@ -140,6 +141,10 @@ func (r *abstractDefaultRule) Action() adapter.RuleAction {
 	return r.action
 }
 
+func (r *abstractDefaultRule) Statistics() *adapter.RuleStatistics {
+	return &r.statistics
+}
+
 func (r *abstractDefaultRule) String() string {
 	if !r.invert {
 		return strings.Join(F.MapToString(r.allItems), " ")
@@ -149,10 +154,11 @@ func (r *abstractDefaultRule) String() string {
 }
 
 type abstractLogicalRule struct {
-	rules  []adapter.HeadlessRule
-	mode   string
-	invert bool
-	action adapter.RuleAction
+	rules      []adapter.HeadlessRule
+	mode       string
+	invert     bool
+	action     adapter.RuleAction
+	statistics adapter.RuleStatistics
 }
 
 func (r *abstractLogicalRule) Type() string {
@@ -174,7 +180,7 @@ func (r *abstractLogicalRule) Start() error {
 			return err
 		}
 	}
-	return nil
+	return startAction(r.action)
 }
 
 func (r *abstractLogicalRule) Close() error {
@@ -187,7 +193,7 @@ func (r *abstractLogicalRule) Close() error {
 			return err
 		}
 	}
-	return nil
+	return common.Close(r.action)
 }
 
 func (r *abstractLogicalRule) Match(metadata *adapter.InboundContext) bool {
@@ -208,6 +214,10 @@ func (r *abstractLogicalRule) Action() adapter.RuleAction {
 	return r.action
 }
 
+func (r *abstractLogicalRule) Statistics() *adapter.RuleStatistics {
+	return &r.statistics
+}
+
 func (r *abstractLogicalRule) String() string {
 	var op string
 	switch r.mode {
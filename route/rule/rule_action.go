@@ -10,6 +10,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/mirror"
 	"github.com/sagernet/sing-box/common/sniff"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -113,11 +114,24 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 			RewriteTTL:   action.ResolveOptions.RewriteTTL,
 			ClientSubnet: action.ResolveOptions.ClientSubnet.Build(netip.Prefix{}),
 		}, nil
+	case C.RuleActionTypeMirror:
+		return &RuleActionMirror{
+			Target: mirror.NewTarget(ctx, logger, action.MirrorOptions),
+		}, nil
 	default:
 		panic(F.ToString("unknown rule action: ", action.Action))
 	}
 }
 
+func startAction(action adapter.RuleAction) error {
+	if starter, isStarter := action.(interface {
+		Start() error
+	}); isStarter {
+		return starter.Start()
+	}
+	return nil
+}
+
 func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction) adapter.RuleAction {
 	switch action.Action {
 	case "":
@@ -506,6 +520,26 @@ func (r *RuleActionResolve) String() string {
 	}
 }
 
+type RuleActionMirror struct {
+	Target mirror.Target
+}
+
+func (r *RuleActionMirror) Type() string {
+	return C.RuleActionTypeMirror
+}
+
+func (r *RuleActionMirror) Start() error {
+	return r.Target.Start()
+}
+
+func (r *RuleActionMirror) Close() error {
+	return r.Target.Close()
+}
+
+func (r *RuleActionMirror) String() string {
+	return F.ToString("mirror(", r.Target.String(), ")")
+}
+
 type RuleActionPredefined struct {
 	Rcode  int
 	Answer []dns.RR
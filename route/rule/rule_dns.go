@@ -86,6 +86,21 @@ func NewDefaultDNSRule(ctx context.Context, logger log.ContextLogger, options op
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.ClientCertificateCommonName) > 0 {
+		item := NewClientCertificateCommonNameItem(options.ClientCertificateCommonName)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ClientCertificateSAN) > 0 {
+		item := NewClientCertificateSANItem(options.ClientCertificateSAN)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ClientCertificatePublicKeySHA256) > 0 {
+		item := NewClientCertificatePublicKeySHA256Item(options.ClientCertificatePublicKeySHA256)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.Protocol) > 0 {
 		item := NewProtocolItem(options.Protocol)
 		rule.items = append(rule.items, item)
@@ -271,6 +286,16 @@ func NewDefaultDNSRule(ctx context.Context, logger log.ContextLogger, options op
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.SourceDevice) > 0 {
+		item := NewSourceDeviceItem(options.SourceDevice)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.SourceDeviceGroup) > 0 {
+		item := NewSourceDeviceGroupItem(options.SourceDeviceGroup)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.RuleSet) > 0 {
 		//nolint:staticcheck
 		if options.Deprecated_RulesetIPCIDRMatchSource {
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/compact"
 	E "github.com/sagernet/sing/common/exceptions"
 
 	"go4.org/netipx"
@@ -13,11 +14,15 @@ import (
 var _ RuleItem = (*IPCIDRItem)(nil)
 
 type IPCIDRItem struct {
-	ipSet       *netipx.IPSet
+	ipSet       ipSetMatcher
 	isSource    bool
 	description string
 }
 
+type ipSetMatcher interface {
+	Contains(addr netip.Addr) bool
+}
+
 func NewIPCIDRItem(isSource bool, prefixStrings []string) (*IPCIDRItem, error) {
 	var builder netipx.IPSetBuilder
 	for i, prefixString := range prefixStrings {
@@ -58,6 +63,14 @@ func NewIPCIDRItem(isSource bool, prefixStrings []string) (*IPCIDRItem, error) {
 }
 
 func NewRawIPCIDRItem(isSource bool, ipSet *netipx.IPSet) *IPCIDRItem {
+	return newRawIPCIDRItem(isSource, ipSet)
+}
+
+func NewCompactIPCIDRItem(isSource bool, ipSet *compact.IPSet) *IPCIDRItem {
+	return newRawIPCIDRItem(isSource, ipSet)
+}
+
+func newRawIPCIDRItem(isSource bool, ipSet ipSetMatcher) *IPCIDRItem {
 	var description string
 	if isSource {
 		description = "source_ip_cidr="
@@ -72,6 +85,17 @@ func NewRawIPCIDRItem(isSource bool, ipSet *netipx.IPSet) *IPCIDRItem {
 	}
 }
 
+func (r *IPCIDRItem) IPSet() *netipx.IPSet {
+	switch ipSet := r.ipSet.(type) {
+	case *netipx.IPSet:
+		return ipSet
+	case *compact.IPSet:
+		return ipSet.IPSet()
+	default:
+		panic("unexpected ip set type")
+	}
+}
+
 func (r *IPCIDRItem) Match(metadata *adapter.InboundContext) bool {
 	if r.isSource || metadata.IPCIDRMatchSource {
 		return r.ipSet.Contains(metadata.Source.Addr)
@@ -0,0 +1,106 @@
+package rule
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var (
+	_ RuleItem = (*ClientCertificateCommonNameItem)(nil)
+	_ RuleItem = (*ClientCertificateSANItem)(nil)
+	_ RuleItem = (*ClientCertificatePublicKeySHA256Item)(nil)
+)
+
+type ClientCertificateCommonNameItem struct {
+	names   []string
+	nameMap map[string]bool
+}
+
+func NewClientCertificateCommonNameItem(names []string) *ClientCertificateCommonNameItem {
+	nameMap := make(map[string]bool)
+	for _, name := range names {
+		nameMap[name] = true
+	}
+	return &ClientCertificateCommonNameItem{
+		names:   names,
+		nameMap: nameMap,
+	}
+}
+
+func (r *ClientCertificateCommonNameItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.ClientCertificateCommonName == "" {
+		return false
+	}
+	return r.nameMap[metadata.ClientCertificateCommonName]
+}
+
+func (r *ClientCertificateCommonNameItem) String() string {
+	if len(r.names) == 1 {
+		return F.ToString("client_certificate_common_name=", r.names[0])
+	}
+	return F.ToString("client_certificate_common_name=[", strings.Join(r.names, " "), "]")
+}
+
+type ClientCertificateSANItem struct {
+	names   []string
+	nameMap map[string]bool
+}
+
+func NewClientCertificateSANItem(names []string) *ClientCertificateSANItem {
+	nameMap := make(map[string]bool)
+	for _, name := range names {
+		nameMap[name] = true
+	}
+	return &ClientCertificateSANItem{
+		names:   names,
+		nameMap: nameMap,
+	}
+}
+
+func (r *ClientCertificateSANItem) Match(metadata *adapter.InboundContext) bool {
+	return common.Any(metadata.ClientCertificateSAN, func(it string) bool {
+		return r.nameMap[it]
+	})
+}
+
+func (r *ClientCertificateSANItem) String() string {
+	if len(r.names) == 1 {
+		return F.ToString("client_certificate_san=", r.names[0])
+	}
+	return F.ToString("client_certificate_san=[", strings.Join(r.names, " "), "]")
+}
+
+type ClientCertificatePublicKeySHA256Item struct {
+	hashValues   [][]byte
+	hashValueMap map[string]bool
+}
+
+func NewClientCertificatePublicKeySHA256Item(hashValues [][]byte) *ClientCertificatePublicKeySHA256Item {
+	hashValueMap := make(map[string]bool)
+	for _, hashValue := range hashValues {
+		hashValueMap[string(hashValue)] = true
+	}
+	return &ClientCertificatePublicKeySHA256Item{
+		hashValues:   hashValues,
+		hashValueMap: hashValueMap,
+	}
+}
+
+func (r *ClientCertificatePublicKeySHA256Item) Match(metadata *adapter.InboundContext) bool {
+	if len(metadata.ClientCertificatePublicKeySHA256) == 0 {
+		return false
+	}
+	return r.hashValueMap[string(metadata.ClientCertificatePublicKeySHA256)]
+}
+
+func (r *ClientCertificatePublicKeySHA256Item) String() string {
+	hashValues := common.Map(r.hashValues, base64.StdEncoding.EncodeToString)
+	if len(hashValues) == 1 {
+		return F.ToString("client_certificate_public_key_sha256=", hashValues[0])
+	}
+	return F.ToString("client_certificate_public_key_sha256=[", strings.Join(hashValues, " "), "]")
+}
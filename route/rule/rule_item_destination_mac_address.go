@@ -0,0 +1,48 @@
+package rule
+
+import (
+	"net"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*DestinationMACAddressItem)(nil)
+
+type DestinationMACAddressItem struct {
+	addresses  []string
+	addressMap map[string]bool
+}
+
+func NewDestinationMACAddressItem(addressList []string) *DestinationMACAddressItem {
+	rule := &DestinationMACAddressItem{
+		addresses:  addressList,
+		addressMap: make(map[string]bool),
+	}
+	for _, address := range addressList {
+		parsed, err := net.ParseMAC(address)
+		if err == nil {
+			rule.addressMap[parsed.String()] = true
+		} else {
+			rule.addressMap[address] = true
+		}
+	}
+	return rule
+}
+
+func (r *DestinationMACAddressItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.DestinationMACAddress == nil {
+		return false
+	}
+	return r.addressMap[metadata.DestinationMACAddress.String()]
+}
+
+func (r *DestinationMACAddressItem) String() string {
+	var description string
+	if len(r.addresses) == 1 {
+		description = "destination_mac_address=" + r.addresses[0]
+	} else {
+		description = "destination_mac_address=[" + strings.Join(r.addresses, " ") + "]"
+	}
+	return description
+}
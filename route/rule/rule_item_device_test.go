@@ -0,0 +1,40 @@
+package rule
+
+import (
+	"net"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceDeviceItem(t *testing.T) {
+	t.Parallel()
+	item := NewSourceDeviceItem([]string{"laptop", "phone"})
+	require.Equal(t, "source_device=[laptop phone]", item.String())
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.True(t, item.Match(&adapter.InboundContext{SourceDevice: &adapter.Device{Name: "phone"}}))
+	require.False(t, item.Match(&adapter.InboundContext{SourceDevice: &adapter.Device{Name: "tablet"}}))
+}
+
+func TestSourceDeviceGroupItem(t *testing.T) {
+	t.Parallel()
+	item := NewSourceDeviceGroupItem([]string{"family"})
+	require.Equal(t, "source_device_group=family", item.String())
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	require.False(t, item.Match(&adapter.InboundContext{SourceDevice: &adapter.Device{Name: "laptop"}}))
+	require.True(t, item.Match(&adapter.InboundContext{SourceDevice: &adapter.Device{Name: "phone", Groups: []string{"guest", "family"}}}))
+}
+
+func TestDestinationMACAddressItem(t *testing.T) {
+	t.Parallel()
+	item := NewDestinationMACAddressItem([]string{"AA-BB-CC-DD-EE-FF"})
+	require.False(t, item.Match(&adapter.InboundContext{}))
+	macAddress, err := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	require.NoError(t, err)
+	require.True(t, item.Match(&adapter.InboundContext{DestinationMACAddress: macAddress}))
+	macAddress, err = net.ParseMAC("aa:bb:cc:dd:ee:00")
+	require.NoError(t, err)
+	require.False(t, item.Match(&adapter.InboundContext{DestinationMACAddress: macAddress}))
+}
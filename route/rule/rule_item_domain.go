@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/compact"
 	"github.com/sagernet/sing/common/domain"
 	E "github.com/sagernet/sing/common/exceptions"
 )
@@ -11,10 +12,14 @@ import (
 var _ RuleItem = (*DomainItem)(nil)
 
 type DomainItem struct {
-	matcher     *domain.Matcher
+	matcher     domainMatcher
 	description string
 }
 
+type domainMatcher interface {
+	Match(domain string) bool
+}
+
 func NewDomainItem(domains []string, domainSuffixes []string) (*DomainItem, error) {
 	for _, domainItem := range domains {
 		if domainItem == "" {
@@ -61,6 +66,13 @@ func NewRawDomainItem(matcher *domain.Matcher) *DomainItem {
 	}
 }
 
+func NewCompactDomainItem(set *compact.DomainSet) *DomainItem {
+	return &DomainItem{
+		set,
+		"domain/domain_suffix=<binary>",
+	}
+}
+
 func (r *DomainItem) Match(metadata *adapter.InboundContext) bool {
 	var domainHost string
 	if metadata.Domain != "" {
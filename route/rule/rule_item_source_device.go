@@ -0,0 +1,42 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*SourceDeviceItem)(nil)
+
+type SourceDeviceItem struct {
+	names   []string
+	nameMap map[string]bool
+}
+
+func NewSourceDeviceItem(nameList []string) *SourceDeviceItem {
+	rule := &SourceDeviceItem{
+		names:   nameList,
+		nameMap: make(map[string]bool),
+	}
+	for _, name := range nameList {
+		rule.nameMap[name] = true
+	}
+	return rule
+}
+
+func (r *SourceDeviceItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.SourceDevice == nil {
+		return false
+	}
+	return r.nameMap[metadata.SourceDevice.Name]
+}
+
+func (r *SourceDeviceItem) String() string {
+	var description string
+	if len(r.names) == 1 {
+		description = "source_device=" + r.names[0]
+	} else {
+		description = "source_device=[" + strings.Join(r.names, " ") + "]"
+	}
+	return description
+}
@@ -0,0 +1,47 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+)
+
+var _ RuleItem = (*SourceDeviceGroupItem)(nil)
+
+type SourceDeviceGroupItem struct {
+	groups   []string
+	groupMap map[string]bool
+}
+
+func NewSourceDeviceGroupItem(groupList []string) *SourceDeviceGroupItem {
+	rule := &SourceDeviceGroupItem{
+		groups:   groupList,
+		groupMap: make(map[string]bool),
+	}
+	for _, group := range groupList {
+		rule.groupMap[group] = true
+	}
+	return rule
+}
+
+func (r *SourceDeviceGroupItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.SourceDevice == nil {
+		return false
+	}
+	for _, group := range metadata.SourceDevice.Groups {
+		if r.groupMap[group] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SourceDeviceGroupItem) String() string {
+	var description string
+	if len(r.groups) == 1 {
+		description = "source_device_group=" + r.groups[0]
+	} else {
+		description = "source_device_group=[" + strings.Join(r.groups, " ") + "]"
+	}
+	return description
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sagernet/fswatch"
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/mmap"
 	"github.com/sagernet/sing-box/common/srs"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -110,11 +111,11 @@ func (s *LocalRuleSet) reloadFile(path string) error {
 		}
 
 	case C.RuleSetFormatBinary:
-		setFile, err := os.Open(path)
+		setFile, err := mmap.Open(path)
 		if err != nil {
 			return err
 		}
-		ruleSet, err = srs.Read(setFile, false)
+		ruleSet, err = srs.ReadBytes(setFile.Bytes(), setFile, false)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,67 @@
+package rule
+
+import (
+	"github.com/sagernet/sing-box/adapter"
+)
+
+type conditionExplainer interface {
+	explainConditions(metadata *adapter.InboundContext) []adapter.RuleTraceCondition
+}
+
+// TraceRule reports the match result of a rule with its conditions to the tracer.
+func TraceRule(tracer adapter.RuleTracer, traceType string, index int, rule adapter.Rule, matched bool, metadata *adapter.InboundContext) {
+	tracer.TraceRule(adapter.RuleTrace{
+		Type:       traceType,
+		Index:      index,
+		Rule:       rule.String(),
+		Action:     rule.Action().String(),
+		Matched:    matched,
+		Conditions: ExplainConditions(rule, metadata),
+	})
+}
+
+// TraceSkippedRule reports a rule not evaluated for the reason to the tracer.
+func TraceSkippedRule(tracer adapter.RuleTracer, traceType string, index int, rule adapter.Rule, reason string) {
+	tracer.TraceRule(adapter.RuleTrace{
+		Type:    traceType,
+		Index:   index,
+		Rule:    rule.String(),
+		Action:  rule.Action().String(),
+		Skipped: reason,
+	})
+}
+
+// ExplainConditions evaluates each condition of the rule independently against a copy of metadata.
+func ExplainConditions(rule adapter.HeadlessRule, metadata *adapter.InboundContext) []adapter.RuleTraceCondition {
+	explainer, isExplainer := rule.(conditionExplainer)
+	if !isExplainer {
+		return nil
+	}
+	return explainer.explainConditions(metadata)
+}
+
+func (r *abstractDefaultRule) explainConditions(metadata *adapter.InboundContext) []adapter.RuleTraceCondition {
+	conditions := make([]adapter.RuleTraceCondition, 0, len(r.allItems))
+	for _, item := range r.allItems {
+		itemMetadata := *metadata
+		itemMetadata.ResetRuleCache()
+		conditions = append(conditions, adapter.RuleTraceCondition{
+			Condition: item.String(),
+			Matched:   item.Match(&itemMetadata),
+		})
+	}
+	return conditions
+}
+
+func (r *abstractLogicalRule) explainConditions(metadata *adapter.InboundContext) []adapter.RuleTraceCondition {
+	conditions := make([]adapter.RuleTraceCondition, 0, len(r.rules))
+	for _, rule := range r.rules {
+		ruleMetadata := *metadata
+		ruleMetadata.ResetRuleCache()
+		conditions = append(conditions, adapter.RuleTraceCondition{
+			Condition: "(" + rule.String() + ")",
+			Matched:   rule.Match(&ruleMetadata),
+		})
+	}
+	return conditions
+}
@@ -0,0 +1,41 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainConditions(t *testing.T) {
+	t.Parallel()
+	matchedItem := &fakeRuleItem{matched: true}
+	failedItem := &fakeRuleItem{matched: false}
+	defaultRule := &DefaultRule{
+		abstractDefaultRule: abstractDefaultRule{
+			items:    []RuleItem{matchedItem, failedItem},
+			allItems: []RuleItem{matchedItem, failedItem},
+		},
+	}
+	metadata := &adapter.InboundContext{}
+	require.Equal(t, []adapter.RuleTraceCondition{
+		{Condition: "fake-rule-item", Matched: true},
+		{Condition: "fake-rule-item", Matched: false},
+	}, ExplainConditions(defaultRule, metadata))
+	require.False(t, metadata.DidMatch)
+	logicalRule := &LogicalRule{
+		abstractLogicalRule: abstractLogicalRule{
+			mode: C.LogicalTypeOr,
+			rules: []adapter.HeadlessRule{
+				newSingleItemRule(true),
+				newRuleSetOnlyRule(true, true),
+			},
+		},
+	}
+	require.Equal(t, []adapter.RuleTraceCondition{
+		{Condition: "(fake-rule-item)", Matched: true},
+		{Condition: "(!(rule_set=[]))", Matched: false},
+	}, ExplainConditions(logicalRule, metadata))
+}
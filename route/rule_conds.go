@@ -46,11 +46,15 @@ func isProcessDNSRule(rule option.DefaultDNSRule) bool {
 }
 
 func isNeighborRule(rule option.DefaultRule) bool {
-	return len(rule.SourceMACAddress) > 0 || len(rule.SourceHostname) > 0
+	return len(rule.SourceMACAddress) > 0 || len(rule.SourceHostname) > 0 || len(rule.SourceDevice) > 0 || len(rule.SourceDeviceGroup) > 0 || len(rule.DestinationMACAddress) > 0
+}
+
+func isDestinationMACRule(rule option.DefaultRule) bool {
+	return len(rule.DestinationMACAddress) > 0
 }
 
 func isNeighborDNSRule(rule option.DefaultDNSRule) bool {
-	return len(rule.SourceMACAddress) > 0 || len(rule.SourceHostname) > 0
+	return len(rule.SourceMACAddress) > 0 || len(rule.SourceHostname) > 0 || len(rule.SourceDevice) > 0 || len(rule.SourceDeviceGroup) > 0
 }
 
 func isWIFIRule(rule option.DefaultRule) bool {
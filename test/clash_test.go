@@ -40,6 +40,7 @@ const (
 	ImageShadowsocksLegacy     = "mritd/shadowsocks:latest"
 	ImageTUICServer            = "kilvn/tuic-server:latest"
 	ImageTUICClient            = "kilvn/tuic-client:latest"
+	ImageOpenVPN               = "kylemanna/openvpn:latest"
 )
 
 var allImages = []string{
@@ -57,6 +58,7 @@ var allImages = []string{
 	ImageShadowsocksLegacy,
 	ImageTUICServer,
 	ImageTUICClient,
+	ImageOpenVPN,
 }
 
 var localIP = netip.MustParseAddr("127.0.0.1")
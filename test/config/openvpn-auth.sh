@@ -0,0 +1,2 @@
+#!/bin/sh
+[ "$username" = "sekai" ] && [ "$password" = "password" ]
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/netip"
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+func TestDemuxInbound(t *testing.T) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	trojanOutbound := func(tag string, alpn []string) option.Outbound {
+		return option.Outbound{
+			Type: C.TypeTrojan,
+			Tag:  tag,
+			Options: &option.TrojanOutboundOptions{
+				ServerOptions: option.ServerOptions{
+					Server:     "127.0.0.1",
+					ServerPort: serverPort,
+				},
+				Password: "password",
+				OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+					TLS: &option.OutboundTLSOptions{
+						Enabled:         true,
+						ServerName:      "example.org",
+						CertificatePath: certPem,
+						ALPN:            alpn,
+					},
+				},
+			},
+		}
+	}
+	routeRule := func(inbound string, outbound string) option.Rule {
+		return option.Rule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{
+					Inbound: []string{inbound},
+				},
+				RuleAction: option.RuleAction{
+					Action: C.RuleActionTypeRoute,
+
+					RouteOptions: option.RouteActionOptions{
+						Outbound: outbound,
+					},
+				},
+			},
+		}
+	}
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-raw-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: otherClientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeDemux,
+				Options: &option.DemuxInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: serverPort,
+					},
+					Rules: []option.DemuxRule{
+						{
+							ALPN: []string{"h2"},
+							DemuxTarget: option.DemuxTarget{
+								Inbound: "trojan-in",
+							},
+						},
+						{
+							ServerName: []string{"*.org"},
+							Protocol:   []string{C.ProtocolTLS},
+							DemuxTarget: option.DemuxTarget{
+								Server:     "127.0.0.1",
+								ServerPort: otherPort,
+							},
+						},
+					},
+				},
+			},
+			{
+				Type: C.TypeTrojan,
+				Tag:  "trojan-in",
+				Options: &option.TrojanInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: otherPort,
+					},
+					Users: []option.TrojanUser{
+						{
+							Name:     "sekai",
+							Password: "password",
+						},
+					},
+					InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+						TLS: &option.InboundTLSOptions{
+							Enabled:         true,
+							ServerName:      "example.org",
+							CertificatePath: certPem,
+							KeyPath:         keyPem,
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+			},
+			trojanOutbound("trojan-out", []string{"h2"}),
+			trojanOutbound("trojan-raw-out", nil),
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				routeRule("mixed-in", "trojan-out"),
+				routeRule("mixed-raw-in", "trojan-raw-out"),
+			},
+		},
+	})
+	testTCP(t, clientPort, testPort)
+	testTCP(t, otherClientPort, testPort)
+}
@@ -10,8 +10,7 @@ import (
 	"github.com/sagernet/sing/common/json/badoption"
 )
 
-// Since this is a feature one-off added by outsiders, I won't address these anymore.
-func _TestProxyProtocol(t *testing.T) {
+func TestProxyProtocol(t *testing.T) {
 	startInstance(t, option.Options{
 		Inbounds: []option.Inbound{
 			{
@@ -28,10 +27,13 @@ func _TestProxyProtocol(t *testing.T) {
 				Type: C.TypeDirect,
 				Options: &option.DirectInboundOptions{
 					ListenOptions: option.ListenOptions{
-						Listen:        common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
-						ListenPort:    serverPort,
-						ProxyProtocol: true,
+						Listen:                   common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort:               serverPort,
+						ProxyProtocol:            true,
+						ProxyProtocolTrustedCIDR: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
 					},
+					OverrideAddress: "127.0.0.1",
+					OverridePort:    testPort,
 				},
 			},
 		},
@@ -43,9 +45,9 @@ func _TestProxyProtocol(t *testing.T) {
 				Type: C.TypeDirect,
 				Tag:  "proxy-out",
 				Options: &option.DirectOutboundOptions{
-					OverrideAddress: "127.0.0.1",
-					OverridePort:    serverPort,
-					ProxyProtocol:   2,
+					DialerOptions: option.DialerOptions{
+						ProxyProtocol: 2,
+					},
 				},
 			},
 		},
@@ -62,6 +64,10 @@ func _TestProxyProtocol(t *testing.T) {
 
 							RouteOptions: option.RouteActionOptions{
 								Outbound: "proxy-out",
+								RawRouteOptionsActionOptions: option.RawRouteOptionsActionOptions{
+									OverrideAddress: "127.0.0.1",
+									OverridePort:    serverPort,
+								},
 							},
 						},
 					},
@@ -7,6 +7,7 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/auth"
 	"github.com/sagernet/sing/common/json/badoption"
 )
 
@@ -70,3 +71,224 @@ func TestHTTPSelf(t *testing.T) {
 	})
 	testTCP(t, clientPort, testPort)
 }
+
+func TestHTTPSelfTLS(t *testing.T) {
+	t.Run("http2", func(t *testing.T) {
+		testHTTPSelfTLS(t, []string{"h2", "http/1.1"}, []string{"h2"})
+	})
+	t.Run("http2-fallback", func(t *testing.T) {
+		testHTTPSelfTLS(t, []string{"http/1.1"}, []string{"h2", "http/1.1"})
+	})
+	t.Run("http3", func(t *testing.T) {
+		testHTTPSelfTLS(t, []string{"h3", "h2", "http/1.1"}, []string{"h3"})
+	})
+}
+
+func testHTTPSelfTLS(t *testing.T, serverALPN []string, clientALPN []string) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeHTTP,
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: serverPort,
+					},
+					Users: []auth.User{{
+						Username: "sekai",
+						Password: "password",
+					}},
+					InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+						TLS: &option.InboundTLSOptions{
+							Enabled:         true,
+							ServerName:      "example.org",
+							ALPN:            serverALPN,
+							CertificatePath: certPem,
+							KeyPath:         keyPem,
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+			},
+			{
+				Type: C.TypeHTTP,
+				Tag:  "http-out",
+				Options: &option.HTTPOutboundOptions{
+					ServerOptions: option.ServerOptions{
+						Server:     "127.0.0.1",
+						ServerPort: serverPort,
+					},
+					Username: "sekai",
+					Password: "password",
+					OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+						TLS: &option.OutboundTLSOptions{
+							Enabled:         true,
+							ServerName:      "example.org",
+							ALPN:            clientALPN,
+							CertificatePath: certPem,
+						},
+					},
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound: []string{"mixed-in"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeRoute,
+
+							RouteOptions: option.RouteActionOptions{
+								Outbound: "http-out",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	testTCP(t, clientPort, testPort)
+}
+
+func TestHTTPClientCertificateUser(t *testing.T) {
+	t.Run("http2", func(t *testing.T) {
+		testHTTPClientCertificateUser(t, []string{"h2", "http/1.1"}, []string{"h2"})
+	})
+	t.Run("http3", func(t *testing.T) {
+		testHTTPClientCertificateUser(t, []string{"h3", "h2", "http/1.1"}, []string{"h3"})
+	})
+}
+
+func testHTTPClientCertificateUser(t *testing.T, serverALPN []string, clientALPN []string) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	clientCertPem, clientKeyPem := createClientCertificate(t, "sekai")
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeHTTP,
+				Tag:  "http-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: serverPort,
+					},
+					Users: []auth.User{{
+						Username: "sekai",
+						Password: "password",
+					}},
+					InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+						TLS: &option.InboundTLSOptions{
+							Enabled:               true,
+							ServerName:            "example.org",
+							ALPN:                  serverALPN,
+							CertificatePath:       certPem,
+							KeyPath:               keyPem,
+							ClientCertificatePath: []string{clientCertPem},
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+				Tag:  "direct",
+			},
+			{
+				Type: C.TypeHTTP,
+				Tag:  "http-out",
+				Options: &option.HTTPOutboundOptions{
+					ServerOptions: option.ServerOptions{
+						Server:     "127.0.0.1",
+						ServerPort: serverPort,
+					},
+					OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+						TLS: &option.OutboundTLSOptions{
+							Enabled:               true,
+							ServerName:            "example.org",
+							ALPN:                  clientALPN,
+							CertificatePath:       certPem,
+							ClientCertificatePath: clientCertPem,
+							ClientKeyPath:         clientKeyPem,
+						},
+					},
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound: []string{"mixed-in"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeRoute,
+							RouteOptions: option.RouteActionOptions{
+								Outbound: "http-out",
+							},
+						},
+					},
+				},
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound:                     []string{"http-in"},
+							AuthUser:                    []string{"sekai"},
+							ClientCertificateCommonName: []string{"sekai"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeRoute,
+							RouteOptions: option.RouteActionOptions{
+								Outbound: "direct",
+							},
+						},
+					},
+				},
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound: []string{"http-in"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeReject,
+						},
+					},
+				},
+			},
+		},
+	})
+	testTCP(t, clientPort, testPort)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/protocol/socks"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorOutbound(t *testing.T) {
+	serverListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", F.ToString(testPort)))
+	require.NoError(t, err)
+	defer serverListener.Close()
+	go func() {
+		for {
+			conn, aErr := serverListener.Accept()
+			if aErr != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				request := make([]byte, 4)
+				_, rErr := io.ReadFull(conn, request)
+				if rErr != nil {
+					return
+				}
+				conn.Write([]byte("pong"))
+			}()
+		}
+	}()
+	mirrorListener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", F.ToString(otherPort)))
+	require.NoError(t, err)
+	defer mirrorListener.Close()
+	mirrored := make(chan string, 2)
+	go func() {
+		for {
+			conn, aErr := mirrorListener.Accept()
+			if aErr != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				content, _ := io.ReadAll(conn)
+				mirrored <- string(content)
+			}()
+		}
+	}()
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+				Tag:  "direct",
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound: []string{"mixed-in"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeMirror,
+							MirrorOptions: option.RouteActionMirror{
+								Outbound:   "direct",
+								Server:     "127.0.0.1",
+								ServerPort: otherPort,
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	dialer := socks.NewClient(N.SystemDialer, M.ParseSocksaddrHostPort("127.0.0.1", clientPort), socks.Version5, "", "")
+	conn, err := dialer.DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddrHostPort("127.0.0.1", testPort))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	response := make([]byte, 4)
+	_, err = io.ReadFull(conn, response)
+	require.NoError(t, err)
+	require.Equal(t, "pong", string(response))
+	conn.Close()
+	var contents []string
+	for range 2 {
+		select {
+		case content := <-mirrored:
+			contents = append(contents, content)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for mirrored traffic")
+		}
+	}
+	require.ElementsMatch(t, []string{"ping", "pong"}, contents)
+}
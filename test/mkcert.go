@@ -86,3 +86,37 @@ func randomSerialNumber(t *testing.T) *big.Int {
 	require.NoError(t, err)
 	return serialNumber
 }
+
+func createClientCertificate(t *testing.T, commonName string) (certPem, keyPem string) {
+	tempDir, err := os.MkdirTemp("", "sing-box-test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(tempDir)
+	})
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	clientTpl := &x509.Certificate{
+		SerialNumber: randomSerialNumber(t),
+		Subject: pkix.Name{
+			Organization: []string{"sing-box test client certificate"},
+			CommonName:   commonName,
+		},
+		EmailAddresses: []string{commonName + "@nekohasekai.local"},
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().AddDate(0, 0, 30),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	// self-signed, the certificate itself is trusted by the server
+	cert, err := x509.CreateCertificate(rand.Reader, clientTpl, clientTpl, key.Public(), key)
+	require.NoError(t, err)
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	certPem = filepath.Join(tempDir, commonName+".pem")
+	keyPem = filepath.Join(tempDir, commonName+".key.pem")
+	err = rw.WriteFile(certPem, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}))
+	require.NoError(t, err)
+	err = rw.WriteFile(keyPem, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	require.NoError(t, err)
+	return
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"io"
+	"net"
 	"net/netip"
 	"testing"
 
+	"github.com/sagernet/sing-box"
+	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-shadowsocks/shadowaead_2022"
 	"github.com/sagernet/sing/common"
+	F "github.com/sagernet/sing/common/format"
 	"github.com/sagernet/sing/common/json/badoption"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/protocol/socks"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/require"
 )
 
 var muxProtocols = []string{
@@ -53,10 +63,78 @@ func TestShadowsockSMuxPadding(t *testing.T) {
 	})
 }
 
-func testShadowsocksMux(t *testing.T, options option.OutboundMultiplexOptions) {
+func TestShadowsocksMuxAdaptive(t *testing.T) {
+	for _, protocol := range muxProtocols {
+		t.Run(protocol, func(t *testing.T) {
+			instance := testShadowsocksMux(t, option.OutboundMultiplexOptions{
+				Enabled:   true,
+				Protocol:  protocol,
+				Scheduler: "adaptive",
+			})
+			outbound, loaded := instance.Outbound().Outbound("ss-out")
+			require.True(t, loaded)
+			status := outbound.(adapter.MultiplexOutbound).MultiplexStatus()
+			require.NotNil(t, status)
+			require.Equal(t, "adaptive", status.Scheduler)
+			require.NotEmpty(t, status.Sessions)
+			require.LessOrEqual(t, len(status.Sessions), 8)
+			var download int64
+			for _, session := range status.Sessions {
+				download += session.Download
+			}
+			require.Positive(t, download)
+		})
+	}
+}
+
+func TestShadowsocksMuxFixedStatus(t *testing.T) {
+	for _, protocol := range muxProtocols {
+		t.Run(protocol, func(t *testing.T) {
+			instance := testShadowsocksMux(t, option.OutboundMultiplexOptions{
+				Enabled:  true,
+				Protocol: protocol,
+			})
+			outbound, loaded := instance.Outbound().Outbound("ss-out")
+			require.True(t, loaded)
+			listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", F.ToString(testPort)))
+			require.NoError(t, err)
+			defer listener.Close()
+			go func() {
+				for {
+					conn, aErr := listener.Accept()
+					if aErr != nil {
+						return
+					}
+					go func() {
+						defer conn.Close()
+						io.Copy(conn, conn)
+					}()
+				}
+			}()
+			dialer := socks.NewClient(N.SystemDialer, M.ParseSocksaddrHostPort("127.0.0.1", clientPort), socks.Version5, "", "")
+			conn, err := dialer.DialContext(context.Background(), N.NetworkTCP, M.ParseSocksaddrHostPort("127.0.0.1", testPort))
+			require.NoError(t, err)
+			defer conn.Close()
+			_, err = conn.Write([]byte("ping"))
+			require.NoError(t, err)
+			_, err = io.ReadFull(conn, make([]byte, 4))
+			require.NoError(t, err)
+			status := outbound.(adapter.MultiplexOutbound).MultiplexStatus()
+			require.NotNil(t, status)
+			require.Equal(t, "fixed", status.Scheduler)
+			var streams int
+			for _, session := range status.Sessions {
+				streams += session.Streams
+			}
+			require.Positive(t, streams)
+		})
+	}
+}
+
+func testShadowsocksMux(t *testing.T, options option.OutboundMultiplexOptions) *box.Box {
 	method := shadowaead_2022.List[0]
 	password := mkBase64(t, 16)
-	startInstance(t, option.Options{
+	instance := startInstance(t, option.Options{
 		Inbounds: []option.Inbound{
 			{
 				Type: C.TypeMixed,
@@ -122,6 +200,7 @@ func testShadowsocksMux(t *testing.T, options option.OutboundMultiplexOptions) {
 		},
 	})
 	testSuit(t, clientPort, testPort)
+	return instance
 }
 
 func testVMessMux(t *testing.T, options option.OutboundMultiplexOptions) {
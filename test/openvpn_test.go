@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenVPNEndpoint(t *testing.T) {
+	caPem, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	caContent, err := os.ReadFile(caPem)
+	require.NoError(t, err)
+	startDockerContainer(t, DockerOptions{
+		Image:      ImageOpenVPN,
+		EntryPoint: "sh",
+		Cmd:        []string{"-c", "mkdir -p /dev/net && mknod /dev/net/tun c 10 200; exec openvpn --config /etc/openvpn/server.conf"},
+		Ports:      []uint16{serverPort},
+		Cap:        []string{"NET_ADMIN"},
+		Bind: map[string]string{
+			"openvpn-server.conf": "/etc/openvpn/server.conf",
+			"openvpn-auth.sh":     "/etc/openvpn/auth.sh",
+			caPem:                 "/etc/openvpn/ca.pem",
+			certPem:               "/etc/openvpn/cert.pem",
+			keyPem:                "/etc/openvpn/key.pem",
+		},
+	})
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+		},
+		Endpoints: []option.Endpoint{
+			{
+				Type: C.TypeOpenVPN,
+				Tag:  "ovpn-out",
+				Options: &option.OpenVPNEndpointOptions{
+					Config: []string{
+						"client",
+						"dev tun",
+						"proto udp",
+						"remote 127.0.0.1 " + F.ToString(serverPort),
+						"remote-cert-tls server",
+						"data-ciphers AES-256-GCM",
+						"auth-user-pass",
+						"<ca>",
+						strings.TrimSpace(string(caContent)),
+						"</ca>",
+					},
+					Username: "sekai",
+					Password: "password",
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Final: "ovpn-out",
+		},
+	})
+	// the server listens at 10.0.0.1 inside the tunnel
+	testSuitWg(t, clientPort, testPort)
+}
@@ -7,6 +7,7 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/auth"
 	"github.com/sagernet/sing/common/json/badoption"
 )
 
@@ -97,3 +98,228 @@ func TestUTLS(t *testing.T) {
 	})
 	testSuit(t, clientPort, testPort)
 }
+
+func TestTLSCertificates(t *testing.T) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	_, otherCertPem, otherKeyPem := createSelfSignedCertificate(t, "example.com")
+	trojanOutbound := func(tag string, serverName string, certificatePath string) option.Outbound {
+		return option.Outbound{
+			Type: C.TypeTrojan,
+			Tag:  tag,
+			Options: &option.TrojanOutboundOptions{
+				ServerOptions: option.ServerOptions{
+					Server:     "127.0.0.1",
+					ServerPort: serverPort,
+				},
+				Password: "password",
+				OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+					TLS: &option.OutboundTLSOptions{
+						Enabled:         true,
+						ServerName:      serverName,
+						CertificatePath: certificatePath,
+					},
+				},
+			},
+		}
+	}
+	routeRule := func(inbound string, outbound string) option.Rule {
+		return option.Rule{
+			Type: C.RuleTypeDefault,
+			DefaultOptions: option.DefaultRule{
+				RawDefaultRule: option.RawDefaultRule{
+					Inbound: []string{inbound},
+				},
+				RuleAction: option.RuleAction{
+					Action: C.RuleActionTypeRoute,
+					RouteOptions: option.RouteActionOptions{
+						Outbound: outbound,
+					},
+				},
+			},
+		}
+	}
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-other-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: otherClientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeTrojan,
+				Options: &option.TrojanInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: serverPort,
+					},
+					Users: []option.TrojanUser{
+						{
+							Name:     "sekai",
+							Password: "password",
+						},
+					},
+					InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+						TLS: &option.InboundTLSOptions{
+							Enabled:         true,
+							CertificatePath: certPem,
+							KeyPath:         keyPem,
+							Certificates: []option.InboundTLSCertificate{
+								{
+									ServerName:      []string{"example.com", "*.example.com"},
+									CertificatePath: otherCertPem,
+									KeyPath:         otherKeyPem,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+			},
+			trojanOutbound("trojan-out", "example.org", certPem),
+			trojanOutbound("trojan-other-out", "example.com", otherCertPem),
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				routeRule("mixed-in", "trojan-out"),
+				routeRule("mixed-other-in", "trojan-other-out"),
+			},
+		},
+	})
+	testTCP(t, clientPort, testPort)
+	testTCP(t, otherClientPort, testPort)
+}
+
+func TestTLSClientCertificateUser(t *testing.T) {
+	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
+	clientCertPem, clientKeyPem := createClientCertificate(t, "sekai")
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+			{
+				Type: C.TypeMixed,
+				Tag:  "mixed-tls-in",
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: serverPort,
+					},
+					Users: []auth.User{
+						{
+							Username: "sekai",
+							Password: "password",
+						},
+					},
+					InboundTLSOptionsContainer: option.InboundTLSOptionsContainer{
+						TLS: &option.InboundTLSOptions{
+							Enabled:               true,
+							ServerName:            "example.org",
+							CertificatePath:       certPem,
+							KeyPath:               keyPem,
+							ClientCertificatePath: []string{clientCertPem},
+						},
+					},
+				},
+			},
+		},
+		Outbounds: []option.Outbound{
+			{
+				Type: C.TypeDirect,
+				Tag:  "direct",
+			},
+			{
+				Type: C.TypeHTTP,
+				Tag:  "http-out",
+				Options: &option.HTTPOutboundOptions{
+					ServerOptions: option.ServerOptions{
+						Server:     "127.0.0.1",
+						ServerPort: serverPort,
+					},
+					OutboundTLSOptionsContainer: option.OutboundTLSOptionsContainer{
+						TLS: &option.OutboundTLSOptions{
+							Enabled:               true,
+							ServerName:            "example.org",
+							CertificatePath:       certPem,
+							ClientCertificatePath: clientCertPem,
+							ClientKeyPath:         clientKeyPem,
+						},
+					},
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Rules: []option.Rule{
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound: []string{"mixed-in"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeRoute,
+							RouteOptions: option.RouteActionOptions{
+								Outbound: "http-out",
+							},
+						},
+					},
+				},
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound:                     []string{"mixed-tls-in"},
+							AuthUser:                    []string{"sekai"},
+							ClientCertificateCommonName: []string{"sekai"},
+							ClientCertificateSAN:        []string{"sekai@nekohasekai.local"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeRoute,
+							RouteOptions: option.RouteActionOptions{
+								Outbound: "direct",
+							},
+						},
+					},
+				},
+				{
+					Type: C.RuleTypeDefault,
+					DefaultOptions: option.DefaultRule{
+						RawDefaultRule: option.RawDefaultRule{
+							Inbound: []string{"mixed-tls-in"},
+						},
+						RuleAction: option.RuleAction{
+							Action: C.RuleActionTypeReject,
+						},
+					},
+				},
+			},
+		},
+	})
+	testTCP(t, clientPort, testPort)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"net/netip"
 	"testing"
+	"time"
 
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
@@ -14,22 +15,33 @@ import (
 
 func TestTUICSelf(t *testing.T) {
 	t.Run("self", func(t *testing.T) {
-		testTUICSelf(t, false, false)
+		testTUICSelf(t, false, false, false)
 	})
 	t.Run("self-udp-stream", func(t *testing.T) {
-		testTUICSelf(t, true, false)
+		testTUICSelf(t, true, false, false)
 	})
 	t.Run("self-early", func(t *testing.T) {
-		testTUICSelf(t, false, true)
+		testTUICSelf(t, false, true, false)
+	})
+	t.Run("self-port-hopping", func(t *testing.T) {
+		testTUICSelf(t, false, false, true)
 	})
 }
 
-func testTUICSelf(t *testing.T, udpStream bool, zeroRTTHandshake bool) {
+func testTUICSelf(t *testing.T, udpStream bool, zeroRTTHandshake bool, portHopping bool) {
 	_, certPem, keyPem := createSelfSignedCertificate(t, "example.org")
 	var udpRelayMode string
 	if udpStream {
 		udpRelayMode = "quic"
 	}
+	var (
+		listenPorts badoption.Listable[string]
+		serverPorts badoption.Listable[string]
+	)
+	if portHopping {
+		listenPorts = []string{"10010:10012"}
+		serverPorts = []string{"10010:10012"}
+	}
 	startInstance(t, option.Options{
 		Inbounds: []option.Inbound{
 			{
@@ -46,8 +58,9 @@ func testTUICSelf(t *testing.T, udpStream bool, zeroRTTHandshake bool) {
 				Type: C.TypeTUIC,
 				Options: &option.TUICInboundOptions{
 					ListenOptions: option.ListenOptions{
-						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
-						ListenPort: serverPort,
+						Listen:      common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort:  serverPort,
+						ListenPorts: listenPorts,
 					},
 					Users: []option.TUICUser{{
 						UUID: uuid.Nil.String(),
@@ -76,6 +89,8 @@ func testTUICSelf(t *testing.T, udpStream bool, zeroRTTHandshake bool) {
 						Server:     "127.0.0.1",
 						ServerPort: serverPort,
 					},
+					ServerPorts:      serverPorts,
+					HopInterval:      badoption.Duration(time.Second),
 					UUID:             uuid.Nil.String(),
 					UDPRelayMode:     udpRelayMode,
 					ZeroRTTHandshake: zeroRTTHandshake,
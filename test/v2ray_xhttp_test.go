@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayxhttp"
+)
+
+func TestV2RayXHTTP(t *testing.T) {
+	for _, mode := range []string{v2rayxhttp.ModePacketUp, v2rayxhttp.ModeStreamUp, v2rayxhttp.ModeStreamOne} {
+		t.Run(mode, func(t *testing.T) {
+			testV2RayTransportSelf(t, &option.V2RayTransportOptions{
+				Type: C.V2RayTransportTypeXHTTP,
+				XHTTPOptions: option.V2RayXHTTPOptions{
+					Mode: mode,
+				},
+			})
+		})
+	}
+}
+
+func TestV2RayXHTTPPlainSelf(t *testing.T) {
+	testV2RayTransportNOTLSSelf(t, &option.V2RayTransportOptions{
+		Type: C.V2RayTransportTypeXHTTP,
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json/badoption"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	wireGuardServerPrivateKey = "mDsOVrWJffv/QvxN8zuMLZtSo0FBdxzR+57tl/HVfFI="
+	wireGuardServerPublicKey  = "kPvvMoL5R629d7qDhvA4GK6iYNTP+lshLMpy5Ju2VA0="
+	wireGuardClientPrivateKey = "oJaHUj3auqtex5vXjGMX1mUg0wj1nGMjoNY8nYAmWmM="
+	wireGuardClientPublicKey  = "0V1UDdO7zaFklIQXEdMrZYNvytgpIpXynf5tBbj2AkQ="
+)
+
+func TestWireGuardDynamicPeer(t *testing.T) {
+	server := startInstance(t, option.Options{
+		Endpoints: []option.Endpoint{
+			{
+				Type: C.TypeWireGuard,
+				Tag:  "wg-in",
+				Options: &option.WireGuardEndpointOptions{
+					Address:    []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")},
+					PrivateKey: wireGuardServerPrivateKey,
+					ListenPort: serverPort,
+				},
+			},
+		},
+	})
+	startInstance(t, option.Options{
+		Inbounds: []option.Inbound{
+			{
+				Type: C.TypeMixed,
+				Options: &option.HTTPMixedInboundOptions{
+					ListenOptions: option.ListenOptions{
+						Listen:     common.Ptr(badoption.Addr(netip.IPv4Unspecified())),
+						ListenPort: clientPort,
+					},
+				},
+			},
+		},
+		Endpoints: []option.Endpoint{
+			{
+				Type: C.TypeWireGuard,
+				Tag:  "wg-out",
+				Options: &option.WireGuardEndpointOptions{
+					Address:             []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")},
+					PrivateKey:          wireGuardClientPrivateKey,
+					PeerResolveInterval: common.Ptr(badoption.Duration(0)),
+					Peers: []option.WireGuardPeer{
+						{
+							Address:    "127.0.0.1",
+							Port:       serverPort,
+							PublicKey:  wireGuardServerPublicKey,
+							AllowedIPs: []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0")},
+						},
+					},
+				},
+			},
+		},
+		Route: &option.RouteOptions{
+			Final: "wg-out",
+		},
+	})
+	outbound, loaded := server.Outbound().Outbound("wg-in")
+	require.True(t, loaded)
+	endpoint, isWireGuard := outbound.(adapter.WireGuardEndpoint)
+	require.True(t, isWireGuard)
+	peers, err := endpoint.Peers()
+	require.NoError(t, err)
+	require.Empty(t, peers)
+	require.NoError(t, endpoint.UpdatePeer(option.WireGuardPeer{
+		PublicKey:  wireGuardClientPublicKey,
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")},
+	}))
+	testSuitWg(t, clientPort, testPort)
+	peers, err = endpoint.Peers()
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	require.Equal(t, wireGuardClientPublicKey, peers[0].PublicKey)
+	require.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")}, peers[0].AllowedIPs)
+	require.NotEmpty(t, peers[0].Endpoint)
+	require.False(t, peers[0].LastHandshake.IsZero())
+	require.NotZero(t, peers[0].Download)
+	require.NoError(t, endpoint.RemovePeer(wireGuardClientPublicKey))
+	peers, err = endpoint.Peers()
+	require.NoError(t, err)
+	require.Empty(t, peers)
+	require.ErrorIs(t, endpoint.RemovePeer(wireGuardClientPublicKey), os.ErrNotExist)
+	require.NoError(t, endpoint.UpdatePeer(option.WireGuardPeer{
+		Address:    "127.0.0.1",
+		Port:       clientPort,
+		PublicKey:  wireGuardClientPublicKey,
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")},
+		Reserved:   []uint8{1, 2, 3},
+	}))
+	require.Error(t, endpoint.UpdatePeer(option.WireGuardPeer{
+		PublicKey:  wireGuardClientPublicKey,
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")},
+		Reserved:   []uint8{1, 2},
+	}))
+	peers, err = endpoint.Peers()
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+}
@@ -0,0 +1,695 @@
+package openvpn
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const (
+	HandshakeTimeout    = time.Minute
+	pushRequestInterval = 2 * time.Second
+	timerInterval       = 500 * time.Millisecond
+)
+
+const (
+	ivProtoDataV2       = 1 << 1
+	ivProtoTLSKeyExport = 1 << 3
+)
+
+var ErrAuthFailed = E.New("authentication failed")
+
+type ClientOptions struct {
+	Context  context.Context
+	Logger   logger.ContextLogger
+	Dialer   N.Dialer
+	Config   *Config
+	Remote   Remote
+	Username string
+	Password string
+	// Handler is called with IP packets received from the server.
+	Handler func(packet []byte)
+}
+
+type Client struct {
+	ctx                   context.Context
+	cancel                context.CancelCauseFunc
+	logger                logger.ContextLogger
+	dialer                N.Dialer
+	config                *Config
+	remote                Remote
+	username              string
+	password              string
+	handler               func(packet []byte)
+	tlsConfig             *tls.Config
+	wrapper               controlWrapper
+	conn                  packetTransport
+	localSessionID        sessionID
+	access                sync.RWMutex
+	remoteSessionID       sessionID
+	remoteSessionIDLoaded bool
+	keys                  [8]*keyState
+	primary               atomic.Pointer[dataChannel]
+	pushReplyAccess       sync.Mutex
+	pushOptions           []string
+	pushResult            chan pushResult
+	cipher                string
+	peerID                int
+	useEKM                bool
+	compression           Compression
+	pingInterval          time.Duration
+	pingRestart           time.Duration
+	authToken             atomic.Pointer[string]
+	lastSend              atomic.Int64
+	lastReceive           atomic.Int64
+}
+
+type pushResult struct {
+	reply *PushReply
+	err   error
+}
+
+type keySource struct {
+	preMaster     []byte
+	random1       []byte
+	random2       []byte
+	serverRandom1 []byte
+	serverRandom2 []byte
+}
+
+func NewClient(options ClientOptions) (*Client, error) {
+	tlsConfig, err := newTLSConfig(options.Config)
+	if err != nil {
+		return nil, err
+	}
+	wrapper, err := newControlWrapper(options.Config)
+	if err != nil {
+		return nil, err
+	}
+	username, password := options.Username, options.Password
+	if username == "" {
+		username, password = options.Config.Username, options.Config.Password
+	}
+	if options.Config.AuthUserPass && username == "" {
+		return nil, E.New("missing username")
+	}
+	ctx, cancel := context.WithCancelCause(options.Context)
+	return &Client{
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     options.Logger,
+		dialer:     options.Dialer,
+		config:     options.Config,
+		remote:     options.Remote,
+		username:   username,
+		password:   password,
+		handler:    options.Handler,
+		tlsConfig:  tlsConfig,
+		wrapper:    wrapper,
+		pushResult: make(chan pushResult, 1),
+	}, nil
+}
+
+// Start connects to the remote and returns after the pushed configuration is received.
+func (c *Client) Start(ctx context.Context) (*PushReply, error) {
+	conn, err := c.dialer.DialContext(ctx, c.remote.Network, M.ParseSocksaddrHostPort(c.remote.Host, c.remote.Port))
+	if err != nil {
+		return nil, E.Cause(err, "dial ", c.remote)
+	}
+	if c.remote.Network == N.NetworkTCP {
+		c.conn = newTCPTransport(conn)
+	} else {
+		c.conn = newUDPTransport(conn)
+	}
+	_, err = rand.Read(c.localSessionID[:])
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	now := time.Now().UnixNano()
+	c.lastSend.Store(now)
+	c.lastReceive.Store(now)
+	initialKey := newKeyState(c, 0)
+	c.keys[0] = initialKey
+	go c.loopReceive()
+	go c.loopTimer()
+	pushReply, err := c.startInitialKey(ctx, initialKey)
+	if err != nil {
+		c.closeWithError(err)
+		return nil, err
+	}
+	return pushReply, nil
+}
+
+func (c *Client) startInitialKey(ctx context.Context, initialKey *keyState) (*PushReply, error) {
+	err := initialKey.sendReliable(opcodeControlHardResetClientV2, nil)
+	if err != nil {
+		return nil, E.Cause(err, "send reset")
+	}
+	tlsConn, source, err := c.negotiate(ctx, initialKey)
+	if err != nil {
+		return nil, err
+	}
+	go c.loopControlMessages(initialKey, tlsConn)
+	pushReply, err := c.requestPush(ctx, tlsConn)
+	if err != nil {
+		return nil, err
+	}
+	cipherName := pushReply.Cipher
+	if cipherName == "" {
+		cipherName = c.config.Cipher
+	}
+	if cipherName == "" {
+		return nil, E.New("no data channel cipher negotiated")
+	} else if !isSupportedCipher(cipherName) {
+		return nil, E.New("unsupported data channel cipher: ", cipherName)
+	}
+	c.cipher = cipherName
+	c.peerID = pushReply.PeerID
+	c.useEKM = pushReply.KeyDerivationEKM
+	c.compression = c.config.Compression
+	if pushReply.CompressionSet {
+		c.compression = pushReply.Compression
+	}
+	c.pingInterval = c.config.PingInterval
+	if pushReply.PingInterval > 0 {
+		c.pingInterval = pushReply.PingInterval
+	}
+	c.pingRestart = c.config.PingRestart
+	if pushReply.PingRestart > 0 {
+		c.pingRestart = pushReply.PingRestart
+	}
+	if pushReply.AuthToken != "" {
+		c.authToken.Store(common.Ptr(pushReply.AuthToken))
+	}
+	data, err := c.newDataChannel(initialKey, tlsConn, source)
+	if err != nil {
+		return nil, err
+	}
+	c.access.Lock()
+	initialKey.data = data
+	c.access.Unlock()
+	c.primary.Store(data)
+	return pushReply, nil
+}
+
+// negotiate runs the TLS handshake and the key method 2 exchange of the key state.
+func (c *Client) negotiate(ctx context.Context, key *keyState) (*tls.Conn, *keySource, error) {
+	tlsConn := tls.Client(&controlConn{key}, c.tlsConfig)
+	err := tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return nil, nil, E.Cause(err, "TLS handshake")
+	}
+	source := &keySource{
+		preMaster: make([]byte, 48),
+		random1:   make([]byte, 32),
+		random2:   make([]byte, 32),
+	}
+	for _, randomBytes := range [][]byte{source.preMaster, source.random1, source.random2} {
+		_, err = rand.Read(randomBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	message := []byte{0, 0, 0, 0, 2}
+	message = append(message, source.preMaster...)
+	message = append(message, source.random1...)
+	message = append(message, source.random2...)
+	message = appendControlString(message, c.optionsString())
+	if c.config.AuthUserPass {
+		password := c.password
+		if authToken := c.authToken.Load(); authToken != nil {
+			password = *authToken
+		}
+		message = appendControlString(message, c.username)
+		message = appendControlString(message, password)
+	} else {
+		message = appendControlString(message, "")
+		message = appendControlString(message, "")
+	}
+	message = appendControlString(message, c.peerInfo())
+	_, err = tlsConn.Write(message)
+	if err != nil {
+		return nil, nil, E.Cause(err, "write key method")
+	}
+	response := make([]byte, 16*1024)
+	n, err := tlsConn.Read(response)
+	if err != nil {
+		return nil, nil, E.Cause(err, "read key method")
+	}
+	response = response[:n]
+	if len(response) < 5+64 || binary.BigEndian.Uint32(response) != 0 || response[4] != 2 {
+		return nil, nil, E.New("invalid key method response")
+	}
+	// the server sends random1 and random2 without pre master secret
+	source.serverRandom1 = response[5:37]
+	source.serverRandom2 = response[37:69]
+	return tlsConn, source, nil
+}
+
+func (c *Client) newDataChannel(key *keyState, tlsConn *tls.Conn, source *keySource) (*dataChannel, error) {
+	var keyMaterial []byte
+	if c.useEKM {
+		connectionState := tlsConn.ConnectionState()
+		var err error
+		keyMaterial, err = connectionState.ExportKeyingMaterial("EXPORTER-OpenVPN-datakeys", nil, 256)
+		if err != nil {
+			return nil, E.Cause(err, "export keying material")
+		}
+	} else {
+		c.access.RLock()
+		remoteSessionID := c.remoteSessionID
+		c.access.RUnlock()
+		masterSecret := tls1PRF(source.preMaster, "OpenVPN master secret", append(append([]byte(nil), source.random1...), source.serverRandom1...), 48)
+		seed := make([]byte, 0, 32*2+sessionIDLength*2)
+		seed = append(seed, source.random2...)
+		seed = append(seed, source.serverRandom2...)
+		seed = append(seed, c.localSessionID[:]...)
+		seed = append(seed, remoteSessionID[:]...)
+		keyMaterial = tls1PRF(masterSecret, "OpenVPN key expansion", seed, 256)
+	}
+	// the client uses the normal key direction
+	return newDataChannel(key.keyID, c.peerID, c.cipher, c.config.Auth, newStaticKey(keyMaterial), 0)
+}
+
+func (c *Client) requestPush(ctx context.Context, tlsConn *tls.Conn) (*PushReply, error) {
+	ticker := time.NewTicker(pushRequestInterval)
+	defer ticker.Stop()
+	for {
+		_, err := tlsConn.Write([]byte("PUSH_REQUEST\x00"))
+		if err != nil {
+			return nil, E.Cause(err, "write push request")
+		}
+		select {
+		case result := <-c.pushResult:
+			return result.reply, result.err
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.ctx.Done():
+			return nil, context.Cause(c.ctx)
+		}
+	}
+}
+
+func (c *Client) loopControlMessages(key *keyState, tlsConn *tls.Conn) {
+	var (
+		buffer  = make([]byte, 16*1024)
+		pending []byte
+	)
+	for {
+		n, err := tlsConn.Read(buffer)
+		if err != nil {
+			return
+		}
+		pending = append(pending, buffer[:n]...)
+		for {
+			index := bytes.IndexByte(pending, 0)
+			if index == -1 {
+				break
+			}
+			message := string(pending[:index])
+			pending = pending[index+1:]
+			c.handleControlMessage(message)
+		}
+	}
+}
+
+func (c *Client) handleControlMessage(message string) {
+	message = strings.TrimSpace(message)
+	switch {
+	case strings.HasPrefix(message, "PUSH_REPLY"):
+		c.handlePushReply(message)
+	case strings.HasPrefix(message, "AUTH_FAILED"):
+		var err error
+		if reason := strings.TrimPrefix(strings.TrimPrefix(message, "AUTH_FAILED"), ","); reason != "" {
+			err = E.Extend(ErrAuthFailed, reason)
+		} else {
+			err = ErrAuthFailed
+		}
+		c.sendPushResult(pushResult{err: err})
+		c.closeWithError(err)
+	case strings.HasPrefix(message, "AUTH_PENDING"):
+		c.logger.Info("authentication pending")
+	case message == "RESTART" || strings.HasPrefix(message, "RESTART,"):
+		c.closeWithError(E.New("server requested restart: ", message))
+	case message == "HALT" || strings.HasPrefix(message, "HALT,"):
+		c.closeWithError(E.New("server requested halt: ", message))
+	default:
+		c.logger.Debug("control message: ", message)
+	}
+}
+
+func (c *Client) handlePushReply(message string) {
+	c.pushReplyAccess.Lock()
+	defer c.pushReplyAccess.Unlock()
+	if c.primary.Load() != nil {
+		// updates of pushed options are not supported
+		return
+	}
+	var continuation bool
+	for _, option := range strings.Split(message, ",")[1:] {
+		if option == "push-continuation 2" {
+			continuation = true
+			continue
+		} else if strings.HasPrefix(option, "push-continuation ") {
+			continue
+		}
+		c.pushOptions = append(c.pushOptions, option)
+	}
+	if continuation {
+		return
+	}
+	pushOptions := c.pushOptions
+	c.pushOptions = nil
+	c.logger.Debug("received push reply: ", strings.Join(pushOptions, ","))
+	reply, err := ParsePushReply(pushOptions)
+	c.sendPushResult(pushResult{reply: reply, err: err})
+}
+
+func (c *Client) sendPushResult(result pushResult) {
+	select {
+	case c.pushResult <- result:
+	default:
+	}
+}
+
+func (c *Client) renegotiate(key *keyState) {
+	ctx, cancel := context.WithTimeout(c.ctx, HandshakeTimeout)
+	defer cancel()
+	err := key.sendReliable(opcodeControlSoftResetV1, nil)
+	if err != nil {
+		return
+	}
+	tlsConn, source, err := c.negotiate(ctx, key)
+	if err != nil {
+		c.logger.Error(E.Cause(err, "renegotiate key ", key.keyID))
+		key.Close()
+		return
+	}
+	data, err := c.newDataChannel(key, tlsConn, source)
+	if err != nil {
+		c.logger.Error(E.Cause(err, "renegotiate key ", key.keyID))
+		key.Close()
+		return
+	}
+	c.access.Lock()
+	key.data = data
+	var previousKey *keyState
+	for _, otherKey := range c.keys {
+		if otherKey != nil && otherKey != key && otherKey.data == c.primary.Load() {
+			previousKey = otherKey
+		}
+	}
+	c.access.Unlock()
+	c.primary.Store(data)
+	go c.loopControlMessages(key, tlsConn)
+	if previousKey != nil {
+		// keep the previous key for packets in flight
+		time.AfterFunc(controlTransitionTimeout, func() {
+			previousKey.Close()
+		})
+	}
+	c.logger.Debug("renegotiated key ", key.keyID)
+}
+
+// renegotiateExpired starts a renegotiation from the client for the next key ID,
+// the server only renegotiates by time or traffic limits.
+func (c *Client) renegotiateExpired(data *dataChannel) {
+	nextKeyID := data.keyID%7 + 1
+	c.access.Lock()
+	if key := c.keys[nextKeyID]; key != nil {
+		key.Close()
+	}
+	key := newKeyState(c, nextKeyID)
+	c.keys[nextKeyID] = key
+	c.access.Unlock()
+	c.logger.Debug("renegotiate key ", data.keyID, ": packet ID is close to wrapping")
+	go c.renegotiate(key)
+}
+
+func (c *Client) loopReceive() {
+	for {
+		packet, err := c.conn.ReadPacket()
+		if err != nil {
+			c.closeWithError(E.Cause(err, "read packet"))
+			return
+		}
+		if len(packet) == 0 {
+			continue
+		}
+		c.lastReceive.Store(time.Now().UnixNano())
+		switch packet[0] >> 3 {
+		case opcodeDataV1, opcodeDataV2:
+			c.handleData(packet)
+		default:
+			c.handleControl(packet)
+		}
+	}
+}
+
+func (c *Client) handleData(packet []byte) {
+	c.access.RLock()
+	var data *dataChannel
+	if key := c.keys[packet[0]&0x07]; key != nil {
+		data = key.data
+	}
+	c.access.RUnlock()
+	if data == nil {
+		return
+	}
+	payload, err := data.Open(packet)
+	if err != nil {
+		c.logger.Trace(E.Cause(err, "drop data packet"))
+		return
+	}
+	payload, err = c.compression.unframe(payload)
+	if err != nil {
+		c.logger.Trace(E.Cause(err, "drop data packet"))
+		return
+	}
+	if len(payload) == 0 || bytes.Equal(payload, pingMessage) {
+		return
+	}
+	c.handler(payload)
+}
+
+func (c *Client) handleControl(packet []byte) {
+	header, body, err := c.wrapper.Unwrap(packet)
+	if err != nil {
+		c.logger.Trace(E.Cause(err, "drop control packet"))
+		return
+	}
+	control, err := parseControlPacket(header, body)
+	if err != nil {
+		c.logger.Trace(E.Cause(err, "drop control packet"))
+		return
+	}
+	c.access.Lock()
+	if !c.remoteSessionIDLoaded {
+		if control.opcode != opcodeControlHardResetServerV2 {
+			c.access.Unlock()
+			return
+		}
+		c.remoteSessionID = control.sessionID
+		c.remoteSessionIDLoaded = true
+	} else if control.sessionID != c.remoteSessionID {
+		c.access.Unlock()
+		return
+	}
+	if len(control.acks) > 0 && control.remoteSessionID != c.localSessionID {
+		c.access.Unlock()
+		return
+	}
+	key := c.keys[control.keyID]
+	if control.opcode == opcodeControlSoftResetV1 && control.keyID != 0 && (key == nil || key.data != nil && key.data != c.primary.Load()) {
+		if key != nil {
+			key.Close()
+		}
+		key = newKeyState(c, control.keyID)
+		c.keys[control.keyID] = key
+		go c.renegotiate(key)
+	}
+	c.access.Unlock()
+	if key == nil {
+		return
+	}
+	key.receive(control)
+}
+
+func (c *Client) writeControl(packet *controlPacket) error {
+	c.access.RLock()
+	packet.sessionID = c.localSessionID
+	packet.remoteSessionID = c.remoteSessionID
+	c.access.RUnlock()
+	return c.conn.WritePacket(c.wrapper.Wrap(packet.header(), packet.body()))
+}
+
+func (c *Client) localAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Client) remoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Client) loopTimer() {
+	ticker := time.NewTicker(timerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if c.remote.Network != N.NetworkTCP {
+				c.access.RLock()
+				keys := c.keys
+				c.access.RUnlock()
+				for _, key := range keys {
+					if key != nil {
+						key.retransmit(now)
+					}
+				}
+			}
+			if c.primary.Load() == nil {
+				continue
+			}
+			if c.pingRestart > 0 && now.Sub(time.Unix(0, c.lastReceive.Load())) > c.pingRestart {
+				c.closeWithError(E.New("ping restart: no packets received in ", c.pingRestart))
+				return
+			}
+			if c.pingInterval > 0 && now.Sub(time.Unix(0, c.lastSend.Load())) >= c.pingInterval {
+				err := c.writeData(pingMessage)
+				if err != nil {
+					c.closeWithError(E.Cause(err, "write ping"))
+					return
+				}
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// WritePacket sends an IP packet to the server.
+func (c *Client) WritePacket(packet []byte) error {
+	if c.primary.Load() == nil {
+		return E.New("OpenVPN client not ready")
+	}
+	return c.writeData(packet)
+}
+
+func (c *Client) writeData(payload []byte) error {
+	data := c.primary.Load()
+	packet, err := data.Seal(c.compression.frame(payload))
+	if err != nil {
+		c.closeWithError(E.Cause(err, "key ", data.keyID))
+		return err
+	}
+	if data.expire() {
+		c.renegotiateExpired(data)
+	}
+	err = c.conn.WritePacket(packet)
+	if err != nil {
+		return err
+	}
+	c.lastSend.Store(time.Now().UnixNano())
+	return nil
+}
+
+func (c *Client) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Err returns the reason why the client was closed.
+func (c *Client) Err() error {
+	return context.Cause(c.ctx)
+}
+
+func (c *Client) closeWithError(err error) {
+	if c.ctx.Err() != nil {
+		return
+	}
+	c.cancel(err)
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.access.RLock()
+	keys := c.keys
+	c.access.RUnlock()
+	for _, key := range keys {
+		if key != nil {
+			key.Close()
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	c.closeWithError(os.ErrClosed)
+	return nil
+}
+
+func (c *Client) optionsString() string {
+	var proto string
+	if c.remote.Network == N.NetworkTCP {
+		proto = "TCPv4_CLIENT"
+	} else {
+		proto = "UDPv4"
+	}
+	return "V4,dev-type tun,link-mtu 1549,tun-mtu " + strconv.Itoa(c.config.TunMTU) + ",proto " + proto + ",auth " + c.config.Auth + ",keysize 256,key-method 2,tls-client"
+}
+
+func (c *Client) peerInfo() string {
+	var platform string
+	switch runtime.GOOS {
+	case "darwin":
+		platform = "mac"
+	case "windows":
+		platform = "win"
+	default:
+		platform = runtime.GOOS
+	}
+	dataCiphers := c.config.DataCiphers
+	if len(dataCiphers) == 0 {
+		dataCiphers = defaultDataCiphers
+	}
+	dataCiphers = common.Filter(dataCiphers, isSupportedCipher)
+	if c.config.Cipher != "" && isSupportedCipher(c.config.Cipher) && !common.Contains(dataCiphers, c.config.Cipher) {
+		dataCiphers = append(dataCiphers, c.config.Cipher)
+	}
+	return strings.Join([]string{
+		"IV_VER=2.6.0",
+		"IV_PLAT=" + platform,
+		"IV_PROTO=" + strconv.Itoa(ivProtoDataV2|ivProtoTLSKeyExport),
+		"IV_NCP=2",
+		"IV_CIPHERS=" + strings.Join(dataCiphers, ":"),
+		"IV_TCPNL=1",
+		"IV_LZO_STUB=1",
+		"IV_COMP_STUB=1",
+		"IV_COMP_STUBv2=1",
+		"IV_GUI_VER=sing-box",
+	}, "\n") + "\n"
+}
+
+func appendControlString(message []byte, value string) []byte {
+	if value == "" {
+		return binary.BigEndian.AppendUint16(message, 0)
+	}
+	message = binary.BigEndian.AppendUint16(message, uint16(len(value)+1))
+	message = append(message, value...)
+	return append(message, 0)
+}
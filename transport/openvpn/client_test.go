@@ -0,0 +1,496 @@
+package openvpn
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing/common/logger"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	certificates := newTestCertificates(t)
+	staticKey := newTestStaticKey(t)
+	for _, testCase := range []struct {
+		name       string
+		network    string
+		wrap       string
+		cipher     string
+		useEKM     bool
+		credential bool
+	}{
+		{name: "udp-tls-crypt-gcm", network: N.NetworkUDP, wrap: "tls-crypt", cipher: "AES-256-GCM"},
+		{name: "udp-tls-auth-chacha20", network: N.NetworkUDP, wrap: "tls-auth", cipher: "CHACHA20-POLY1305", useEKM: true},
+		{name: "tcp-tls-auth-cbc", network: N.NetworkTCP, wrap: "tls-auth", cipher: "AES-256-CBC", credential: true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := &testServer{
+				t:         t,
+				cipher:    testCase.cipher,
+				auth:      "SHA256",
+				useEKM:    testCase.useEKM,
+				tlsConfig: certificates.serverConfig,
+			}
+			var configContent strings.Builder
+			configContent.WriteString("client\ndev tun\nproto " + testCase.network + "\n")
+			configContent.WriteString("remote-cert-tls server\nverify-x509-name server name\nauth SHA256\n")
+			configContent.WriteString("<ca>\n" + certificates.ca + "</ca>\n")
+			if testCase.credential {
+				configContent.WriteString("auth-user-pass\n")
+				server.username = "user"
+				server.password = "pass"
+			} else {
+				configContent.WriteString("<cert>\n" + certificates.clientCert + "</cert>\n")
+				configContent.WriteString("<key>\n" + certificates.clientKey + "</key>\n")
+			}
+			switch testCase.wrap {
+			case "tls-crypt":
+				configContent.WriteString("<tls-crypt>\n" + staticKey + "</tls-crypt>\n")
+				server.wrapper = must(newTLSCryptWrapper(must(ParseStaticKey(staticKey))(t), 0))(t)
+			case "tls-auth":
+				configContent.WriteString("key-direction 1\n<tls-auth>\n" + staticKey + "</tls-auth>\n")
+				server.wrapper = must(newTLSAuthWrapper(must(ParseStaticKey(staticKey))(t), 0, "SHA256"))(t)
+			}
+			var serverPort uint16
+			if testCase.network == N.NetworkTCP {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { listener.Close() })
+				serverPort = uint16(listener.Addr().(*net.TCPAddr).Port)
+				go func() {
+					conn, acceptErr := listener.Accept()
+					if acceptErr != nil {
+						return
+					}
+					server.conn = newTCPTransport(conn)
+					server.serve()
+				}()
+			} else {
+				packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { packetConn.Close() })
+				serverPort = uint16(packetConn.LocalAddr().(*net.UDPAddr).Port)
+				server.conn = &testUDPTransport{PacketConn: packetConn}
+				go server.serve()
+			}
+			configContent.WriteString("remote 127.0.0.1 " + strconv.Itoa(int(serverPort)) + "\n")
+			config, err := ParseConfig(configContent.String(), "")
+			require.NoError(t, err)
+			received := make(chan []byte, 1)
+			client, err := NewClient(ClientOptions{
+				Context:  context.Background(),
+				Logger:   logger.NOP(),
+				Dialer:   N.SystemDialer,
+				Config:   config,
+				Remote:   config.Remotes[0],
+				Username: server.username,
+				Password: server.password,
+				Handler: func(packet []byte) {
+					received <- append([]byte(nil), packet...)
+				},
+			})
+			require.NoError(t, err)
+			t.Cleanup(func() { client.Close() })
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			pushReply, err := client.Start(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.8.0.2/24")}, pushReply.Address)
+			require.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.9.0.0/16")}, pushReply.Routes)
+			require.Equal(t, []netip.Addr{netip.MustParseAddr("10.8.0.1")}, pushReply.DNSServers)
+			testEcho := func() {
+				for i := 0; i < 3; i++ {
+					packet := make([]byte, 100+i*500)
+					rand.Read(packet)
+					require.NoError(t, client.WritePacket(packet))
+					select {
+					case echo := <-received:
+						require.Equal(t, packet, echo)
+					case <-time.After(5 * time.Second):
+						t.Fatal("echo timeout")
+					}
+				}
+			}
+			testEcho()
+			server.renegotiate(1)
+			require.Eventually(t, func() bool {
+				return client.primary.Load().keyID == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			testEcho()
+			client.primary.Load().packetID.Store(packetIDRenegotiate)
+			testEcho()
+			require.Eventually(t, func() bool {
+				return client.primary.Load().keyID == 2
+			}, 5*time.Second, 10*time.Millisecond)
+			testEcho()
+		})
+	}
+}
+
+func TestDataChannelPacketID(t *testing.T) {
+	t.Parallel()
+	keyMaterial := make([]byte, 256)
+	rand.Read(keyMaterial)
+	data, err := newDataChannel(0, -1, "AES-256-GCM", "SHA256", newStaticKey(keyMaterial), 0)
+	require.NoError(t, err)
+	_, err = data.Seal([]byte("payload"))
+	require.NoError(t, err)
+	require.False(t, data.expire())
+	data.packetID.Store(packetIDRenegotiate - 1)
+	_, err = data.Seal([]byte("payload"))
+	require.NoError(t, err)
+	require.True(t, data.expire())
+	require.False(t, data.expire())
+	data.packetID.Store(packetIDMax - 1)
+	_, err = data.Seal([]byte("payload"))
+	require.NoError(t, err)
+	_, err = data.Seal([]byte("payload"))
+	require.ErrorIs(t, err, errPacketIDExhausted)
+	require.Equal(t, uint32(packetIDMax), data.packetID.Load())
+}
+
+func TestParseConfigCompression(t *testing.T) {
+	t.Parallel()
+	for _, compression := range []Compression{CompressionNone, CompressionLZOStub, CompressionStub, CompressionStubV2} {
+		for _, payload := range [][]byte{{0x45, 0x00, 0x01}, {compressV2Indicator, 0x01}, {0x60}} {
+			unframed, err := compression.unframe(compression.frame(payload))
+			require.NoError(t, err)
+			require.Equal(t, payload, unframed)
+		}
+	}
+}
+
+func must[T any](value T, err error) func(t *testing.T) T {
+	return func(t *testing.T) T {
+		require.NoError(t, err)
+		return value
+	}
+}
+
+type testUDPTransport struct {
+	net.PacketConn
+	clientAddr atomic.Pointer[net.Addr]
+}
+
+func (t *testUDPTransport) ReadPacket() ([]byte, error) {
+	buffer := make([]byte, 65535)
+	n, addr, err := t.PacketConn.ReadFrom(buffer)
+	if err != nil {
+		return nil, err
+	}
+	t.clientAddr.Store(&addr)
+	return buffer[:n], nil
+}
+
+func (t *testUDPTransport) WritePacket(packet []byte) error {
+	_, err := t.PacketConn.WriteTo(packet, *t.clientAddr.Load())
+	return err
+}
+
+func (t *testUDPTransport) RemoteAddr() net.Addr {
+	return *t.clientAddr.Load()
+}
+
+// testServer is a minimal OpenVPN server for a single client.
+type testServer struct {
+	t               *testing.T
+	conn            packetTransport
+	wrapper         controlWrapper
+	tlsConfig       *tls.Config
+	cipher          string
+	auth            string
+	useEKM          bool
+	username        string
+	password        string
+	localSessionID  sessionID
+	remoteSessionID sessionID
+	access          sync.Mutex
+	keys            [8]*keyState
+	data            [8]atomic.Pointer[dataChannel]
+}
+
+func (s *testServer) writeControl(packet *controlPacket) error {
+	packet.sessionID = s.localSessionID
+	packet.remoteSessionID = s.remoteSessionID
+	return s.conn.WritePacket(s.wrapper.Wrap(packet.header(), packet.body()))
+}
+
+func (s *testServer) localAddr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *testServer) remoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+func (s *testServer) serve() {
+	rand.Read(s.localSessionID[:])
+	for {
+		packet, err := s.conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		keyID := packet[0] & 0x07
+		switch packet[0] >> 3 {
+		case opcodeDataV1, opcodeDataV2:
+			data := s.data[keyID].Load()
+			if data == nil {
+				s.t.Error("unexpected data packet")
+				return
+			}
+			payload, err := data.Open(packet)
+			if err != nil {
+				s.t.Error(err)
+				return
+			}
+			if bytes.Equal(payload, pingMessage) {
+				continue
+			}
+			packet, err = data.Seal(payload)
+			if err != nil {
+				s.t.Error(err)
+				return
+			}
+			err = s.conn.WritePacket(packet)
+			if err != nil {
+				return
+			}
+		default:
+			header, body, err := s.wrapper.Unwrap(packet)
+			if err != nil {
+				s.t.Error(err)
+				return
+			}
+			control, err := parseControlPacket(header, body)
+			if err != nil {
+				s.t.Error(err)
+				return
+			}
+			s.access.Lock()
+			if control.opcode == opcodeControlHardResetClientV2 && s.keys[0] == nil {
+				s.remoteSessionID = control.sessionID
+				s.keys[0] = newKeyState(s, 0)
+				go s.handshake(s.keys[0])
+			} else if control.opcode == opcodeControlSoftResetV1 && keyID != 0 && s.keys[keyID] == nil {
+				// renegotiation started by the client
+				s.keys[keyID] = newKeyState(s, keyID)
+				go s.handshake(s.keys[keyID])
+			}
+			key := s.keys[keyID]
+			s.access.Unlock()
+			if key == nil {
+				s.t.Error("unexpected key id: ", keyID)
+				return
+			}
+			key.receive(control)
+		}
+	}
+}
+
+func (s *testServer) renegotiate(keyID byte) {
+	key := newKeyState(s, keyID)
+	s.access.Lock()
+	s.keys[keyID] = key
+	s.access.Unlock()
+	s.handshake(key)
+}
+
+func (s *testServer) handshake(key *keyState) {
+	initial := key.keyID == 0
+	var err error
+	if initial {
+		err = key.sendReliable(opcodeControlHardResetServerV2, nil)
+	} else {
+		err = key.sendReliable(opcodeControlSoftResetV1, nil)
+	}
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+	tlsConn := tls.Server(&controlConn{key}, s.tlsConfig)
+	err = tlsConn.Handshake()
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+	buffer := make([]byte, 16*1024)
+	n, err := tlsConn.Read(buffer)
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+	clientMessage := append([]byte(nil), buffer[:n]...)
+	if binary.BigEndian.Uint32(clientMessage) != 0 || clientMessage[4] != 2 {
+		s.t.Error("invalid client key method")
+		return
+	}
+	clientPreMaster := clientMessage[5:53]
+	clientRandom1 := clientMessage[53:85]
+	clientRandom2 := clientMessage[85:117]
+	var controlStrings []string
+	for remaining := clientMessage[117:]; len(remaining) >= 2; {
+		length := int(binary.BigEndian.Uint16(remaining))
+		remaining = remaining[2:]
+		controlStrings = append(controlStrings, strings.TrimSuffix(string(remaining[:length]), "\x00"))
+		remaining = remaining[length:]
+	}
+	if len(controlStrings) != 4 {
+		s.t.Error("invalid client key method strings: ", controlStrings)
+		return
+	}
+	if controlStrings[1] != s.username || controlStrings[2] != s.password {
+		s.t.Error("invalid credentials: ", controlStrings[1], " ", controlStrings[2])
+		return
+	}
+	if !strings.Contains(controlStrings[3], "IV_CIPHERS=AES-256-GCM:AES-128-GCM:CHACHA20-POLY1305\n") {
+		s.t.Error("invalid peer info: ", controlStrings[3])
+		return
+	}
+	serverRandom := make([]byte, 64)
+	rand.Read(serverRandom)
+	serverMessage := append([]byte{0, 0, 0, 0, 2}, serverRandom...)
+	serverMessage = appendControlString(serverMessage, "V4,dev-type tun")
+	_, err = tlsConn.Write(serverMessage)
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+	if initial {
+		n, err = tlsConn.Read(buffer)
+		if err != nil {
+			s.t.Error(err)
+			return
+		}
+		if !strings.HasPrefix(string(buffer[:n]), "PUSH_REQUEST\x00") {
+			s.t.Error("unexpected control message: ", string(buffer[:n]))
+			return
+		}
+	}
+	var keyMaterial []byte
+	if s.useEKM {
+		connectionState := tlsConn.ConnectionState()
+		keyMaterial, err = connectionState.ExportKeyingMaterial("EXPORTER-OpenVPN-datakeys", nil, 256)
+		if err != nil {
+			s.t.Error(err)
+			return
+		}
+	} else {
+		masterSecret := tls1PRF(clientPreMaster, "OpenVPN master secret", append(append([]byte(nil), clientRandom1...), serverRandom[:32]...), 48)
+		seed := append(append([]byte(nil), clientRandom2...), serverRandom[32:]...)
+		seed = append(seed, s.remoteSessionID[:]...)
+		seed = append(seed, s.localSessionID[:]...)
+		keyMaterial = tls1PRF(masterSecret, "OpenVPN key expansion", seed, 256)
+	}
+	data, err := newDataChannel(key.keyID, 5, s.cipher, s.auth, newStaticKey(keyMaterial), 1)
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+	s.data[key.keyID].Store(data)
+	if !initial {
+		return
+	}
+	pushReply := "PUSH_REPLY,topology subnet,ifconfig 10.8.0.2 255.255.255.0,route 10.9.0.0 255.255.0.0,dhcp-option DNS 10.8.0.1,push-continuation 2\x00" +
+		"PUSH_REPLY,peer-id 5,cipher " + s.cipher + ",ping 1,ping-restart 10"
+	if s.useEKM {
+		pushReply += ",key-derivation tls-ekm"
+	}
+	_, err = tlsConn.Write([]byte(pushReply + ",push-continuation 1\x00"))
+	if err != nil {
+		s.t.Error(err)
+	}
+}
+
+type testCertificates struct {
+	ca           string
+	clientCert   string
+	clientKey    string
+	serverConfig *tls.Config
+}
+
+func newTestCertificates(t *testing.T) *testCertificates {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCertificate, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	issue := func(name string, usage x509.ExtKeyUsage) (string, string, tls.Certificate) {
+		key, issueErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, issueErr)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(time.Now().UnixNano()),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		certificateDER, issueErr := x509.CreateCertificate(rand.Reader, template, caCertificate, &key.PublicKey, caKey)
+		require.NoError(t, issueErr)
+		keyDER, issueErr := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, issueErr)
+		certificatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}))
+		keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+		keyPair, issueErr := tls.X509KeyPair([]byte(certificatePEM), []byte(keyPEM))
+		require.NoError(t, issueErr)
+		return certificatePEM, keyPEM, keyPair
+	}
+	_, _, serverKeyPair := issue("server", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey, _ := issue("client", x509.ExtKeyUsageClientAuth)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCertificate)
+	return &testCertificates{
+		ca:         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		clientCert: clientCert,
+		clientKey:  clientKey,
+		serverConfig: &tls.Config{
+			Certificates: []tls.Certificate{serverKeyPair},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    clientCAs,
+		},
+	}
+}
+
+func newTestStaticKey(t *testing.T) string {
+	keyData := make([]byte, 256)
+	_, err := rand.Read(keyData)
+	require.NoError(t, err)
+	var content strings.Builder
+	content.WriteString("#\n# 2048 bit OpenVPN static key\n#\n" + staticKeyBegin + "\n")
+	for i := 0; i < len(keyData); i += 16 {
+		content.WriteString(hex.EncodeToString(keyData[i:i+16]) + "\n")
+	}
+	content.WriteString(staticKeyEnd + "\n")
+	return content.String()
+}
@@ -0,0 +1,468 @@
+package openvpn
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+const (
+	DefaultPort        = 1194
+	DefaultTunMTU      = 1500
+	DefaultPingRestart = 120 * time.Second
+)
+
+type Remote struct {
+	Host    string
+	Port    uint16
+	Network string
+}
+
+func (r Remote) String() string {
+	return r.Network + "://" + M.ParseSocksaddrHostPort(r.Host, r.Port).String()
+}
+
+// Config is the subset of an OpenVPN client configuration file supported by the userspace client.
+type Config struct {
+	Remotes        []Remote
+	RemoteRandom   bool
+	CA             string
+	Cert           string
+	Key            string
+	ExtraCerts     string
+	TLSAuth        *StaticKey
+	KeyDirection   int
+	TLSCrypt       *StaticKey
+	Cipher         string
+	DataCiphers    []string
+	Auth           string
+	AuthUserPass   bool
+	Username       string
+	Password       string
+	RemoteCertTLS  string
+	VerifyX509Name string
+	VerifyX509Type string
+	TLSVersionMin  string
+	Compression    Compression
+	TunMTU         int
+	PingInterval   time.Duration
+	PingRestart    time.Duration
+	RouteNoPull    bool
+	Routes         []netip.Prefix
+}
+
+// ParseConfig parses an OpenVPN client configuration, file references are resolved relative to baseDir.
+func ParseConfig(content string, baseDir string) (*Config, error) {
+	config := &Config{
+		KeyDirection: -1,
+		Auth:         "SHA1",
+		TunMTU:       DefaultTunMTU,
+	}
+	var (
+		defaultNetwork = N.NetworkUDP
+		defaultPort    = uint16(DefaultPort)
+		remotes        [][]string
+		inlineBlocks   = make(map[string]string)
+		directives     [][]string
+	)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var (
+		blockName    string
+		blockContent strings.Builder
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if blockName != "" {
+			if line == "</"+blockName+">" {
+				inlineBlocks[blockName] = blockContent.String()
+				directives = append(directives, []string{blockName, "[inline]"})
+				blockName = ""
+				blockContent.Reset()
+			} else {
+				blockContent.WriteString(line)
+				blockContent.WriteString("\n")
+			}
+			continue
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "<") && strings.HasSuffix(line, ">") && !strings.HasPrefix(line, "</") {
+			blockName = line[1 : len(line)-1]
+			continue
+		}
+		fields, err := splitLine(line)
+		if err != nil {
+			return nil, E.Cause(err, "parse line: ", line)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = strings.TrimPrefix(fields[0], "--")
+		directives = append(directives, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if blockName != "" {
+		return nil, E.New("unterminated inline block: ", blockName)
+	}
+	readData := func(name string, args []string) (string, error) {
+		if len(args) == 0 || args[0] == "[inline]" {
+			data, loaded := inlineBlocks[name]
+			if !loaded {
+				return "", E.New("missing inline ", name)
+			}
+			return data, nil
+		}
+		path := args[0]
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", E.Cause(err, "read ", name)
+		}
+		return string(data), nil
+	}
+	tlsAuthDirection := -1
+	for _, fields := range directives {
+		name, args := fields[0], fields[1:]
+		var err error
+		switch name {
+		case "dev":
+			if len(args) > 0 && !strings.HasPrefix(args[0], "tun") {
+				return nil, E.New("unsupported device type: ", args[0], ", only tun is supported")
+			}
+		case "dev-type":
+			if len(args) > 0 && args[0] != "tun" {
+				return nil, E.New("unsupported device type: ", args[0], ", only tun is supported")
+			}
+		case "proto":
+			if len(args) > 0 {
+				defaultNetwork, err = parseProto(args[0])
+			}
+		case "port", "rport":
+			if len(args) > 0 {
+				defaultPort, err = parsePort(args[0])
+			}
+		case "remote":
+			if len(args) == 0 {
+				return nil, E.New("missing remote address")
+			}
+			remotes = append(remotes, args)
+		case "remote-random":
+			config.RemoteRandom = true
+		case "ca", "cert", "extra-certs":
+			var data string
+			data, err = readData(name, args)
+			switch name {
+			case "ca":
+				config.CA = data
+			case "cert":
+				config.Cert = data
+			case "extra-certs":
+				config.ExtraCerts = data
+			}
+		case "key":
+			config.Key, err = readData(name, args)
+		case "tls-auth":
+			var data string
+			data, err = readData(name, args)
+			if err == nil {
+				config.TLSAuth, err = ParseStaticKey(data)
+			}
+			if err == nil && len(args) > 1 {
+				tlsAuthDirection, err = parseKeyDirection(args[1])
+			}
+		case "key-direction":
+			if len(args) > 0 {
+				config.KeyDirection, err = parseKeyDirection(args[0])
+			}
+		case "tls-crypt":
+			var data string
+			data, err = readData(name, args)
+			if err == nil {
+				config.TLSCrypt, err = ParseStaticKey(data)
+			}
+		case "tls-crypt-v2":
+			return nil, E.New("tls-crypt-v2 is not supported")
+		case "secret":
+			return nil, E.New("static key mode is not supported")
+		case "pkcs12":
+			return nil, E.New("pkcs12 is not supported, use inline cert and key")
+		case "cipher":
+			if len(args) > 0 {
+				config.Cipher = strings.ToUpper(args[0])
+			}
+		case "data-ciphers", "ncp-ciphers":
+			if len(args) > 0 {
+				config.DataCiphers = strings.Split(strings.ToUpper(args[0]), ":")
+			}
+		case "auth":
+			if len(args) > 0 {
+				config.Auth = strings.ToUpper(args[0])
+			}
+		case "auth-user-pass":
+			config.AuthUserPass = true
+			if len(args) > 0 {
+				var data string
+				data, err = readData(name, args)
+				if err == nil {
+					lines := strings.Split(strings.TrimSpace(data), "\n")
+					config.Username = strings.TrimSpace(lines[0])
+					if len(lines) > 1 {
+						config.Password = strings.TrimSpace(lines[1])
+					}
+				}
+			} else if data, loaded := inlineBlocks[name]; loaded {
+				lines := strings.Split(strings.TrimSpace(data), "\n")
+				config.Username = strings.TrimSpace(lines[0])
+				if len(lines) > 1 {
+					config.Password = strings.TrimSpace(lines[1])
+				}
+			}
+		case "remote-cert-tls":
+			if len(args) > 0 {
+				config.RemoteCertTLS = args[0]
+			}
+		case "ns-cert-type":
+			if len(args) > 0 {
+				config.RemoteCertTLS = args[0]
+			}
+		case "verify-x509-name":
+			if len(args) > 0 {
+				config.VerifyX509Name = args[0]
+				config.VerifyX509Type = "subject"
+				if len(args) > 1 {
+					config.VerifyX509Type = args[1]
+				}
+			}
+		case "tls-version-min":
+			if len(args) > 0 {
+				config.TLSVersionMin = args[0]
+			}
+		case "comp-lzo":
+			config.Compression = CompressionLZOStub
+		case "compress":
+			config.Compression, err = parseCompress(args)
+		case "tun-mtu":
+			if len(args) > 0 {
+				config.TunMTU, err = strconv.Atoi(args[0])
+			}
+		case "ping":
+			if len(args) > 0 {
+				config.PingInterval, err = parseSeconds(args[0])
+			}
+		case "ping-restart":
+			if len(args) > 0 {
+				config.PingRestart, err = parseSeconds(args[0])
+			}
+		case "keepalive":
+			if len(args) > 1 {
+				config.PingInterval, err = parseSeconds(args[0])
+				if err == nil {
+					config.PingRestart, err = parseSeconds(args[1])
+				}
+			}
+		case "route-nopull":
+			config.RouteNoPull = true
+		case "route":
+			var route netip.Prefix
+			route, err = parseRoute(args)
+			if err == nil && route.IsValid() {
+				config.Routes = append(config.Routes, route)
+			}
+		case "route-ipv6":
+			if len(args) > 0 {
+				var route netip.Prefix
+				route, err = netip.ParsePrefix(args[0])
+				if err == nil {
+					config.Routes = append(config.Routes, route)
+				}
+			}
+		}
+		if err != nil {
+			return nil, E.Cause(err, name)
+		}
+	}
+	if tlsAuthDirection != -1 {
+		config.KeyDirection = tlsAuthDirection
+	}
+	if config.TLSAuth != nil && config.TLSCrypt != nil {
+		return nil, E.New("tls-auth and tls-crypt are mutually exclusive")
+	}
+	for _, remote := range remotes {
+		host := remote[0]
+		port := defaultPort
+		network := defaultNetwork
+		var err error
+		if len(remote) > 1 {
+			port, err = parsePort(remote[1])
+			if err != nil {
+				return nil, E.Cause(err, "remote")
+			}
+		}
+		if len(remote) > 2 {
+			network, err = parseProto(remote[2])
+			if err != nil {
+				return nil, E.Cause(err, "remote")
+			}
+		}
+		config.Remotes = append(config.Remotes, Remote{
+			Host:    host,
+			Port:    port,
+			Network: network,
+		})
+	}
+	if len(config.Remotes) == 0 {
+		return nil, E.New("missing remote")
+	}
+	if config.CA == "" {
+		return nil, E.New("missing ca")
+	}
+	if (config.Cert == "") != (config.Key == "") {
+		return nil, E.New("cert and key must be specified together")
+	}
+	if config.Cert == "" && !config.AuthUserPass {
+		return nil, E.New("missing cert or auth-user-pass")
+	}
+	return config, nil
+}
+
+func splitLine(line string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		quote   byte
+		inField bool
+	)
+	for i := 0; i < len(line); i++ {
+		char := line[i]
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else if char == '\\' && quote == '"' && i+1 < len(line) {
+				i++
+				current.WriteByte(line[i])
+			} else {
+				current.WriteByte(char)
+			}
+		case char == '"' || char == '\'':
+			quote = char
+			inField = true
+		case char == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+			inField = true
+		case char == ' ' || char == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		case (char == '#' || char == ';') && !inField:
+			i = len(line)
+		default:
+			current.WriteByte(char)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, E.New("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+func parseProto(proto string) (string, error) {
+	switch strings.ToLower(proto) {
+	case "udp", "udp4", "udp6":
+		return N.NetworkUDP, nil
+	case "tcp", "tcp4", "tcp6", "tcp-client", "tcp4-client", "tcp6-client":
+		return N.NetworkTCP, nil
+	default:
+		return "", E.New("unsupported protocol: ", proto)
+	}
+}
+
+func parsePort(port string) (uint16, error) {
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return 0, E.Cause(err, "parse port")
+	}
+	return uint16(portNumber), nil
+}
+
+func parseKeyDirection(direction string) (int, error) {
+	switch direction {
+	case "0":
+		return 0, nil
+	case "1":
+		return 1, nil
+	default:
+		return 0, E.New("invalid key direction: ", direction)
+	}
+}
+
+func parseSeconds(seconds string) (time.Duration, error) {
+	secondsNumber, err := strconv.ParseUint(seconds, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secondsNumber) * time.Second, nil
+}
+
+// parseRoute parses the arguments of a `route network [netmask] [gateway] [metric]` option,
+// an invalid prefix is returned for special network keywords.
+func parseRoute(args []string) (netip.Prefix, error) {
+	if len(args) == 0 {
+		return netip.Prefix{}, E.New("missing route network")
+	}
+	network, err := netip.ParseAddr(args[0])
+	if err != nil {
+		switch args[0] {
+		case "vpn_gateway", "net_gateway", "remote_host":
+			return netip.Prefix{}, nil
+		}
+		return netip.Prefix{}, E.Cause(err, "parse route network")
+	}
+	bits := 32
+	if len(args) > 1 && args[1] != "default" {
+		bits, err = parseNetmask(args[1])
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+	}
+	return netip.PrefixFrom(network, bits).Masked(), nil
+}
+
+func parseNetmask(netmask string) (int, error) {
+	maskAddr, err := netip.ParseAddr(netmask)
+	if err != nil || !maskAddr.Is4() {
+		return 0, E.New("invalid netmask: ", netmask)
+	}
+	mask := maskAddr.As4()
+	maskValue := uint32(mask[0])<<24 | uint32(mask[1])<<16 | uint32(mask[2])<<8 | uint32(mask[3])
+	bits := 0
+	for maskValue&0x80000000 != 0 {
+		bits++
+		maskValue <<= 1
+	}
+	if maskValue != 0 {
+		return 0, E.New("invalid netmask: ", netmask)
+	}
+	return bits, nil
+}
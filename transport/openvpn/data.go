@@ -0,0 +1,388 @@
+package openvpn
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"hash"
+	"math"
+	"strings"
+	"sync/atomic"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var pingMessage = []byte{
+	0x2a, 0x18, 0x7b, 0xf3, 0x64, 0x1e, 0xb4, 0xcb,
+	0x07, 0xed, 0x2d, 0x0a, 0x98, 0x1f, 0xc7, 0x48,
+}
+
+const (
+	// packetIDRenegotiate is where the client renegotiates the key,
+	// since the packet ID is part of the nonce of AEAD ciphers and must not wrap.
+	packetIDRenegotiate = 0xff000000
+	packetIDMax         = math.MaxUint32
+)
+
+var errPacketIDExhausted = E.New("packet ID exhausted")
+
+var defaultDataCiphers = []string{"AES-256-GCM", "AES-128-GCM", "CHACHA20-POLY1305"}
+
+func isAEADCipher(name string) bool {
+	switch name {
+	case "AES-128-GCM", "AES-192-GCM", "AES-256-GCM", "CHACHA20-POLY1305":
+		return true
+	default:
+		return false
+	}
+}
+
+func isSupportedCipher(name string) bool {
+	switch name {
+	case "AES-128-CBC", "AES-192-CBC", "AES-256-CBC":
+		return true
+	default:
+		return isAEADCipher(name)
+	}
+}
+
+func cipherKeyLength(name string) int {
+	switch {
+	case strings.HasPrefix(name, "AES-128-"):
+		return 16
+	case strings.HasPrefix(name, "AES-192-"):
+		return 24
+	default:
+		return 32
+	}
+}
+
+// dataChannel encrypts and decrypts data channel packets of one key.
+type dataChannel struct {
+	keyID    byte
+	peerID   int
+	encrypt  dataCipher
+	decrypt  dataCipher
+	packetID atomic.Uint32
+	replay   replayWindow
+	expiring atomic.Bool
+}
+
+type dataCipher interface {
+	seal(header []byte, packetID uint32, plaintext []byte) []byte
+	open(header []byte, packet []byte) (packetID uint32, plaintext []byte, err error)
+}
+
+func newDataChannel(keyID byte, peerID int, cipherName string, auth string, keyMaterial *StaticKey, direction int) (*dataChannel, error) {
+	outgoing, incoming := keyMaterial.Directional(direction)
+	encrypt, err := newDataCipher(cipherName, auth, outgoing)
+	if err != nil {
+		return nil, err
+	}
+	decrypt, err := newDataCipher(cipherName, auth, incoming)
+	if err != nil {
+		return nil, err
+	}
+	return &dataChannel{
+		keyID:   keyID,
+		peerID:  peerID,
+		encrypt: encrypt,
+		decrypt: decrypt,
+	}, nil
+}
+
+func newDataCipher(cipherName string, auth string, key *Key) (dataCipher, error) {
+	keyLength := cipherKeyLength(cipherName)
+	switch cipherName {
+	case "AES-128-GCM", "AES-192-GCM", "AES-256-GCM":
+		block, err := aes.NewCipher(key.Cipher[:keyLength])
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return &aeadCipher{aead: aead, implicitIV: key.HMAC[:8]}, nil
+	case "CHACHA20-POLY1305":
+		aead, err := chacha20poly1305.New(key.Cipher[:chacha20poly1305.KeySize])
+		if err != nil {
+			return nil, err
+		}
+		return &aeadCipher{aead: aead, implicitIV: key.HMAC[:8]}, nil
+	case "AES-128-CBC", "AES-192-CBC", "AES-256-CBC":
+		block, err := aes.NewCipher(key.Cipher[:keyLength])
+		if err != nil {
+			return nil, err
+		}
+		newHash, err := newHashFunc(auth)
+		if err != nil {
+			return nil, err
+		}
+		return &cbcCipher{block: block, newHash: newHash, hmacKey: key.HMAC[:newHash().Size()]}, nil
+	default:
+		return nil, E.New("unsupported cipher: ", cipherName)
+	}
+}
+
+func (c *dataChannel) Seal(payload []byte) ([]byte, error) {
+	packetID, err := c.nextPacketID()
+	if err != nil {
+		return nil, err
+	}
+	var header []byte
+	if c.peerID >= 0 {
+		header = []byte{opcodeDataV2<<3 | c.keyID, byte(c.peerID >> 16), byte(c.peerID >> 8), byte(c.peerID)}
+	} else {
+		header = []byte{opcodeDataV1<<3 | c.keyID}
+	}
+	return c.encrypt.seal(header, packetID, payload), nil
+}
+
+func (c *dataChannel) nextPacketID() (uint32, error) {
+	for {
+		packetID := c.packetID.Load()
+		if packetID == packetIDMax {
+			return 0, errPacketIDExhausted
+		}
+		if c.packetID.CompareAndSwap(packetID, packetID+1) {
+			return packetID + 1, nil
+		}
+	}
+}
+
+// expire reports once whether the key should be renegotiated before its packet IDs are exhausted.
+func (c *dataChannel) expire() bool {
+	return c.packetID.Load() >= packetIDRenegotiate && c.expiring.CompareAndSwap(false, true)
+}
+
+func (c *dataChannel) Open(packet []byte) ([]byte, error) {
+	var headerLength int
+	if packet[0]>>3 == opcodeDataV2 {
+		headerLength = 4
+	} else {
+		headerLength = 1
+	}
+	if len(packet) < headerLength {
+		return nil, E.New("data packet too short")
+	}
+	packetID, payload, err := c.decrypt.open(packet[:headerLength], packet[headerLength:])
+	if err != nil {
+		return nil, err
+	}
+	if !c.replay.check(packetID) {
+		return nil, E.New("replayed data packet: ", packetID)
+	}
+	return payload, nil
+}
+
+type aeadCipher struct {
+	aead       cipher.AEAD
+	implicitIV []byte
+}
+
+func (c *aeadCipher) nonce(packetID []byte) []byte {
+	nonce := make([]byte, 0, c.aead.NonceSize())
+	nonce = append(nonce, packetID...)
+	return append(nonce, c.implicitIV...)
+}
+
+func (c *aeadCipher) additionalData(header []byte, packetID []byte) []byte {
+	// the opcode is only authenticated with P_DATA_V2
+	if len(header) == 1 {
+		return packetID
+	}
+	return append(append([]byte(nil), header...), packetID...)
+}
+
+func (c *aeadCipher) seal(header []byte, packetID uint32, plaintext []byte) []byte {
+	packetIDBytes := binary.BigEndian.AppendUint32(nil, packetID)
+	sealed := c.aead.Seal(nil, c.nonce(packetIDBytes), plaintext, c.additionalData(header, packetIDBytes))
+	tagOffset := len(sealed) - c.aead.Overhead()
+	packet := make([]byte, 0, len(header)+4+len(sealed))
+	packet = append(packet, header...)
+	packet = append(packet, packetIDBytes...)
+	packet = append(packet, sealed[tagOffset:]...)
+	return append(packet, sealed[:tagOffset]...)
+}
+
+func (c *aeadCipher) open(header []byte, packet []byte) (uint32, []byte, error) {
+	overhead := c.aead.Overhead()
+	if len(packet) < 4+overhead {
+		return 0, nil, E.New("data packet too short")
+	}
+	packetIDBytes := packet[:4]
+	sealed := make([]byte, 0, len(packet)-4)
+	sealed = append(sealed, packet[4+overhead:]...)
+	sealed = append(sealed, packet[4:4+overhead]...)
+	plaintext, err := c.aead.Open(sealed[:0], c.nonce(packetIDBytes), sealed, c.additionalData(header, packetIDBytes))
+	if err != nil {
+		return 0, nil, E.Cause(err, "decrypt data packet")
+	}
+	return binary.BigEndian.Uint32(packetIDBytes), plaintext, nil
+}
+
+type cbcCipher struct {
+	block   cipher.Block
+	newHash func() hash.Hash
+	hmacKey []byte
+}
+
+func (c *cbcCipher) seal(header []byte, packetID uint32, plaintext []byte) []byte {
+	blockSize := c.block.BlockSize()
+	padding := blockSize - (4+len(plaintext))%blockSize
+	content := make([]byte, 0, 4+len(plaintext)+padding)
+	content = binary.BigEndian.AppendUint32(content, packetID)
+	content = append(content, plaintext...)
+	content = append(content, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ivAndCiphertext := make([]byte, blockSize+len(content))
+	rand.Read(ivAndCiphertext[:blockSize])
+	cipher.NewCBCEncrypter(c.block, ivAndCiphertext[:blockSize]).CryptBlocks(ivAndCiphertext[blockSize:], content)
+	mac := hmac.New(c.newHash, c.hmacKey)
+	mac.Write(ivAndCiphertext)
+	packet := append(append([]byte(nil), header...), mac.Sum(nil)...)
+	return append(packet, ivAndCiphertext...)
+}
+
+func (c *cbcCipher) open(header []byte, packet []byte) (uint32, []byte, error) {
+	blockSize := c.block.BlockSize()
+	hashSize := len(c.hmacKey)
+	if len(packet) < hashSize+blockSize*2 || (len(packet)-hashSize)%blockSize != 0 {
+		return 0, nil, E.New("invalid data packet length")
+	}
+	mac := hmac.New(c.newHash, c.hmacKey)
+	mac.Write(packet[hashSize:])
+	if !hmac.Equal(packet[:hashSize], mac.Sum(nil)) {
+		return 0, nil, E.New("data packet authentication failed")
+	}
+	content := make([]byte, len(packet)-hashSize-blockSize)
+	cipher.NewCBCDecrypter(c.block, packet[hashSize:hashSize+blockSize]).CryptBlocks(content, packet[hashSize+blockSize:])
+	padding := int(content[len(content)-1])
+	if padding == 0 || padding > blockSize || padding > len(content)-4 {
+		return 0, nil, E.New("invalid data packet padding")
+	}
+	content = content[:len(content)-padding]
+	return binary.BigEndian.Uint32(content), content[4:], nil
+}
+
+const replayWindowSize = 64
+
+// replayWindow is a sliding window over received packet ids.
+type replayWindow struct {
+	highest uint32
+	bitmap  uint64
+}
+
+func (w *replayWindow) check(packetID uint32) bool {
+	if packetID == 0 {
+		return false
+	}
+	if packetID > w.highest {
+		shift := packetID - w.highest
+		if shift >= replayWindowSize {
+			w.bitmap = 1
+		} else {
+			w.bitmap = w.bitmap<<shift | 1
+		}
+		w.highest = packetID
+		return true
+	}
+	offset := w.highest - packetID
+	if offset >= replayWindowSize || w.bitmap&(1<<offset) != 0 {
+		return false
+	}
+	w.bitmap |= 1 << offset
+	return true
+}
+
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionLZOStub
+	CompressionStub
+	CompressionStubV2
+)
+
+const (
+	lzoCompressByte     = 0x66
+	lz4CompressByte     = 0x69
+	noCompressByte      = 0xfa
+	noCompressByteSwap  = 0xfb
+	compressV2Indicator = 0x50
+)
+
+func parseCompress(args []string) (Compression, error) {
+	if len(args) == 0 {
+		return CompressionStub, nil
+	}
+	switch args[0] {
+	case "stub", "lz4", "lzo":
+		return CompressionStub, nil
+	case "stub-v2", "lz4-v2":
+		return CompressionStubV2, nil
+	case "migrate":
+		return CompressionNone, nil
+	default:
+		return 0, E.New("unsupported compression: ", args[0])
+	}
+}
+
+// frame adds the compression framing of uncompressed packets, compression itself is never used.
+func (c Compression) frame(payload []byte) []byte {
+	switch c {
+	case CompressionLZOStub:
+		return append([]byte{noCompressByte}, payload...)
+	case CompressionStub:
+		if len(payload) == 0 {
+			return []byte{noCompressByte}
+		}
+		framed := append([]byte{noCompressByteSwap}, payload[1:]...)
+		return append(framed, payload[0])
+	case CompressionStubV2:
+		if len(payload) > 0 && payload[0] == compressV2Indicator {
+			return append([]byte{compressV2Indicator, 0}, payload...)
+		}
+		return payload
+	default:
+		return payload
+	}
+}
+
+func (c Compression) unframe(payload []byte) ([]byte, error) {
+	switch c {
+	case CompressionLZOStub, CompressionStub:
+		if len(payload) == 0 {
+			return payload, nil
+		}
+		switch payload[0] {
+		case noCompressByte:
+			return payload[1:], nil
+		case noCompressByteSwap:
+			if len(payload) == 1 {
+				return payload[1:], nil
+			}
+			unframed := append([]byte{payload[len(payload)-1]}, payload[1:len(payload)-1]...)
+			return unframed, nil
+		case lzoCompressByte, lz4CompressByte:
+			return nil, E.New("compressed data packets are not supported")
+		default:
+			return nil, E.New("unknown compression header: ", payload[0])
+		}
+	case CompressionStubV2:
+		if len(payload) >= 2 && payload[0] == compressV2Indicator {
+			if payload[1] != 0 {
+				return nil, E.New("compressed data packets are not supported")
+			}
+			return payload[2:], nil
+		}
+		return payload, nil
+	default:
+		return payload, nil
+	}
+}
@@ -0,0 +1,104 @@
+package openvpn
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	staticKeyBegin = "-----BEGIN OpenVPN Static key V1-----"
+	staticKeyEnd   = "-----END OpenVPN Static key V1-----"
+)
+
+// Key is one direction of OpenVPN key material.
+type Key struct {
+	Cipher [64]byte
+	HMAC   [64]byte
+}
+
+// StaticKey is an OpenVPN 2048 bit static key, as used by tls-auth and tls-crypt,
+// and as produced by the data channel key expansion.
+type StaticKey struct {
+	Keys [2]Key
+}
+
+func ParseStaticKey(content string) (*StaticKey, error) {
+	beginIndex := strings.Index(content, staticKeyBegin)
+	endIndex := strings.Index(content, staticKeyEnd)
+	if beginIndex == -1 || endIndex == -1 || endIndex < beginIndex {
+		return nil, E.New("invalid static key: missing header or footer")
+	}
+	var hexContent strings.Builder
+	for _, line := range strings.Split(content[beginIndex+len(staticKeyBegin):endIndex], "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		hexContent.WriteString(line)
+	}
+	keyData, err := hex.DecodeString(hexContent.String())
+	if err != nil {
+		return nil, E.Cause(err, "decode static key")
+	}
+	if len(keyData) != 256 {
+		return nil, E.New("invalid static key length: ", len(keyData))
+	}
+	return newStaticKey(keyData), nil
+}
+
+func newStaticKey(keyData []byte) *StaticKey {
+	var key StaticKey
+	copy(key.Keys[0].Cipher[:], keyData[0:64])
+	copy(key.Keys[0].HMAC[:], keyData[64:128])
+	copy(key.Keys[1].Cipher[:], keyData[128:192])
+	copy(key.Keys[1].HMAC[:], keyData[192:256])
+	return &key
+}
+
+// Directional returns the outgoing and incoming keys for the key direction,
+// -1 is bidirectional, 0 is normal and 1 is inverse.
+func (k *StaticKey) Directional(direction int) (outgoing *Key, incoming *Key) {
+	switch direction {
+	case 0:
+		return &k.Keys[0], &k.Keys[1]
+	case 1:
+		return &k.Keys[1], &k.Keys[0]
+	default:
+		return &k.Keys[0], &k.Keys[0]
+	}
+}
+
+// tls1PRF is the TLS 1.0 pseudo random function used by key method 2.
+func tls1PRF(secret []byte, label string, seed []byte, length int) []byte {
+	labelAndSeed := append([]byte(label), seed...)
+	halfLength := (len(secret) + 1) / 2
+	result := make([]byte, length)
+	pHash(result, secret[:halfLength], labelAndSeed, md5.New)
+	sha1Result := make([]byte, length)
+	pHash(sha1Result, secret[len(secret)-halfLength:], labelAndSeed, sha1.New)
+	for i := range result {
+		result[i] ^= sha1Result[i]
+	}
+	return result
+}
+
+func pHash(result []byte, secret []byte, seed []byte, newHash func() hash.Hash) {
+	h := hmac.New(newHash, secret)
+	h.Write(seed)
+	a := h.Sum(nil)
+	for offset := 0; offset < len(result); {
+		h.Reset()
+		h.Write(a)
+		h.Write(seed)
+		offset += copy(result[offset:], h.Sum(nil))
+		h.Reset()
+		h.Write(a)
+		a = h.Sum(nil)
+	}
+}
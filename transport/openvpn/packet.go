@@ -0,0 +1,272 @@
+package openvpn
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"sync/atomic"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	opcodeControlSoftResetV1       = 3
+	opcodeControlV1                = 4
+	opcodeAckV1                    = 5
+	opcodeDataV1                   = 6
+	opcodeControlHardResetClientV2 = 7
+	opcodeControlHardResetServerV2 = 8
+	opcodeDataV2                   = 9
+)
+
+const (
+	sessionIDLength = 8
+	maxAcks         = 8
+)
+
+type sessionID [sessionIDLength]byte
+
+type controlPacket struct {
+	opcode          byte
+	keyID           byte
+	sessionID       sessionID
+	acks            []uint32
+	remoteSessionID sessionID
+	messageID       uint32
+	payload         []byte
+}
+
+func (p *controlPacket) header() []byte {
+	header := make([]byte, 1+sessionIDLength)
+	header[0] = p.opcode<<3 | p.keyID
+	copy(header[1:], p.sessionID[:])
+	return header
+}
+
+func (p *controlPacket) body() []byte {
+	body := make([]byte, 0, 1+len(p.acks)*4+sessionIDLength+4+len(p.payload))
+	body = append(body, byte(len(p.acks)))
+	for _, ack := range p.acks {
+		body = binary.BigEndian.AppendUint32(body, ack)
+	}
+	if len(p.acks) > 0 {
+		body = append(body, p.remoteSessionID[:]...)
+	}
+	if p.opcode != opcodeAckV1 {
+		body = binary.BigEndian.AppendUint32(body, p.messageID)
+		body = append(body, p.payload...)
+	}
+	return body
+}
+
+func parseControlPacket(header []byte, body []byte) (*controlPacket, error) {
+	packet := &controlPacket{
+		opcode: header[0] >> 3,
+		keyID:  header[0] & 0x07,
+	}
+	copy(packet.sessionID[:], header[1:])
+	if len(body) < 1 {
+		return nil, E.New("control packet too short")
+	}
+	ackCount := int(body[0])
+	body = body[1:]
+	if ackCount > maxAcks || len(body) < ackCount*4 {
+		return nil, E.New("invalid control packet ack array")
+	}
+	for i := 0; i < ackCount; i++ {
+		packet.acks = append(packet.acks, binary.BigEndian.Uint32(body[i*4:]))
+	}
+	body = body[ackCount*4:]
+	if ackCount > 0 {
+		if len(body) < sessionIDLength {
+			return nil, E.New("control packet too short")
+		}
+		copy(packet.remoteSessionID[:], body)
+		body = body[sessionIDLength:]
+	}
+	if packet.opcode != opcodeAckV1 {
+		if len(body) < 4 {
+			return nil, E.New("control packet too short")
+		}
+		packet.messageID = binary.BigEndian.Uint32(body)
+		packet.payload = body[4:]
+	}
+	return packet, nil
+}
+
+// controlWrapper protects control channel packets with tls-auth or tls-crypt.
+type controlWrapper interface {
+	Wrap(header []byte, body []byte) []byte
+	Unwrap(packet []byte) (header []byte, body []byte, err error)
+}
+
+func newControlWrapper(config *Config) (controlWrapper, error) {
+	switch {
+	case config.TLSCrypt != nil:
+		// the client uses the inverse key direction
+		return newTLSCryptWrapper(config.TLSCrypt, 1)
+	case config.TLSAuth != nil:
+		return newTLSAuthWrapper(config.TLSAuth, config.KeyDirection, config.Auth)
+	default:
+		return (*plainWrapper)(nil), nil
+	}
+}
+
+type plainWrapper struct{}
+
+func (w *plainWrapper) Wrap(header []byte, body []byte) []byte {
+	return append(header, body...)
+}
+
+func (w *plainWrapper) Unwrap(packet []byte) ([]byte, []byte, error) {
+	if len(packet) < 1+sessionIDLength {
+		return nil, nil, E.New("control packet too short")
+	}
+	return packet[:1+sessionIDLength], packet[1+sessionIDLength:], nil
+}
+
+// replayID is the long form packet id of tls-auth and tls-crypt.
+type replayID struct {
+	packetID atomic.Uint32
+	time     uint32
+}
+
+func (r *replayID) next() []byte {
+	id := make([]byte, 8)
+	binary.BigEndian.PutUint32(id, r.packetID.Add(1))
+	binary.BigEndian.PutUint32(id[4:], r.time)
+	return id
+}
+
+type tlsAuthWrapper struct {
+	newHash     func() hash.Hash
+	outgoingKey []byte
+	incomingKey []byte
+	replayID    replayID
+}
+
+func newTLSAuthWrapper(key *StaticKey, direction int, auth string) (*tlsAuthWrapper, error) {
+	newHash, err := newHashFunc(auth)
+	if err != nil {
+		return nil, E.Cause(err, "tls-auth")
+	}
+	hashSize := newHash().Size()
+	outgoing, incoming := key.Directional(direction)
+	return &tlsAuthWrapper{
+		newHash:     newHash,
+		outgoingKey: outgoing.HMAC[:hashSize],
+		incomingKey: incoming.HMAC[:hashSize],
+		replayID:    replayID{time: uint32(time.Now().Unix())},
+	}, nil
+}
+
+func (w *tlsAuthWrapper) sign(key []byte, header []byte, replayID []byte, body []byte) []byte {
+	mac := hmac.New(w.newHash, key)
+	mac.Write(replayID)
+	mac.Write(header)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func (w *tlsAuthWrapper) Wrap(header []byte, body []byte) []byte {
+	replayID := w.replayID.next()
+	packet := append(header, w.sign(w.outgoingKey, header, replayID, body)...)
+	packet = append(packet, replayID...)
+	return append(packet, body...)
+}
+
+func (w *tlsAuthWrapper) Unwrap(packet []byte) ([]byte, []byte, error) {
+	hashSize := len(w.incomingKey)
+	if len(packet) < 1+sessionIDLength+hashSize+8 {
+		return nil, nil, E.New("control packet too short")
+	}
+	header := packet[:1+sessionIDLength]
+	packetHMAC := packet[1+sessionIDLength : 1+sessionIDLength+hashSize]
+	replayID := packet[1+sessionIDLength+hashSize : 1+sessionIDLength+hashSize+8]
+	body := packet[1+sessionIDLength+hashSize+8:]
+	if !hmac.Equal(packetHMAC, w.sign(w.incomingKey, header, replayID, body)) {
+		return nil, nil, E.New("tls-auth: packet authentication failed")
+	}
+	return header, body, nil
+}
+
+const tlsCryptTagLength = sha256.Size
+
+type tlsCryptWrapper struct {
+	outgoingCipher cipher.Block
+	outgoingKey    []byte
+	incomingCipher cipher.Block
+	incomingKey    []byte
+	replayID       replayID
+}
+
+func newTLSCryptWrapper(key *StaticKey, direction int) (*tlsCryptWrapper, error) {
+	outgoing, incoming := key.Directional(direction)
+	outgoingCipher, err := aes.NewCipher(outgoing.Cipher[:32])
+	if err != nil {
+		return nil, err
+	}
+	incomingCipher, err := aes.NewCipher(incoming.Cipher[:32])
+	if err != nil {
+		return nil, err
+	}
+	return &tlsCryptWrapper{
+		outgoingCipher: outgoingCipher,
+		outgoingKey:    outgoing.HMAC[:32],
+		incomingCipher: incomingCipher,
+		incomingKey:    incoming.HMAC[:32],
+		replayID:       replayID{time: uint32(time.Now().Unix())},
+	}, nil
+}
+
+func (w *tlsCryptWrapper) Wrap(header []byte, body []byte) []byte {
+	header = append(header, w.replayID.next()...)
+	mac := hmac.New(sha256.New, w.outgoingKey)
+	mac.Write(header)
+	mac.Write(body)
+	tag := mac.Sum(nil)
+	packet := append(header, tag...)
+	encrypted := make([]byte, len(body))
+	cipher.NewCTR(w.outgoingCipher, tag[:aes.BlockSize]).XORKeyStream(encrypted, body)
+	return append(packet, encrypted...)
+}
+
+func (w *tlsCryptWrapper) Unwrap(packet []byte) ([]byte, []byte, error) {
+	authenticatedLength := 1 + sessionIDLength + 8
+	if len(packet) < authenticatedLength+tlsCryptTagLength {
+		return nil, nil, E.New("control packet too short")
+	}
+	tag := packet[authenticatedLength : authenticatedLength+tlsCryptTagLength]
+	body := make([]byte, len(packet)-authenticatedLength-tlsCryptTagLength)
+	cipher.NewCTR(w.incomingCipher, tag[:aes.BlockSize]).XORKeyStream(body, packet[authenticatedLength+tlsCryptTagLength:])
+	mac := hmac.New(sha256.New, w.incomingKey)
+	mac.Write(packet[:authenticatedLength])
+	mac.Write(body)
+	if !hmac.Equal(tag, mac.Sum(nil)) {
+		return nil, nil, E.New("tls-crypt: packet authentication failed")
+	}
+	return packet[:1+sessionIDLength], body, nil
+}
+
+func newHashFunc(name string) (func() hash.Hash, error) {
+	switch name {
+	case "SHA1":
+		return sha1.New, nil
+	case "SHA224":
+		return sha256.New224, nil
+	case "SHA256":
+		return sha256.New, nil
+	case "SHA384":
+		return sha512.New384, nil
+	case "SHA512":
+		return sha512.New, nil
+	default:
+		return nil, E.New("unsupported digest: ", name)
+	}
+}
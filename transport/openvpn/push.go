@@ -0,0 +1,210 @@
+package openvpn
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// PushReply is the configuration pushed by the server.
+type PushReply struct {
+	Address          []netip.Prefix
+	Routes           []netip.Prefix
+	DNSServers       []netip.Addr
+	SearchDomains    []string
+	Cipher           string
+	PeerID           int
+	PingInterval     time.Duration
+	PingRestart      time.Duration
+	TunMTU           int
+	Compression      Compression
+	CompressionSet   bool
+	KeyDerivationEKM bool
+	AuthToken        string
+}
+
+func ParsePushReply(options []string) (*PushReply, error) {
+	reply := &PushReply{
+		PeerID: -1,
+	}
+	var (
+		topology       string
+		ifconfig       []string
+		redirectIPv4   bool
+		redirectIPv6   bool
+		dnsServerIndex = -1
+	)
+	for _, option := range options {
+		fields, err := splitLine(option)
+		if err != nil {
+			return nil, E.Cause(err, "parse pushed option: ", option)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		name, args := fields[0], fields[1:]
+		switch name {
+		case "topology":
+			if len(args) > 0 {
+				topology = args[0]
+			}
+		case "ifconfig":
+			if len(args) < 2 {
+				return nil, E.New("invalid pushed option: ", option)
+			}
+			ifconfig = args
+		case "ifconfig-ipv6":
+			if len(args) < 1 {
+				return nil, E.New("invalid pushed option: ", option)
+			}
+			var prefix netip.Prefix
+			prefix, err = netip.ParsePrefix(args[0])
+			if err == nil {
+				reply.Address = append(reply.Address, prefix)
+			}
+		case "route":
+			var route netip.Prefix
+			route, err = parseRoute(args)
+			if err == nil && route.IsValid() {
+				reply.Routes = append(reply.Routes, route)
+			}
+		case "route-ipv6":
+			if len(args) > 0 {
+				var route netip.Prefix
+				route, err = netip.ParsePrefix(args[0])
+				if err == nil {
+					reply.Routes = append(reply.Routes, route.Masked())
+				}
+			}
+		case "redirect-gateway":
+			redirectIPv4 = true
+			for _, flag := range args {
+				switch flag {
+				case "!ipv4":
+					redirectIPv4 = false
+				case "ipv6":
+					redirectIPv6 = true
+				}
+			}
+		case "dhcp-option":
+			if len(args) < 2 {
+				continue
+			}
+			switch strings.ToUpper(args[0]) {
+			case "DNS", "DNS6":
+				var server netip.Addr
+				server, err = netip.ParseAddr(args[1])
+				if err == nil {
+					reply.DNSServers = append(reply.DNSServers, server)
+				}
+			case "DOMAIN", "DOMAIN-SEARCH":
+				reply.SearchDomains = append(reply.SearchDomains, args[1])
+			}
+		case "dns":
+			// dns server <priority> address <address>... / dns search-domains <domain>...
+			if len(args) >= 4 && args[0] == "server" && args[2] == "address" {
+				var priority int
+				priority, err = strconv.Atoi(args[1])
+				if err != nil {
+					break
+				}
+				if dnsServerIndex != -1 && priority != dnsServerIndex {
+					continue
+				}
+				dnsServerIndex = priority
+				for _, address := range args[3:] {
+					addrPort, parseErr := netip.ParseAddrPort(address)
+					if parseErr == nil {
+						reply.DNSServers = append(reply.DNSServers, addrPort.Addr())
+						continue
+					}
+					var server netip.Addr
+					server, err = netip.ParseAddr(strings.Trim(address, "[]"))
+					if err != nil {
+						break
+					}
+					reply.DNSServers = append(reply.DNSServers, server)
+				}
+			} else if len(args) > 1 && args[0] == "search-domains" {
+				reply.SearchDomains = append(reply.SearchDomains, args[1:]...)
+			}
+		case "cipher":
+			if len(args) > 0 {
+				reply.Cipher = strings.ToUpper(args[0])
+			}
+		case "peer-id":
+			if len(args) > 0 {
+				reply.PeerID, err = strconv.Atoi(args[0])
+				if err == nil && (reply.PeerID < 0 || reply.PeerID > 0xffffff) {
+					err = E.New("invalid peer id")
+				}
+			}
+		case "ping":
+			if len(args) > 0 {
+				reply.PingInterval, err = parseSeconds(args[0])
+			}
+		case "ping-restart":
+			if len(args) > 0 {
+				reply.PingRestart, err = parseSeconds(args[0])
+			}
+		case "tun-mtu":
+			if len(args) > 0 {
+				reply.TunMTU, err = strconv.Atoi(args[0])
+			}
+		case "comp-lzo":
+			reply.Compression = CompressionLZOStub
+			reply.CompressionSet = true
+		case "compress":
+			reply.Compression, err = parseCompress(args)
+			reply.CompressionSet = true
+		case "key-derivation":
+			reply.KeyDerivationEKM = len(args) > 0 && args[0] == "tls-ekm"
+		case "protocol-flags":
+			for _, flag := range args {
+				if flag == "tls-ekm" {
+					reply.KeyDerivationEKM = true
+				}
+			}
+		case "auth-token":
+			if len(args) > 0 {
+				reply.AuthToken = args[0]
+			}
+		}
+		if err != nil {
+			return nil, E.Cause(err, "parse pushed option: ", option)
+		}
+	}
+	if len(ifconfig) > 0 {
+		localAddress, err := netip.ParseAddr(ifconfig[0])
+		if err != nil || !localAddress.Is4() {
+			return nil, E.New("invalid pushed ifconfig address: ", ifconfig[0])
+		}
+		if topology == "subnet" || strings.HasPrefix(ifconfig[1], "255.") {
+			bits, err := parseNetmask(ifconfig[1])
+			if err != nil {
+				return nil, E.Cause(err, "parse pushed ifconfig")
+			}
+			reply.Address = append([]netip.Prefix{netip.PrefixFrom(localAddress, bits)}, reply.Address...)
+		} else {
+			remoteAddress, err := netip.ParseAddr(ifconfig[1])
+			if err != nil {
+				return nil, E.New("invalid pushed ifconfig remote address: ", ifconfig[1])
+			}
+			reply.Address = append([]netip.Prefix{netip.PrefixFrom(localAddress, 32)}, reply.Address...)
+			reply.Routes = append(reply.Routes, netip.PrefixFrom(remoteAddress, 32))
+		}
+	}
+	if len(reply.Address) == 0 {
+		return nil, E.New("missing pushed ifconfig")
+	}
+	if redirectIPv4 {
+		reply.Routes = append(reply.Routes, netip.PrefixFrom(netip.IPv4Unspecified(), 0))
+	}
+	if redirectIPv6 {
+		reply.Routes = append(reply.Routes, netip.PrefixFrom(netip.IPv6Unspecified(), 0))
+	}
+	return reply, nil
+}
@@ -0,0 +1,254 @@
+package openvpn
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	reliableSendWindow       = 4
+	reliableReceiveWindow    = 32
+	reliableInitialTimeout   = 2 * time.Second
+	reliableMaxTimeout       = 16 * time.Second
+	maxControlPayloadLength  = 1100
+	controlTransitionTimeout = time.Minute
+)
+
+type pendingPacket struct {
+	opcode    byte
+	messageID uint32
+	payload   []byte
+	sentAt    time.Time
+	timeout   time.Duration
+}
+
+type controlWriter interface {
+	writeControl(packet *controlPacket) error
+	localAddr() net.Addr
+	remoteAddr() net.Addr
+}
+
+// keyState is the reliability layer and TLS session of one key id.
+type keyState struct {
+	writer        controlWriter
+	keyID         byte
+	access        sync.Mutex
+	nextMessageID uint32
+	pending       []*pendingPacket
+	pendingAcks   []uint32
+	nextReceiveID uint32
+	received      map[uint32][]byte
+	readBuffer    bytes.Buffer
+	readNotify    chan struct{}
+	ackNotify     chan struct{}
+	done          chan struct{}
+	closeOnce     sync.Once
+	data          *dataChannel
+}
+
+func newKeyState(writer controlWriter, keyID byte) *keyState {
+	return &keyState{
+		writer:     writer,
+		keyID:      keyID,
+		received:   make(map[uint32][]byte),
+		readNotify: make(chan struct{}, 1),
+		ackNotify:  make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+func (k *keyState) sendReliable(opcode byte, payload []byte) error {
+	for {
+		k.access.Lock()
+		if len(k.pending) < reliableSendWindow {
+			break
+		}
+		k.access.Unlock()
+		select {
+		case <-k.ackNotify:
+		case <-k.done:
+			return net.ErrClosed
+		}
+	}
+	packet := &pendingPacket{
+		opcode:    opcode,
+		messageID: k.nextMessageID,
+		payload:   payload,
+		sentAt:    time.Now(),
+		timeout:   reliableInitialTimeout,
+	}
+	k.nextMessageID++
+	k.pending = append(k.pending, packet)
+	acks := k.takeAcks()
+	k.access.Unlock()
+	return k.writer.writeControl(&controlPacket{
+		opcode:    packet.opcode,
+		keyID:     k.keyID,
+		acks:      acks,
+		messageID: packet.messageID,
+		payload:   packet.payload,
+	})
+}
+
+// takeAcks must be called with access held.
+func (k *keyState) takeAcks() []uint32 {
+	if len(k.pendingAcks) == 0 {
+		return nil
+	}
+	count := min(len(k.pendingAcks), maxAcks)
+	acks := k.pendingAcks[:count:count]
+	k.pendingAcks = k.pendingAcks[count:]
+	return acks
+}
+
+func (k *keyState) receive(packet *controlPacket) {
+	k.access.Lock()
+	if len(packet.acks) > 0 {
+		var acked bool
+		for _, ack := range packet.acks {
+			for i, pending := range k.pending {
+				if pending.messageID == ack {
+					k.pending = append(k.pending[:i], k.pending[i+1:]...)
+					acked = true
+					break
+				}
+			}
+		}
+		if acked {
+			select {
+			case k.ackNotify <- struct{}{}:
+			default:
+			}
+		}
+	}
+	if packet.opcode == opcodeAckV1 {
+		k.access.Unlock()
+		return
+	}
+	if len(k.pendingAcks) < reliableReceiveWindow {
+		k.pendingAcks = append(k.pendingAcks, packet.messageID)
+	}
+	var delivered bool
+	if packet.messageID == k.nextReceiveID {
+		k.deliver(packet.payload)
+		delivered = true
+		for {
+			payload, loaded := k.received[k.nextReceiveID]
+			if !loaded {
+				break
+			}
+			delete(k.received, k.nextReceiveID)
+			k.deliver(payload)
+		}
+	} else if packet.messageID > k.nextReceiveID && packet.messageID-k.nextReceiveID < reliableReceiveWindow {
+		k.received[packet.messageID] = packet.payload
+	}
+	acks := k.takeAcks()
+	k.access.Unlock()
+	if delivered {
+		select {
+		case k.readNotify <- struct{}{}:
+		default:
+		}
+	}
+	if len(acks) > 0 {
+		k.writer.writeControl(&controlPacket{
+			opcode: opcodeAckV1,
+			keyID:  k.keyID,
+			acks:   acks,
+		})
+	}
+}
+
+// deliver must be called with access held.
+func (k *keyState) deliver(payload []byte) {
+	k.nextReceiveID++
+	k.readBuffer.Write(payload)
+}
+
+func (k *keyState) retransmit(now time.Time) {
+	var packets []*controlPacket
+	k.access.Lock()
+	for _, pending := range k.pending {
+		if now.Sub(pending.sentAt) < pending.timeout {
+			continue
+		}
+		pending.sentAt = now
+		pending.timeout = min(pending.timeout*2, reliableMaxTimeout)
+		packets = append(packets, &controlPacket{
+			opcode:    pending.opcode,
+			keyID:     k.keyID,
+			acks:      k.takeAcks(),
+			messageID: pending.messageID,
+			payload:   pending.payload,
+		})
+	}
+	k.access.Unlock()
+	for _, packet := range packets {
+		k.writer.writeControl(packet)
+	}
+}
+
+func (k *keyState) Close() error {
+	k.closeOnce.Do(func() {
+		close(k.done)
+	})
+	return nil
+}
+
+var _ net.Conn = (*controlConn)(nil)
+
+// controlConn is the TLS transport over the reliability layer.
+type controlConn struct {
+	*keyState
+}
+
+func (c *controlConn) Read(b []byte) (int, error) {
+	for {
+		c.access.Lock()
+		if c.readBuffer.Len() > 0 {
+			n, _ := c.readBuffer.Read(b)
+			c.access.Unlock()
+			return n, nil
+		}
+		c.access.Unlock()
+		select {
+		case <-c.readNotify:
+		case <-c.done:
+			return 0, net.ErrClosed
+		}
+	}
+}
+
+func (c *controlConn) Write(b []byte) (int, error) {
+	for offset := 0; offset < len(b); offset += maxControlPayloadLength {
+		chunk := b[offset:min(offset+maxControlPayloadLength, len(b))]
+		err := c.sendReliable(opcodeControlV1, append([]byte(nil), chunk...))
+		if err != nil {
+			return offset, err
+		}
+	}
+	return len(b), nil
+}
+
+func (c *controlConn) LocalAddr() net.Addr {
+	return c.writer.localAddr()
+}
+
+func (c *controlConn) RemoteAddr() net.Addr {
+	return c.writer.remoteAddr()
+}
+
+func (c *controlConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *controlConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *controlConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
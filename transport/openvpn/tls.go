@@ -0,0 +1,138 @@
+package openvpn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"slices"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM([]byte(config.CA)) {
+		return nil, E.New("parse ca: no certificates found")
+	}
+	tlsConfig := &tls.Config{
+		// OpenVPN verifies the server certificate against the CA only, without the server name
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			return verifyServerCertificate(config, caPool, rawCerts)
+		},
+	}
+	switch config.TLSVersionMin {
+	case "":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.0":
+		tlsConfig.MinVersion = tls.VersionTLS10
+	case "1.1":
+		tlsConfig.MinVersion = tls.VersionTLS11
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, E.New("unknown tls-version-min: ", config.TLSVersionMin)
+	}
+	if config.Cert != "" {
+		certificateChain := config.Cert
+		if config.ExtraCerts != "" {
+			certificateChain += "\n" + config.ExtraCerts
+		}
+		keyPair, err := tls.X509KeyPair([]byte(certificateChain), []byte(config.Key))
+		if err != nil {
+			return nil, E.Cause(err, "parse cert and key")
+		}
+		tlsConfig.Certificates = []tls.Certificate{keyPair}
+	}
+	return tlsConfig, nil
+}
+
+func verifyServerCertificate(config *Config, caPool *x509.CertPool, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return E.New("missing server certificate")
+	}
+	certificates := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, rawCert := range rawCerts {
+		certificate, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return E.Cause(err, "parse server certificate")
+		}
+		certificates = append(certificates, certificate)
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	serverCertificate := certificates[0]
+	_, err := serverCertificate.Verify(x509.VerifyOptions{
+		Roots:         caPool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return E.Cause(err, "verify server certificate")
+	}
+	if config.RemoteCertTLS == "server" && !slices.Contains(serverCertificate.ExtKeyUsage, x509.ExtKeyUsageServerAuth) {
+		return E.New("remote-cert-tls: server certificate is missing TLS Web Server Authentication usage")
+	}
+	if config.VerifyX509Name != "" {
+		var matched bool
+		switch config.VerifyX509Type {
+		case "name":
+			matched = serverCertificate.Subject.CommonName == config.VerifyX509Name
+		case "name-prefix":
+			matched = strings.HasPrefix(serverCertificate.Subject.CommonName, config.VerifyX509Name)
+		case "subject":
+			matched = formatSubject(serverCertificate) == config.VerifyX509Name
+		default:
+			return E.New("unknown verify-x509-name type: ", config.VerifyX509Type)
+		}
+		if !matched {
+			return E.New("verify-x509-name: server certificate subject mismatch: ", formatSubject(serverCertificate))
+		}
+	}
+	return nil
+}
+
+var subjectAttributeNames = map[string]string{
+	"2.5.4.3":              "CN",
+	"2.5.4.5":              "serialNumber",
+	"2.5.4.6":              "C",
+	"2.5.4.7":              "L",
+	"2.5.4.8":              "ST",
+	"2.5.4.9":              "street",
+	"2.5.4.10":             "O",
+	"2.5.4.11":             "OU",
+	"2.5.4.41":             "name",
+	"1.2.840.113549.1.9.1": "emailAddress",
+}
+
+// formatSubject formats the certificate subject like OpenVPN, e.g. `C=US, O=Example, CN=server`.
+func formatSubject(certificate *x509.Certificate) string {
+	var attributes []string
+	for _, rdn := range certificate.Subject.ToRDNSequence() {
+		for _, attribute := range rdn {
+			name, loaded := subjectAttributeNames[attribute.Type.String()]
+			if !loaded {
+				name = attribute.Type.String()
+			}
+			attributes = append(attributes, name+"="+formatAttributeValue(attribute.Value))
+		}
+	}
+	return strings.Join(attributes, ", ")
+}
+
+func formatAttributeValue(value any) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case asn1.RawValue:
+		return string(typedValue.Bytes)
+	default:
+		return fmt.Sprint(value)
+	}
+}
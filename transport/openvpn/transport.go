@@ -0,0 +1,85 @@
+package openvpn
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// packetTransport carries OpenVPN packets over UDP datagrams or length prefixed TCP records.
+type packetTransport interface {
+	ReadPacket() ([]byte, error)
+	WritePacket(packet []byte) error
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+type udpTransport struct {
+	net.Conn
+	buffer []byte
+}
+
+func newUDPTransport(conn net.Conn) *udpTransport {
+	return &udpTransport{
+		Conn:   conn,
+		buffer: make([]byte, 65535),
+	}
+}
+
+func (t *udpTransport) ReadPacket() ([]byte, error) {
+	n, err := t.Conn.Read(t.buffer)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), t.buffer[:n]...), nil
+}
+
+func (t *udpTransport) WritePacket(packet []byte) error {
+	_, err := t.Conn.Write(packet)
+	return err
+}
+
+type tcpTransport struct {
+	net.Conn
+	reader      *bufio.Reader
+	writeAccess sync.Mutex
+}
+
+func newTCPTransport(conn net.Conn) *tcpTransport {
+	return &tcpTransport{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+func (t *tcpTransport) ReadPacket() ([]byte, error) {
+	var length uint16
+	err := binary.Read(t.reader, binary.BigEndian, &length)
+	if err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return nil, E.New("invalid packet length")
+	}
+	packet := make([]byte, length)
+	_, err = io.ReadFull(t.reader, packet)
+	if err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+func (t *tcpTransport) WritePacket(packet []byte) error {
+	record := make([]byte, 2+len(packet))
+	binary.BigEndian.PutUint16(record, uint16(len(packet)))
+	copy(record[2:], packet)
+	t.writeAccess.Lock()
+	defer t.writeAccess.Unlock()
+	_, err := t.Conn.Write(record)
+	return err
+}
@@ -0,0 +1,349 @@
+package v2rayxhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/gofrs/uuid/v5"
+	"golang.org/x/net/http2"
+)
+
+var _ adapter.V2RayClientTransport = (*Client)(nil)
+
+type Client struct {
+	ctx              context.Context
+	dialer           N.Dialer
+	serverAddr       M.Socksaddr
+	transport        http.RoundTripper
+	http2            bool
+	requestURL       url.URL
+	host             string
+	mode             string
+	headers          http.Header
+	paddingBytes     paddingRange
+	noGRPCHeader     bool
+	maxEachPostBytes int
+	minPostsInterval time.Duration
+}
+
+func NewClient(ctx context.Context, dialer N.Dialer, serverAddr M.Socksaddr, options option.V2RayXHTTPOptions, tlsConfig tls.Config) (adapter.V2RayClientTransport, error) {
+	mode, err := checkMode(options.Mode)
+	if err != nil {
+		return nil, err
+	}
+	paddingBytes, err := parseRange(options.XPaddingBytes, defaultPaddingBytes)
+	if err != nil {
+		return nil, E.Cause(err, "x_padding_bytes")
+	}
+	var (
+		transport http.RoundTripper
+		useHTTP2  bool
+	)
+	if tlsConfig == nil {
+		transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, M.ParseSocksaddr(addr))
+			},
+		}
+	} else {
+		if len(tlsConfig.NextProtos()) == 0 {
+			tlsConfig.SetNextProtos([]string{http2.NextProtoTLS})
+		}
+		tlsDialer := tls.NewDialer(dialer, tlsConfig)
+		if common.Contains(tlsConfig.NextProtos(), http2.NextProtoTLS) {
+			useHTTP2 = true
+			transport = &http2.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.STDConfig) (net.Conn, error) {
+					return tlsDialer.DialTLSContext(ctx, M.ParseSocksaddr(addr))
+				},
+			}
+		} else {
+			transport = &http.Transport{
+				DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return tlsDialer.DialTLSContext(ctx, M.ParseSocksaddr(addr))
+				},
+			}
+		}
+	}
+	if mode == ModeAuto {
+		// streaming uploads are unreliable over HTTP/1.1 and most CDNs
+		if useHTTP2 {
+			mode = ModeStreamUp
+		} else {
+			mode = ModePacketUp
+		}
+	}
+	var requestURL url.URL
+	if tlsConfig == nil {
+		requestURL.Scheme = "http"
+	} else {
+		requestURL.Scheme = "https"
+	}
+	requestURL.Host = serverAddr.String()
+	requestURL.Path, requestURL.RawQuery = normalizePath(options.Path)
+	client := &Client{
+		ctx:              ctx,
+		dialer:           dialer,
+		serverAddr:       serverAddr,
+		transport:        transport,
+		http2:            useHTTP2,
+		requestURL:       requestURL,
+		host:             options.Host,
+		mode:             mode,
+		headers:          options.Headers.Build(),
+		paddingBytes:     paddingBytes,
+		noGRPCHeader:     options.NoGRPCHeader,
+		maxEachPostBytes: int(options.ScMaxEachPostBytes),
+		minPostsInterval: time.Duration(options.ScMinPostsInterval),
+	}
+	if client.maxEachPostBytes == 0 {
+		client.maxEachPostBytes = defaultMaxEachPostBytes
+	}
+	if client.minPostsInterval == 0 {
+		client.minPostsInterval = defaultMinPostsInterval
+	}
+	return client, nil
+}
+
+func (c *Client) DialContext(ctx context.Context) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	if c.mode == ModeStreamOne {
+		pipeReader, pipeWriter := io.Pipe()
+		request := c.newRequest(ctx, http.MethodPost, c.requestURL.Path, pipeReader)
+		c.setStreamHeader(request)
+		conn := &clientConn{
+			HTTP2Conn: v2rayhttp.NewLateHTTPConn(pipeWriter),
+			cancel:    cancel,
+		}
+		go c.roundTripDownload(request, conn.HTTP2Conn)
+		return conn, nil
+	}
+	sessionID, err := uuid.NewV4()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sessionPath := c.requestURL.Path + sessionID.String()
+	var uploadWriter io.WriteCloser
+	if c.mode == ModeStreamUp {
+		pipeReader, pipeWriter := io.Pipe()
+		request := c.newRequest(ctx, http.MethodPost, sessionPath, pipeReader)
+		c.setStreamHeader(request)
+		go func() {
+			response, err := c.transport.RoundTrip(request)
+			if err == nil {
+				if response.StatusCode != http.StatusOK {
+					err = E.New("v2ray-xhttp: unexpected upload status: ", response.Status)
+				} else {
+					// closing the response early resets the HTTP/2 stream, and
+					// the response body only contains keepalive padding
+					_, err = io.Copy(io.Discard, response.Body)
+				}
+				response.Body.Close()
+			}
+			if err != nil {
+				pipeReader.CloseWithError(err)
+			}
+		}()
+		uploadWriter = pipeWriter
+	} else {
+		uploader := newPacketUploader(c.maxEachPostBytes)
+		go c.loopUpload(ctx, sessionPath+"/", uploader)
+		uploadWriter = uploader
+	}
+	conn := &clientConn{
+		HTTP2Conn: v2rayhttp.NewLateHTTPConn(uploadWriter),
+		cancel:    cancel,
+	}
+	go c.roundTripDownload(c.newRequest(ctx, http.MethodGet, sessionPath, nil), conn.HTTP2Conn)
+	return conn, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, path string, body io.Reader) *http.Request {
+	requestURL := c.requestURL
+	requestURL.Path = path
+	request := &http.Request{
+		Method:     method,
+		URL:        &requestURL,
+		Header:     c.headers.Clone(),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	switch reader := body.(type) {
+	case nil:
+	case *bytes.Reader:
+		request.Body = io.NopCloser(reader)
+		request.ContentLength = int64(reader.Len())
+	case io.ReadCloser:
+		// the transport closes the body to unblock pending writes when the stream is reset
+		request.Body = reader
+	default:
+		request.Body = io.NopCloser(reader)
+	}
+	if c.host != "" {
+		request.Host = c.host
+	}
+	// the padding is sent in the Referer header, since it is not compressed by HPACK and QPACK
+	referrerURL := requestURL
+	referrerURL.RawQuery = "x_padding=" + paddingString(c.paddingBytes.rand())
+	request.Header.Set("Referer", referrerURL.String())
+	return request.WithContext(ctx)
+}
+
+func (c *Client) setStreamHeader(request *http.Request) {
+	if !c.noGRPCHeader {
+		// prevent CDNs from buffering the streaming request body
+		request.Header.Set("Content-Type", "application/grpc")
+	}
+}
+
+func (c *Client) roundTripDownload(request *http.Request, conn *v2rayhttp.HTTP2Conn) {
+	response, err := c.transport.RoundTrip(request)
+	if err != nil {
+		conn.Setup(nil, err)
+	} else if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		conn.Setup(nil, E.New("v2ray-xhttp: unexpected status: ", response.Status))
+	} else {
+		conn.Setup(response.Body, nil)
+	}
+}
+
+func (c *Client) loopUpload(ctx context.Context, sessionPath string, uploader *packetUploader) {
+	var (
+		seq      uint64
+		lastPost time.Time
+	)
+	for {
+		payload, err := uploader.next()
+		if err != nil {
+			return
+		}
+		if wait := c.minPostsInterval - time.Since(lastPost); wait > 0 {
+			select {
+			case <-ctx.Done():
+				uploader.fail(ctx.Err())
+				return
+			case <-time.After(wait):
+			}
+		}
+		lastPost = time.Now()
+		request := c.newRequest(ctx, http.MethodPost, sessionPath+strconv.FormatUint(seq, 10), bytes.NewReader(payload))
+		seq++
+		response, err := c.transport.RoundTrip(request)
+		if err != nil {
+			uploader.fail(err)
+			return
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			uploader.fail(E.New("v2ray-xhttp: unexpected upload status: ", response.Status))
+			return
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	c.transport = v2rayhttp.ResetTransport(c.transport)
+	return nil
+}
+
+type clientConn struct {
+	*v2rayhttp.HTTP2Conn
+	cancel context.CancelFunc
+}
+
+func (c *clientConn) Close() error {
+	c.cancel()
+	return c.HTTP2Conn.Close()
+}
+
+func (c *clientConn) Upstream() any {
+	return c.HTTP2Conn
+}
+
+// packetUploader buffers written data and hands it out in chunks of at most
+// maxSize bytes, one for each packet-up POST request.
+type packetUploader struct {
+	access  sync.Mutex
+	cond    *sync.Cond
+	buffer  []byte
+	maxSize int
+	closed  bool
+	err     error
+}
+
+func newPacketUploader(maxSize int) *packetUploader {
+	uploader := &packetUploader{maxSize: maxSize}
+	uploader.cond = sync.NewCond(&uploader.access)
+	return uploader
+}
+
+func (u *packetUploader) Write(p []byte) (n int, err error) {
+	u.access.Lock()
+	defer u.access.Unlock()
+	for len(u.buffer) >= u.maxSize && !u.closed && u.err == nil {
+		u.cond.Wait()
+	}
+	if u.err != nil {
+		return 0, u.err
+	}
+	if u.closed {
+		return 0, net.ErrClosed
+	}
+	u.buffer = append(u.buffer, p...)
+	u.cond.Broadcast()
+	return len(p), nil
+}
+
+func (u *packetUploader) next() ([]byte, error) {
+	u.access.Lock()
+	defer u.access.Unlock()
+	for len(u.buffer) == 0 && !u.closed && u.err == nil {
+		u.cond.Wait()
+	}
+	if u.err != nil {
+		return nil, u.err
+	}
+	if len(u.buffer) == 0 {
+		return nil, net.ErrClosed
+	}
+	payloadLen := min(len(u.buffer), u.maxSize)
+	payload := make([]byte, payloadLen)
+	copy(payload, u.buffer)
+	u.buffer = append(u.buffer[:0], u.buffer[payloadLen:]...)
+	u.cond.Broadcast()
+	return payload, nil
+}
+
+func (u *packetUploader) fail(err error) {
+	u.access.Lock()
+	defer u.access.Unlock()
+	u.err = err
+	u.cond.Broadcast()
+}
+
+func (u *packetUploader) Close() error {
+	u.access.Lock()
+	defer u.access.Unlock()
+	u.closed = true
+	u.cond.Broadcast()
+	return nil
+}
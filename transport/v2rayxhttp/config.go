@@ -0,0 +1,105 @@
+package v2rayxhttp
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const (
+	ModeAuto      = "auto"
+	ModePacketUp  = "packet-up"
+	ModeStreamUp  = "stream-up"
+	ModeStreamOne = "stream-one"
+)
+
+const (
+	defaultMaxEachPostBytes = 1000000
+	defaultMinPostsInterval = 30 * time.Millisecond
+	defaultMaxBufferedPosts = 30
+	sessionTimeout          = 30 * time.Second
+)
+
+var (
+	defaultPaddingBytes       = paddingRange{100, 1000}
+	defaultStreamUpServerSecs = paddingRange{20, 80}
+)
+
+type paddingRange struct {
+	from int
+	to   int
+}
+
+func parseRange(value string, defaultValue paddingRange) (paddingRange, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	fromString, toString, isRange := strings.Cut(value, "-")
+	from, err := strconv.Atoi(strings.TrimSpace(fromString))
+	if err != nil {
+		return paddingRange{}, E.Cause(err, "parse range: ", value)
+	}
+	to := from
+	if isRange {
+		to, err = strconv.Atoi(strings.TrimSpace(toString))
+		if err != nil {
+			return paddingRange{}, E.Cause(err, "parse range: ", value)
+		}
+	}
+	if from < 0 || to < from {
+		return paddingRange{}, E.New("invalid range: ", value)
+	}
+	return paddingRange{from, to}, nil
+}
+
+func (r paddingRange) rand() int {
+	if r.to <= r.from {
+		return r.from
+	}
+	return r.from + rand.Intn(r.to-r.from+1)
+}
+
+func (r paddingRange) contains(value int) bool {
+	return value >= r.from && value <= r.to
+}
+
+func checkMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ModeAuto, nil
+	case ModeAuto, ModePacketUp, ModeStreamUp, ModeStreamOne:
+		return mode, nil
+	default:
+		return "", E.New("unknown xhttp mode: ", mode)
+	}
+}
+
+// normalizePath returns the path with leading and trailing slashes, and the query
+// part of the configured path if any.
+func normalizePath(path string) (string, string) {
+	path, query, _ := strings.Cut(path, "?")
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "/", query
+	}
+	return "/" + path + "/", query
+}
+
+// 'X' has an 8-bit code in the static huffman table of HPACK and QPACK,
+// so header compression does not change the padding length on the wire.
+func paddingString(length int) string {
+	return strings.Repeat("X", length)
+}
+
+func writeResponseHeader(header http.Header, paddingBytes paddingRange) {
+	// CORS headers for browser based clients
+	header.Set("Access-Control-Allow-Origin", "*")
+	header.Set("Access-Control-Allow-Methods", "GET, POST")
+	if paddingLength := paddingBytes.rand(); paddingLength > 0 {
+		header.Set("X-Padding", paddingString(paddingLength))
+	}
+}
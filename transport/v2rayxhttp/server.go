@@ -0,0 +1,361 @@
+package v2rayxhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/transport/v2rayhttp"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	aTLS "github.com/sagernet/sing/common/tls"
+	sHttp "github.com/sagernet/sing/protocol/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var _ adapter.V2RayServerTransport = (*Server)(nil)
+
+type Server struct {
+	ctx              context.Context
+	logger           logger.ContextLogger
+	tlsConfig        tls.ServerConfig
+	handler          adapter.V2RayServerTransportHandler
+	httpServer       *http.Server
+	h2Server         *http2.Server
+	h2cHandler       http.Handler
+	host             string
+	path             string
+	mode             string
+	headers          http.Header
+	paddingBytes     paddingRange
+	noSSEHeader      bool
+	maxEachPostBytes int
+	maxBufferedPosts int
+	sessionAccess    sync.Mutex
+	sessions         map[string]*serverSession
+}
+
+type serverSession struct {
+	uploadQueue *uploadQueue
+	connected   chan struct{}
+	connectOnce sync.Once
+}
+
+func NewServer(ctx context.Context, logger logger.ContextLogger, options option.V2RayXHTTPOptions, tlsConfig tls.ServerConfig, handler adapter.V2RayServerTransportHandler) (*Server, error) {
+	mode, err := checkMode(options.Mode)
+	if err != nil {
+		return nil, err
+	}
+	paddingBytes, err := parseRange(options.XPaddingBytes, defaultPaddingBytes)
+	if err != nil {
+		return nil, E.Cause(err, "x_padding_bytes")
+	}
+	path, _ := normalizePath(options.Path)
+	server := &Server{
+		ctx:              ctx,
+		logger:           logger,
+		tlsConfig:        tlsConfig,
+		handler:          handler,
+		h2Server:         new(http2.Server),
+		host:             options.Host,
+		path:             path,
+		mode:             mode,
+		headers:          options.Headers.Build(),
+		paddingBytes:     paddingBytes,
+		noSSEHeader:      options.NoSSEHeader,
+		maxEachPostBytes: int(options.ScMaxEachPostBytes),
+		maxBufferedPosts: int(options.ScMaxBufferedPosts),
+		sessions:         make(map[string]*serverSession),
+	}
+	if server.maxEachPostBytes == 0 {
+		server.maxEachPostBytes = defaultMaxEachPostBytes
+	}
+	if server.maxBufferedPosts == 0 {
+		server.maxBufferedPosts = defaultMaxBufferedPosts
+	}
+	server.httpServer = &http.Server{
+		Handler:           server,
+		ReadHeaderTimeout: C.TCPTimeout,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return log.ContextWithNewID(ctx)
+		},
+	}
+	server.h2cHandler = h2c.NewHandler(server, server.h2Server)
+	return server, nil
+}
+
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == "PRI" && len(request.Header) == 0 && request.URL.Path == "*" && request.Proto == "HTTP/2.0" {
+		s.h2cHandler.ServeHTTP(writer, request)
+		return
+	}
+	if len(s.host) > 0 && request.Host != s.host {
+		s.invalidRequest(writer, request, http.StatusNotFound, E.New("bad host: ", request.Host))
+		return
+	}
+	if !strings.HasPrefix(request.URL.Path, s.path) {
+		s.invalidRequest(writer, request, http.StatusNotFound, E.New("bad path: ", request.URL.Path))
+		return
+	}
+	var paddingLength int
+	if referrer := request.Header.Get("Referer"); referrer != "" {
+		// only the query is checked, since browser based clients cannot control the rest
+		referrerURL, err := url.Parse(referrer)
+		if err == nil {
+			paddingLength = len(referrerURL.Query().Get("x_padding"))
+		}
+	} else {
+		paddingLength = len(request.URL.Query().Get("x_padding"))
+	}
+	if !s.paddingBytes.contains(paddingLength) {
+		s.invalidRequest(writer, request, http.StatusBadRequest, E.New("invalid padding length: ", paddingLength))
+		return
+	}
+	var sessionID, seqString string
+	subPath := strings.Split(strings.Trim(request.URL.Path[len(s.path):], "/"), "/")
+	if len(subPath) > 0 {
+		sessionID = subPath[0]
+	}
+	if len(subPath) > 1 {
+		seqString = subPath[1]
+	}
+	for key, values := range s.headers {
+		for _, value := range values {
+			writer.Header().Set(key, value)
+		}
+	}
+	writeResponseHeader(writer.Header(), s.paddingBytes)
+	switch {
+	case sessionID == "" && request.Method == http.MethodPost:
+		if s.mode != ModeAuto && s.mode != ModeStreamOne {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("stream-one mode is not allowed"))
+			return
+		}
+		// HTTP/1.1 servers consume the request body before writing the response by default
+		_ = http.NewResponseController(writer).EnableFullDuplex()
+		s.serveDownload(writer, request, request.Body, nil)
+	case sessionID != "" && request.Method == http.MethodGet:
+		if s.mode == ModeStreamOne {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("packet-up and stream-up modes are not allowed"))
+			return
+		}
+		session := s.loadSession(sessionID)
+		var connected bool
+		session.connectOnce.Do(func() {
+			connected = true
+			close(session.connected)
+		})
+		if !connected {
+			s.invalidRequest(writer, request, http.StatusConflict, E.New("duplicate download for session ", sessionID))
+			return
+		}
+		s.serveDownload(writer, request, session.uploadQueue, func() {
+			s.sessionAccess.Lock()
+			delete(s.sessions, sessionID)
+			s.sessionAccess.Unlock()
+		})
+	case sessionID != "" && request.Method == http.MethodPost && seqString == "":
+		if s.mode != ModeAuto && s.mode != ModeStreamUp {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("stream-up mode is not allowed"))
+			return
+		}
+		s.serveStreamUp(writer, request, s.loadSession(sessionID))
+	case sessionID != "" && request.Method == http.MethodPost:
+		if s.mode != ModeAuto && s.mode != ModePacketUp {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.New("packet-up mode is not allowed"))
+			return
+		}
+		seq, err := strconv.ParseUint(seqString, 10, 64)
+		if err != nil {
+			s.invalidRequest(writer, request, http.StatusBadRequest, E.Cause(err, "invalid seq"))
+			return
+		}
+		payload, err := io.ReadAll(io.LimitReader(request.Body, int64(s.maxEachPostBytes)+1))
+		if err != nil {
+			s.invalidRequest(writer, request, http.StatusInternalServerError, E.Cause(err, "read upload"))
+			return
+		}
+		if len(payload) > s.maxEachPostBytes {
+			s.invalidRequest(writer, request, http.StatusRequestEntityTooLarge, E.New("too large upload"))
+			return
+		}
+		err = s.loadSession(sessionID).uploadQueue.Push(seq, payload)
+		if err != nil {
+			s.invalidRequest(writer, request, http.StatusInternalServerError, E.Cause(err, "push upload"))
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	default:
+		s.invalidRequest(writer, request, http.StatusNotFound, E.New("bad request: ", request.Method, " ", request.URL.Path))
+	}
+}
+
+func (s *Server) loadSession(sessionID string) *serverSession {
+	s.sessionAccess.Lock()
+	defer s.sessionAccess.Unlock()
+	session, loaded := s.sessions[sessionID]
+	if loaded {
+		return session
+	}
+	session = &serverSession{
+		uploadQueue: newUploadQueue(s.maxBufferedPosts),
+		connected:   make(chan struct{}),
+	}
+	s.sessions[sessionID] = session
+	go func() {
+		select {
+		case <-session.connected:
+		case <-time.After(sessionTimeout):
+			s.sessionAccess.Lock()
+			if s.sessions[sessionID] == session {
+				delete(s.sessions, sessionID)
+			}
+			s.sessionAccess.Unlock()
+			session.uploadQueue.Close()
+		}
+	}()
+	return session
+}
+
+func (s *Server) serveDownload(writer http.ResponseWriter, request *http.Request, reader io.Reader, cleanup func()) {
+	// disable buffering of nginx and apache, and caching of all middleboxes
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	if !s.noSSEHeader {
+		writer.Header().Set("Content-Type", "text/event-stream")
+	}
+	writer.WriteHeader(http.StatusOK)
+	flusher, isFlusher := writer.(http.Flusher)
+	if !isFlusher {
+		s.invalidRequest(writer, request, 0, E.New("flush not supported"))
+		return
+	}
+	flusher.Flush()
+	done := make(chan struct{})
+	conn := v2rayhttp.NewHTTP2Wrapper(&serverConn{
+		ServerHTTPConn: v2rayhttp.ServerHTTPConn{
+			HTTP2Conn: v2rayhttp.NewHTTPConn(reader, writer),
+			Flusher:   flusher,
+		},
+		reader: reader,
+	})
+	s.handler.NewConnectionEx(v2rayhttp.DupContext(request.Context()), conn, sHttp.SourceAddress(request), M.Socksaddr{}, N.OnceClose(func(it error) {
+		close(done)
+	}))
+	select {
+	case <-done:
+	case <-request.Context().Done():
+	}
+	conn.CloseWrapper()
+	common.Close(reader)
+	if cleanup != nil {
+		cleanup()
+	}
+}
+
+func (s *Server) serveStreamUp(writer http.ResponseWriter, request *http.Request, session *serverSession) {
+	done, err := session.uploadQueue.PushReader(request.Body)
+	if err != nil {
+		s.invalidRequest(writer, request, http.StatusConflict, E.Cause(err, "push upload stream"))
+		return
+	}
+	_ = http.NewResponseController(writer).EnableFullDuplex()
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(http.StatusOK)
+	flusher, isFlusher := writer.(http.Flusher)
+	if isFlusher {
+		flusher.Flush()
+	}
+	var writeAccess sync.Mutex
+	if request.Header.Get("Referer") != "" && isFlusher {
+		// keep the response alive for CDNs that close idle streams
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Duration(defaultStreamUpServerSecs.rand()) * time.Second):
+				}
+				writeAccess.Lock()
+				_, err := writer.Write(bytes.Repeat([]byte{'X'}, s.paddingBytes.rand()))
+				if err == nil {
+					flusher.Flush()
+				}
+				writeAccess.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	select {
+	case <-done:
+	case <-request.Context().Done():
+	}
+	// the response writer must not be used after returning
+	writeAccess.Lock()
+	defer writeAccess.Unlock()
+}
+
+func (s *Server) invalidRequest(writer http.ResponseWriter, request *http.Request, statusCode int, err error) {
+	if statusCode > 0 {
+		writer.WriteHeader(statusCode)
+	}
+	s.logger.ErrorContext(request.Context(), E.Cause(err, "process connection from ", request.RemoteAddr))
+}
+
+func (s *Server) Network() []string {
+	return []string{N.NetworkTCP}
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	if s.tlsConfig != nil {
+		if len(s.tlsConfig.NextProtos()) == 0 {
+			s.tlsConfig.SetNextProtos([]string{http2.NextProtoTLS, "http/1.1"})
+		} else if !common.Contains(s.tlsConfig.NextProtos(), http2.NextProtoTLS) {
+			s.tlsConfig.SetNextProtos(append([]string{http2.NextProtoTLS}, s.tlsConfig.NextProtos()...))
+		}
+		listener = aTLS.NewListener(listener, s.tlsConfig)
+	}
+	return s.httpServer.Serve(listener)
+}
+
+func (s *Server) ServePacket(listener net.PacketConn) error {
+	return os.ErrInvalid
+}
+
+func (s *Server) Close() error {
+	return common.Close(common.PtrOrNil(s.httpServer))
+}
+
+type serverConn struct {
+	v2rayhttp.ServerHTTPConn
+	reader io.Reader
+}
+
+func (c *serverConn) Close() error {
+	return common.Close(c.reader)
+}
@@ -0,0 +1,125 @@
+package v2rayxhttp
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// uploadQueue reassembles the upload stream of a session on the server, either
+// from sequenced packet-up POST bodies or from a single stream-up POST body.
+type uploadQueue struct {
+	access     sync.Mutex
+	cond       *sync.Cond
+	packets    map[uint64][]byte
+	nextSeq    uint64
+	current    []byte
+	reader     *streamReader
+	maxPackets int
+	closed     bool
+}
+
+type streamReader struct {
+	io.Reader
+	done chan struct{}
+	once sync.Once
+}
+
+func (r *streamReader) finish() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+}
+
+func newUploadQueue(maxPackets int) *uploadQueue {
+	queue := &uploadQueue{
+		packets:    make(map[uint64][]byte),
+		maxPackets: maxPackets,
+	}
+	queue.cond = sync.NewCond(&queue.access)
+	return queue
+}
+
+func (q *uploadQueue) Push(seq uint64, payload []byte) error {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.closed {
+		return net.ErrClosed
+	}
+	if q.reader != nil {
+		return E.New("packet received on stream-up session")
+	}
+	if seq < q.nextSeq {
+		return E.New("duplicate packet: ", seq)
+	}
+	if len(q.packets) >= q.maxPackets {
+		return E.New("too many buffered packets")
+	}
+	q.packets[seq] = payload
+	q.cond.Broadcast()
+	return nil
+}
+
+// PushReader sets the upload stream and returns a channel which is closed once
+// the stream is fully consumed or the queue is closed.
+func (q *uploadQueue) PushReader(reader io.Reader) (<-chan struct{}, error) {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.closed {
+		return nil, net.ErrClosed
+	}
+	if q.reader != nil || q.nextSeq > 0 || len(q.packets) > 0 {
+		return nil, E.New("duplicate upload stream")
+	}
+	q.reader = &streamReader{Reader: reader, done: make(chan struct{})}
+	q.cond.Broadcast()
+	return q.reader.done, nil
+}
+
+func (q *uploadQueue) Read(p []byte) (n int, err error) {
+	q.access.Lock()
+	for {
+		if len(q.current) > 0 {
+			n = copy(p, q.current)
+			q.current = q.current[n:]
+			q.access.Unlock()
+			return
+		}
+		if q.closed {
+			q.access.Unlock()
+			return 0, io.EOF
+		}
+		if q.reader != nil {
+			reader := q.reader
+			q.access.Unlock()
+			n, err = reader.Read(p)
+			if err != nil {
+				reader.finish()
+			}
+			return
+		}
+		if payload, loaded := q.packets[q.nextSeq]; loaded {
+			delete(q.packets, q.nextSeq)
+			q.nextSeq++
+			q.current = payload
+			continue
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *uploadQueue) Close() error {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	if q.reader != nil {
+		q.reader.finish()
+	}
+	q.cond.Broadcast()
+	return nil
+}
@@ -27,6 +27,7 @@ type ClientBind struct {
 	bindCtx             context.Context
 	bindDone            context.CancelFunc
 	dialer              N.Dialer
+	reservedAccess      sync.RWMutex
 	reservedForEndpoint map[netip.AddrPort][3]uint8
 	connAccess          sync.Mutex
 	conn                *wireConn
@@ -128,7 +129,9 @@ func (c *ClientBind) receive(packets [][]byte, sizes []int, eps []conn.Endpoint)
 		select {
 		case <-c.done:
 		default:
-			c.logger.Error(E.Cause(err, "read packet"))
+			if !E.IsClosed(err) {
+				c.logger.Error(E.Cause(err, "read packet"))
+			}
 			err = nil
 		}
 		return
@@ -175,10 +178,12 @@ func (c *ClientBind) Send(bufs [][]byte, ep conn.Endpoint, offset int) error {
 			buf = buf[offset:]
 		}
 		if len(buf) > 3 {
+			c.reservedAccess.RLock()
 			reserved, loaded := c.reservedForEndpoint[destination]
 			if !loaded {
 				reserved = c.reserved
 			}
+			c.reservedAccess.RUnlock()
 			copy(buf[1:4], reserved[:])
 		}
 		_, err = udpConn.WriteToUDPAddrPort(buf, destination)
@@ -203,9 +208,27 @@ func (c *ClientBind) BatchSize() int {
 }
 
 func (c *ClientBind) SetReservedForEndpoint(destination netip.AddrPort, reserved [3]byte) {
+	c.reservedAccess.Lock()
+	defer c.reservedAccess.Unlock()
 	c.reservedForEndpoint[destination] = reserved
 }
 
+// SetConnect switches between a connected socket to the only peer and an unconnected one,
+// the current socket is closed and reopened in the new mode.
+func (c *ClientBind) SetConnect(isConnect bool, connectAddr netip.AddrPort, reserved [3]uint8) {
+	c.connAccess.Lock()
+	defer c.connAccess.Unlock()
+	c.reservedAccess.Lock()
+	changed := c.isConnect != isConnect || c.connectAddr != connectAddr
+	c.isConnect = isConnect
+	c.connectAddr = connectAddr
+	c.reserved = reserved
+	c.reservedAccess.Unlock()
+	if changed && c.conn != nil {
+		c.conn.Close()
+	}
+}
+
 type wireConn struct {
 	net.PacketConn
 	conn   net.Conn
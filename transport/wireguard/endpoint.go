@@ -9,7 +9,10 @@ import (
 	"net/netip"
 	"os"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unsafe"
 
@@ -29,9 +32,15 @@ import (
 	"go4.org/netipx"
 )
 
+const DefaultPeerResolveInterval = time.Minute
+
 type Endpoint struct {
 	options        EndpointOptions
+	peerAccess     sync.Mutex
 	peers          []peerConfig
+	bind           conn.Bind
+	bindAccess     *sync.RWMutex
+	closeResolve   context.CancelFunc
 	ipcConf        string
 	allowedAddress []netip.Prefix
 	tunDevice      Device
@@ -57,35 +66,9 @@ func NewEndpoint(options EndpointOptions) (*Endpoint, error) {
 	}
 	var peers []peerConfig
 	for peerIndex, rawPeer := range options.Peers {
-		peer := peerConfig{
-			allowedIPs: rawPeer.AllowedIPs,
-			keepalive:  rawPeer.PersistentKeepaliveInterval,
-		}
-		if rawPeer.Endpoint.Addr.IsValid() {
-			peer.endpoint = rawPeer.Endpoint.AddrPort()
-		} else if rawPeer.Endpoint.IsFqdn() {
-			peer.destination = rawPeer.Endpoint
-		}
-		publicKeyBytes, err := base64.StdEncoding.DecodeString(rawPeer.PublicKey)
+		peer, err := newPeerConfig(rawPeer)
 		if err != nil {
-			return nil, E.Cause(err, "decode public key for peer ", peerIndex)
-		}
-		peer.publicKeyHex = hex.EncodeToString(publicKeyBytes)
-		if rawPeer.PreSharedKey != "" {
-			preSharedKeyBytes, err := base64.StdEncoding.DecodeString(rawPeer.PreSharedKey)
-			if err != nil {
-				return nil, E.Cause(err, "decode pre shared key for peer ", peerIndex)
-			}
-			peer.preSharedKeyHex = hex.EncodeToString(preSharedKeyBytes)
-		}
-		if len(rawPeer.AllowedIPs) == 0 {
-			return nil, E.New("missing allowed ips for peer ", peerIndex)
-		}
-		if len(rawPeer.Reserved) > 0 {
-			if len(rawPeer.Reserved) != 3 {
-				return nil, E.New("invalid reserved value for peer ", peerIndex, ", required 3 bytes, got ", len(peer.reserved))
-			}
-			copy(peer.reserved[:], rawPeer.Reserved[:])
+			return nil, E.Cause(err, "peer[", peerIndex, "]")
 		}
 		peers = append(peers, peer)
 	}
@@ -177,6 +160,7 @@ func (e *Endpoint) Start(resolve bool) error {
 			}
 		}
 	}
+	e.bind = bind
 	err := e.tunDevice.Start()
 	if err != nil {
 		return err
@@ -205,12 +189,21 @@ func (e *Endpoint) Start(resolve bool) error {
 	if err != nil {
 		return E.Cause(err, "setup wireguard: \n", ipcConf)
 	}
+	e.peerAccess.Lock()
 	e.device = wgDevice
+	// the device holds the lock of its bind when sending
+	e.bindAccess = (*sync.RWMutex)(unsafe.Pointer(reflect.Indirect(reflect.ValueOf(wgDevice)).FieldByName("net").FieldByName("RWMutex").UnsafeAddr()))
+	e.peerAccess.Unlock()
 	e.pause = service.FromContext[pause.Manager](e.options.Context)
 	if e.pause != nil {
 		e.pauseCallback = e.pause.RegisterCallback(e.onPauseUpdated)
 	}
 	e.allowedIPs = (*device.AllowedIPs)(unsafe.Pointer(reflect.Indirect(reflect.ValueOf(wgDevice)).FieldByName("allowedips").UnsafeAddr()))
+	if e.options.ResolvePeer != nil && e.options.ResolveInterval > 0 {
+		var resolveCtx context.Context
+		resolveCtx, e.closeResolve = context.WithCancel(e.options.Context)
+		go e.loopResolve(resolveCtx)
+	}
 	return nil
 }
 
@@ -229,6 +222,9 @@ func (e *Endpoint) ListenPacket(ctx context.Context, destination M.Socksaddr) (n
 }
 
 func (e *Endpoint) Close() error {
+	if e.closeResolve != nil {
+		e.closeResolve()
+	}
 	if e.device != nil {
 		e.device.Close()
 	}
@@ -261,6 +257,229 @@ func (e *Endpoint) onPauseUpdated(event int) {
 	}
 }
 
+// Peers returns the configured peers with the state reported by the device.
+func (e *Endpoint) Peers() ([]adapter.WireGuardPeerStatus, error) {
+	e.peerAccess.Lock()
+	wgDevice := e.device
+	destinations := make(map[string]M.Socksaddr)
+	for _, peer := range e.peers {
+		destinations[peer.publicKeyHex] = peer.destination
+	}
+	e.peerAccess.Unlock()
+	if wgDevice == nil {
+		return nil, E.New("endpoint not started")
+	}
+	ipcConf, err := wgDevice.IpcGet()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		peers         []adapter.WireGuardPeerStatus
+		peer          *adapter.WireGuardPeerStatus
+		handshakeSec  int64
+		handshakeNsec int64
+	)
+	flushPeer := func() {
+		if peer == nil {
+			return
+		}
+		if handshakeSec != 0 || handshakeNsec != 0 {
+			peer.LastHandshake = time.Unix(handshakeSec, handshakeNsec)
+		}
+		peers = append(peers, *peer)
+		handshakeSec, handshakeNsec = 0, 0
+	}
+	for _, line := range strings.Split(ipcConf, "\n") {
+		key, value, loaded := strings.Cut(line, "=")
+		if !loaded {
+			continue
+		}
+		if key == "public_key" {
+			flushPeer()
+			publicKey, _ := hex.DecodeString(value)
+			peer = &adapter.WireGuardPeerStatus{
+				PublicKey:  base64.StdEncoding.EncodeToString(publicKey),
+				AllowedIPs: []netip.Prefix{},
+			}
+			if destination := destinations[value]; destination.IsValid() {
+				peer.Address = destination.AddrString()
+				peer.Port = destination.Port
+			}
+			continue
+		}
+		if peer == nil {
+			continue
+		}
+		switch key {
+		case "endpoint":
+			peer.Endpoint = value
+		case "allowed_ip":
+			prefix, parseErr := netip.ParsePrefix(value)
+			if parseErr == nil {
+				peer.AllowedIPs = append(peer.AllowedIPs, prefix)
+			}
+		case "persistent_keepalive_interval":
+			keepalive, _ := strconv.ParseUint(value, 10, 16)
+			peer.PersistentKeepaliveInterval = uint16(keepalive)
+		case "last_handshake_time_sec":
+			handshakeSec, _ = strconv.ParseInt(value, 10, 64)
+		case "last_handshake_time_nsec":
+			handshakeNsec, _ = strconv.ParseInt(value, 10, 64)
+		case "tx_bytes":
+			peer.Upload, _ = strconv.ParseUint(value, 10, 64)
+		case "rx_bytes":
+			peer.Download, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+	flushPeer()
+	return peers, nil
+}
+
+// UpdatePeer adds the peer, or replaces the configuration of the peer with the same public key.
+func (e *Endpoint) UpdatePeer(options PeerOptions) error {
+	peer, err := newPeerConfig(options)
+	if err != nil {
+		return err
+	}
+	if peer.destination.IsFqdn() {
+		destinationAddress, err := e.options.ResolvePeer(peer.destination.Fqdn)
+		if err != nil {
+			return E.Cause(err, "resolve endpoint domain: ", peer.destination)
+		}
+		peer.endpoint = netip.AddrPortFrom(destinationAddress, peer.destination.Port)
+	}
+	e.peerAccess.Lock()
+	defer e.peerAccess.Unlock()
+	if e.device == nil {
+		return E.New("endpoint not started")
+	}
+	err = e.device.IpcSet(peer.GenerateUpdateIpcLines())
+	if err != nil {
+		return E.Cause(err, "update peer")
+	}
+	peerIndex := slices.IndexFunc(e.peers, func(it peerConfig) bool {
+		return it.publicKeyHex == peer.publicKeyHex
+	})
+	if peerIndex >= 0 {
+		e.peers[peerIndex] = peer
+	} else {
+		e.peers = append(e.peers, peer)
+	}
+	e.updateBind()
+	return nil
+}
+
+func (e *Endpoint) RemovePeer(publicKey string) error {
+	publicKeyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return E.Cause(err, "decode public key")
+	}
+	publicKeyHex := hex.EncodeToString(publicKeyBytes)
+	e.peerAccess.Lock()
+	defer e.peerAccess.Unlock()
+	if e.device == nil {
+		return E.New("endpoint not started")
+	}
+	peerIndex := slices.IndexFunc(e.peers, func(it peerConfig) bool {
+		return it.publicKeyHex == publicKeyHex
+	})
+	if peerIndex < 0 {
+		return E.Extend(os.ErrNotExist, "peer ", publicKey)
+	}
+	err = e.device.IpcSet("public_key=" + publicKeyHex + "\nremove=true")
+	if err != nil {
+		return E.Cause(err, "remove peer")
+	}
+	e.peers = slices.Delete(e.peers, peerIndex, peerIndex+1)
+	e.updateBind()
+	return nil
+}
+
+// updateBind must be called with peerAccess held.
+func (e *Endpoint) updateBind() {
+	clientBind, isClientBind := e.bind.(*ClientBind)
+	if !isClientBind {
+		// StdNetBind does not synchronize its reserved map, so it is set with the lock of the device bind held
+		e.bindAccess.Lock()
+		e.setReserved()
+		e.bindAccess.Unlock()
+		return
+	}
+	var (
+		isConnect   bool
+		connectAddr netip.AddrPort
+		reserved    [3]uint8
+	)
+	if len(e.peers) == 1 && e.peers[0].endpoint.IsValid() {
+		isConnect = true
+		connectAddr = e.peers[0].endpoint
+		reserved = e.peers[0].reserved
+	}
+	e.setReserved()
+	clientBind.SetConnect(isConnect, connectAddr, reserved)
+}
+
+func (e *Endpoint) setReserved() {
+	for _, peer := range e.peers {
+		// also set empty values, so that reserved removed by an update is not kept
+		if peer.endpoint.IsValid() {
+			e.bind.SetReservedForEndpoint(peer.endpoint, peer.reserved)
+		}
+	}
+}
+
+func (e *Endpoint) loopResolve(ctx context.Context) {
+	ticker := time.NewTicker(e.options.ResolveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		e.resolvePeers()
+	}
+}
+
+func (e *Endpoint) resolvePeers() {
+	e.peerAccess.Lock()
+	destinations := make(map[string]M.Socksaddr)
+	for _, peer := range e.peers {
+		if peer.destination.IsFqdn() {
+			destinations[peer.publicKeyHex] = peer.destination
+		}
+	}
+	e.peerAccess.Unlock()
+	for publicKeyHex, destination := range destinations {
+		destinationAddress, err := e.options.ResolvePeer(destination.Fqdn)
+		if err != nil {
+			e.options.Logger.Warn(E.Cause(err, "resolve endpoint domain for peer: ", destination))
+			continue
+		}
+		e.updatePeerEndpoint(publicKeyHex, destination, netip.AddrPortFrom(destinationAddress, destination.Port))
+	}
+}
+
+func (e *Endpoint) updatePeerEndpoint(publicKeyHex string, destination M.Socksaddr, endpoint netip.AddrPort) {
+	e.peerAccess.Lock()
+	defer e.peerAccess.Unlock()
+	peerIndex := slices.IndexFunc(e.peers, func(it peerConfig) bool {
+		return it.publicKeyHex == publicKeyHex
+	})
+	// skip peers removed or updated while resolving
+	if peerIndex < 0 || e.peers[peerIndex].destination != destination || e.peers[peerIndex].endpoint == endpoint {
+		return
+	}
+	err := e.device.IpcSet("public_key=" + publicKeyHex + "\nupdate_only=true\nendpoint=" + endpoint.String())
+	if err != nil {
+		e.options.Logger.Error(E.Cause(err, "update endpoint for peer: ", destination))
+		return
+	}
+	e.options.Logger.Info("endpoint of peer ", destination, " changed to ", endpoint)
+	e.peers[peerIndex].endpoint = endpoint
+	e.updateBind()
+}
+
 type peerConfig struct {
 	destination     M.Socksaddr
 	endpoint        netip.AddrPort
@@ -287,3 +506,55 @@ func (c peerConfig) GenerateIpcLines() string {
 	}
 	return ipcLines
 }
+
+// GenerateUpdateIpcLines replaces the whole configuration of a running peer.
+func (c peerConfig) GenerateUpdateIpcLines() string {
+	ipcLines := "public_key=" + c.publicKeyHex + "\nreplace_allowed_ips=true"
+	if c.endpoint.IsValid() {
+		ipcLines += "\nendpoint=" + c.endpoint.String()
+	}
+	preSharedKeyHex := c.preSharedKeyHex
+	if preSharedKeyHex == "" {
+		// an all-zero key disables the pre-shared key
+		preSharedKeyHex = strings.Repeat("0", hex.EncodedLen(device.NoisePresharedKeySize))
+	}
+	ipcLines += "\npreshared_key=" + preSharedKeyHex
+	for _, allowedIP := range c.allowedIPs {
+		ipcLines += "\nallowed_ip=" + allowedIP.String()
+	}
+	ipcLines += "\npersistent_keepalive_interval=" + F.ToString(c.keepalive)
+	return ipcLines
+}
+
+func newPeerConfig(rawPeer PeerOptions) (peerConfig, error) {
+	peer := peerConfig{
+		destination: rawPeer.Endpoint,
+		allowedIPs:  rawPeer.AllowedIPs,
+		keepalive:   rawPeer.PersistentKeepaliveInterval,
+	}
+	if rawPeer.Endpoint.Addr.IsValid() {
+		peer.endpoint = rawPeer.Endpoint.AddrPort()
+	}
+	publicKeyBytes, err := base64.StdEncoding.DecodeString(rawPeer.PublicKey)
+	if err != nil {
+		return peerConfig{}, E.Cause(err, "decode public key")
+	}
+	peer.publicKeyHex = hex.EncodeToString(publicKeyBytes)
+	if rawPeer.PreSharedKey != "" {
+		preSharedKeyBytes, err := base64.StdEncoding.DecodeString(rawPeer.PreSharedKey)
+		if err != nil {
+			return peerConfig{}, E.Cause(err, "decode pre shared key")
+		}
+		peer.preSharedKeyHex = hex.EncodeToString(preSharedKeyBytes)
+	}
+	if len(rawPeer.AllowedIPs) == 0 {
+		return peerConfig{}, E.New("missing allowed ips")
+	}
+	if len(rawPeer.Reserved) > 0 {
+		if len(rawPeer.Reserved) != 3 {
+			return peerConfig{}, E.New("invalid reserved value, required 3 bytes, got ", len(rawPeer.Reserved))
+		}
+		copy(peer.reserved[:], rawPeer.Reserved[:])
+	}
+	return peer, nil
+}